@@ -2,21 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"time"
 
 	"go.uber.org/fx"
 
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
 	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
 	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
 	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
 	"github.com/k6zma/avito-lab1/internal/infrastructure/flags"
 	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
 	infrastructureRepos "github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/sqlite"
+	"github.com/k6zma/avito-lab1/internal/presentation/cli"
+	"github.com/k6zma/avito-lab1/internal/presentation/httpapi"
 	"github.com/k6zma/avito-lab1/internal/presentation/tui"
 	"github.com/k6zma/avito-lab1/pkg/validators"
 )
 
+// trashPurgeInterval is how often serve applies the purge_after_days policy.
+const trashPurgeInterval = time.Hour
+
 func main() {
 	app := fx.New(
 		fx.NopLogger,
@@ -30,7 +43,7 @@ func main() {
 			return l
 		}),
 
-		fx.Invoke(validators.InitValidators()),
+		fx.Invoke(validators.InitValidators),
 
 		fx.Provide(
 			func() (*flags.StudyFlags, error) {
@@ -38,52 +51,418 @@ func main() {
 			},
 
 			func(cfg *flags.StudyFlags) (ciphers.Cipher, error) {
-				return ciphers.NewAESGCM(cfg.CipherKey)
+				return newCipher(cfg.KDF, cfg.CipherKey)
 			},
 
-			func(cfg *flags.StudyFlags, c ciphers.Cipher) persisters.StudentPersister {
-				return persisters.NewJSONStudentPersister(cfg.ConfigPath, c)
-			},
+			newStudentPersister,
 
-			func(p persisters.StudentPersister) (domainRepos.StudentRepository, error) {
-				return infrastructureRepos.NewStudentStorageWithPersister(p)
-			},
+			newRepositories,
 
-			func(repo domainRepos.StudentRepository) services.StudentServiceContract {
-				return services.NewStudentService(repo)
-			},
+			newAuditLog,
+
+			newGradingPolicy,
+
+			services.NewStudentService,
+			services.NewCourseService,
+			services.NewReportService,
+			services.NewAuditService,
 		),
 
 		fx.Invoke(func(
 			lc fx.Lifecycle,
+			cfg *flags.StudyFlags,
 			svc services.StudentServiceContract,
+			courses services.CourseServiceContract,
+			reports services.ReportServiceContract,
+			audit services.AuditServiceContract,
+			p persisters.StudentPersister,
+			c ciphers.Cipher,
 			sd fx.Shutdowner,
 			log *slog.Logger,
-		) {
-			lc.Append(fx.Hook{
-				OnStart: func(ctx context.Context) error {
-					go func() {
-						if err := tui.Run(svc); err != nil {
-							log.Error(
-								"TUI exited with error",
-								"error", err,
-							)
-						}
-
-						err := sd.Shutdown()
-						if err != nil {
-							log.Error(
-								"Failed to shutdown TUI app",
-								"error", err,
-							)
-						}
-					}()
-
-					return nil
-				},
-			})
+		) error {
+			svc = svc.WithActor(cfg.Actor)
+
+			switch cfg.Command {
+			case flags.ServeCommand:
+				registerTrashPurger(lc, cfg, svc, log)
+
+				return registerHTTPServer(lc, cfg, svc, courses, log)
+			case flags.RekeyCommand:
+				registerRekeyRunner(lc, cfg, p, c, sd, log)
+
+				return nil
+			case "":
+				purgeTrash(cfg, svc, log)
+			}
+
+			registerTerminalRunner(lc, cfg, svc, courses, reports, audit, sd, log)
+
+			return nil
 		}),
 	)
 
+	if err := app.Err(); err != nil {
+		slog.Error(
+			"Failed to initialize studify app",
+			"error", err,
+		)
+
+		os.Exit(cli.ExitFailure)
+	}
+
 	app.Run()
 }
+
+type repositoriesOut struct {
+	fx.Out
+
+	Students domainRepos.StudentRepository
+	Courses  domainRepos.CourseRepository
+}
+
+func newRepositories(
+	lc fx.Lifecycle,
+	cfg *flags.StudyFlags,
+	p persisters.StudentPersister,
+	c ciphers.Cipher,
+) (repositoriesOut, error) {
+	var opts []infrastructureRepos.StudentStorageOption
+	if cfg.UniqueNames {
+		opts = append(opts, infrastructureRepos.WithUniqueFullName())
+	}
+
+	if cfg.Storage != flags.StorageSQLite {
+		opts = append(opts, infrastructureRepos.WithHistoryPersister(newHistoryPersister(cfg, c)))
+
+		students, err := infrastructureRepos.NewStudentStorageWithPersister(p, opts...)
+		if err != nil {
+			return repositoriesOut{}, err
+		}
+
+		courses, err := infrastructureRepos.NewCourseStorageWithPersister(newCoursePersister(cfg, c))
+		if err != nil {
+			return repositoriesOut{}, err
+		}
+
+		return repositoriesOut{Students: students, Courses: courses}, nil
+	}
+
+	db, err := sqlite.Open(context.Background(), cfg.ConfigPath)
+	if err != nil {
+		return repositoriesOut{}, err
+	}
+
+	lc.Append(fx.StopHook(db.Close))
+
+	return repositoriesOut{
+		Students: infrastructureRepos.NewSQLiteStudentStorage(db, opts...),
+		Courses:  infrastructureRepos.NewSQLiteCourseStorage(db),
+	}, nil
+}
+
+func newAuditLog(cfg *flags.StudyFlags, c ciphers.Cipher) (domainRepos.AuditLog, error) {
+	return infrastructureRepos.NewAuditStorageWithPersister(newAuditPersister(cfg, c))
+}
+
+func newGradingPolicy(cfg *flags.StudyFlags) (grading.Policy, error) {
+	p, err := grading.NewPolicy(cfg.Averaging, cfg.GradeScale)
+	if err != nil {
+		return grading.Policy{}, fmt.Errorf("failed to build grading policy: %w", err)
+	}
+
+	return p, nil
+}
+
+func newCipher(kdf, key string) (ciphers.Cipher, error) {
+	if kdf == flags.KDFArgon2id {
+		return ciphers.NewPassphraseAESGCM(key, ciphers.DefaultArgon2Params)
+	}
+
+	return ciphers.NewAESGCM(key)
+}
+
+func newStudentPersister(cfg *flags.StudyFlags, c ciphers.Cipher) persisters.StudentPersister {
+	if cfg.Persister == flags.PersisterWAL {
+		return persisters.NewWALStudentPersister(
+			cfg.ConfigPath,
+			c,
+			persisters.DefaultWALCompactEvery,
+		)
+	}
+
+	return persisters.NewJSONStudentPersister(cfg.ConfigPath, c)
+}
+
+func newCoursePersister(cfg *flags.StudyFlags, c ciphers.Cipher) persisters.CoursePersister {
+	return persisters.NewJSONCoursePersister(persisters.CoursesPath(cfg.ConfigPath), c)
+}
+
+func newAuditPersister(cfg *flags.StudyFlags, c ciphers.Cipher) persisters.AuditPersister {
+	return persisters.NewRecordAuditPersister(persisters.AuditPath(cfg.ConfigPath), c)
+}
+
+func newHistoryPersister(cfg *flags.StudyFlags, c ciphers.Cipher) persisters.HistoryPersister {
+	return persisters.NewRecordHistoryPersister(persisters.HistoryPath(cfg.ConfigPath), c)
+}
+
+// purgeTrash applies the purge_after_days policy. The TUI applies it once per
+// start and serve every trashPurgeInterval, one-shot commands leave the trash
+// alone, trash purge empties it by hand. A failure is only logged: the
+// students stay in the trash and the next run retries.
+func purgeTrash(cfg *flags.StudyFlags, svc services.StudentServiceContract, log *slog.Logger) {
+	if cfg.PurgeAfterDays == 0 {
+		return
+	}
+
+	purged, err := svc.PurgeDeleted(dtos.PurgeDeletedDTO{OlderThanDays: cfg.PurgeAfterDays})
+	if err != nil {
+		log.Error(
+			"Failed to purge students trash",
+			"error", err,
+		)
+
+		return
+	}
+
+	if len(purged) > 0 {
+		log.Info(
+			"Purged students deleted long ago",
+			"count", len(purged),
+			"older_than_days", cfg.PurgeAfterDays,
+		)
+	}
+}
+
+func registerTrashPurger(
+	lc fx.Lifecycle,
+	cfg *flags.StudyFlags,
+	svc services.StudentServiceContract,
+	log *slog.Logger,
+) {
+	if cfg.PurgeAfterDays == 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+
+				ticker := time.NewTicker(trashPurgeInterval)
+				defer ticker.Stop()
+
+				for {
+					purgeTrash(cfg, svc, log)
+
+					select {
+					case <-ticker.C:
+					case <-stop:
+						return
+					}
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(stop)
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return fmt.Errorf("failed to stop trash purger: %w", ctx.Err())
+			}
+		},
+	})
+}
+
+func registerRekeyRunner(
+	lc fx.Lifecycle,
+	cfg *flags.StudyFlags,
+	p persisters.StudentPersister,
+	oldCipher ciphers.Cipher,
+	sd fx.Shutdowner,
+	log *slog.Logger,
+) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				exitCode := cli.ExitOK
+
+				if err := rekey(cfg, p, oldCipher); err != nil {
+					log.Error(
+						"Failed to rekey students data",
+						"error", err,
+					)
+
+					exitCode = cli.ExitFailure
+				}
+
+				err := sd.Shutdown(fx.ExitCode(exitCode))
+				if err != nil {
+					log.Error(
+						"Failed to shutdown studify app",
+						"error", err,
+					)
+				}
+			}()
+
+			return nil
+		},
+	})
+}
+
+// rekey stages every data file with the new key before any of them is
+// replaced, a failure leaves all of them readable with the old key.
+func rekey(cfg *flags.StudyFlags, p persisters.StudentPersister, oldCipher ciphers.Cipher) error {
+	c, err := newCipher(cfg.Rekey.NewKDF, cfg.Rekey.NewKey)
+	if err != nil {
+		return err
+	}
+
+	r := persisters.NewRekeyer(c)
+
+	n, err := r.Students(p, cfg.ConfigPath)
+	if err != nil {
+		r.Abort()
+
+		return err
+	}
+
+	courses, err := r.Courses(
+		newCoursePersister(cfg, oldCipher),
+		persisters.CoursesPath(cfg.ConfigPath),
+	)
+	if err != nil {
+		r.Abort()
+
+		return err
+	}
+
+	entries, err := r.Audit(
+		newAuditPersister(cfg, oldCipher),
+		persisters.AuditPath(cfg.ConfigPath),
+	)
+	if err != nil {
+		r.Abort()
+
+		return err
+	}
+
+	revisions, err := r.History(
+		newHistoryPersister(cfg, oldCipher),
+		persisters.HistoryPath(cfg.ConfigPath),
+	)
+	if err != nil {
+		r.Abort()
+
+		return err
+	}
+
+	if err := r.Commit(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(
+		os.Stdout,
+		"Re-encrypted %d students, %d courses, %d audit entries and %d revisions"+
+			" in %s with the new key\n",
+		n,
+		courses,
+		entries,
+		revisions,
+		cfg.ConfigPath,
+	)
+
+	return err
+}
+
+func registerTerminalRunner(
+	lc fx.Lifecycle,
+	cfg *flags.StudyFlags,
+	svc services.StudentServiceContract,
+	courses services.CourseServiceContract,
+	reports services.ReportServiceContract,
+	audit services.AuditServiceContract,
+	sd fx.Shutdowner,
+	log *slog.Logger,
+) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			go func() {
+				exitCode := cli.ExitOK
+
+				if cfg.Command != "" {
+					exitCode = cli.NewRunner(svc, courses, reports, audit, os.Stdout, os.Stderr).
+						WithUniqueNames(cfg.UniqueNames).
+						Run(cfg.Command, cfg.Args)
+				} else if err := tui.Run(svc, reports, audit); err != nil {
+					log.Error(
+						"TUI exited with error",
+						"error", err,
+					)
+				}
+
+				err := sd.Shutdown(fx.ExitCode(exitCode))
+				if err != nil {
+					log.Error(
+						"Failed to shutdown studify app",
+						"error", err,
+					)
+				}
+			}()
+
+			return nil
+		},
+	})
+}
+
+func registerHTTPServer(
+	lc fx.Lifecycle,
+	cfg *flags.StudyFlags,
+	svc services.StudentServiceContract,
+	courses services.CourseServiceContract,
+	log *slog.Logger,
+) error {
+	serveCfg, err := flags.GetServeFlags(cfg.Args)
+	if err != nil {
+		return err
+	}
+
+	srv := httpapi.NewServer(serveCfg.Addr, svc, courses, log)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", srv.Addr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", srv.Addr, err)
+			}
+
+			log.Info("HTTP API server started", "addr", ln.Addr().String())
+
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Error(
+						"HTTP API server exited with error",
+						"error", err,
+					)
+				}
+			}()
+
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			if err := srv.Shutdown(ctx); err != nil {
+				return fmt.Errorf("failed to shutdown HTTP API server: %w", err)
+			}
+
+			return nil
+		},
+	})
+
+	return nil
+}
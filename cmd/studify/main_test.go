@@ -5,7 +5,9 @@ import (
 	"testing"
 
 	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
 	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
 	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
 	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
 	infrastructureRepos "github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
@@ -42,7 +44,14 @@ func newStudentService(t *testing.T, jsonDataPath string) services.StudentServic
 		t.Fatalf("Failed to init repository: %v", err)
 	}
 
-	return services.NewStudentService(repository)
+	courses, err := infrastructureRepos.NewCourseStorageWithPersister(
+		persisters.NewJSONCoursePersister(persisters.CoursesPath(jsonDataPath), cipher),
+	)
+	if err != nil {
+		t.Fatalf("Failed to init course repository: %v", err)
+	}
+
+	return services.NewStudentService(repository, courses, grading.DefaultPolicy(), nil)
 }
 
 func TestStudentStorage(t *testing.T) {
@@ -53,26 +62,30 @@ func TestStudentStorage(t *testing.T) {
 
 	svc := newStudentService(t, testFilePath)
 
-	var aliceID string
+	var (
+		aliceID      string
+		aliceVersion int
+	)
 
 	t.Run("AddStudent", func(t *testing.T) {
 		created, err := svc.Register(dtos.StudentCreateDTO{
 			Name:    aliceTestName,
 			Surname: aliceTestSurname,
 			Age:     20,
-			Grades:  []int{90, 85, 95},
+			Grades:  mappers.MapGradeValuesToDTOs("", []int{90, 85, 95}),
 		})
 		if err != nil {
 			t.Errorf("Failed to add student: %v", err)
 		}
 
 		aliceID = created.ID
+		aliceVersion = created.Version
 
 		_, err = svc.Register(dtos.StudentCreateDTO{
 			Name:    aliceTestName,
 			Surname: aliceTestSurname,
 			Age:     20,
-			Grades:  []int{90, 85, 95},
+			Grades:  mappers.MapGradeValuesToDTOs("", []int{90, 85, 95}),
 		})
 		if err != nil {
 			t.Fatalf("Unexpected error when adding same full name again: %v", err)
@@ -107,7 +120,8 @@ func TestStudentStorage(t *testing.T) {
 			Name:    aliceTestName,
 			Surname: aliceTestSurname,
 			Age:     21,
-			Grades:  []int{95, 90, 100},
+			Grades:  mappers.MapGradeValuesToDTOs("", []int{95, 90, 100}),
+			Version: aliceVersion,
 		})
 		if err != nil {
 			t.Errorf("Failed to update student: %v", err)
@@ -118,7 +132,7 @@ func TestStudentStorage(t *testing.T) {
 			t.Errorf("Failed to get student: %v", err)
 		}
 
-		if gotUpd.Age != 21 || len(gotUpd.Grades) != 3 || gotUpd.Grades[0] != 95 {
+		if gotUpd.Age != 21 || len(gotUpd.Grades) != 3 || gotUpd.Grades[0].Value != 95 {
 			t.Error("Student data wasn't updated correctly")
 		}
 
@@ -127,14 +141,15 @@ func TestStudentStorage(t *testing.T) {
 			Name:    bobTestName,
 			Surname: bobTestSurname,
 			Age:     21,
-			Grades:  []int{95, 90, 100},
+			Grades:  mappers.MapGradeValuesToDTOs("", []int{95, 90, 100}),
+			Version: 1,
 		}); err == nil {
 			t.Error("Expected error when updating non-existent student")
 		}
 	})
 
 	t.Run("CalculateAverageGrade", func(t *testing.T) {
-		avg, err := svc.AVGByID(dtos.GetByIDDTO{ID: aliceID})
+		avg, err := svc.AVGByID(dtos.AVGQueryDTO{ID: aliceID})
 		if err != nil {
 			t.Errorf("Failed to calculate average grade: %v", err)
 		}
@@ -148,13 +163,13 @@ func TestStudentStorage(t *testing.T) {
 			Name:    bobTestName,
 			Surname: bobTestSurname,
 			Age:     22,
-			Grades:  []int{},
+			Grades:  mappers.MapGradeValuesToDTOs("", []int{}),
 		})
 		if err != nil {
 			t.Errorf("Failed to add student: %v", err)
 		}
 
-		avg2, err := svc.AVGByID(dtos.GetByIDDTO{ID: noGrades.ID})
+		avg2, err := svc.AVGByID(dtos.AVGQueryDTO{ID: noGrades.ID})
 		if err != nil {
 			t.Errorf("Failed to calculate average grade: %v", err)
 		}
@@ -0,0 +1,27 @@
+package dtos
+
+type AuditQueryDTO struct {
+	StudentID string `json:"student_id,omitempty" validate:"omitempty,uuid"`
+	Actor     string `json:"actor,omitempty"      validate:"max=64"`
+	Action    string `json:"action,omitempty"     validate:"omitempty,oneof=register update delete add_grades restore purge"`
+	Limit     int    `json:"limit,omitempty"      validate:"gte=0,lte=1000"`
+}
+
+type AuditEntryDTO struct {
+	Seq       uint64              `json:"seq"`
+	At        string              `json:"at"`
+	Actor     string              `json:"actor"`
+	Action    string              `json:"action"`
+	StudentID string              `json:"student_id"`
+	Before    *StudentListItemDTO `json:"before,omitempty"`
+	After     *StudentListItemDTO `json:"after,omitempty"`
+	Changes   []string            `json:"changes"`
+	Hash      string              `json:"hash"`
+}
+
+type AuditLogDTO struct {
+	Entries  []AuditEntryDTO `json:"entries"`
+	Total    int             `json:"total"`
+	Verified bool            `json:"verified"`
+	Problem  string          `json:"problem,omitempty"`
+}
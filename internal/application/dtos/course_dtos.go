@@ -0,0 +1,10 @@
+package dtos
+
+type CourseCreateDTO struct {
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+type CourseResponseDTO struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
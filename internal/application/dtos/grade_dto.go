@@ -0,0 +1,34 @@
+package dtos
+
+import (
+	"bytes"
+
+	"github.com/goccy/go-json"
+)
+
+// UnmarshalJSON keeps accepting plain integers so clients that still send
+// "grades": [90, 85] get them recorded in the unassigned course. On update
+// they keep the details of the stored grades they stand for.
+func (g *GradeDTO) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '{' {
+		var value int
+		if err := json.Unmarshal(trimmed, &value); err != nil {
+			return err
+		}
+
+		*g = GradeDTO{Value: value}
+
+		return nil
+	}
+
+	type plainGradeDTO GradeDTO
+
+	var p plainGradeDTO
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	*g = GradeDTO(p)
+
+	return nil
+}
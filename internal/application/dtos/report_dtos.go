@@ -0,0 +1,40 @@
+package dtos
+
+type ReportQueryDTO struct {
+	Top int `json:"top,omitempty" validate:"gte=0,lte=100"`
+}
+
+type StudentSummaryDTO struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Surname string `json:"surname"`
+	Age     int    `json:"age"`
+}
+
+type StudentAVGDTO struct {
+	StudentSummaryDTO
+
+	AVG    float64 `json:"avg"`
+	Mapped string  `json:"mapped,omitempty"`
+	Count  int     `json:"count"`
+}
+
+type HistogramBucketDTO struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+type RosterReportDTO struct {
+	Averaging         string               `json:"averaging"`
+	Scale             string               `json:"scale"`
+	Students          int                  `json:"students"`
+	Graded            int                  `json:"graded"`
+	Mean              float64              `json:"mean"`
+	Median            float64              `json:"median"`
+	StdDev            float64              `json:"stddev"`
+	GradeDistribution []HistogramBucketDTO `json:"grade_distribution"`
+	AgeDistribution   []HistogramBucketDTO `json:"age_distribution"`
+	Top               []StudentAVGDTO      `json:"top"`
+	Bottom            []StudentAVGDTO      `json:"bottom"`
+	NoGrades          []StudentSummaryDTO  `json:"no_grades"`
+}
@@ -1,23 +1,41 @@
 package dtos
 
+type GradeDTO struct {
+	CourseID string  `json:"course_id"         validate:"omitempty,uuid"`
+	Value    int     `json:"value"             validate:"gte=0,lte=100"`
+	Date     string  `json:"date,omitempty"    validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Author   string  `json:"author,omitempty"  validate:"max=64"`
+	Comment  string  `json:"comment,omitempty" validate:"max=256"`
+	Weight   float64 `json:"weight,omitempty"  validate:"gte=0,lte=10"`
+}
+
+// StudentCreateDTO registers a new student. ID is left empty to get a fresh
+// one, only imports set it to keep the ids of exported students, clients of
+// the API can not choose it.
 type StudentCreateDTO struct {
-	Name    string `json:"name"    validate:"required,capitalized"`
-	Surname string `json:"surname" validate:"required,capitalized"`
-	Age     int    `json:"age"     validate:"gte=0,lte=150"`
-	Grades  []int  `json:"grades"  validate:"omitempty,dive,gte=0,lte=100"`
+	ID      string     `json:"-"       validate:"omitempty,uuid4"`
+	Name    string     `json:"name"    validate:"required,capitalized"`
+	Surname string     `json:"surname" validate:"required,capitalized"`
+	Age     int        `json:"age"     validate:"gte=0,lte=150"`
+	Grades  []GradeDTO `json:"grades"  validate:"omitempty,dive"`
 }
 
 type StudentUpdateDTO struct {
-	ID      string `json:"id"      validate:"required,uuid4"`
-	Name    string `json:"name"    validate:"required,capitalized"`
-	Surname string `json:"surname" validate:"required,capitalized"`
-	Age     int    `json:"age"     validate:"gte=0,lte=150"`
-	Grades  []int  `json:"grades"  validate:"omitempty,dive,gte=0,lte=100"`
+	ID      string     `json:"id"      validate:"required,uuid4"`
+	Name    string     `json:"name"    validate:"required,capitalized"`
+	Surname string     `json:"surname" validate:"required,capitalized"`
+	Age     int        `json:"age"     validate:"gte=0,lte=150"`
+	Grades  []GradeDTO `json:"grades"  validate:"omitempty,dive"`
+	Version int        `json:"version" validate:"required,gte=1"`
 }
 
 type AddGradesDTO struct {
-	ID     string `json:"id"     validate:"required,uuid4"`
-	Grades []int  `json:"grades" validate:"required,min=1,dive,gte=0,lte=100"`
+	ID       string  `json:"id"                  validate:"required,uuid4"`
+	CourseID string  `json:"course_id,omitempty" validate:"omitempty,uuid"`
+	Grades   []int   `json:"grades"              validate:"required,min=1,dive,gte=0,lte=100"`
+	Author   string  `json:"author,omitempty"    validate:"max=64"`
+	Comment  string  `json:"comment,omitempty"   validate:"max=256"`
+	Weight   float64 `json:"weight,omitempty"    validate:"gte=0,lte=10"`
 }
 
 type GetByFullNameDTO struct {
@@ -25,28 +43,106 @@ type GetByFullNameDTO struct {
 	Surname string `json:"surname" validate:"required,capitalized"`
 }
 
+type SearchByNameDTO struct {
+	Query         string `json:"query"                    validate:"required,max=128"`
+	IncludeGrades bool   `json:"include_grades,omitempty"`
+}
+
 type GetByIDDTO struct {
 	ID string `json:"id" validate:"required,uuid"`
 }
 
+type AVGQueryDTO struct {
+	ID    string `json:"id"              validate:"required,uuid"`
+	Days  int    `json:"days,omitempty"  validate:"gte=0,lte=3650,excluded_with=Since"`
+	Since string `json:"since,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
 type DefaultStudentResponseDTO struct {
-	ID       string   `json:"id"`
-	Name     string   `json:"name"`
-	Surname  string   `json:"surname"`
-	Age      int      `json:"age"`
-	Grades   []int    `json:"grades,omitempty"`
-	AvgGrade *float64 `json:"avg_grade,omitempty"`
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Surname   string     `json:"surname"`
+	Age       int        `json:"age"`
+	Grades    []GradeDTO `json:"grades,omitempty"`
+	AvgGrade  *float64   `json:"avg_grade,omitempty"`
+	AvgMapped string     `json:"avg_mapped,omitempty"`
+	Version   int        `json:"version"`
 }
 
 type StudentListItemDTO struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Surname string `json:"surname"`
-	Age     int    `json:"age"`
-	Grades  []int  `json:"grades,omitempty"`
+	ID       string     `json:"id"`
+	Name     string     `json:"name"`
+	Surname  string     `json:"surname"`
+	Age      int        `json:"age"`
+	Grades   []GradeDTO `json:"grades,omitempty"`
+	AvgGrade *float64   `json:"avg_grade,omitempty"`
+}
+
+type DeletedStudentDTO struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Surname   string     `json:"surname"`
+	Age       int        `json:"age"`
+	Grades    []GradeDTO `json:"grades,omitempty"`
+	DeletedAt string     `json:"deleted_at"`
+}
+
+// StudentAtDTO asks for a student as it was at At, either an RFC 3339 time or
+// a date meaning the end of that day in UTC.
+type StudentAtDTO struct {
+	ID string `json:"id" validate:"required,uuid4"`
+	At string `json:"at" validate:"required"`
+}
+
+type StudentRevisionDTO struct {
+	Version int                       `json:"version"`
+	At      string                    `json:"at"`
+	Deleted bool                      `json:"deleted"`
+	Student DefaultStudentResponseDTO `json:"student"`
+	Changes []string                  `json:"changes"`
+}
+
+type PurgeDeletedDTO struct {
+	OlderThanDays int `json:"older_than_days" validate:"gte=0,lte=3650"`
+}
+
+type CourseAVGDTO struct {
+	CourseID   string  `json:"course_id"`
+	CourseName string  `json:"course_name"`
+	AVG        float64 `json:"avg"`
+	Mapped     string  `json:"mapped,omitempty"`
+	Count      int     `json:"count"`
 }
 
 type AVGResponseDTO struct {
-	ID  string  `json:"id"`
-	AVG float64 `json:"avg"`
+	ID        string         `json:"id"`
+	AVG       float64        `json:"avg"`
+	Mapped    string         `json:"mapped,omitempty"`
+	Averaging string         `json:"averaging"`
+	Scale     string         `json:"scale"`
+	Count     int            `json:"count"`
+	Since     string         `json:"since,omitempty"`
+	Courses   []CourseAVGDTO `json:"courses,omitempty"`
+}
+
+type StudentQueryDTO struct {
+	Name          string   `json:"name,omitempty"           validate:"max=64"`
+	Surname       string   `json:"surname,omitempty"        validate:"max=64"`
+	MinAge        *int     `json:"min_age,omitempty"        validate:"omitempty,gte=0,lte=150"`
+	MaxAge        *int     `json:"max_age,omitempty"        validate:"omitempty,gte=0,lte=150"`
+	MinAVG        *float64 `json:"min_avg,omitempty"        validate:"omitempty,gte=0,lte=100"`
+	MaxAVG        *float64 `json:"max_avg,omitempty"        validate:"omitempty,gte=0,lte=100"`
+	HasGrades     *bool    `json:"has_grades,omitempty"`
+	Sort          string   `json:"sort,omitempty"           validate:"omitempty,oneof=created name surname age avg"`
+	Desc          bool     `json:"desc,omitempty"`
+	Offset        int      `json:"offset,omitempty"         validate:"gte=0"`
+	Limit         int      `json:"limit,omitempty"          validate:"gte=0,lte=1000"`
+	IncludeGrades bool     `json:"include_grades,omitempty"`
+}
+
+type StudentPageDTO struct {
+	Items  []StudentListItemDTO `json:"items"`
+	Total  int                  `json:"total"`
+	Offset int                  `json:"offset"`
+	Limit  int                  `json:"limit"`
 }
@@ -0,0 +1,136 @@
+package mappers
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+func MapAuditQueryDTOToFilter(d dtos.AuditQueryDTO) (repositories.AuditFilter, error) {
+	if err := validators.Validate.Struct(d); err != nil {
+		return repositories.AuditFilter{}, fmt.Errorf("failed to validate audit query dto: %w", err)
+	}
+
+	filter := repositories.AuditFilter{
+		Actor:  strings.TrimSpace(d.Actor),
+		Action: models.AuditAction(d.Action),
+	}
+
+	if d.StudentID != "" {
+		id, err := uuid.Parse(d.StudentID)
+		if err != nil {
+			return repositories.AuditFilter{}, fmt.Errorf(
+				"failed to parse student id from string to uuid: %w",
+				err,
+			)
+		}
+
+		filter.StudentID = id
+	}
+
+	return filter, nil
+}
+
+func MapAuditEntryToDTO(e models.AuditEntry) dtos.AuditEntryDTO {
+	return dtos.AuditEntryDTO{
+		Seq:       e.Seq,
+		At:        e.At.UTC().Format(time.RFC3339),
+		Actor:     e.Actor,
+		Action:    string(e.Action),
+		StudentID: e.StudentID.String(),
+		Before:    mapAuditStudent(e.Before),
+		After:     mapAuditStudent(e.After),
+		Changes:   auditChanges(e.Before, e.After),
+		Hash:      e.Hash,
+	}
+}
+
+func mapAuditStudent(st *models.Student) *dtos.StudentListItemDTO {
+	if st == nil {
+		return nil
+	}
+
+	item := mapStudentListItem(st, true)
+
+	return &item
+}
+
+// auditChanges describes an entry in short human readable lines.
+func auditChanges(before, after *models.Student) []string {
+	switch {
+	case before == nil && after == nil:
+		return []string{}
+	case before == nil:
+		return []string{fmt.Sprintf(
+			"created %s %s, age %d, grades [%s]",
+			after.Name, after.Surname, after.Age, gradeValues(after.Grades),
+		)}
+	case after == nil && before.Deleted():
+		return []string{fmt.Sprintf("purged %s %s from trash", before.Name, before.Surname)}
+	case after == nil:
+		return []string{fmt.Sprintf("deleted %s %s", before.Name, before.Surname)}
+	}
+
+	changes := []string{}
+
+	switch {
+	case before.Deleted() && !after.Deleted():
+		changes = append(changes, "restored from trash")
+	case !before.Deleted() && after.Deleted():
+		changes = append(changes, "moved to trash")
+	}
+
+	if before.Name != after.Name {
+		changes = append(changes, fmt.Sprintf("name: %s -> %s", before.Name, after.Name))
+	}
+
+	if before.Surname != after.Surname {
+		changes = append(changes, fmt.Sprintf("surname: %s -> %s", before.Surname, after.Surname))
+	}
+
+	if before.Age != after.Age {
+		changes = append(changes, fmt.Sprintf("age: %d -> %d", before.Age, after.Age))
+	}
+
+	n := len(before.Grades)
+
+	switch {
+	case len(after.Grades) > n && slices.EqualFunc(before.Grades, after.Grades[:n], sameGrade):
+		changes = append(changes, fmt.Sprintf("grades added: [%s]", gradeValues(after.Grades[n:])))
+	case !slices.EqualFunc(before.Grades, after.Grades, sameGrade):
+		changes = append(changes, fmt.Sprintf(
+			"grades: [%s] -> [%s]",
+			gradeValues(before.Grades),
+			gradeValues(after.Grades),
+		))
+	}
+
+	return changes
+}
+
+func sameGrade(a, b models.Grade) bool {
+	return a.CourseID == b.CourseID &&
+		a.Value == b.Value &&
+		a.Date.Equal(b.Date) &&
+		a.Author == b.Author &&
+		a.Comment == b.Comment &&
+		a.Weight == b.Weight
+}
+
+func gradeValues(grades []models.Grade) string {
+	values := make([]string, 0, len(grades))
+	for _, g := range grades {
+		values = append(values, strconv.Itoa(g.Value))
+	}
+
+	return strings.Join(values, ", ")
+}
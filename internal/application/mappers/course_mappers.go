@@ -0,0 +1,47 @@
+package mappers
+
+import (
+	"fmt"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+func MapCourseCreateDTOToDomain(d dtos.CourseCreateDTO) (*models.Course, error) {
+	if err := validators.Validate.Struct(d); err != nil {
+		return nil, fmt.Errorf("failed to validate course create dto: %w", err)
+	}
+
+	course, err := models.NewCourse(d.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build domain course from create dto: %w", err)
+	}
+
+	return course, nil
+}
+
+func MapCourseDomainToResponseDTO(course *models.Course) dtos.CourseResponseDTO {
+	if course == nil {
+		return dtos.CourseResponseDTO{}
+	}
+
+	return dtos.CourseResponseDTO{
+		ID:   course.ID.String(),
+		Name: course.Name,
+	}
+}
+
+func MapCoursesDomainToResponseDTO(list []*models.Course) []dtos.CourseResponseDTO {
+	out := make([]dtos.CourseResponseDTO, 0, len(list))
+
+	for _, course := range list {
+		if course == nil {
+			continue
+		}
+
+		out = append(out, MapCourseDomainToResponseDTO(course))
+	}
+
+	return out
+}
@@ -0,0 +1,165 @@
+package mappers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+func MapGradeValuesToDTOs(courseID string, values []int) []dtos.GradeDTO {
+	if len(values) == 0 {
+		return nil
+	}
+
+	out := make([]dtos.GradeDTO, 0, len(values))
+
+	for _, v := range values {
+		out = append(out, dtos.GradeDTO{CourseID: courseID, Value: v})
+	}
+
+	return out
+}
+
+func MapGradeDTOsToValues(grades []dtos.GradeDTO) []int {
+	values := make([]int, 0, len(grades))
+
+	for _, g := range grades {
+		values = append(values, g.Value)
+	}
+
+	return values
+}
+
+func mapGradeDTOsToDomain(in []dtos.GradeDTO, now time.Time) ([]models.Grade, error) {
+	if len(in) == 0 {
+		return nil, nil
+	}
+
+	grades := make([]models.Grade, 0, len(in))
+
+	for i, d := range in {
+		courseID, err := parseCourseID(d.CourseID)
+		if err != nil {
+			return nil, fmt.Errorf("grade #%d: %w", i+1, err)
+		}
+
+		date := now
+
+		if d.Date != "" {
+			date, err = time.Parse(time.RFC3339, d.Date)
+			if err != nil {
+				return nil, fmt.Errorf("grade #%d: failed to parse date: %w", i+1, err)
+			}
+		}
+
+		grades = append(grades, models.Grade{
+			CourseID: courseID,
+			Value:    d.Value,
+			Date:     date.UTC(),
+			Author:   d.Author,
+			Comment:  d.Comment,
+			Weight:   d.Weight,
+		})
+	}
+
+	return grades, nil
+}
+
+// KeepStoredGradeMetadata gives bare grades of an update, the value only form
+// legacy clients still send, the course, date, author, comment and weight of
+// the stored grade they stand for, so resending the grades as plain integers
+// does not rewrite them. grades are the mapped in grades and are changed in
+// place. A bare grade stands for the stored grade at its position when the
+// values match, otherwise for the first stored grade with its value that no
+// other grade of the update stands for. The rest are new grades.
+func KeepStoredGradeMetadata(in []dtos.GradeDTO, grades, stored []models.Grade) {
+	claimed := make([]bool, len(stored))
+
+	claim := func(match func(g models.Grade) bool) (models.Grade, bool) {
+		for i, g := range stored {
+			if !claimed[i] && match(g) {
+				claimed[i] = true
+
+				return g, true
+			}
+		}
+
+		return models.Grade{}, false
+	}
+
+	for i, d := range in {
+		if isBareGrade(d) {
+			continue
+		}
+
+		claim(func(g models.Grade) bool {
+			return g.CourseID == grades[i].CourseID &&
+				g.Value == grades[i].Value &&
+				g.Date.Equal(grades[i].Date)
+		})
+	}
+
+	for i, d := range in {
+		if !isBareGrade(d) {
+			continue
+		}
+
+		if i < len(stored) && !claimed[i] && stored[i].Value == d.Value {
+			claimed[i] = true
+			grades[i] = stored[i]
+
+			continue
+		}
+
+		if g, ok := claim(func(g models.Grade) bool { return g.Value == d.Value }); ok {
+			grades[i] = g
+		}
+	}
+}
+
+func isBareGrade(d dtos.GradeDTO) bool {
+	return d == dtos.GradeDTO{Value: d.Value}
+}
+
+func mapGradesDomainToDTO(grades []models.Grade) []dtos.GradeDTO {
+	if len(grades) == 0 {
+		return nil
+	}
+
+	out := make([]dtos.GradeDTO, 0, len(grades))
+
+	for _, g := range grades {
+		d := dtos.GradeDTO{
+			CourseID: g.CourseID.String(),
+			Value:    g.Value,
+			Author:   g.Author,
+			Comment:  g.Comment,
+			Weight:   g.Weight,
+		}
+
+		if !g.Date.IsZero() {
+			d.Date = g.Date.UTC().Format(time.RFC3339)
+		}
+
+		out = append(out, d)
+	}
+
+	return out
+}
+
+func parseCourseID(raw string) (uuid.UUID, error) {
+	if raw == "" {
+		return models.UnassignedCourseID, nil
+	}
+
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to parse course id from string to uuid: %w", err)
+	}
+
+	return id, nil
+}
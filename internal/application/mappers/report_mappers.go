@@ -0,0 +1,36 @@
+package mappers
+
+import (
+	"fmt"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const DefaultReportTop = 5
+
+func MapReportQueryDTOToTop(d dtos.ReportQueryDTO) (int, error) {
+	if err := validators.Validate.Struct(d); err != nil {
+		return 0, fmt.Errorf("failed to validate report query dto: %w", err)
+	}
+
+	if d.Top == 0 {
+		return DefaultReportTop, nil
+	}
+
+	return d.Top, nil
+}
+
+func MapStudentDomainToSummaryDTO(student *models.Student) dtos.StudentSummaryDTO {
+	if student == nil {
+		return dtos.StudentSummaryDTO{}
+	}
+
+	return dtos.StudentSummaryDTO{
+		ID:      student.ID.String(),
+		Name:    student.Name,
+		Surname: student.Surname,
+		Age:     student.Age,
+	}
+}
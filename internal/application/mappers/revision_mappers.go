@@ -0,0 +1,34 @@
+package mappers
+
+import (
+	"time"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+// MapStudentRevisionsToDTO keeps the order of revisions and describes each one
+// by its changes against the revision before it.
+func MapStudentRevisionsToDTO(
+	revisions []models.StudentRevision,
+	policy grading.Policy,
+) []dtos.StudentRevisionDTO {
+	out := make([]dtos.StudentRevisionDTO, 0, len(revisions))
+
+	var prev *models.Student
+
+	for _, rev := range revisions {
+		out = append(out, dtos.StudentRevisionDTO{
+			Version: rev.Version,
+			At:      rev.At.UTC().Format(time.RFC3339),
+			Deleted: rev.Student.Deleted(),
+			Student: MapStudentDomainToDefaultResponseDTO(rev.Student, true, policy),
+			Changes: auditChanges(prev, rev.Student),
+		})
+
+		prev = rev.Student
+	}
+
+	return out
+}
@@ -1,13 +1,17 @@
 package mappers
 
 import (
+	"time"
+
 	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
 	"github.com/k6zma/avito-lab1/internal/domain/models"
 )
 
 func MapStudentDomainToDefaultResponseDTO(
 	student *models.Student,
 	withAVG bool,
+	policy grading.Policy,
 ) dtos.DefaultStudentResponseDTO {
 	if student == nil {
 		return dtos.DefaultStudentResponseDTO{}
@@ -18,26 +22,25 @@ func MapStudentDomainToDefaultResponseDTO(
 		Name:    student.Name,
 		Surname: student.Surname,
 		Age:     student.Age,
-		Grades:  append([]int(nil), student.Grades...),
+		Grades:  mapGradesDomainToDTO(student.Grades),
+		Version: student.Version,
 	}
 
 	if withAVG && len(student.Grades) > 0 {
-		sum := 0
-
-		for _, g := range student.Grades {
-			sum += g
-		}
-
-		avg := float64(sum) / float64(len(student.Grades))
+		avg := policy.Average(student.Grades)
 		res.AvgGrade = &avg
+		res.AvgMapped = policy.Map(avg)
 	}
 
 	return res
 }
 
+// MapStudentsDomainToListDTO averages the grades with the policy, so lists
+// show the same average as the student itself.
 func MapStudentsDomainToListDTO(
 	list []*models.Student,
 	includeGrades bool,
+	policy grading.Policy,
 ) []dtos.StudentListItemDTO {
 	out := make([]dtos.StudentListItemDTO, 0, len(list))
 	for _, student := range list {
@@ -45,15 +48,54 @@ func MapStudentsDomainToListDTO(
 			continue
 		}
 
-		item := dtos.StudentListItemDTO{
-			ID:      student.ID.String(),
-			Name:    student.Name,
-			Surname: student.Surname,
-			Age:     student.Age,
+		item := mapStudentListItem(student, includeGrades)
+
+		if len(student.Grades) > 0 {
+			avg := policy.Average(student.Grades)
+			item.AvgGrade = &avg
+		}
+
+		out = append(out, item)
+	}
+
+	return out
+}
+
+func mapStudentListItem(student *models.Student, includeGrades bool) dtos.StudentListItemDTO {
+	item := dtos.StudentListItemDTO{
+		ID:      student.ID.String(),
+		Name:    student.Name,
+		Surname: student.Surname,
+		Age:     student.Age,
+	}
+
+	if includeGrades && len(student.Grades) > 0 {
+		item.Grades = mapGradesDomainToDTO(student.Grades)
+	}
+
+	return item
+}
+
+func MapStudentsDomainToDeletedDTO(
+	list []*models.Student,
+	includeGrades bool,
+) []dtos.DeletedStudentDTO {
+	out := make([]dtos.DeletedStudentDTO, 0, len(list))
+	for _, student := range list {
+		if student == nil || student.DeletedAt == nil {
+			continue
+		}
+
+		item := dtos.DeletedStudentDTO{
+			ID:        student.ID.String(),
+			Name:      student.Name,
+			Surname:   student.Surname,
+			Age:       student.Age,
+			DeletedAt: student.DeletedAt.UTC().Format(time.RFC3339),
 		}
 
 		if includeGrades && len(student.Grades) > 0 {
-			item.Grades = append([]int(nil), student.Grades...)
+			item.Grades = mapGradesDomainToDTO(student.Grades)
 		}
 
 		out = append(out, item)
@@ -2,11 +2,14 @@ package mappers
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/k6zma/avito-lab1/internal/application/dtos"
 	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
 	"github.com/k6zma/avito-lab1/pkg/validators"
 )
 
@@ -15,11 +18,28 @@ func MapStudentCreateDTOToDomain(d dtos.StudentCreateDTO) (*models.Student, erro
 		return nil, fmt.Errorf("failed to validate student create dto: %w", err)
 	}
 
+	var id uuid.UUID
+
+	if d.ID != "" {
+		parsed, err := uuid.Parse(d.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse id from string to uuid: %w", err)
+		}
+
+		id = parsed
+	}
+
+	grades, err := mapGradeDTOsToDomain(d.Grades, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to map grades from create dto: %w", err)
+	}
+
 	student, err := models.NewStudentBuilder().
+		SetID(id).
 		SetName(d.Name).
 		SetSurname(d.Surname).
 		SetAge(d.Age).
-		SetGrades(append([]int(nil), d.Grades...)).
+		SetGrades(grades).
 		Build()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build domain student from create dto: %w", err)
@@ -38,22 +58,28 @@ func MapStudentUpdateDTOToDomain(d dtos.StudentUpdateDTO) (*models.Student, erro
 		return nil, fmt.Errorf("failed to parse id from string to uuid: %w", err)
 	}
 
+	grades, err := mapGradeDTOsToDomain(d.Grades, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to map grades from update dto: %w", err)
+	}
+
 	student, err := models.NewStudentBuilder().
 		SetName(d.Name).
 		SetSurname(d.Surname).
 		SetAge(d.Age).
-		SetGrades(append([]int(nil), d.Grades...)).
+		SetGrades(grades).
 		Build()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build domain student from update dto: %w", err)
 	}
 
 	student.ID = id
+	student.Version = d.Version
 
 	return student, nil
 }
 
-func MapAddGradesDTOToArgs(d dtos.AddGradesDTO) (uuid.UUID, []int, error) {
+func MapAddGradesDTOToArgs(d dtos.AddGradesDTO) (uuid.UUID, []models.Grade, error) {
 	if err := validators.Validate.Struct(d); err != nil {
 		return uuid.Nil, nil, fmt.Errorf("failed to validate add-grades dto: %w", err)
 	}
@@ -63,7 +89,83 @@ func MapAddGradesDTOToArgs(d dtos.AddGradesDTO) (uuid.UUID, []int, error) {
 		return uuid.Nil, nil, fmt.Errorf("failed to parse id from string to uuid: %w", err)
 	}
 
-	return id, append([]int(nil), d.Grades...), nil
+	courseID, err := parseCourseID(d.CourseID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	grades := models.NewGrades(courseID, time.Now().UTC(), d.Grades...)
+
+	for i := range grades {
+		grades[i].Author = d.Author
+		grades[i].Comment = d.Comment
+		grades[i].Weight = d.Weight
+	}
+
+	return id, grades, nil
+}
+
+func MapAVGQueryDTOToArgs(d dtos.AVGQueryDTO, now time.Time) (uuid.UUID, time.Time, error) {
+	if err := validators.Validate.Struct(d); err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("failed to validate avg query dto: %w", err)
+	}
+
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("failed to parse id from string to uuid: %w", err)
+	}
+
+	switch {
+	case d.Since != "":
+		since, err := time.Parse(time.DateOnly, d.Since)
+		if err != nil {
+			return uuid.Nil, time.Time{}, fmt.Errorf("failed to parse since date: %w", err)
+		}
+
+		return id, since, nil
+	case d.Days > 0:
+		return id, now.UTC().AddDate(0, 0, -d.Days), nil
+	default:
+		return id, time.Time{}, nil
+	}
+}
+
+// MapPurgeDeletedDTOToCutoff returns the moment students must have been
+// deleted by to be purged; zero days means everything in the trash.
+func MapPurgeDeletedDTOToCutoff(d dtos.PurgeDeletedDTO, now time.Time) (time.Time, error) {
+	if err := validators.Validate.Struct(d); err != nil {
+		return time.Time{}, fmt.Errorf("failed to validate purge-deleted dto: %w", err)
+	}
+
+	return now.UTC().AddDate(0, 0, -d.OlderThanDays), nil
+}
+
+// MapStudentAtDTOToArgs returns the last moment the wanted state may have been
+// recorded at. A bare date covers that whole day.
+func MapStudentAtDTOToArgs(d dtos.StudentAtDTO) (uuid.UUID, time.Time, error) {
+	if err := validators.Validate.Struct(d); err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("failed to validate student-at dto: %w", err)
+	}
+
+	id, err := uuid.Parse(d.ID)
+	if err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf("failed to parse id from string to uuid: %w", err)
+	}
+
+	if at, err := time.Parse(time.RFC3339, d.At); err == nil {
+		return id, at, nil
+	}
+
+	day, err := time.Parse(time.DateOnly, d.At)
+	if err != nil {
+		return uuid.Nil, time.Time{}, fmt.Errorf(
+			"failed to parse %q as a date or an RFC 3339 time: %w",
+			d.At,
+			err,
+		)
+	}
+
+	return id, day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
 }
 
 func MapGetByFullNameDTOToArgs(d dtos.GetByFullNameDTO) (string, string, error) {
@@ -74,6 +176,14 @@ func MapGetByFullNameDTOToArgs(d dtos.GetByFullNameDTO) (string, string, error)
 	return d.Name, d.Surname, nil
 }
 
+func MapSearchByNameDTOToQuery(d dtos.SearchByNameDTO) (string, error) {
+	if err := validators.Validate.Struct(d); err != nil {
+		return "", fmt.Errorf("failed to validate search-by-name dto: %w", err)
+	}
+
+	return strings.TrimSpace(d.Query), nil
+}
+
 func MapGetByIDDTOToUUID(d dtos.GetByIDDTO) (uuid.UUID, error) {
 	if err := validators.Validate.Struct(d); err != nil {
 		return uuid.Nil, fmt.Errorf("failed to validate get-by-id dto: %w", err)
@@ -86,3 +196,23 @@ func MapGetByIDDTOToUUID(d dtos.GetByIDDTO) (uuid.UUID, error) {
 
 	return id, nil
 }
+
+func MapStudentQueryDTOToDomain(d dtos.StudentQueryDTO) (repositories.StudentQuery, error) {
+	if err := validators.Validate.Struct(d); err != nil {
+		return repositories.StudentQuery{}, fmt.Errorf("failed to validate student query dto: %w", err)
+	}
+
+	return repositories.StudentQuery{
+		NamePrefix:    strings.TrimSpace(d.Name),
+		SurnamePrefix: strings.TrimSpace(d.Surname),
+		MinAge:        d.MinAge,
+		MaxAge:        d.MaxAge,
+		MinAVG:        d.MinAVG,
+		MaxAVG:        d.MaxAVG,
+		HasGrades:     d.HasGrades,
+		SortBy:        repositories.StudentSortKey(d.Sort),
+		Desc:          d.Desc,
+		Offset:        d.Offset,
+		Limit:         d.Limit,
+	}, nil
+}
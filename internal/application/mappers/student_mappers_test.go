@@ -3,11 +3,13 @@ package mappers_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/k6zma/avito-lab1/internal/application/dtos"
 	"github.com/k6zma/avito-lab1/internal/application/mappers"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
 	"github.com/k6zma/avito-lab1/internal/domain/models"
 	"github.com/k6zma/avito-lab1/pkg/validators"
 )
@@ -40,7 +42,7 @@ func TestMapStudentCreateDTOToDomain_Success_And_Validation(t *testing.T) {
 				Name:    "Mikhail",
 				Surname: "Gunin",
 				Age:     19,
-				Grades:  []int{90, 60},
+				Grades:  mappers.MapGradeValuesToDTOs("", []int{90, 60}),
 			},
 			ok: true,
 		},
@@ -68,7 +70,7 @@ func TestMapStudentCreateDTOToDomain_Success_And_Validation(t *testing.T) {
 				Name:    "Mikhail",
 				Surname: "Gunin",
 				Age:     19,
-				Grades:  []int{120},
+				Grades:  mappers.MapGradeValuesToDTOs("", []int{120}),
 			},
 			ok: false,
 		},
@@ -103,7 +105,8 @@ func TestMapStudentCreateDTOToDomain_Success_And_Validation(t *testing.T) {
 					)
 				}
 
-				if fmt.Sprint(got.Grades) != fmt.Sprint(tc.in.Grades) {
+				if fmt.Sprint(models.GradeValues(got.Grades)) !=
+					fmt.Sprint(mappers.MapGradeDTOsToValues(tc.in.Grades)) {
 					t.Fatalf("[%s][MapStudentCreateDTOToDomain] grades mismatch: got=%v want=%v",
 						mapperTestPrefix, got.Grades, tc.in.Grades)
 				}
@@ -127,10 +130,21 @@ func TestMapStudentUpdateDTOToDomain_Success_And_Validation(t *testing.T) {
 				Name:    "Alexander",
 				Surname: "Gunin",
 				Age:     21,
-				Grades:  []int{70, 85},
+				Grades:  mappers.MapGradeValuesToDTOs("", []int{70, 85}),
+				Version: 3,
 			},
 			ok: true,
 		},
+		{
+			name: "missing version",
+			in: dtos.StudentUpdateDTO{
+				ID:      validID,
+				Name:    "Alexander",
+				Surname: "Gunin",
+				Age:     21,
+			},
+			ok: false,
+		},
 		{
 			name: "invalid id (not uuid4)",
 			in: dtos.StudentUpdateDTO{
@@ -182,7 +196,17 @@ func TestMapStudentUpdateDTOToDomain_Success_And_Validation(t *testing.T) {
 					)
 				}
 
-				if fmt.Sprint(got.Grades) != fmt.Sprint(tc.in.Grades) {
+				if got.Version != tc.in.Version {
+					t.Fatalf(
+						"[%s][MapStudentUpdateDTOToDomain] version mismatch: got=%d want=%d",
+						mapperTestPrefix,
+						got.Version,
+						tc.in.Version,
+					)
+				}
+
+				if fmt.Sprint(models.GradeValues(got.Grades)) !=
+					fmt.Sprint(mappers.MapGradeDTOsToValues(tc.in.Grades)) {
 					t.Fatalf("[%s][MapStudentUpdateDTOToDomain] grades mismatch: got=%v want=%v",
 						mapperTestPrefix, got.Grades, tc.in.Grades)
 				}
@@ -219,7 +243,7 @@ func TestMapAddGradesDTOToArgs_Success_And_Validation(t *testing.T) {
 			id,
 		)
 	}
-	if fmt.Sprint(gotGrades) != fmt.Sprint([]int{10, 20}) {
+	if fmt.Sprint(models.GradeValues(gotGrades)) != fmt.Sprint([]int{10, 20}) {
 		t.Fatalf("[%s][MapAddGradesDTOToArgs(ok)] grades mismatch: got=%v want=%v",
 			mapperTestPrefix, gotGrades, []int{10, 20})
 	}
@@ -310,13 +334,13 @@ func TestMapStudentDomainToDefaultResponseDTO_WithAndWithoutAVG_And_Nil(t *testi
 		SetName("Mikhail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{90, 60}).
+		SetGrades(models.NewGrades(models.UnassignedCourseID, time.Time{}, 90, 60)).
 		Build()
 	if err != nil {
 		t.Fatalf("[%s][DomainToDTO] failed to build domain student: %v", mapperTestPrefix, err)
 	}
 
-	with := mappers.MapStudentDomainToDefaultResponseDTO(st, true)
+	with := mappers.MapStudentDomainToDefaultResponseDTO(st, true, grading.DefaultPolicy())
 	if with.ID != st.ID.String() || with.Name != st.Name || with.Surname != st.Surname ||
 		with.Age != st.Age {
 		t.Fatalf("[%s][DomainToDTO(with avg)] fields mismatch: got{%q,%q,%q,%d} want{%q,%q,%q,%d}",
@@ -338,7 +362,8 @@ func TestMapStudentDomainToDefaultResponseDTO_WithAndWithoutAVG_And_Nil(t *testi
 		)
 	}
 
-	if fmt.Sprint(with.Grades) != fmt.Sprint(st.Grades) {
+	if fmt.Sprint(mappers.MapGradeDTOsToValues(with.Grades)) !=
+		fmt.Sprint(models.GradeValues(st.Grades)) {
 		t.Fatalf(
 			"[%s][DomainToDTO(with avg)] grades mismatch: got=%v want=%v",
 			mapperTestPrefix,
@@ -347,7 +372,7 @@ func TestMapStudentDomainToDefaultResponseDTO_WithAndWithoutAVG_And_Nil(t *testi
 		)
 	}
 
-	without := mappers.MapStudentDomainToDefaultResponseDTO(st, false)
+	without := mappers.MapStudentDomainToDefaultResponseDTO(st, false, grading.DefaultPolicy())
 	if without.AvgGrade != nil {
 		t.Fatalf(
 			"[%s][DomainToDTO(without avg)] expected AvgGrade nil, got=%v",
@@ -365,7 +390,7 @@ func TestMapStudentDomainToDefaultResponseDTO_WithAndWithoutAVG_And_Nil(t *testi
 		t.Fatalf("[%s][DomainToDTO(empty)] build: %v", mapperTestPrefix, err)
 	}
 
-	no := mappers.MapStudentDomainToDefaultResponseDTO(stNo, true)
+	no := mappers.MapStudentDomainToDefaultResponseDTO(stNo, true, grading.DefaultPolicy())
 	if no.AvgGrade != nil {
 		t.Fatalf(
 			"[%s][DomainToDTO(empty)] expected AvgGrade nil for empty grades",
@@ -373,7 +398,7 @@ func TestMapStudentDomainToDefaultResponseDTO_WithAndWithoutAVG_And_Nil(t *testi
 		)
 	}
 
-	zero := mappers.MapStudentDomainToDefaultResponseDTO(nil, true)
+	zero := mappers.MapStudentDomainToDefaultResponseDTO(nil, true, grading.DefaultPolicy())
 	if zero.ID != "" || zero.Name != "" || zero.Surname != "" || zero.Age != 0 ||
 		len(zero.Grades) != 0 ||
 		zero.AvgGrade != nil {
@@ -390,7 +415,7 @@ func TestMapStudentsDomainToListDTO_IncludeGrades_And_NilItems(t *testing.T) {
 		SetName("Eleven").
 		SetSurname("Doctor").
 		SetAge(100).
-		SetGrades([]int{100}).
+		SetGrades(models.NewGrades(models.UnassignedCourseID, time.Time{}, 100)).
 		Build()
 	if err != nil {
 		t.Fatalf("[%s][ListMap] failed to build first student: %v", mapperTestPrefix, err)
@@ -400,13 +425,17 @@ func TestMapStudentsDomainToListDTO_IncludeGrades_And_NilItems(t *testing.T) {
 		SetName("Mikhail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{80}).
+		SetGrades(models.NewGrades(models.UnassignedCourseID, time.Time{}, 80)).
 		Build()
 	if err != nil {
 		t.Fatalf("[%s][ListMap] failed to build second student: %v", mapperTestPrefix, err)
 	}
 
-	listNo := mappers.MapStudentsDomainToListDTO([]*models.Student{a, nil, b}, false)
+	listNo := mappers.MapStudentsDomainToListDTO(
+		[]*models.Student{a, nil, b},
+		false,
+		grading.DefaultPolicy(),
+	)
 	if len(listNo) != 2 {
 		t.Fatalf(
 			"[%s][ListMap(false)] length mismatch: got=%d want=%d",
@@ -427,7 +456,11 @@ func TestMapStudentsDomainToListDTO_IncludeGrades_And_NilItems(t *testing.T) {
 		}
 	}
 
-	listYes := mappers.MapStudentsDomainToListDTO([]*models.Student{a, nil, b}, true)
+	listYes := mappers.MapStudentsDomainToListDTO(
+		[]*models.Student{a, nil, b},
+		true,
+		grading.DefaultPolicy(),
+	)
 	if len(listYes) != 2 {
 		t.Fatalf(
 			"[%s][ListMap(true)] length mismatch: got=%d want=%d",
@@ -451,4 +484,65 @@ func TestMapStudentsDomainToListDTO_IncludeGrades_And_NilItems(t *testing.T) {
 			hasGrades,
 		)
 	}
+
+	for i, it := range listNo {
+		want := []float64{100, 80}[i]
+		if it.AvgGrade == nil || *it.AvgGrade != want {
+			t.Fatalf(
+				"[%s][ListMap(false)] avg mismatch at idx=%d: got=%v want=%v",
+				mapperTestPrefix,
+				i,
+				it.AvgGrade,
+				want,
+			)
+		}
+	}
+}
+
+func TestMapStudentCreateDTOToDomain_GradeCoursesAndDates(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][GradeCourses] failed to init validators: %v", mapperTestPrefix, err)
+	}
+
+	courseID := uuid.New()
+
+	got, err := mappers.MapStudentCreateDTOToDomain(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades: []dtos.GradeDTO{
+			{CourseID: courseID.String(), Value: 90, Date: "2025-03-14T10:30:00Z"},
+			{Value: 70},
+		},
+	})
+	if err != nil {
+		t.Fatalf("[%s][GradeCourses] unexpected error: %v", mapperTestPrefix, err)
+	}
+
+	wantDate := time.Date(2025, time.March, 14, 10, 30, 0, 0, time.UTC)
+
+	if got.Grades[0].CourseID != courseID || !got.Grades[0].Date.Equal(wantDate) {
+		t.Fatalf("[%s][GradeCourses] first grade mismatch: %+v", mapperTestPrefix, got.Grades[0])
+	}
+
+	if got.Grades[1].CourseID != models.UnassignedCourseID || got.Grades[1].Date.IsZero() {
+		t.Fatalf(
+			"[%s][GradeCourses] grade without course must be unassigned and dated now: %+v",
+			mapperTestPrefix,
+			got.Grades[1],
+		)
+	}
+
+	if _, _, err := mappers.MapAddGradesDTOToArgs(dtos.AddGradesDTO{
+		ID:       uuid.NewString(),
+		CourseID: "not-uuid",
+		Grades:   []int{10},
+	}); err == nil {
+		t.Fatalf("[%s][GradeCourses] expected error for bad course id, got nil", mapperTestPrefix)
+	}
+
+	back := mappers.MapStudentDomainToDefaultResponseDTO(got, false, grading.DefaultPolicy())
+	if back.Grades[0].CourseID != courseID.String() || back.Grades[0].Date != "2025-03-14T10:30:00Z" {
+		t.Fatalf("[%s][GradeCourses] response grade mismatch: %+v", mapperTestPrefix, back.Grades[0])
+	}
 }
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+)
+
+type AuditServiceContract interface {
+	List(in dtos.AuditQueryDTO) (dtos.AuditLogDTO, error)
+}
+
+type AuditService struct {
+	auditLog repositories.AuditLog
+}
+
+func NewAuditService(log repositories.AuditLog) AuditServiceContract {
+	return &AuditService{
+		auditLog: log,
+	}
+}
+
+// List returns matching entries newest first together with the result of
+// verifying the whole hash chain.
+func (s *AuditService) List(in dtos.AuditQueryDTO) (dtos.AuditLogDTO, error) {
+	filter, err := mappers.MapAuditQueryDTOToFilter(in)
+	if err != nil {
+		return dtos.AuditLogDTO{}, fmt.Errorf("failed to map audit query dto: %w", err)
+	}
+
+	entries, err := s.auditLog.List(filter)
+	if err != nil {
+		return dtos.AuditLogDTO{}, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	out := dtos.AuditLogDTO{
+		Entries:  make([]dtos.AuditEntryDTO, 0, len(entries)),
+		Total:    len(entries),
+		Verified: true,
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if in.Limit > 0 && len(out.Entries) == in.Limit {
+			break
+		}
+
+		out.Entries = append(out.Entries, mappers.MapAuditEntryToDTO(entries[i]))
+	}
+
+	if err := s.auditLog.Verify(); err != nil {
+		out.Verified = false
+		out.Problem = err.Error()
+	}
+
+	return out, nil
+}
@@ -0,0 +1,120 @@
+package services_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	infrarepo "github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	auditServiceTestPrefix = "AuditService"
+)
+
+func TestAuditService_RecordsMutations(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Record] failed to init validators: %v", auditServiceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][Record] error while creating repository: %v", auditServiceTestPrefix, err)
+	}
+
+	log, err := infrarepo.NewAuditStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][Record] error while creating audit log: %v", auditServiceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), log)
+	audit := services.NewAuditService(log)
+
+	created, err := svc.WithActor("dean").Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+	})
+	if err != nil {
+		t.Fatalf("[%s][Register] unexpected error: %v", auditServiceTestPrefix, err)
+	}
+
+	registrar := svc.WithActor("registrar")
+
+	if _, err := registrar.Update(dtos.StudentUpdateDTO{
+		ID:      created.ID,
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     20,
+		Version: created.Version,
+	}); err != nil {
+		t.Fatalf("[%s][Update] unexpected error: %v", auditServiceTestPrefix, err)
+	}
+
+	if _, err := registrar.AddGrades(dtos.AddGradesDTO{ID: created.ID, Grades: []int{80, 90}}); err != nil {
+		t.Fatalf("[%s][AddGrades] unexpected error: %v", auditServiceTestPrefix, err)
+	}
+
+	if err := svc.DeleteByID(dtos.GetByIDDTO{ID: created.ID}); err != nil {
+		t.Fatalf("[%s][DeleteByID] unexpected error: %v", auditServiceTestPrefix, err)
+	}
+
+	got, err := audit.List(dtos.AuditQueryDTO{})
+	if err != nil {
+		t.Fatalf("[%s][List] unexpected error: %v", auditServiceTestPrefix, err)
+	}
+
+	if !got.Verified || got.Total != 4 || len(got.Entries) != 4 {
+		t.Fatalf("[%s][List] unexpected audit log: %+v", auditServiceTestPrefix, got)
+	}
+
+	want := []struct {
+		action string
+		actor  string
+	}{
+		{action: "delete", actor: services.DefaultActor},
+		{action: "add_grades", actor: "registrar"},
+		{action: "update", actor: "registrar"},
+		{action: "register", actor: "dean"},
+	}
+
+	for i, w := range want {
+		e := got.Entries[i]
+
+		if e.Action != w.action || e.Actor != w.actor || e.StudentID != created.ID {
+			t.Fatalf(
+				"[%s][List] entry #%d: got action=%s actor=%s want action=%s actor=%s",
+				auditServiceTestPrefix, i, e.Action, e.Actor, w.action, w.actor,
+			)
+		}
+	}
+
+	update := got.Entries[2]
+	if update.Before == nil || update.After == nil || update.Before.Age != 19 || update.After.Age != 20 {
+		t.Fatalf("[%s][List] update entry must keep both snapshots: %+v", auditServiceTestPrefix, update)
+	}
+
+	if !slices.Contains(update.Changes, "age: 19 -> 20") {
+		t.Fatalf("[%s][List] update entry changes: %v", auditServiceTestPrefix, update.Changes)
+	}
+
+	if got.Entries[0].After != nil || got.Entries[3].Before != nil {
+		t.Fatalf("[%s][List] register and delete entries must have one snapshot", auditServiceTestPrefix)
+	}
+
+	limited, err := audit.List(dtos.AuditQueryDTO{Actor: "registrar", Limit: 1})
+	if err != nil {
+		t.Fatalf("[%s][List(limit)] unexpected error: %v", auditServiceTestPrefix, err)
+	}
+
+	if limited.Total != 2 || len(limited.Entries) != 1 || limited.Entries[0].Action != "add_grades" {
+		t.Fatalf("[%s][List(limit)] unexpected result: %+v", auditServiceTestPrefix, limited)
+	}
+
+	if _, err := audit.List(dtos.AuditQueryDTO{Action: "rename"}); err == nil {
+		t.Fatalf("[%s][List(invalid)] expected validation error for unknown action", auditServiceTestPrefix)
+	}
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+)
+
+type CourseServiceContract interface {
+	Create(in dtos.CourseCreateDTO) (dtos.CourseResponseDTO, error)
+	GetByID(in dtos.GetByIDDTO) (dtos.CourseResponseDTO, error)
+	List() ([]dtos.CourseResponseDTO, error)
+}
+
+type CourseService struct {
+	courseRepo repositories.CourseRepository
+}
+
+func NewCourseService(repo repositories.CourseRepository) CourseServiceContract {
+	return &CourseService{
+		courseRepo: repo,
+	}
+}
+
+func (s *CourseService) Create(in dtos.CourseCreateDTO) (dtos.CourseResponseDTO, error) {
+	course, err := mappers.MapCourseCreateDTOToDomain(in)
+	if err != nil {
+		return dtos.CourseResponseDTO{}, fmt.Errorf("failed to map course create dto: %w", err)
+	}
+
+	if _, err := s.courseRepo.Create(course); err != nil {
+		return dtos.CourseResponseDTO{}, fmt.Errorf(
+			"failed to create course in repository: %w",
+			err,
+		)
+	}
+
+	return mappers.MapCourseDomainToResponseDTO(course), nil
+}
+
+func (s *CourseService) GetByID(in dtos.GetByIDDTO) (dtos.CourseResponseDTO, error) {
+	id, err := mappers.MapGetByIDDTOToUUID(in)
+	if err != nil {
+		return dtos.CourseResponseDTO{}, fmt.Errorf("failed to map get-by-id dto to uuid: %w", err)
+	}
+
+	course, err := s.courseRepo.GetByID(id)
+	if err != nil {
+		return dtos.CourseResponseDTO{}, fmt.Errorf("failed to get course by id: %w", err)
+	}
+
+	return mappers.MapCourseDomainToResponseDTO(course), nil
+}
+
+func (s *CourseService) List() ([]dtos.CourseResponseDTO, error) {
+	list, err := s.courseRepo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list courses: %w", err)
+	}
+
+	return mappers.MapCoursesDomainToResponseDTO(list), nil
+}
@@ -0,0 +1,59 @@
+package services_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	courseServiceTestPrefix = "CourseService"
+)
+
+func TestCourseService_Create_GetByID_List(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", courseServiceTestPrefix, err)
+	}
+
+	svc := services.NewCourseService(newCourseRepo(t))
+
+	history, err := svc.Create(dtos.CourseCreateDTO{Name: "History"})
+	if err != nil {
+		t.Fatalf("[%s][Create] unexpected error: %v", courseServiceTestPrefix, err)
+	}
+
+	if _, err := svc.Create(dtos.CourseCreateDTO{Name: "History"}); !errors.Is(
+		err,
+		repositories.ErrCourseAlreadyExists,
+	) {
+		t.Fatalf("[%s][Create(duplicate)] want ErrCourseAlreadyExists, got=%v", courseServiceTestPrefix, err)
+	}
+
+	if _, err := svc.Create(dtos.CourseCreateDTO{}); !validators.IsValidationError(err) {
+		t.Fatalf("[%s][Create(empty)] want validation error, got=%v", courseServiceTestPrefix, err)
+	}
+
+	got, err := svc.GetByID(dtos.GetByIDDTO{ID: history.ID})
+	if err != nil || got != history {
+		t.Fatalf("[%s][GetByID] got=%+v err=%v, want=%+v", courseServiceTestPrefix, got, err, history)
+	}
+
+	list, err := svc.List()
+	if err != nil {
+		t.Fatalf("[%s][List] unexpected error: %v", courseServiceTestPrefix, err)
+	}
+
+	want := []dtos.CourseResponseDTO{
+		history,
+		{ID: models.UnassignedCourseID.String(), Name: models.UnassignedCourseName},
+	}
+
+	if len(list) != len(want) || list[0] != want[0] || list[1] != want[1] {
+		t.Fatalf("[%s][List] got=%+v want=%+v", courseServiceTestPrefix, list, want)
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+)
+
+const gradeBucketWidth = 10
+
+type ReportServiceContract interface {
+	Roster(in dtos.ReportQueryDTO) (dtos.RosterReportDTO, error)
+}
+
+type ReportService struct {
+	studentRepo repositories.StudentRepository
+	policy      grading.Policy
+}
+
+func NewReportService(
+	repo repositories.StudentRepository,
+	policy grading.Policy,
+) ReportServiceContract {
+	return &ReportService{
+		studentRepo: repo,
+		policy:      policy,
+	}
+}
+
+func (s *ReportService) Roster(in dtos.ReportQueryDTO) (dtos.RosterReportDTO, error) {
+	top, err := mappers.MapReportQueryDTOToTop(in)
+	if err != nil {
+		return dtos.RosterReportDTO{}, fmt.Errorf("failed to map report query dto: %w", err)
+	}
+
+	students, err := s.studentRepo.List()
+	if err != nil {
+		return dtos.RosterReportDTO{}, fmt.Errorf("failed to list students: %w", err)
+	}
+
+	report := dtos.RosterReportDTO{
+		Averaging:         s.policy.AveragingName(),
+		Scale:             s.policy.ScaleName(),
+		Students:          len(students),
+		GradeDistribution: gradeDistribution(students),
+		AgeDistribution:   ageDistribution(students),
+		Top:               []dtos.StudentAVGDTO{},
+		Bottom:            []dtos.StudentAVGDTO{},
+		NoGrades:          []dtos.StudentSummaryDTO{},
+	}
+
+	averages := make([]dtos.StudentAVGDTO, 0, len(students))
+
+	for _, st := range students {
+		if len(st.Grades) == 0 {
+			report.NoGrades = append(report.NoGrades, mappers.MapStudentDomainToSummaryDTO(st))
+
+			continue
+		}
+
+		avg := s.policy.Average(st.Grades)
+
+		averages = append(averages, dtos.StudentAVGDTO{
+			StudentSummaryDTO: mappers.MapStudentDomainToSummaryDTO(st),
+			AVG:               avg,
+			Mapped:            s.policy.Map(avg),
+			Count:             len(st.Grades),
+		})
+	}
+
+	slices.SortFunc(report.NoGrades, compareSummaries)
+
+	if len(averages) == 0 {
+		return report, nil
+	}
+
+	slices.SortFunc(averages, func(a, b dtos.StudentAVGDTO) int {
+		if c := cmp.Compare(b.AVG, a.AVG); c != 0 {
+			return c
+		}
+
+		return compareSummaries(a.StudentSummaryDTO, b.StudentSummaryDTO)
+	})
+
+	values := make([]float64, 0, len(averages))
+	for _, a := range averages {
+		values = append(values, a.AVG)
+	}
+
+	report.Graded = len(averages)
+	report.Mean, report.StdDev = meanStdDev(values)
+	report.Median = median(values)
+	report.Top = slices.Clone(averages[:min(top, len(averages))])
+
+	for i := len(averages) - 1; i >= 0 && len(report.Bottom) < top; i-- {
+		report.Bottom = append(report.Bottom, averages[i])
+	}
+
+	return report, nil
+}
+
+func compareSummaries(a, b dtos.StudentSummaryDTO) int {
+	return cmp.Or(
+		cmp.Compare(a.Surname, b.Surname),
+		cmp.Compare(a.Name, b.Name),
+		cmp.Compare(a.ID, b.ID),
+	)
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+
+	for _, v := range values {
+		sum += v
+	}
+
+	mean := sum / float64(len(values))
+
+	var sq float64
+
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+// median expects values sorted in any direction.
+func median(values []float64) float64 {
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+func gradeDistribution(students []*models.Student) []dtos.HistogramBucketDTO {
+	buckets := make([]dtos.HistogramBucketDTO, 0, 100/gradeBucketWidth)
+
+	for lo := 0; lo < 100; lo += gradeBucketWidth {
+		hi := lo + gradeBucketWidth - 1
+		if hi == 99 {
+			hi = 100
+		}
+
+		buckets = append(buckets, dtos.HistogramBucketDTO{
+			Label: strconv.Itoa(lo) + "-" + strconv.Itoa(hi),
+		})
+	}
+
+	for _, st := range students {
+		for _, g := range st.Grades {
+			buckets[min(g.Value/gradeBucketWidth, len(buckets)-1)].Count++
+		}
+	}
+
+	return buckets
+}
+
+func ageDistribution(students []*models.Student) []dtos.HistogramBucketDTO {
+	counts := make(map[int]int)
+
+	for _, st := range students {
+		counts[st.Age]++
+	}
+
+	ages := make([]int, 0, len(counts))
+	for age := range counts {
+		ages = append(ages, age)
+	}
+
+	slices.Sort(ages)
+
+	buckets := make([]dtos.HistogramBucketDTO, 0, len(ages))
+	for _, age := range ages {
+		buckets = append(buckets, dtos.HistogramBucketDTO{
+			Label: strconv.Itoa(age),
+			Count: counts[age],
+		})
+	}
+
+	return buckets
+}
@@ -0,0 +1,103 @@
+package services_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	infrarepo "github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	reportTestPrefix = "ReportService"
+)
+
+func TestReportService_Roster(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Roster] failed to init validators: %v", reportTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][Roster] error while creating repository: %v", reportTestPrefix, err)
+	}
+
+	policy, err := grading.NewPolicy("mean", "letter")
+	if err != nil {
+		t.Fatalf("[%s][Roster] failed to build policy: %v", reportTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), policy, nil)
+	reports := services.NewReportService(repo, policy)
+
+	empty, err := reports.Roster(dtos.ReportQueryDTO{})
+	if err != nil || empty.Students != 0 || len(empty.GradeDistribution) != 10 {
+		t.Fatalf("[%s][Roster(empty)] unexpected report: %+v (err=%v)", reportTestPrefix, empty, err)
+	}
+
+	if _, err := svc.RegisterMany([]dtos.StudentCreateDTO{
+		{Name: "Anna", Surname: "Petrova", Age: 18, Grades: gradeDTOs(100, 90)},
+		{Name: "Boris", Surname: "Ivanov", Age: 19, Grades: gradeDTOs(70)},
+		{Name: "Clara", Surname: "Smirnova", Age: 19, Grades: gradeDTOs(50, 60)},
+		{Name: "Denis", Surname: "Orlov", Age: 20},
+	}); err != nil {
+		t.Fatalf("[%s][Roster] failed to register students: %v", reportTestPrefix, err)
+	}
+
+	report, err := reports.Roster(dtos.ReportQueryDTO{Top: 2})
+	if err != nil {
+		t.Fatalf("[%s][Roster] unexpected error: %v", reportTestPrefix, err)
+	}
+
+	if report.Students != 4 || report.Graded != 3 || report.Scale != "letter" {
+		t.Fatalf("[%s][Roster] unexpected counters: %+v", reportTestPrefix, report)
+	}
+
+	if report.Mean != 220.0/3 || report.Median != 70 || math.Abs(report.StdDev-16.4992) > 1e-4 {
+		t.Fatalf(
+			"[%s][Roster] unexpected stats: mean=%v median=%v stddev=%v",
+			reportTestPrefix,
+			report.Mean,
+			report.Median,
+			report.StdDev,
+		)
+	}
+
+	if len(report.Top) != 2 || report.Top[0].Name != "Anna" || report.Top[0].Mapped != "A" ||
+		report.Top[1].Name != "Boris" {
+		t.Fatalf("[%s][Roster] unexpected top: %+v", reportTestPrefix, report.Top)
+	}
+
+	if len(report.Bottom) != 2 || report.Bottom[0].Name != "Clara" || report.Bottom[1].Name != "Boris" {
+		t.Fatalf("[%s][Roster] unexpected bottom: %+v", reportTestPrefix, report.Bottom)
+	}
+
+	if len(report.NoGrades) != 1 || report.NoGrades[0].Name != "Denis" {
+		t.Fatalf("[%s][Roster] unexpected students without grades: %+v", reportTestPrefix, report.NoGrades)
+	}
+
+	wantGrades := map[string]int{"50-59": 1, "60-69": 1, "70-79": 1, "90-100": 2}
+	for _, b := range report.GradeDistribution {
+		if b.Count != wantGrades[b.Label] {
+			t.Fatalf("[%s][Roster] grade bucket %s: got=%d want=%d", reportTestPrefix, b.Label, b.Count, wantGrades[b.Label])
+		}
+	}
+
+	wantAges := []dtos.HistogramBucketDTO{{Label: "18", Count: 1}, {Label: "19", Count: 2}, {Label: "20", Count: 1}}
+	if len(report.AgeDistribution) != len(wantAges) {
+		t.Fatalf("[%s][Roster] unexpected age distribution: %+v", reportTestPrefix, report.AgeDistribution)
+	}
+
+	for i := range wantAges {
+		if report.AgeDistribution[i] != wantAges[i] {
+			t.Fatalf("[%s][Roster] age bucket #%d: got=%+v want=%+v", reportTestPrefix, i+1, report.AgeDistribution[i], wantAges[i])
+		}
+	}
+
+	if _, err := reports.Roster(dtos.ReportQueryDTO{Top: -1}); err == nil {
+		t.Fatalf("[%s][Roster] expected validation error for negative top", reportTestPrefix)
+	}
+}
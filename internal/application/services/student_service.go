@@ -2,33 +2,78 @@ package services
 
 import (
 	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/k6zma/avito-lab1/internal/application/dtos"
 	"github.com/k6zma/avito-lab1/internal/application/mappers"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
 	"github.com/k6zma/avito-lab1/internal/domain/repositories"
 )
 
 type StudentServiceContract interface {
 	Register(in dtos.StudentCreateDTO) (dtos.DefaultStudentResponseDTO, error)
+	RegisterMany(in []dtos.StudentCreateDTO) ([]dtos.DefaultStudentResponseDTO, error)
 	Update(in dtos.StudentUpdateDTO) (dtos.DefaultStudentResponseDTO, error)
 	DeleteByID(in dtos.GetByIDDTO) error
+	ListDeleted(includeGrades bool) ([]dtos.DeletedStudentDTO, error)
+	Restore(in dtos.GetByIDDTO) (dtos.DefaultStudentResponseDTO, error)
+	PurgeDeleted(in dtos.PurgeDeletedDTO) ([]dtos.DeletedStudentDTO, error)
 	GetByID(in dtos.GetByIDDTO) (dtos.DefaultStudentResponseDTO, error)
 	GetByFullName(in dtos.GetByFullNameDTO) (dtos.DefaultStudentResponseDTO, error)
+	History(in dtos.GetByIDDTO) ([]dtos.StudentRevisionDTO, error)
+	GetAt(in dtos.StudentAtDTO) (dtos.DefaultStudentResponseDTO, error)
+	SearchByName(in dtos.SearchByNameDTO) ([]dtos.StudentListItemDTO, error)
 	List(includeGrades bool) ([]dtos.StudentListItemDTO, error)
+	Query(in dtos.StudentQueryDTO) (dtos.StudentPageDTO, error)
 	AddGrades(in dtos.AddGradesDTO) (dtos.DefaultStudentResponseDTO, error)
-	AVGByID(in dtos.GetByIDDTO) (dtos.AVGResponseDTO, error)
+	AVGByID(in dtos.AVGQueryDTO) (dtos.AVGResponseDTO, error)
+	WithActor(actor string) StudentServiceContract
 }
 
+const DefaultActor = "system"
+
 type StudentService struct {
 	studentRepo repositories.StudentRepository
+	courseRepo  repositories.CourseRepository
+	auditLog    repositories.AuditLog
+	policy      grading.Policy
+	actor       string
 }
 
-func NewStudentService(repo repositories.StudentRepository) StudentServiceContract {
+// NewStudentService builds the service. Changes are recorded in audit under
+// DefaultActor until WithActor is used; a nil audit log disables recording.
+func NewStudentService(
+	repo repositories.StudentRepository,
+	courses repositories.CourseRepository,
+	policy grading.Policy,
+	audit repositories.AuditLog,
+) StudentServiceContract {
 	return &StudentService{
 		studentRepo: repo,
+		courseRepo:  courses,
+		auditLog:    audit,
+		policy:      policy,
+		actor:       DefaultActor,
 	}
 }
 
+// WithActor returns a service sharing the same repositories that records its
+// changes under the given actor. An empty actor keeps the current one.
+func (s *StudentService) WithActor(actor string) StudentServiceContract {
+	cp := *s
+
+	if actor = strings.TrimSpace(actor); actor != "" {
+		cp.actor = actor
+	}
+
+	return &cp
+}
+
 func (s *StudentService) Register(
 	in dtos.StudentCreateDTO,
 ) (dtos.DefaultStudentResponseDTO, error) {
@@ -40,6 +85,10 @@ func (s *StudentService) Register(
 		)
 	}
 
+	if err := s.checkCourses(student.Grades); err != nil {
+		return dtos.DefaultStudentResponseDTO{}, err
+	}
+
 	id, err := s.studentRepo.Create(student)
 	if err != nil {
 		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
@@ -56,7 +105,64 @@ func (s *StudentService) Register(
 		)
 	}
 
-	return mappers.MapStudentDomainToDefaultResponseDTO(back, true), nil
+	if err := s.record(models.AuditActionRegister, nil, back); err != nil {
+		return dtos.DefaultStudentResponseDTO{}, err
+	}
+
+	return mappers.MapStudentDomainToDefaultResponseDTO(back, true, s.policy), nil
+}
+
+func (s *StudentService) RegisterMany(
+	in []dtos.StudentCreateDTO,
+) ([]dtos.DefaultStudentResponseDTO, error) {
+	students := make([]*models.Student, 0, len(in))
+
+	for i, d := range in {
+		student, err := mappers.MapStudentCreateDTOToDomain(d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to map create dto #%d to domain: %w", i+1, err)
+		}
+
+		if err := s.checkCourses(student.Grades); err != nil {
+			return nil, fmt.Errorf("create dto #%d: %w", i+1, err)
+		}
+
+		students = append(students, student)
+	}
+
+	ids, err := s.studentRepo.CreateMany(students)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create students in repository: %w", err)
+	}
+
+	// The repository stores copies with the version it gave them, the audit
+	// and the response are built from the stored students.
+	students = students[:0]
+
+	for _, id := range ids {
+		back, err := s.studentRepo.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch student after create: %w", err)
+		}
+
+		students = append(students, back)
+	}
+
+	entries := make([]models.AuditEntry, 0, len(students))
+	for _, student := range students {
+		entries = append(entries, s.auditEntry(models.AuditActionRegister, nil, student))
+	}
+
+	if err := s.appendAudit(entries...); err != nil {
+		return nil, err
+	}
+
+	out := make([]dtos.DefaultStudentResponseDTO, 0, len(students))
+	for _, student := range students {
+		out = append(out, mappers.MapStudentDomainToDefaultResponseDTO(student, true, s.policy))
+	}
+
+	return out, nil
 }
 
 func (s *StudentService) Update(
@@ -70,6 +176,20 @@ func (s *StudentService) Update(
 		)
 	}
 
+	before, err := s.studentRepo.GetByID(student.ID)
+	if err != nil {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"failed to get student before update: %w",
+			err,
+		)
+	}
+
+	mappers.KeepStoredGradeMetadata(in.Grades, student.Grades, before.Grades)
+
+	if err := s.checkCourses(student.Grades); err != nil {
+		return dtos.DefaultStudentResponseDTO{}, err
+	}
+
 	if err := s.studentRepo.Update(student); err != nil {
 		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
 			"failed to update student in repository: %w",
@@ -85,7 +205,11 @@ func (s *StudentService) Update(
 		)
 	}
 
-	return mappers.MapStudentDomainToDefaultResponseDTO(back, true), nil
+	if err := s.record(models.AuditActionUpdate, before, back); err != nil {
+		return dtos.DefaultStudentResponseDTO{}, err
+	}
+
+	return mappers.MapStudentDomainToDefaultResponseDTO(back, true, s.policy), nil
 }
 
 func (s *StudentService) DeleteByID(in dtos.GetByIDDTO) error {
@@ -94,11 +218,97 @@ func (s *StudentService) DeleteByID(in dtos.GetByIDDTO) error {
 		return fmt.Errorf("failed to map get-by-id dto to uuid: %w", err)
 	}
 
+	before, err := s.studentRepo.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get student before delete: %w", err)
+	}
+
 	if err := s.studentRepo.DeleteByID(id); err != nil {
 		return fmt.Errorf("failed to delete student in repository: %w", err)
 	}
 
-	return nil
+	return s.record(models.AuditActionDelete, before, nil)
+}
+
+func (s *StudentService) ListDeleted(includeGrades bool) ([]dtos.DeletedStudentDTO, error) {
+	list, err := s.studentRepo.ListDeleted()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted students: %w", err)
+	}
+
+	return mappers.MapStudentsDomainToDeletedDTO(list, includeGrades), nil
+}
+
+func (s *StudentService) Restore(in dtos.GetByIDDTO) (dtos.DefaultStudentResponseDTO, error) {
+	id, err := mappers.MapGetByIDDTOToUUID(in)
+	if err != nil {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"failed to map get-by-id dto to uuid: %w",
+			err,
+		)
+	}
+
+	trash, err := s.studentRepo.ListDeleted()
+	if err != nil {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"failed to get student before restore: %w",
+			err,
+		)
+	}
+
+	var before *models.Student
+
+	if i := slices.IndexFunc(trash, func(st *models.Student) bool { return st.ID == id }); i >= 0 {
+		before = trash[i]
+	}
+
+	if err := s.studentRepo.Restore(id); err != nil {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"failed to restore student in repository: %w",
+			err,
+		)
+	}
+
+	back, err := s.studentRepo.GetByID(id)
+	if err != nil {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"failed to fetch student after restore: %w",
+			err,
+		)
+	}
+
+	if err := s.record(models.AuditActionRestore, before, back); err != nil {
+		return dtos.DefaultStudentResponseDTO{}, err
+	}
+
+	return mappers.MapStudentDomainToDefaultResponseDTO(back, true, s.policy), nil
+}
+
+// PurgeDeleted removes for good the students that have been in the trash for
+// at least the given number of days.
+func (s *StudentService) PurgeDeleted(
+	in dtos.PurgeDeletedDTO,
+) ([]dtos.DeletedStudentDTO, error) {
+	cutoff, err := mappers.MapPurgeDeletedDTOToCutoff(in, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to map purge-deleted dto: %w", err)
+	}
+
+	purged, err := s.studentRepo.PurgeDeleted(cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted students in repository: %w", err)
+	}
+
+	entries := make([]models.AuditEntry, 0, len(purged))
+	for _, st := range purged {
+		entries = append(entries, s.auditEntry(models.AuditActionPurge, st, nil))
+	}
+
+	if err := s.appendAudit(entries...); err != nil {
+		return nil, err
+	}
+
+	return mappers.MapStudentsDomainToDeletedDTO(purged, false), nil
 }
 
 func (s *StudentService) GetByID(
@@ -117,7 +327,7 @@ func (s *StudentService) GetByID(
 		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf("failed to get student by id: %w", err)
 	}
 
-	return mappers.MapStudentDomainToDefaultResponseDTO(student, true), nil
+	return mappers.MapStudentDomainToDefaultResponseDTO(student, true, s.policy), nil
 }
 
 func (s *StudentService) GetByFullName(
@@ -139,7 +349,52 @@ func (s *StudentService) GetByFullName(
 		)
 	}
 
-	return mappers.MapStudentDomainToDefaultResponseDTO(student, true), nil
+	return mappers.MapStudentDomainToDefaultResponseDTO(student, true, s.policy), nil
+}
+
+func (s *StudentService) History(in dtos.GetByIDDTO) ([]dtos.StudentRevisionDTO, error) {
+	id, err := mappers.MapGetByIDDTOToUUID(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map get-by-id dto to uuid: %w", err)
+	}
+
+	revisions, err := s.studentRepo.History(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get student history: %w", err)
+	}
+
+	return mappers.MapStudentRevisionsToDTO(revisions, s.policy), nil
+}
+
+// GetAt answers with the student as it was stored at the given moment. A
+// student that did not exist yet or was in the trash then is not found.
+func (s *StudentService) GetAt(in dtos.StudentAtDTO) (dtos.DefaultStudentResponseDTO, error) {
+	id, at, err := mappers.MapStudentAtDTOToArgs(in)
+	if err != nil {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"failed to map student-at dto to args: %w",
+			err,
+		)
+	}
+
+	revisions, err := s.studentRepo.History(id)
+	if err != nil {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"failed to get student history: %w",
+			err,
+		)
+	}
+
+	rev, ok := models.RevisionAt(revisions, at)
+	if !ok || rev.Student.Deleted() {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"%w: no stored state at %s",
+			repositories.ErrStudentNotFound,
+			at.UTC().Format(time.RFC3339),
+		)
+	}
+
+	return mappers.MapStudentDomainToDefaultResponseDTO(rev.Student, true, s.policy), nil
 }
 
 func (s *StudentService) List(
@@ -150,7 +405,44 @@ func (s *StudentService) List(
 		return nil, fmt.Errorf("failed to list students: %w", err)
 	}
 
-	return mappers.MapStudentsDomainToListDTO(list, includeGrades), nil
+	return mappers.MapStudentsDomainToListDTO(list, includeGrades, s.policy), nil
+}
+
+func (s *StudentService) SearchByName(
+	in dtos.SearchByNameDTO,
+) ([]dtos.StudentListItemDTO, error) {
+	query, err := mappers.MapSearchByNameDTOToQuery(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map search-by-name dto: %w", err)
+	}
+
+	students, err := s.studentRepo.SearchByName(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search students by name: %w", err)
+	}
+
+	return mappers.MapStudentsDomainToListDTO(students, in.IncludeGrades, s.policy), nil
+}
+
+func (s *StudentService) Query(in dtos.StudentQueryDTO) (dtos.StudentPageDTO, error) {
+	q, err := mappers.MapStudentQueryDTOToDomain(in)
+	if err != nil {
+		return dtos.StudentPageDTO{}, fmt.Errorf("failed to map student query dto: %w", err)
+	}
+
+	q.Averaging = s.policy.Averaging
+
+	page, err := s.studentRepo.Query(q)
+	if err != nil {
+		return dtos.StudentPageDTO{}, fmt.Errorf("failed to query students: %w", err)
+	}
+
+	return dtos.StudentPageDTO{
+		Items:  mappers.MapStudentsDomainToListDTO(page.Students, in.IncludeGrades, s.policy),
+		Total:  page.Total,
+		Offset: in.Offset,
+		Limit:  in.Limit,
+	}, nil
 }
 
 func (s *StudentService) AddGrades(
@@ -161,6 +453,18 @@ func (s *StudentService) AddGrades(
 		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf("failed to map add-grades dto: %w", err)
 	}
 
+	if err := s.checkCourses(grades); err != nil {
+		return dtos.DefaultStudentResponseDTO{}, err
+	}
+
+	before, err := s.studentRepo.GetByID(id)
+	if err != nil {
+		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
+			"failed to get student before add-grades: %w",
+			err,
+		)
+	}
+
 	if err := s.studentRepo.AddGrades(id, grades...); err != nil {
 		return dtos.DefaultStudentResponseDTO{}, fmt.Errorf(
 			"failed to add grades in repository: %w",
@@ -176,15 +480,19 @@ func (s *StudentService) AddGrades(
 		)
 	}
 
-	return mappers.MapStudentDomainToDefaultResponseDTO(back, true), nil
+	if err := s.record(models.AuditActionAddGrades, before, back); err != nil {
+		return dtos.DefaultStudentResponseDTO{}, err
+	}
+
+	return mappers.MapStudentDomainToDefaultResponseDTO(back, true, s.policy), nil
 }
 
 func (s *StudentService) AVGByID(
-	in dtos.GetByIDDTO,
+	in dtos.AVGQueryDTO,
 ) (dtos.AVGResponseDTO, error) {
-	id, err := mappers.MapGetByIDDTOToUUID(in)
+	id, since, err := mappers.MapAVGQueryDTOToArgs(in, time.Now())
 	if err != nil {
-		return dtos.AVGResponseDTO{}, fmt.Errorf("failed to map get-by-id dto to uuid: %w", err)
+		return dtos.AVGResponseDTO{}, fmt.Errorf("failed to map avg query dto: %w", err)
 	}
 
 	st, err := s.studentRepo.GetByID(id)
@@ -192,16 +500,105 @@ func (s *StudentService) AVGByID(
 		return dtos.AVGResponseDTO{}, fmt.Errorf("failed to get student by id: %w", err)
 	}
 
-	avg := 0.0
-	if n := len(st.Grades); n > 0 {
-		sum := 0
+	grades := models.GradesSince(st.Grades, since)
+
+	courses, err := s.courseAVGs(grades)
+	if err != nil {
+		return dtos.AVGResponseDTO{}, err
+	}
+
+	avg := s.policy.Average(grades)
+
+	resp := dtos.AVGResponseDTO{
+		ID:        st.ID.String(),
+		AVG:       avg,
+		Mapped:    s.policy.Map(avg),
+		Averaging: s.policy.AveragingName(),
+		Scale:     s.policy.ScaleName(),
+		Count:     len(grades),
+		Courses:   courses,
+	}
+
+	if !since.IsZero() {
+		resp.Since = since.UTC().Format(time.RFC3339)
+	}
+
+	return resp, nil
+}
+
+func (s *StudentService) auditEntry(
+	action models.AuditAction,
+	before, after *models.Student,
+) models.AuditEntry {
+	return models.NewAuditEntry(action, s.actor, before, after, time.Now())
+}
+
+func (s *StudentService) record(action models.AuditAction, before, after *models.Student) error {
+	return s.appendAudit(s.auditEntry(action, before, after))
+}
+
+// appendAudit runs after the change is stored, the two are not atomic. A
+// failure here, or a crash between the two writes, leaves a change that is
+// missing from the audit log.
+func (s *StudentService) appendAudit(entries ...models.AuditEntry) error {
+	if s.auditLog == nil {
+		return nil
+	}
+
+	if err := s.auditLog.Append(entries...); err != nil {
+		return fmt.Errorf("student data was changed but the audit entry was not recorded: %w", err)
+	}
+
+	return nil
+}
+
+func (s *StudentService) checkCourses(grades []models.Grade) error {
+	seen := make(map[uuid.UUID]struct{})
+
+	for _, g := range grades {
+		if _, ok := seen[g.CourseID]; ok {
+			continue
+		}
+
+		seen[g.CourseID] = struct{}{}
+
+		if _, err := s.courseRepo.GetByID(g.CourseID); err != nil {
+			return fmt.Errorf("failed to get course %s for grade: %w", g.CourseID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *StudentService) courseAVGs(grades []models.Grade) ([]dtos.CourseAVGDTO, error) {
+	byCourse := make(map[uuid.UUID][]models.Grade)
+
+	for _, g := range grades {
+		byCourse[g.CourseID] = append(byCourse[g.CourseID], g)
+	}
+
+	out := make([]dtos.CourseAVGDTO, 0, len(byCourse))
 
-		for _, g := range st.Grades {
-			sum += g
+	for courseID, courseGrades := range byCourse {
+		course, err := s.courseRepo.GetByID(courseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get course %s for average: %w", courseID, err)
 		}
 
-		avg = float64(sum) / float64(n)
+		avg := s.policy.Average(courseGrades)
+
+		out = append(out, dtos.CourseAVGDTO{
+			CourseID:   courseID.String(),
+			CourseName: course.Name,
+			AVG:        avg,
+			Mapped:     s.policy.Map(avg),
+			Count:      len(courseGrades),
+		})
 	}
 
-	return dtos.AVGResponseDTO{ID: st.ID.String(), AVG: avg}, nil
+	slices.SortFunc(out, func(a, b dtos.CourseAVGDTO) int {
+		return strings.Compare(a.CourseName, b.CourseName)
+	})
+
+	return out, nil
 }
@@ -1,11 +1,18 @@
 package services_test
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
 	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
 	infrarepo "github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
 	"github.com/k6zma/avito-lab1/pkg/validators"
 )
@@ -20,6 +27,21 @@ type registerCase struct {
 	ok      bool
 }
 
+func gradeDTOs(values ...int) []dtos.GradeDTO {
+	return mappers.MapGradeValuesToDTOs("", values)
+}
+
+func newCourseRepo(t *testing.T) *infrarepo.CourseStorage {
+	t.Helper()
+
+	courses, err := infrarepo.NewCourseStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] error while creating course repository: %v", serviceTestPrefix, err)
+	}
+
+	return courses
+}
+
 func TestStudentService_Register_And_GetByID(t *testing.T) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][Register_And_GetByID] failed to init validators: %v", serviceTestPrefix, err)
@@ -34,7 +56,7 @@ func TestStudentService_Register_And_GetByID(t *testing.T) {
 		)
 	}
 
-	svc := services.NewStudentService(repo)
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
 
 	tests := []registerCase{
 		{
@@ -43,7 +65,7 @@ func TestStudentService_Register_And_GetByID(t *testing.T) {
 				Name:    "Mikhail",
 				Surname: "Gunin",
 				Age:     19,
-				Grades:  []int{90, 60},
+				Grades:  gradeDTOs(90, 60),
 			},
 			ok: true,
 		},
@@ -146,13 +168,13 @@ func TestStudentService_Update_Success_And_Validation(t *testing.T) {
 		t.Fatalf("[%s][Update] error while creating repository: %v", serviceTestPrefix, err)
 	}
 
-	svc := services.NewStudentService(repo)
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
 
 	created, err := svc.Register(dtos.StudentCreateDTO{
 		Name:    "Mikhail",
 		Surname: "Gunin",
 		Age:     19,
-		Grades:  []int{70},
+		Grades:  gradeDTOs(70),
 	})
 	if err != nil {
 		t.Fatalf(
@@ -167,7 +189,8 @@ func TestStudentService_Update_Success_And_Validation(t *testing.T) {
 		Name:    "Alexander",
 		Surname: "Gunin",
 		Age:     20,
-		Grades:  []int{70, 85},
+		Grades:  gradeDTOs(70, 85),
+		Version: created.Version,
 	})
 	if err != nil {
 		t.Fatalf(
@@ -184,11 +207,45 @@ func TestStudentService_Update_Success_And_Validation(t *testing.T) {
 		)
 	}
 
+	if upd.Version != created.Version+1 {
+		t.Fatalf(
+			"[%s][Update(valid)] version mismatch: got=%d want=%d",
+			serviceTestPrefix, upd.Version, created.Version+1,
+		)
+	}
+
+	if _, err := svc.Update(dtos.StudentUpdateDTO{
+		ID:      created.ID,
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     21,
+		Version: created.Version,
+	}); !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Fatalf(
+			"[%s][Update(stale)] want ErrVersionConflict, got=%v",
+			serviceTestPrefix,
+			err,
+		)
+	}
+
+	if _, err := svc.Update(dtos.StudentUpdateDTO{
+		ID:      created.ID,
+		Name:    "Alexander",
+		Surname: "Gunin",
+		Age:     20,
+	}); err == nil {
+		t.Fatalf(
+			"[%s][Update(invalid)] expected validation error for missing version, got nil",
+			serviceTestPrefix,
+		)
+	}
+
 	if _, err := svc.Update(dtos.StudentUpdateDTO{
 		ID:      created.ID,
 		Name:    "alexander",
 		Surname: "gunin",
 		Age:     20,
+		Version: upd.Version,
 	}); err == nil {
 		t.Fatalf(
 			"[%s][Update(invalid)] expected validation error for non-capitalized name and surname, got nil",
@@ -197,6 +254,59 @@ func TestStudentService_Update_Success_And_Validation(t *testing.T) {
 	}
 }
 
+func TestStudentService_Update_PlainGradesKeepMetadata(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][UpdatePlain] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][UpdatePlain] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
+
+	created, err := svc.Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades: []dtos.GradeDTO{
+			{Value: 70, Date: "2024-09-01T10:00:00Z", Author: "dean", Weight: 2},
+			{Value: 90, Date: "2024-10-01T10:00:00Z", Author: "tutor"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("[%s][UpdatePlain] unexpected error while seeding student: %v", serviceTestPrefix, err)
+	}
+
+	// a legacy client resends the grades as plain integers, swapped, and adds one
+	upd, err := svc.Update(dtos.StudentUpdateDTO{
+		ID:      created.ID,
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades:  gradeDTOs(90, 70, 55),
+		Version: created.Version,
+	})
+	if err != nil {
+		t.Fatalf("[%s][UpdatePlain] unexpected error while updating: %v", serviceTestPrefix, err)
+	}
+
+	want := []dtos.GradeDTO{created.Grades[1], created.Grades[0]}
+	if !slices.Equal(upd.Grades[:2], want) {
+		t.Fatalf(
+			"[%s][UpdatePlain] stored grades lost metadata: got=%+v want=%+v",
+			serviceTestPrefix,
+			upd.Grades[:2],
+			want,
+		)
+	}
+
+	if g := upd.Grades[2]; g.Value != 55 || g.Author != "" || g.Date == "" {
+		t.Fatalf("[%s][UpdatePlain] unexpected new grade: %+v", serviceTestPrefix, g)
+	}
+}
+
 func TestStudentService_DeleteByID(t *testing.T) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][DeleteByID] failed to init validators: %v", serviceTestPrefix, err)
@@ -207,7 +317,7 @@ func TestStudentService_DeleteByID(t *testing.T) {
 		t.Fatalf("[%s][DeleteByID] error while creating repository: %v", serviceTestPrefix, err)
 	}
 
-	svc := services.NewStudentService(repo)
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
 
 	created, err := svc.Register(dtos.StudentCreateDTO{
 		Name:    "Mikhail",
@@ -248,7 +358,7 @@ func TestStudentService_GetByFullName(t *testing.T) {
 	if err != nil {
 		t.Fatalf("[%s][GetByFullName] error while creating repository: %v", serviceTestPrefix, err)
 	}
-	svc := services.NewStudentService(repo)
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
 
 	created, err := svc.Register(dtos.StudentCreateDTO{
 		Name:    "Mikhail",
@@ -295,13 +405,13 @@ func TestStudentService_List_WithAndWithoutGrades(t *testing.T) {
 		t.Fatalf("[%s][List] error while creating repository: %v", serviceTestPrefix, err)
 	}
 
-	svc := services.NewStudentService(repo)
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
 
 	_, err = svc.Register(dtos.StudentCreateDTO{
 		Name:    "Eleven",
 		Surname: "Doctor",
 		Age:     100,
-		Grades:  []int{100},
+		Grades:  gradeDTOs(100),
 	})
 	if err != nil {
 		t.Fatalf("[%s][Register(a)] unexpected error: %v", serviceTestPrefix, err)
@@ -311,7 +421,7 @@ func TestStudentService_List_WithAndWithoutGrades(t *testing.T) {
 		Name:    "Mikhail",
 		Surname: "Gunin",
 		Age:     19,
-		Grades:  []int{80},
+		Grades:  gradeDTOs(80),
 	})
 	if err != nil {
 		t.Fatalf("[%s][Register(b)] unexpected error: %v", serviceTestPrefix, err)
@@ -382,13 +492,13 @@ func TestStudentService_AddGrades_Success_And_Validation(t *testing.T) {
 		t.Fatalf("[%s][AddGrades] error while creating repository: %v", serviceTestPrefix, err)
 	}
 
-	svc := services.NewStudentService(repo)
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
 
 	created, err := svc.Register(dtos.StudentCreateDTO{
 		Name:    "Mikhail",
 		Surname: "Gunin",
 		Age:     19,
-		Grades:  []int{60},
+		Grades:  gradeDTOs(60),
 	})
 	if err != nil {
 		t.Fatalf(
@@ -440,7 +550,7 @@ func TestStudentService_AVGByID(t *testing.T) {
 		t.Fatalf("[%s][AVGByID] error while creating repository: %v", serviceTestPrefix, err)
 	}
 
-	svc := services.NewStudentService(repo)
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
 
 	a, err := svc.Register(dtos.StudentCreateDTO{
 		Name:    "Mikhail",
@@ -451,7 +561,7 @@ func TestStudentService_AVGByID(t *testing.T) {
 		t.Fatalf("[%s][Register(a)] unexpected error: %v", serviceTestPrefix, err)
 	}
 
-	avgA, err := svc.AVGByID(dtos.GetByIDDTO{ID: a.ID})
+	avgA, err := svc.AVGByID(dtos.AVGQueryDTO{ID: a.ID})
 	if err != nil {
 		t.Fatalf("[%s][AVGByID(no grades)] unexpected error: %v", serviceTestPrefix, err)
 	}
@@ -469,13 +579,13 @@ func TestStudentService_AVGByID(t *testing.T) {
 		Name:    "With",
 		Surname: "Grades",
 		Age:     21,
-		Grades:  []int{50, 75, 100},
+		Grades:  gradeDTOs(50, 75, 100),
 	})
 	if err != nil {
 		t.Fatalf("[%s][Register(b)] unexpected error: %v", serviceTestPrefix, err)
 	}
 
-	avgB, err := svc.AVGByID(dtos.GetByIDDTO{ID: b.ID})
+	avgB, err := svc.AVGByID(dtos.AVGQueryDTO{ID: b.ID})
 	if err != nil {
 		t.Fatalf("[%s][AVGByID(with grades)] unexpected error: %v", serviceTestPrefix, err)
 	}
@@ -490,3 +600,536 @@ func TestStudentService_AVGByID(t *testing.T) {
 		)
 	}
 }
+
+func TestStudentService_RegisterMany(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][RegisterMany] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][RegisterMany] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	audit, err := infrarepo.NewAuditStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][RegisterMany] error while creating audit log: %v", serviceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), audit)
+
+	created, err := svc.RegisterMany([]dtos.StudentCreateDTO{
+		{Name: "Mikhail", Surname: "Gunin", Age: 19, Grades: gradeDTOs(90, 60)},
+		{Name: "Alexander", Surname: "Gunin", Age: 20},
+	})
+	if err != nil {
+		t.Fatalf("[%s][RegisterMany] unexpected error: %v", serviceTestPrefix, err)
+	}
+
+	if len(created) != 2 || created[0].AvgGrade == nil || *created[0].AvgGrade != 75 {
+		t.Fatalf("[%s][RegisterMany] unexpected response: %+v", serviceTestPrefix, created)
+	}
+
+	for _, st := range created {
+		if st.Version != 1 {
+			t.Fatalf("[%s][RegisterMany] response must carry the stored version: %+v", serviceTestPrefix, st)
+		}
+	}
+
+	entries, err := audit.List(repositories.AuditFilter{})
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("[%s][RegisterMany] want 2 audit entries, got=%d (err=%v)", serviceTestPrefix, len(entries), err)
+	}
+
+	for _, e := range entries {
+		if e.After == nil || e.After.Version != 1 {
+			t.Fatalf("[%s][RegisterMany] audit must record the stored state: %+v", serviceTestPrefix, e.After)
+		}
+	}
+
+	if _, err := svc.Update(dtos.StudentUpdateDTO{
+		ID:      created[1].ID,
+		Name:    created[1].Name,
+		Surname: created[1].Surname,
+		Age:     21,
+		Version: created[1].Version,
+	}); err != nil {
+		t.Fatalf("[%s][RegisterMany] update with the returned version failed: %v", serviceTestPrefix, err)
+	}
+
+	if _, err := svc.RegisterMany([]dtos.StudentCreateDTO{
+		{Name: "Ivan", Surname: "Petrov"},
+		{Name: "oleg", Surname: "Petrov"},
+	}); err == nil {
+		t.Fatalf("[%s][RegisterMany] expected error for invalid dto", serviceTestPrefix)
+	}
+
+	list, err := svc.List(false)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("[%s][RegisterMany] invalid batch must not be saved: %d (err=%v)", serviceTestPrefix, len(list), err)
+	}
+}
+
+func TestStudentService_AddGrades_PerCourseAVG(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][PerCourseAVG] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][PerCourseAVG] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	courses := newCourseRepo(t)
+	svc := services.NewStudentService(repo, courses, grading.DefaultPolicy(), nil)
+
+	math, err := services.NewCourseService(courses).Create(dtos.CourseCreateDTO{Name: "Math"})
+	if err != nil {
+		t.Fatalf("[%s][PerCourseAVG] failed to create course: %v", serviceTestPrefix, err)
+	}
+
+	st, err := svc.Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades:  gradeDTOs(60),
+	})
+	if err != nil {
+		t.Fatalf("[%s][PerCourseAVG] failed to register student: %v", serviceTestPrefix, err)
+	}
+
+	if _, err := svc.AddGrades(dtos.AddGradesDTO{
+		ID:       st.ID,
+		CourseID: math.ID,
+		Grades:   []int{80, 100},
+	}); err != nil {
+		t.Fatalf("[%s][PerCourseAVG] failed to add course grades: %v", serviceTestPrefix, err)
+	}
+
+	if _, err := svc.AddGrades(dtos.AddGradesDTO{
+		ID:       st.ID,
+		CourseID: "6f1c1f3e-8a7d-4f0a-9a51-2b4c3d5e6f70",
+		Grades:   []int{80},
+	}); !errors.Is(err, repositories.ErrCourseNotFound) {
+		t.Fatalf(
+			"[%s][PerCourseAVG] want ErrCourseNotFound for unknown course, got=%v",
+			serviceTestPrefix,
+			err,
+		)
+	}
+
+	avg, err := svc.AVGByID(dtos.AVGQueryDTO{ID: st.ID})
+	if err != nil {
+		t.Fatalf("[%s][PerCourseAVG] unexpected error: %v", serviceTestPrefix, err)
+	}
+
+	if avg.AVG != 80 || len(avg.Courses) != 2 {
+		t.Fatalf("[%s][PerCourseAVG] unexpected average: %+v", serviceTestPrefix, avg)
+	}
+
+	want := []dtos.CourseAVGDTO{
+		{CourseID: math.ID, CourseName: "Math", AVG: 90, Count: 2},
+		{CourseID: models.UnassignedCourseID.String(), CourseName: "Unassigned", AVG: 60, Count: 1},
+	}
+
+	for i := range want {
+		if avg.Courses[i] != want[i] {
+			t.Fatalf(
+				"[%s][PerCourseAVG] course #%d mismatch: got=%+v want=%+v",
+				serviceTestPrefix,
+				i+1,
+				avg.Courses[i],
+				want[i],
+			)
+		}
+	}
+}
+
+func TestStudentService_AVGByID_TimeWindow(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][AVGWindow] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][AVGWindow] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
+
+	old := time.Now().UTC().AddDate(0, 0, -60).Format(time.RFC3339)
+
+	st, err := svc.Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades:  []dtos.GradeDTO{{Value: 40, Date: old}},
+	})
+	if err != nil {
+		t.Fatalf("[%s][AVGWindow] failed to register student: %v", serviceTestPrefix, err)
+	}
+
+	added, err := svc.AddGrades(dtos.AddGradesDTO{
+		ID:      st.ID,
+		Grades:  []int{80, 100},
+		Author:  "Ivanova",
+		Comment: "midterm",
+		Weight:  2,
+	})
+	if err != nil {
+		t.Fatalf("[%s][AVGWindow] failed to add grades: %v", serviceTestPrefix, err)
+	}
+
+	if g := added.Grades[len(added.Grades)-1]; g.Author != "Ivanova" || g.Comment != "midterm" ||
+		g.Weight != 2 || g.Date == "" {
+		t.Fatalf("[%s][AVGWindow] grade details were not recorded: %+v", serviceTestPrefix, g)
+	}
+
+	tests := []struct {
+		name  string
+		in    dtos.AVGQueryDTO
+		avg   float64
+		count int
+	}{
+		{name: "all time", in: dtos.AVGQueryDTO{ID: st.ID}, avg: 220.0 / 3, count: 3},
+		{name: "last 30 days", in: dtos.AVGQueryDTO{ID: st.ID, Days: 30}, avg: 90, count: 2},
+		{name: "since future", in: dtos.AVGQueryDTO{ID: st.ID, Since: "2999-01-01"}, avg: 0, count: 0},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-avg-window-%s-№%d", serviceTestPrefix, tc.name, i+1), func(t *testing.T) {
+			avg, err := svc.AVGByID(tc.in)
+			if err != nil {
+				t.Fatalf("[%s][AVGWindow] unexpected error: %v", serviceTestPrefix, err)
+			}
+
+			if avg.AVG != tc.avg || avg.Count != tc.count {
+				t.Fatalf(
+					"[%s][AVGWindow] got avg=%v count=%d, want avg=%v count=%d",
+					serviceTestPrefix,
+					avg.AVG,
+					avg.Count,
+					tc.avg,
+					tc.count,
+				)
+			}
+		})
+	}
+
+	if _, err := svc.AVGByID(dtos.AVGQueryDTO{ID: st.ID, Days: 7, Since: "2025-01-01"}); err == nil {
+		t.Fatalf("[%s][AVGWindow] expected error when both days and since are set", serviceTestPrefix)
+	}
+}
+
+func TestStudentService_AVGByID_GradingPolicy(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][GradingPolicy] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][GradingPolicy] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	policy, err := grading.NewPolicy("weighted", "letter")
+	if err != nil {
+		t.Fatalf("[%s][GradingPolicy] failed to build policy: %v", serviceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), policy, nil)
+
+	st, err := svc.Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades:  []dtos.GradeDTO{{Value: 60}, {Value: 90, Weight: 2}},
+	})
+	if err != nil {
+		t.Fatalf("[%s][GradingPolicy] failed to register student: %v", serviceTestPrefix, err)
+	}
+
+	if st.AvgGrade == nil || *st.AvgGrade != 80 || st.AvgMapped != "B" {
+		t.Fatalf("[%s][GradingPolicy] unexpected student average: %+v", serviceTestPrefix, st)
+	}
+
+	avg, err := svc.AVGByID(dtos.AVGQueryDTO{ID: st.ID})
+	if err != nil {
+		t.Fatalf("[%s][GradingPolicy] unexpected error: %v", serviceTestPrefix, err)
+	}
+
+	if avg.AVG != 80 || avg.Mapped != "B" || avg.Averaging != "weighted" || avg.Scale != "letter" {
+		t.Fatalf("[%s][GradingPolicy] unexpected average: %+v", serviceTestPrefix, avg)
+	}
+
+	if len(avg.Courses) != 1 || avg.Courses[0].Mapped != "B" {
+		t.Fatalf("[%s][GradingPolicy] unexpected course averages: %+v", serviceTestPrefix, avg.Courses)
+	}
+}
+
+func TestStudentService_Query(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Query] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][Query] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
+
+	if _, err := svc.RegisterMany([]dtos.StudentCreateDTO{
+		{Name: "Mikhail", Surname: "Gunin", Age: 19, Grades: gradeDTOs(60, 80)},
+		{Name: "Anna", Surname: "Petrova", Age: 22, Grades: gradeDTOs(100)},
+		{Name: "Boris", Surname: "Gusev", Age: 20},
+	}); err != nil {
+		t.Fatalf("[%s][Query] failed to register students: %v", serviceTestPrefix, err)
+	}
+
+	minAVG := 65.0
+
+	page, err := svc.Query(dtos.StudentQueryDTO{
+		MinAVG:        &minAVG,
+		Sort:          "avg",
+		Desc:          true,
+		Limit:         1,
+		IncludeGrades: true,
+	})
+	if err != nil {
+		t.Fatalf("[%s][Query] unexpected error: %v", serviceTestPrefix, err)
+	}
+
+	if page.Total != 2 || page.Limit != 1 || len(page.Items) != 1 || page.Items[0].Name != "Anna" ||
+		len(page.Items[0].Grades) != 1 {
+		t.Fatalf("[%s][Query] unexpected page: %+v", serviceTestPrefix, page)
+	}
+
+	if _, err := svc.Query(dtos.StudentQueryDTO{Sort: "grade"}); err == nil {
+		t.Fatalf("[%s][Query] expected validation error for unknown sort", serviceTestPrefix)
+	}
+}
+
+func TestStudentService_SearchByName(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][SearchByName] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][SearchByName] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
+
+	if _, err := svc.RegisterMany([]dtos.StudentCreateDTO{
+		{Name: "Mikhail", Surname: "Gunin", Age: 19, Grades: gradeDTOs(60, 80)},
+		{Name: "Mikhail", Surname: "Gunin", Age: 21},
+		{Name: "Anna", Surname: "Petrova", Age: 22},
+	}); err != nil {
+		t.Fatalf("[%s][SearchByName] failed to register students: %v", serviceTestPrefix, err)
+	}
+
+	list, err := svc.SearchByName(dtos.SearchByNameDTO{Query: " mikhail ", IncludeGrades: true})
+	if err != nil {
+		t.Fatalf("[%s][SearchByName] unexpected error: %v", serviceTestPrefix, err)
+	}
+
+	if len(list) != 2 || list[0].Age != 19 || list[1].Age != 21 || len(list[0].Grades) != 2 {
+		t.Fatalf("[%s][SearchByName] unexpected result: %+v", serviceTestPrefix, list)
+	}
+
+	if _, err := svc.SearchByName(dtos.SearchByNameDTO{}); err == nil {
+		t.Fatalf("[%s][SearchByName] expected validation error for empty query", serviceTestPrefix)
+	}
+}
+
+func TestStudentService_TrashRestoreAndPurge(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Trash] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][Trash] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	audit, err := infrarepo.NewAuditStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][Trash] error while creating audit log: %v", serviceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), audit)
+
+	created, err := svc.Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades:  gradeDTOs(60, 80),
+	})
+	if err != nil {
+		t.Fatalf("[%s][Trash] failed to register student: %v", serviceTestPrefix, err)
+	}
+
+	if err := svc.DeleteByID(dtos.GetByIDDTO{ID: created.ID}); err != nil {
+		t.Fatalf("[%s][Trash] failed to delete student: %v", serviceTestPrefix, err)
+	}
+
+	trash, err := svc.ListDeleted(true)
+	if err != nil || len(trash) != 1 || trash[0].ID != created.ID || len(trash[0].Grades) != 2 {
+		t.Fatalf("[%s][Trash] unexpected trash: %+v err=%v", serviceTestPrefix, trash, err)
+	}
+
+	if _, err := time.Parse(time.RFC3339, trash[0].DeletedAt); err != nil {
+		t.Fatalf("[%s][Trash] deleted at is not RFC3339: %q", serviceTestPrefix, trash[0].DeletedAt)
+	}
+
+	restored, err := svc.Restore(dtos.GetByIDDTO{ID: created.ID})
+	if err != nil || restored.ID != created.ID || restored.AvgGrade == nil {
+		t.Fatalf("[%s][Trash] unexpected restore result: %+v err=%v", serviceTestPrefix, restored, err)
+	}
+
+	if _, err := svc.Restore(dtos.GetByIDDTO{ID: created.ID}); !errors.Is(err, repositories.ErrStudentNotDeleted) {
+		t.Fatalf("[%s][Trash] want ErrStudentNotDeleted, got=%v", serviceTestPrefix, err)
+	}
+
+	if err := svc.DeleteByID(dtos.GetByIDDTO{ID: created.ID}); err != nil {
+		t.Fatalf("[%s][Trash] failed to delete student again: %v", serviceTestPrefix, err)
+	}
+
+	purged, err := svc.PurgeDeleted(dtos.PurgeDeletedDTO{OlderThanDays: 30})
+	if err != nil || len(purged) != 0 {
+		t.Fatalf("[%s][Trash] fresh trash must survive 30 days policy: %+v err=%v", serviceTestPrefix, purged, err)
+	}
+
+	if _, err := svc.PurgeDeleted(dtos.PurgeDeletedDTO{OlderThanDays: -1}); err == nil {
+		t.Fatalf("[%s][Trash] expected validation error for negative days", serviceTestPrefix)
+	}
+
+	purged, err = svc.PurgeDeleted(dtos.PurgeDeletedDTO{})
+	if err != nil || len(purged) != 1 || purged[0].ID != created.ID {
+		t.Fatalf("[%s][Trash] unexpected purge result: %+v err=%v", serviceTestPrefix, purged, err)
+	}
+
+	if _, err := svc.Restore(dtos.GetByIDDTO{ID: created.ID}); !errors.Is(err, repositories.ErrStudentNotFound) {
+		t.Fatalf("[%s][Trash] want ErrStudentNotFound after purge, got=%v", serviceTestPrefix, err)
+	}
+
+	entries, err := audit.List(repositories.AuditFilter{})
+	if err != nil {
+		t.Fatalf("[%s][Trash] failed to list audit entries: %v", serviceTestPrefix, err)
+	}
+
+	var actions []models.AuditAction
+	for _, e := range entries {
+		actions = append(actions, e.Action)
+	}
+
+	want := []models.AuditAction{
+		models.AuditActionRegister,
+		models.AuditActionDelete,
+		models.AuditActionRestore,
+		models.AuditActionDelete,
+		models.AuditActionPurge,
+	}
+
+	if !slices.Equal(actions, want) {
+		t.Fatalf("[%s][Trash] audit actions: got=%v want=%v", serviceTestPrefix, actions, want)
+	}
+
+	if restore := entries[2]; restore.Before == nil || !restore.Before.Deleted() || restore.After.Deleted() {
+		t.Fatalf("[%s][Trash] restore entry must keep trashed and restored snapshots: %+v", serviceTestPrefix, restore)
+	}
+}
+
+func TestStudentService_HistoryAndGetAt(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][History] failed to init validators: %v", serviceTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s][History] error while creating repository: %v", serviceTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, newCourseRepo(t), grading.DefaultPolicy(), nil)
+
+	created, err := svc.Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+	})
+	if err != nil {
+		t.Fatalf("[%s][History] failed to register student: %v", serviceTestPrefix, err)
+	}
+
+	if _, err := svc.Update(dtos.StudentUpdateDTO{
+		ID:      created.ID,
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     20,
+		Version: created.Version,
+	}); err != nil {
+		t.Fatalf("[%s][History] failed to update student: %v", serviceTestPrefix, err)
+	}
+
+	if err := svc.DeleteByID(dtos.GetByIDDTO{ID: created.ID}); err != nil {
+		t.Fatalf("[%s][History] failed to delete student: %v", serviceTestPrefix, err)
+	}
+
+	revisions, err := svc.History(dtos.GetByIDDTO{ID: created.ID})
+	if err != nil || len(revisions) != 3 {
+		t.Fatalf("[%s][History] unexpected history: %+v err=%v", serviceTestPrefix, revisions, err)
+	}
+
+	wantChanges := [][]string{
+		{"created Mikhail Gunin, age 19, grades []"},
+		{"age: 19 -> 20"},
+		{"moved to trash"},
+	}
+
+	for i, want := range wantChanges {
+		if rev := revisions[i]; !slices.Equal(rev.Changes, want) || rev.Version != i+1 {
+			t.Fatalf("[%s][History] revision #%d: got=%+v want changes=%v", serviceTestPrefix, i, rev, want)
+		}
+	}
+
+	if !revisions[2].Deleted || revisions[1].Deleted || revisions[1].Student.Age != 20 {
+		t.Fatalf("[%s][History] revisions must keep state and average: %+v", serviceTestPrefix, revisions)
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format(time.DateOnly)
+
+	if _, err := svc.GetAt(dtos.StudentAtDTO{ID: created.ID, At: today}); !errors.Is(
+		err,
+		repositories.ErrStudentNotFound,
+	) {
+		t.Fatalf("[%s][GetAt] trashed student must not be found, got=%v", serviceTestPrefix, err)
+	}
+
+	if _, err := svc.Restore(dtos.GetByIDDTO{ID: created.ID}); err != nil {
+		t.Fatalf("[%s][GetAt] failed to restore student: %v", serviceTestPrefix, err)
+	}
+
+	got, err := svc.GetAt(dtos.StudentAtDTO{ID: created.ID, At: today})
+	if err != nil || got.Age != 20 || got.Version != 4 {
+		t.Fatalf("[%s][GetAt] unexpected state at end of today: %+v err=%v", serviceTestPrefix, got, err)
+	}
+
+	later := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	if got, err := svc.GetAt(dtos.StudentAtDTO{ID: created.ID, At: later}); err != nil || got.Version != 4 {
+		t.Fatalf("[%s][GetAt] unexpected state at %s: %+v err=%v", serviceTestPrefix, later, got, err)
+	}
+
+	if _, err := svc.GetAt(dtos.StudentAtDTO{ID: created.ID, At: yesterday}); !errors.Is(
+		err,
+		repositories.ErrStudentNotFound,
+	) {
+		t.Fatalf("[%s][GetAt] student must not exist before it was registered, got=%v", serviceTestPrefix, err)
+	}
+
+	if _, err := svc.GetAt(dtos.StudentAtDTO{ID: created.ID, At: "March 1st"}); err == nil {
+		t.Fatalf("[%s][GetAt] expected error for unparsable time", serviceTestPrefix)
+	}
+}
@@ -0,0 +1,8 @@
+package grading
+
+import "errors"
+
+var (
+	ErrUnknownAveraging = errors.New("unknown averaging method")
+	ErrUnknownScale     = errors.New("unknown grading scale")
+)
@@ -0,0 +1,92 @@
+package grading
+
+import (
+	"fmt"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+type Averaging string
+
+const (
+	AveragingMean     Averaging = "mean"
+	AveragingWeighted Averaging = "weighted"
+)
+
+type Policy struct {
+	Averaging Averaging
+	Scale     Scale
+}
+
+func NewPolicy(averaging, scale string) (Policy, error) {
+	p := Policy{
+		Averaging: Averaging(averaging),
+		Scale:     Scale(scale),
+	}
+
+	switch p.Averaging {
+	case AveragingMean, AveragingWeighted:
+	default:
+		return Policy{}, fmt.Errorf("%w: %q", ErrUnknownAveraging, averaging)
+	}
+
+	if _, ok := scales[p.Scale]; !ok {
+		return Policy{}, fmt.Errorf("%w: %q", ErrUnknownScale, scale)
+	}
+
+	return p, nil
+}
+
+func DefaultPolicy() Policy {
+	return Policy{
+		Averaging: AveragingMean,
+		Scale:     ScalePercent,
+	}
+}
+
+// Average returns 0 for no grades. The zero Policy averages like DefaultPolicy.
+func (p Policy) Average(grades []models.Grade) float64 {
+	if len(grades) == 0 {
+		return 0
+	}
+
+	if p.Averaging != AveragingWeighted {
+		sum := 0
+
+		for _, g := range grades {
+			sum += g.Value
+		}
+
+		return float64(sum) / float64(len(grades))
+	}
+
+	var sum, weights float64
+
+	for _, g := range grades {
+		w := g.EffectiveWeight()
+		sum += float64(g.Value) * w
+		weights += w
+	}
+
+	return sum / weights
+}
+
+func (p Policy) Map(avg float64) string {
+	return p.Scale.Map(avg)
+}
+
+func (p Policy) AveragingName() string {
+	if p.Averaging == "" {
+		return string(AveragingMean)
+	}
+
+	return string(p.Averaging)
+}
+
+func (p Policy) ScaleName() string {
+	if p.Scale == "" {
+		return string(ScalePercent)
+	}
+
+	return string(p.Scale)
+}
@@ -0,0 +1,128 @@
+package grading_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+const (
+	gradingTestPrefix = "GradingPolicy"
+)
+
+type averageTestCase struct {
+	name      string
+	averaging grading.Averaging
+	grades    []models.Grade
+	want      float64
+}
+
+func grade(value int, weight float64) models.Grade {
+	return models.Grade{
+		CourseID: models.UnassignedCourseID,
+		Value:    value,
+		Date:     time.Date(2025, time.May, 20, 9, 0, 0, 0, time.UTC),
+		Weight:   weight,
+	}
+}
+
+func TestPolicy_Average(t *testing.T) {
+	tests := []averageTestCase{
+		{name: "mean without grades", averaging: grading.AveragingMean, want: 0},
+		{
+			name:      "mean ignores weights",
+			averaging: grading.AveragingMean,
+			grades:    []models.Grade{grade(60, 1), grade(90, 2)},
+			want:      75,
+		},
+		{
+			name:      "weighted exam counts double",
+			averaging: grading.AveragingWeighted,
+			grades:    []models.Grade{grade(60, 1), grade(90, 2)},
+			want:      80,
+		},
+		{
+			name:      "weighted treats zero weight as one",
+			averaging: grading.AveragingWeighted,
+			grades:    []models.Grade{grade(60, 0), grade(90, 0)},
+			want:      75,
+		},
+		{
+			name:   "zero policy falls back to mean",
+			grades: []models.Grade{grade(60, 1), grade(90, 2)},
+			want:   75,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-average-%s-№%d", gradingTestPrefix, tc.name, i+1), func(t *testing.T) {
+			p := grading.Policy{Averaging: tc.averaging}
+
+			if got := p.Average(tc.grades); got != tc.want {
+				t.Fatalf("[%s][Average] got=%v want=%v", gradingTestPrefix, got, tc.want)
+			}
+		})
+	}
+}
+
+type scaleTestCase struct {
+	scale grading.Scale
+	avg   float64
+	want  string
+}
+
+func TestScale_Map(t *testing.T) {
+	tests := []scaleTestCase{
+		{grading.ScalePercent, 95, ""},
+		{grading.ScaleLetter, 95, "A"},
+		{grading.ScaleLetter, 80, "B"},
+		{grading.ScaleLetter, 79.99, "C"},
+		{grading.ScaleLetter, 60, "D"},
+		{grading.ScaleLetter, 12, "F"},
+		{grading.ScaleFivePoint, 85, "5"},
+		{grading.ScaleFivePoint, 70, "4"},
+		{grading.ScaleFivePoint, 50, "3"},
+		{grading.ScaleFivePoint, 49, "2"},
+		{grading.ScaleGPA, 91, "4.0"},
+		{grading.ScaleGPA, 65, "1.0"},
+		{grading.ScaleGPA, 0, "0.0"},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-map-%s-№%d", gradingTestPrefix, tc.scale, i+1), func(t *testing.T) {
+			if got := tc.scale.Map(tc.avg); got != tc.want {
+				t.Fatalf(
+					"[%s][Map] %s scale for %v: got=%q want=%q",
+					gradingTestPrefix,
+					tc.scale,
+					tc.avg,
+					got,
+					tc.want,
+				)
+			}
+		})
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	p, err := grading.NewPolicy("weighted", "letter")
+	if err != nil {
+		t.Fatalf("[%s][NewPolicy] unexpected error: %v", gradingTestPrefix, err)
+	}
+
+	if p.Averaging != grading.AveragingWeighted || p.Scale != grading.ScaleLetter {
+		t.Fatalf("[%s][NewPolicy] unexpected policy: %+v", gradingTestPrefix, p)
+	}
+
+	if _, err := grading.NewPolicy("median", "letter"); !errors.Is(err, grading.ErrUnknownAveraging) {
+		t.Fatalf("[%s][NewPolicy] want ErrUnknownAveraging, got=%v", gradingTestPrefix, err)
+	}
+
+	if _, err := grading.NewPolicy("mean", "ects"); !errors.Is(err, grading.ErrUnknownScale) {
+		t.Fatalf("[%s][NewPolicy] want ErrUnknownScale, got=%v", gradingTestPrefix, err)
+	}
+}
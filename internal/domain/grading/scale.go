@@ -0,0 +1,51 @@
+package grading
+
+type Scale string
+
+const (
+	ScalePercent   Scale = "percent"
+	ScaleLetter    Scale = "letter"
+	ScaleFivePoint Scale = "five_point"
+	ScaleGPA       Scale = "gpa"
+)
+
+type band struct {
+	min   float64
+	label string
+}
+
+var scales = map[Scale][]band{
+	ScalePercent: nil,
+	ScaleLetter: {
+		{min: 90, label: "A"},
+		{min: 80, label: "B"},
+		{min: 70, label: "C"},
+		{min: 60, label: "D"},
+		{min: 0, label: "F"},
+	},
+	ScaleFivePoint: {
+		{min: 85, label: "5"},
+		{min: 70, label: "4"},
+		{min: 50, label: "3"},
+		{min: 0, label: "2"},
+	},
+	ScaleGPA: {
+		{min: 90, label: "4.0"},
+		{min: 80, label: "3.0"},
+		{min: 70, label: "2.0"},
+		{min: 60, label: "1.0"},
+		{min: 0, label: "0.0"},
+	},
+}
+
+// Map converts a 0-100 average into the scale's label. The percent scale has
+// no labels of its own, so it maps everything to an empty string.
+func (s Scale) Map(avg float64) string {
+	for _, b := range scales[s] {
+		if avg >= b.min {
+			return b.label
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditAction string
+
+const (
+	AuditActionRegister  AuditAction = "register"
+	AuditActionUpdate    AuditAction = "update"
+	AuditActionDelete    AuditAction = "delete"
+	AuditActionAddGrades AuditAction = "add_grades"
+	AuditActionRestore   AuditAction = "restore"
+	AuditActionPurge     AuditAction = "purge"
+)
+
+// AuditActions returns every audit action in the order they are documented.
+func AuditActions() []AuditAction {
+	return []AuditAction{
+		AuditActionRegister,
+		AuditActionUpdate,
+		AuditActionDelete,
+		AuditActionAddGrades,
+		AuditActionRestore,
+		AuditActionPurge,
+	}
+}
+
+// AuditEntry records one change of a student. Seq, PrevHash and Hash are
+// filled in by the audit log when the entry is appended. Anchored marks the
+// entries of a stored log that keeps a head, such a log must not lose it.
+type AuditEntry struct {
+	Seq       uint64      `json:"seq"`
+	At        time.Time   `json:"at"`
+	Actor     string      `json:"actor"`
+	Action    AuditAction `json:"action"`
+	StudentID uuid.UUID   `json:"student_id"`
+	Before    *Student    `json:"before,omitempty"`
+	After     *Student    `json:"after,omitempty"`
+	Anchored  bool        `json:"anchored,omitempty"`
+	PrevHash  string      `json:"prev_hash"`
+	Hash      string      `json:"hash"`
+}
+
+func NewAuditEntry(
+	action AuditAction,
+	actor string,
+	before, after *Student,
+	at time.Time,
+) AuditEntry {
+	e := AuditEntry{
+		At:     at.UTC(),
+		Actor:  actor,
+		Action: action,
+		Before: before.Clone(),
+		After:  after.Clone(),
+	}
+
+	switch {
+	case after != nil:
+		e.StudentID = after.ID
+	case before != nil:
+		e.StudentID = before.ID
+	}
+
+	return e
+}
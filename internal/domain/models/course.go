@@ -0,0 +1,49 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const UnassignedCourseName = "Unassigned"
+
+var UnassignedCourseID = uuid.MustParse("00000000-0000-4000-8000-000000000001")
+
+type Course struct {
+	ID   uuid.UUID `json:"id"   validate:"required"`
+	Name string    `json:"name" validate:"required,max=64"`
+}
+
+func NewCourse(name string) (*Course, error) {
+	course := &Course{
+		ID:   uuid.New(),
+		Name: strings.TrimSpace(name),
+	}
+
+	if err := validators.Validate.Struct(course); err != nil {
+		return nil, fmt.Errorf("error while validating course domain model: %w", err)
+	}
+
+	return course, nil
+}
+
+func UnassignedCourse() *Course {
+	return &Course{
+		ID:   UnassignedCourseID,
+		Name: UnassignedCourseName,
+	}
+}
+
+func (c *Course) Clone() *Course {
+	if c == nil {
+		return nil
+	}
+
+	cp := *c
+
+	return &cp
+}
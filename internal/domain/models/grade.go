@@ -0,0 +1,98 @@
+package models
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+)
+
+const DefaultGradeWeight = 1.0
+
+type Grade struct {
+	CourseID uuid.UUID `json:"course_id"         validate:"required"`
+	Value    int       `json:"value"             validate:"gte=0,lte=100"`
+	Date     time.Time `json:"date"`
+	Author   string    `json:"author,omitempty"  validate:"max=64"`
+	Comment  string    `json:"comment,omitempty" validate:"max=256"`
+	Weight   float64   `json:"weight,omitempty"  validate:"gte=0,lte=10"`
+}
+
+func NewGrades(courseID uuid.UUID, date time.Time, values ...int) []Grade {
+	grades := make([]Grade, 0, len(values))
+
+	for _, v := range values {
+		grades = append(grades, Grade{
+			CourseID: courseID,
+			Value:    v,
+			Date:     date,
+		})
+	}
+
+	return grades
+}
+
+// EffectiveWeight treats a zero weight, as stored by grades recorded before
+// weights existed, as the default weight.
+func (g Grade) EffectiveWeight() float64 {
+	if g.Weight == 0 {
+		return DefaultGradeWeight
+	}
+
+	return g.Weight
+}
+
+// GradesSince keeps grades given at or after since. Grades without a date are
+// only kept when since is zero.
+func GradesSince(grades []Grade, since time.Time) []Grade {
+	if since.IsZero() {
+		return grades
+	}
+
+	out := make([]Grade, 0, len(grades))
+
+	for _, g := range grades {
+		if !g.Date.IsZero() && !g.Date.Before(since) {
+			out = append(out, g)
+		}
+	}
+
+	return out
+}
+
+func GradeValues(grades []Grade) []int {
+	values := make([]int, 0, len(grades))
+
+	for _, g := range grades {
+		values = append(values, g.Value)
+	}
+
+	return values
+}
+
+// UnmarshalJSON also accepts the bare integers stored by snapshots written
+// before grades were tied to courses; such grades land in the unassigned course.
+func (g *Grade) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] != '{' {
+		var value int
+		if err := json.Unmarshal(trimmed, &value); err != nil {
+			return err
+		}
+
+		*g = Grade{CourseID: UnassignedCourseID, Value: value}
+
+		return nil
+	}
+
+	type plainGrade Grade
+
+	var p plainGrade
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	*g = Grade(p)
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package models_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+const (
+	gradeTestPrefix = "GradeDomainModel"
+)
+
+type gradeUnmarshalTestCase struct {
+	name    string
+	payload string
+	want    []models.Grade
+	wantErr bool
+}
+
+func TestGrade_UnmarshalJSON(t *testing.T) {
+	courseID := uuid.MustParse("6f1c1f3e-8a7d-4f0a-9a51-2b4c3d5e6f70")
+	date := time.Date(2025, time.March, 14, 10, 30, 0, 0, time.UTC)
+
+	tests := []gradeUnmarshalTestCase{
+		{
+			name:    "legacy flat grades",
+			payload: `[90, 75]`,
+			want:    models.NewGrades(models.UnassignedCourseID, time.Time{}, 90, 75),
+		},
+		{
+			name:    "course grades",
+			payload: `[{"course_id":"` + courseID.String() + `","value":88,"date":"2025-03-14T10:30:00Z"}]`,
+			want:    models.NewGrades(courseID, date, 88),
+		},
+		{
+			name:    "mixed legacy and course grades",
+			payload: `[60, {"course_id":"` + courseID.String() + `","value":88,"date":"2025-03-14T10:30:00Z"}]`,
+			want: append(
+				models.NewGrades(models.UnassignedCourseID, time.Time{}, 60),
+				models.NewGrades(courseID, date, 88)...,
+			),
+		},
+		{
+			name:    "invalid grade",
+			payload: `["ninety"]`,
+			wantErr: true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(
+			fmt.Sprintf("[%s]-unmarshal-%s-№%d", gradeTestPrefix, tc.name, i+1),
+			func(t *testing.T) {
+				var got []models.Grade
+
+				err := json.Unmarshal([]byte(tc.payload), &got)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf(
+						"[%s][UnmarshalJSON] got err=%v, want error=%v",
+						gradeTestPrefix,
+						err,
+						tc.wantErr,
+					)
+				}
+
+				if tc.wantErr {
+					return
+				}
+
+				if len(got) != len(tc.want) {
+					t.Fatalf(
+						"[%s][UnmarshalJSON] length mismatch: got=%d want=%d",
+						gradeTestPrefix,
+						len(got),
+						len(tc.want),
+					)
+				}
+
+				for j := range got {
+					if got[j].CourseID != tc.want[j].CourseID ||
+						got[j].Value != tc.want[j].Value ||
+						!got[j].Date.Equal(tc.want[j].Date) {
+						t.Fatalf(
+							"[%s][UnmarshalJSON] grade #%d mismatch: got=%+v want=%+v",
+							gradeTestPrefix,
+							j+1,
+							got[j],
+							tc.want[j],
+						)
+					}
+				}
+			},
+		)
+	}
+}
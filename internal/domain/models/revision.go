@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StudentRevision is the state of a student right after one stored write.
+// Version is the student version that write produced.
+type StudentRevision struct {
+	StudentID uuid.UUID `json:"student_id"`
+	Version   int       `json:"version"`
+	At        time.Time `json:"at"`
+	Student   *Student  `json:"student"`
+}
+
+func NewStudentRevision(st *Student, at time.Time) StudentRevision {
+	return StudentRevision{
+		StudentID: st.ID,
+		Version:   st.Version,
+		At:        at.UTC(),
+		Student:   st.Clone(),
+	}
+}
+
+// RevisionAt returns the last revision made at or before at. Revisions must be
+// ordered from the oldest one.
+func RevisionAt(revisions []StudentRevision, at time.Time) (StudentRevision, bool) {
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if !revisions[i].At.After(at) {
+			return revisions[i], true
+		}
+	}
+
+	return StudentRevision{}, false
+}
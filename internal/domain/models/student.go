@@ -2,18 +2,28 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/k6zma/avito-lab1/pkg/validators"
 )
 
+// Student is kept in the trash while DeletedAt is set. Repositories hide such
+// students from everything except the trash listing. Version grows with every
+// stored change, updates must carry the version they were made from.
 type Student struct {
-	ID      uuid.UUID `json:"id"      validate:"required"`
-	Name    string    `json:"name"    validate:"required,capitalized"`
-	Surname string    `json:"surname" validate:"required,capitalized"`
-	Age     int       `json:"age"     validate:"gte=0,lte=150"`
-	Grades  []int     `json:"grades"  validate:"omitempty,dive,gte=0,lte=100"`
+	ID        uuid.UUID  `json:"id"                   validate:"required"`
+	Name      string     `json:"name"                 validate:"required,capitalized"`
+	Surname   string     `json:"surname"              validate:"required,capitalized"`
+	Age       int        `json:"age"                  validate:"gte=0,lte=150"`
+	Grades    []Grade    `json:"grades"               validate:"omitempty,dive"`
+	Version   int        `json:"version"              validate:"gte=0"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+func (s *Student) Deleted() bool {
+	return s.DeletedAt != nil
 }
 
 func (s *Student) SetID(id uuid.UUID) error {
@@ -59,8 +69,8 @@ func (s *Student) SetAge(age int) error {
 	return nil
 }
 
-func (s *Student) SetGrades(grades []int) error {
-	if err := validators.Validate.Var(grades, "required,dive,gte=0,lte=100"); err != nil {
+func (s *Student) SetGrades(grades []Grade) error {
+	if err := validators.Validate.Var(grades, "required,dive"); err != nil {
 		return fmt.Errorf("error while validating grades in student grades setter: %w", err)
 	}
 
@@ -69,8 +79,8 @@ func (s *Student) SetGrades(grades []int) error {
 	return nil
 }
 
-func (s *Student) AddGrades(grades ...int) error {
-	if err := validators.Validate.Var(grades, "required,dive,gte=0,lte=100"); err != nil {
+func (s *Student) AddGrades(grades ...Grade) error {
+	if err := validators.Validate.Var(grades, "required,dive"); err != nil {
 		return fmt.Errorf(
 			"error while validating appended grades in student append grades method: %w",
 			err,
@@ -89,7 +99,12 @@ func (s *Student) Clone() *Student {
 
 	cp := *s
 	if len(s.Grades) > 0 {
-		cp.Grades = append([]int(nil), s.Grades...)
+		cp.Grades = append([]Grade(nil), s.Grades...)
+	}
+
+	if s.DeletedAt != nil {
+		at := *s.DeletedAt
+		cp.DeletedAt = &at
 	}
 
 	return &cp
@@ -100,7 +115,7 @@ type StudentBuilder interface {
 	SetName(name string) StudentBuilder
 	SetSurname(surname string) StudentBuilder
 	SetAge(age int) StudentBuilder
-	SetGrades(grades []int) StudentBuilder
+	SetGrades(grades []Grade) StudentBuilder
 	Build() (*Student, error)
 }
 
@@ -109,7 +124,7 @@ type studentBuilder struct {
 	name    string
 	surname string
 	age     int
-	grades  []int
+	grades  []Grade
 }
 
 func NewStudentBuilder() StudentBuilder {
@@ -140,7 +155,7 @@ func (s *studentBuilder) SetAge(age int) StudentBuilder {
 	return s
 }
 
-func (s *studentBuilder) SetGrades(grades []int) StudentBuilder {
+func (s *studentBuilder) SetGrades(grades []Grade) StudentBuilder {
 	s.grades = grades
 
 	return s
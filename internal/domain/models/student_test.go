@@ -3,6 +3,7 @@ package models_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/k6zma/avito-lab1/internal/domain/models"
 	"github.com/k6zma/avito-lab1/pkg/validators"
@@ -27,6 +28,10 @@ type studentBuilderTestCase struct {
 	wantError bool
 }
 
+func unassignedGrades(values ...int) []models.Grade {
+	return models.NewGrades(models.UnassignedCourseID, time.Time{}, values...)
+}
+
 func TestStudent_Setters(t *testing.T) {
 	err := validators.InitValidators()
 	if err != nil {
@@ -95,42 +100,49 @@ func TestStudent_Setters(t *testing.T) {
 		{
 			name: "valid grades",
 			setFunc: func(s *models.Student) error {
-				return s.SetGrades([]int{80, 90, 100})
+				return s.SetGrades(unassignedGrades(80, 90, 100))
 			},
 			wantError: false,
 		},
 		{
 			name: "invalid grades (out of range)",
 			setFunc: func(s *models.Student) error {
-				return s.SetGrades([]int{187, 104, -653})
+				return s.SetGrades(unassignedGrades(187, 104, -653))
 			},
 			wantError: true,
 		},
 		{
 			name: "invalid grades (mix valid with out of range)",
 			setFunc: func(s *models.Student) error {
-				return s.SetGrades([]int{50, 104, 90, 32})
+				return s.SetGrades(unassignedGrades(50, 104, 90, 32))
 			},
 			wantError: true,
 		},
 		{
 			name: "append valid grade",
 			setFunc: func(s *models.Student) error {
-				return s.AddGrades(75)
+				return s.AddGrades(unassignedGrades(75)...)
 			},
 			wantError: false,
 		},
 		{
 			name: "append invalid grade (too high)",
 			setFunc: func(s *models.Student) error {
-				return s.AddGrades(150)
+				return s.AddGrades(unassignedGrades(150)...)
 			},
 			wantError: true,
 		},
 		{
 			name: "append invalid grade (too low)",
 			setFunc: func(s *models.Student) error {
-				return s.AddGrades(-14)
+				return s.AddGrades(unassignedGrades(-14)...)
+			},
+			wantError: true,
+		},
+		{
+			name: "append grade without course",
+			setFunc: func(s *models.Student) error {
+				return s.AddGrades(models.Grade{Value: 75})
 			},
 			wantError: true,
 		},
@@ -235,7 +247,7 @@ func TestStudentBuilder_Build(t *testing.T) {
 					SetName(tc.nameVal).
 					SetSurname(tc.surname).
 					SetAge(tc.age).
-					SetGrades(tc.grades)
+					SetGrades(unassignedGrades(tc.grades...))
 
 				_, err := student.Build()
 				gotError := err != nil
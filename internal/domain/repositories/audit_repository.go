@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+// AuditFilter narrows audit entries down; zero fields match everything.
+type AuditFilter struct {
+	StudentID uuid.UUID
+	Actor     string
+	Action    models.AuditAction
+}
+
+// AuditLog records the changes of students. Entries are appended once the
+// change itself is stored, not in the same write.
+type AuditLog interface {
+	Append(entries ...models.AuditEntry) error
+	List(filter AuditFilter) ([]models.AuditEntry, error)
+	Verify() error
+}
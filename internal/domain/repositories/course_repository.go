@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+type CourseRepository interface {
+	Create(course *models.Course) (uuid.UUID, error)
+	GetByID(id uuid.UUID) (*models.Course, error)
+	List() ([]*models.Course, error)
+}
@@ -5,6 +5,15 @@ import "errors"
 var (
 	ErrStudentAlreadyExists   = errors.New("student already exists")
 	ErrStudentNotFound        = errors.New("student not found")
+	ErrStudentNotDeleted      = errors.New("student is not in the trash")
+	ErrDuplicateFullName      = errors.New("student with the same name and surname already exists")
+	ErrVersionConflict        = errors.New("student was changed by someone else")
 	ErrInvalidStudentID       = errors.New("invalid student id")
 	ErrInvalidStudentSnapshot = errors.New("invalid student snapshot")
+	ErrBatchClosed            = errors.New("batch transaction is already finished")
+	ErrCourseAlreadyExists    = errors.New("course already exists")
+	ErrCourseNotFound         = errors.New("course not found")
+	ErrInvalidCourseID        = errors.New("invalid course id")
+	ErrInvalidStudentQuery    = errors.New("invalid student query")
+	ErrAuditChainBroken       = errors.New("audit log hash chain is broken")
 )
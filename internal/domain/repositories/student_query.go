@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+type StudentSortKey string
+
+const (
+	SortByCreated StudentSortKey = "created"
+	SortByName    StudentSortKey = "name"
+	SortBySurname StudentSortKey = "surname"
+	SortByAge     StudentSortKey = "age"
+	SortByAVG     StudentSortKey = "avg"
+)
+
+// StudentQuery filters, sorts and pages students. Prefixes are case-sensitive,
+// the average is taken the way Averaging says, the zero value is the plain
+// mean, and students without grades never match an average range. Ties are
+// broken by creation order and a zero Limit returns every matching student.
+type StudentQuery struct {
+	NamePrefix    string
+	SurnamePrefix string
+	MinAge        *int
+	MaxAge        *int
+	MinAVG        *float64
+	MaxAVG        *float64
+	HasGrades     *bool
+	Averaging     grading.Averaging
+	SortBy        StudentSortKey
+	Desc          bool
+	Offset        int
+	Limit         int
+}
+
+type StudentPage struct {
+	Students []*models.Student
+	Total    int
+}
@@ -1,17 +1,46 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/k6zma/avito-lab1/internal/domain/models"
 )
 
+// StudentRepository deletes softly: DeleteByID and DeleteMany move students to
+// the trash, where only ListDeleted, Restore and PurgeDeleted can see them.
+// PurgeDeleted removes for good the students deleted at or before cutoff.
+//
+// Every write bumps Student.Version. Update fails with ErrVersionConflict when
+// the given student does not carry the stored version.
+//
+// Every write also stores a revision of the student. History returns them
+// from the oldest one, trashed students included; purged students lose theirs.
 type StudentRepository interface {
 	Create(student *models.Student) (uuid.UUID, error)
+	CreateMany(students []*models.Student) ([]uuid.UUID, error)
 	Update(student *models.Student) error
+	UpdateMany(students []*models.Student) error
 	DeleteByID(id uuid.UUID) error
+	DeleteMany(ids []uuid.UUID) error
+	ListDeleted() ([]*models.Student, error)
+	Restore(id uuid.UUID) error
+	PurgeDeleted(cutoff time.Time) ([]*models.Student, error)
+	History(id uuid.UUID) ([]models.StudentRevision, error)
 	GetByID(id uuid.UUID) (*models.Student, error)
 	GetByFullName(name, surname string) (*models.Student, error)
+	SearchByName(query string) ([]*models.Student, error)
 	List() ([]*models.Student, error)
-	AddGrades(id uuid.UUID, grades ...int) error
+	Query(q StudentQuery) (StudentPage, error)
+	AddGrades(id uuid.UUID, grades ...models.Grade) error
+	Batch(fn func(tx StudentTx) error) error
+}
+
+type StudentTx interface {
+	Create(student *models.Student) (uuid.UUID, error)
+	Update(student *models.Student) error
+	DeleteByID(id uuid.UUID) error
+	GetByID(id uuid.UUID) (*models.Student, error)
+	AddGrades(id uuid.UUID, grades ...models.Grade) error
 }
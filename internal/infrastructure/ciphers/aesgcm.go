@@ -9,8 +9,9 @@ import (
 )
 
 type AESGCMCipher struct {
-	key  [32]byte
-	aead cipher.AEAD
+	key   [32]byte
+	keyID [KeyIDSize]byte
+	aead  cipher.AEAD
 }
 
 func NewAESGCM(key string) (Cipher, error) {
@@ -22,6 +23,10 @@ func NewAESGCM(key string) (Cipher, error) {
 
 	copy(k[:], key)
 
+	return newAESGCM(k)
+}
+
+func newAESGCM(k [32]byte) (*AESGCMCipher, error) {
 	block, err := aes.NewCipher(k[:])
 	if err != nil {
 		return nil, fmt.Errorf("failed creating cipher block: %w", err)
@@ -33,12 +38,41 @@ func NewAESGCM(key string) (Cipher, error) {
 	}
 
 	return &AESGCMCipher{
-		key:  k,
-		aead: gcm,
+		key:   k,
+		keyID: KeyID(k[:]),
+		aead:  gcm,
 	}, nil
 }
 
 func (a *AESGCMCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	return a.seal(KDFNone, nil, plaintext)
+}
+
+func (a *AESGCMCipher) Decrypt(data []byte) ([]byte, error) {
+	if !IsEnveloped(data) {
+		return a.open(data, nil)
+	}
+
+	header, n, err := parseAESGCMHeader(data, KDFNone)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.openEnvelope(header, data, n)
+}
+
+func (a *AESGCMCipher) seal(kdf KDF, kdfParams []byte, plaintext []byte) ([]byte, error) {
+	header, err := Header{
+		Version:   EnvelopeVersion1,
+		Algorithm: AlgorithmAESGCM,
+		KDF:       kdf,
+		KeyID:     a.keyID,
+		KDFParams: kdfParams,
+	}.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncryptFailed, err)
+	}
+
 	nonceSize := a.aead.NonceSize()
 	nonce := make([]byte, nonceSize)
 
@@ -46,16 +80,22 @@ func (a *AESGCMCipher) Encrypt(plaintext []byte) ([]byte, error) {
 		return nil, fmt.Errorf("failed while generating nonce: %w", err)
 	}
 
-	ct := a.aead.Seal(nil, nonce, plaintext, nil)
-	out := make([]byte, nonceSize+len(ct))
+	out := make([]byte, 0, len(header)+nonceSize+len(plaintext)+a.aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+
+	return a.aead.Seal(out, nonce, plaintext, header), nil
+}
 
-	copy(out, nonce)
-	copy(out[nonceSize:], ct)
+func (a *AESGCMCipher) openEnvelope(header Header, data []byte, n int) ([]byte, error) {
+	if header.KeyID != a.keyID {
+		return nil, ErrWrongKey
+	}
 
-	return out, nil
+	return a.open(data[n:], data[:n])
 }
 
-func (a *AESGCMCipher) Decrypt(data []byte) ([]byte, error) {
+func (a *AESGCMCipher) open(data, aad []byte) ([]byte, error) {
 	nonceSize := a.aead.NonceSize()
 	if len(data) < nonceSize {
 		return nil, ErrCorruptedPayload
@@ -64,10 +104,32 @@ func (a *AESGCMCipher) Decrypt(data []byte) ([]byte, error) {
 	nonce := data[:nonceSize]
 	ct := data[nonceSize:]
 
-	pt, err := a.aead.Open(nil, nonce, ct, nil)
+	pt, err := a.aead.Open(nil, nonce, ct, aad)
 	if err != nil {
 		return nil, ErrCorruptedPayload
 	}
 
 	return pt, nil
 }
+
+func parseAESGCMHeader(data []byte, kdf KDF) (Header, int, error) {
+	header, n, err := ParseHeader(data)
+	if err != nil {
+		return Header{}, 0, err
+	}
+
+	if header.Algorithm != AlgorithmAESGCM {
+		return Header{}, 0, fmt.Errorf("%w: %d", ErrUnsupportedAlgorithm, header.Algorithm)
+	}
+
+	if header.KDF != kdf {
+		return Header{}, 0, fmt.Errorf(
+			"%w: payload uses %s, cipher expects %s",
+			ErrUnsupportedKDF,
+			header.KDF,
+			kdf,
+		)
+	}
+
+	return header, n, nil
+}
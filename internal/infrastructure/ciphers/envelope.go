@@ -0,0 +1,122 @@
+package ciphers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+type Algorithm uint8
+
+const (
+	AlgorithmAESGCM Algorithm = 1
+)
+
+type KDF uint8
+
+const (
+	KDFNone     KDF = 0
+	KDFArgon2id KDF = 1
+)
+
+func (k KDF) String() string {
+	switch k {
+	case KDFNone:
+		return "none"
+	case KDFArgon2id:
+		return "argon2id"
+	default:
+		return fmt.Sprintf("kdf(%d)", uint8(k))
+	}
+}
+
+const (
+	EnvelopeVersion1 uint8 = 1
+
+	KeyIDSize = 8
+
+	envelopeMagic        = "STDF"
+	envelopeFixedSize    = len(envelopeMagic) + 3 + KeyIDSize + 2
+	maxKDFParamsSize     = 1 << 10
+	keyIDDomainSeparator = "studify/key-id/v1"
+)
+
+type Header struct {
+	Version   uint8
+	Algorithm Algorithm
+	KDF       KDF
+	KeyID     [KeyIDSize]byte
+	KDFParams []byte
+}
+
+func KeyID(key []byte) [KeyIDSize]byte {
+	h := sha256.New()
+	h.Write([]byte(keyIDDomainSeparator))
+	h.Write(key)
+
+	var id [KeyIDSize]byte
+
+	copy(id[:], h.Sum(nil))
+
+	return id
+}
+
+func IsEnveloped(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(envelopeMagic))
+}
+
+func (h Header) MarshalBinary() ([]byte, error) {
+	if len(h.KDFParams) > maxKDFParamsSize {
+		return nil, fmt.Errorf("%w: kdf params are too large", ErrInvalidHeader)
+	}
+
+	out := make([]byte, 0, envelopeFixedSize+len(h.KDFParams))
+	out = append(out, envelopeMagic...)
+	out = append(out, h.Version, byte(h.Algorithm), byte(h.KDF))
+	out = append(out, h.KeyID[:]...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(h.KDFParams)))
+	out = append(out, h.KDFParams...)
+
+	return out, nil
+}
+
+func ParseHeader(data []byte) (Header, int, error) {
+	if !IsEnveloped(data) {
+		return Header{}, 0, fmt.Errorf("%w: missing magic bytes", ErrInvalidHeader)
+	}
+
+	if len(data) < envelopeFixedSize {
+		return Header{}, 0, fmt.Errorf("%w: header is truncated", ErrInvalidHeader)
+	}
+
+	offset := len(envelopeMagic)
+
+	h := Header{
+		Version:   data[offset],
+		Algorithm: Algorithm(data[offset+1]),
+		KDF:       KDF(data[offset+2]),
+	}
+
+	if h.Version != EnvelopeVersion1 {
+		return Header{}, 0, fmt.Errorf("%w: %d", ErrUnsupportedVersion, h.Version)
+	}
+
+	offset += 3
+
+	copy(h.KeyID[:], data[offset:offset+KeyIDSize])
+	offset += KeyIDSize
+
+	paramsLen := int(binary.BigEndian.Uint16(data[offset:]))
+	offset += 2
+
+	if paramsLen > maxKDFParamsSize || len(data)-offset < paramsLen {
+		return Header{}, 0, fmt.Errorf("%w: kdf params are truncated", ErrInvalidHeader)
+	}
+
+	if paramsLen > 0 {
+		h.KDFParams = append([]byte(nil), data[offset:offset+paramsLen]...)
+	}
+
+	return h, offset + paramsLen, nil
+}
@@ -0,0 +1,138 @@
+package ciphers_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+const (
+	envelopeTestPrefix = "CipherEnvelope"
+
+	otherTestKey = "12345678910111213141516171819201"
+)
+
+type envelopeTamperCase struct {
+	name    string
+	offset  int
+	value   byte
+	wantErr error
+}
+
+func TestEnvelope_HeaderRoundTrip(t *testing.T) {
+	want := ciphers.Header{
+		Version:   ciphers.EnvelopeVersion1,
+		Algorithm: ciphers.AlgorithmAESGCM,
+		KDF:       ciphers.KDFNone,
+		KeyID:     ciphers.KeyID([]byte(testKey)),
+		KDFParams: []byte("params"),
+	}
+
+	raw, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatalf("[%s][Header] failed to marshal header: %v", envelopeTestPrefix, err)
+	}
+
+	got, n, err := ciphers.ParseHeader(append(raw, "body"...))
+	if err != nil {
+		t.Fatalf("[%s][Header] failed to parse header: %v", envelopeTestPrefix, err)
+	}
+
+	if n != len(raw) {
+		t.Fatalf("[%s][Header] header size mismatch: got=%d want=%d", envelopeTestPrefix, n, len(raw))
+	}
+
+	if got.KeyID != want.KeyID || !bytes.Equal(got.KDFParams, want.KDFParams) ||
+		got.Algorithm != want.Algorithm {
+		t.Fatalf("[%s][Header] header mismatch: got=%+v want=%+v", envelopeTestPrefix, got, want)
+	}
+}
+
+func TestEnvelope_Decrypt_WrongKey(t *testing.T) {
+	c1, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][WrongKey] failed initing first cipher: %v", envelopeTestPrefix, err)
+	}
+
+	c2, err := ciphers.NewAESGCM(otherTestKey)
+	if err != nil {
+		t.Fatalf("[%s][WrongKey] failed initing second cipher: %v", envelopeTestPrefix, err)
+	}
+
+	ct, err := c1.Encrypt([]byte("top_secret"))
+	if err != nil {
+		t.Fatalf("[%s][WrongKey] unexpected error while encrypting: %v", envelopeTestPrefix, err)
+	}
+
+	if !ciphers.IsEnveloped(ct) {
+		t.Fatalf("[%s][WrongKey] ciphertext must start with envelope header", envelopeTestPrefix)
+	}
+
+	if _, err := c2.Decrypt(ct); !errors.Is(err, ciphers.ErrWrongKey) {
+		t.Fatalf("[%s][WrongKey] want ErrWrongKey, got %v", envelopeTestPrefix, err)
+	}
+}
+
+func TestEnvelope_Decrypt_TamperedHeader(t *testing.T) {
+	c, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Tamper] failed initing cipher: %v", envelopeTestPrefix, err)
+	}
+
+	tests := []envelopeTamperCase{
+		{"unknown version", 4, 9, ciphers.ErrUnsupportedVersion},
+		{"unknown algorithm", 5, 9, ciphers.ErrUnsupportedAlgorithm},
+		{"unknown kdf", 6, 9, ciphers.ErrUnsupportedKDF},
+		{"other key id", 7, 0xff, ciphers.ErrWrongKey},
+		{"kdf params length", 16, 1, ciphers.ErrCorruptedPayload},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-tamper-%s-№%d", envelopeTestPrefix, tc.name, i+1), func(t *testing.T) {
+			ct, err := c.Encrypt([]byte("pau pau pau"))
+			if err != nil {
+				t.Fatalf("[%s][Tamper] unexpected error while encrypting: %v", envelopeTestPrefix, err)
+			}
+
+			ct[tc.offset] ^= tc.value
+
+			if _, err := c.Decrypt(ct); !errors.Is(err, tc.wantErr) {
+				t.Fatalf("[%s][Tamper] want %v, got %v", envelopeTestPrefix, tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEnvelope_Decrypt_LegacyPayload(t *testing.T) {
+	block, err := aes.NewCipher([]byte(testKey))
+	if err != nil {
+		t.Fatalf("[%s][Legacy] failed creating block: %v", envelopeTestPrefix, err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("[%s][Legacy] failed creating gcm: %v", envelopeTestPrefix, err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	legacy := gcm.Seal(append([]byte(nil), nonce...), nonce, []byte("legacy"), nil)
+
+	c, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Legacy] failed initing cipher: %v", envelopeTestPrefix, err)
+	}
+
+	pt, err := c.Decrypt(legacy)
+	if err != nil {
+		t.Fatalf("[%s][Legacy] unexpected error while decrypting legacy payload: %v", envelopeTestPrefix, err)
+	}
+
+	if string(pt) != "legacy" {
+		t.Fatalf("[%s][Legacy] plaintext mismatch: got=%q", envelopeTestPrefix, pt)
+	}
+}
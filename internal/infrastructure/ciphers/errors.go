@@ -3,7 +3,13 @@ package ciphers
 import "errors"
 
 var (
-	ErrInvalidKey       = errors.New("invalid encryption key")
-	ErrEncryptFailed    = errors.New("encrypt failed")
-	ErrCorruptedPayload = errors.New("ciphertext/auth tag is invalid or payload is corrupted")
+	ErrInvalidKey           = errors.New("invalid encryption key")
+	ErrEncryptFailed        = errors.New("encrypt failed")
+	ErrCorruptedPayload     = errors.New("ciphertext/auth tag is invalid or payload is corrupted")
+	ErrInvalidHeader        = errors.New("encrypted payload header is invalid")
+	ErrUnsupportedVersion   = errors.New("unsupported encrypted payload format version")
+	ErrUnsupportedAlgorithm = errors.New("unsupported encrypted payload cipher algorithm")
+	ErrUnsupportedKDF       = errors.New("unsupported encrypted payload key derivation function")
+	ErrWrongKey             = errors.New("payload was encrypted with a different key")
+	ErrInvalidKDFParams     = errors.New("invalid key derivation parameters")
 )
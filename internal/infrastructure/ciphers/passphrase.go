@@ -0,0 +1,201 @@
+package ciphers
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	SaltSize = 16
+
+	argon2ParamsSize = 4 + 4 + 1 + SaltSize
+	argon2MaxTime    = 16
+	argon2MaxMemory  = 1 << 20
+)
+
+type Argon2Params struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+var DefaultArgon2Params = Argon2Params{
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
+type PassphraseCipher struct {
+	passphrase []byte
+	params     Argon2Params
+	current    *derivedKey
+	cache      map[string]*AESGCMCipher
+	mu         sync.Mutex
+}
+
+type derivedKey struct {
+	cipher *AESGCMCipher
+	params []byte
+}
+
+func NewPassphraseAESGCM(passphrase string, params Argon2Params) (Cipher, error) {
+	if passphrase == "" {
+		return nil, ErrInvalidKey
+	}
+
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	return &PassphraseCipher{
+		passphrase: []byte(passphrase),
+		params:     params,
+		cache:      make(map[string]*AESGCMCipher),
+	}, nil
+}
+
+func (p *PassphraseCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	key, err := p.encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return key.cipher.seal(KDFArgon2id, key.params, plaintext)
+}
+
+func (p *PassphraseCipher) Decrypt(data []byte) ([]byte, error) {
+	if !IsEnveloped(data) {
+		return nil, fmt.Errorf(
+			"%w: legacy payload uses %s, cipher expects %s",
+			ErrUnsupportedKDF,
+			KDFNone,
+			KDFArgon2id,
+		)
+	}
+
+	header, n, err := parseAESGCMHeader(data, KDFArgon2id)
+	if err != nil {
+		return nil, err
+	}
+
+	params, salt, err := decodeArgon2Params(header.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	c, err := p.derive(params, salt, header.KDFParams)
+	p.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.openEnvelope(header, data, n)
+}
+
+func (p *PassphraseCipher) encryptionKey() (*derivedKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.current != nil {
+		return p.current, nil
+	}
+
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("failed while generating salt: %w", err)
+	}
+
+	encoded := encodeArgon2Params(p.params, salt)
+
+	c, err := p.derive(p.params, salt, encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	p.current = &derivedKey{
+		cipher: c,
+		params: encoded,
+	}
+
+	return p.current, nil
+}
+
+func (p *PassphraseCipher) derive(params Argon2Params, salt, encoded []byte) (*AESGCMCipher, error) {
+	if c, ok := p.cache[string(encoded)]; ok {
+		return c, nil
+	}
+
+	var k [32]byte
+
+	copy(k[:], argon2.IDKey(p.passphrase, salt, params.Time, params.Memory, params.Threads, 32))
+
+	c, err := newAESGCM(k)
+	if err != nil {
+		return nil, err
+	}
+
+	p.cache[string(encoded)] = c
+
+	if p.current == nil && params == p.params {
+		p.current = &derivedKey{
+			cipher: c,
+			params: append([]byte(nil), encoded...),
+		}
+	}
+
+	return c, nil
+}
+
+func (a Argon2Params) validate() error {
+	if a.Time == 0 || a.Time > argon2MaxTime {
+		return fmt.Errorf("%w: argon2 time must be in [1, %d]", ErrInvalidKDFParams, argon2MaxTime)
+	}
+
+	if a.Memory < 8*uint32(a.Threads) || a.Memory > argon2MaxMemory {
+		return fmt.Errorf(
+			"%w: argon2 memory must be in [8*threads, %d] KiB",
+			ErrInvalidKDFParams,
+			argon2MaxMemory,
+		)
+	}
+
+	if a.Threads == 0 {
+		return fmt.Errorf("%w: argon2 threads must be positive", ErrInvalidKDFParams)
+	}
+
+	return nil
+}
+
+func encodeArgon2Params(params Argon2Params, salt []byte) []byte {
+	out := make([]byte, 0, argon2ParamsSize)
+	out = binary.BigEndian.AppendUint32(out, params.Time)
+	out = binary.BigEndian.AppendUint32(out, params.Memory)
+	out = append(out, params.Threads)
+
+	return append(out, salt...)
+}
+
+func decodeArgon2Params(raw []byte) (Argon2Params, []byte, error) {
+	if len(raw) != argon2ParamsSize {
+		return Argon2Params{}, nil, fmt.Errorf("%w: unexpected argon2 params size %d", ErrInvalidKDFParams, len(raw))
+	}
+
+	params := Argon2Params{
+		Time:    binary.BigEndian.Uint32(raw[0:4]),
+		Memory:  binary.BigEndian.Uint32(raw[4:8]),
+		Threads: raw[8],
+	}
+
+	if err := params.validate(); err != nil {
+		return Argon2Params{}, nil, err
+	}
+
+	return params, raw[9:], nil
+}
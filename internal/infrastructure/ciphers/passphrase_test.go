@@ -0,0 +1,123 @@
+package ciphers_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+const (
+	passphraseTestPrefix = "CipherPassphrase"
+
+	testPassphrase = "correct horse battery staple"
+)
+
+var testArgon2Params = ciphers.Argon2Params{
+	Time:    1,
+	Memory:  64,
+	Threads: 1,
+}
+
+func newTestPassphraseCipher(t *testing.T, passphrase string) ciphers.Cipher {
+	t.Helper()
+
+	c, err := ciphers.NewPassphraseAESGCM(passphrase, testArgon2Params)
+	if err != nil {
+		t.Fatalf("[%s] failed initing cipher: %v", passphraseTestPrefix, err)
+	}
+
+	return c
+}
+
+func TestPassphrase_EncryptDecrypt_AcrossInstances(t *testing.T) {
+	writer := newTestPassphraseCipher(t, testPassphrase)
+
+	first, err := writer.Encrypt([]byte("first"))
+	if err != nil {
+		t.Fatalf("[%s][RoundTrip] unexpected error while encrypting: %v", passphraseTestPrefix, err)
+	}
+
+	second, err := writer.Encrypt([]byte("second"))
+	if err != nil {
+		t.Fatalf("[%s][RoundTrip] unexpected error while encrypting: %v", passphraseTestPrefix, err)
+	}
+
+	reader := newTestPassphraseCipher(t, testPassphrase)
+
+	for _, tc := range []struct {
+		ct   []byte
+		want string
+	}{{first, "first"}, {second, "second"}} {
+		pt, err := reader.Decrypt(tc.ct)
+		if err != nil {
+			t.Fatalf("[%s][RoundTrip] unexpected error while decrypting: %v", passphraseTestPrefix, err)
+		}
+
+		if string(pt) != tc.want {
+			t.Fatalf("[%s][RoundTrip] plaintext mismatch: got=%q want=%q", passphraseTestPrefix, pt, tc.want)
+		}
+	}
+
+	header, _, err := ciphers.ParseHeader(first)
+	if err != nil {
+		t.Fatalf("[%s][RoundTrip] failed to parse header: %v", passphraseTestPrefix, err)
+	}
+
+	if header.KDF != ciphers.KDFArgon2id || len(header.KDFParams) == 0 {
+		t.Fatalf("[%s][RoundTrip] header must describe argon2id params: %+v", passphraseTestPrefix, header)
+	}
+}
+
+func TestPassphrase_Decrypt_WrongPassphrase(t *testing.T) {
+	ct, err := newTestPassphraseCipher(t, testPassphrase).Encrypt([]byte("top_secret"))
+	if err != nil {
+		t.Fatalf("[%s][WrongKey] unexpected error while encrypting: %v", passphraseTestPrefix, err)
+	}
+
+	_, err = newTestPassphraseCipher(t, "wrong horse battery staple").Decrypt(ct)
+	if !errors.Is(err, ciphers.ErrWrongKey) {
+		t.Fatalf("[%s][WrongKey] want ErrWrongKey, got %v", passphraseTestPrefix, err)
+	}
+}
+
+func TestPassphrase_Decrypt_KDFMismatch(t *testing.T) {
+	raw, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Mismatch] failed initing raw cipher: %v", passphraseTestPrefix, err)
+	}
+
+	rawCT, err := raw.Encrypt([]byte("raw"))
+	if err != nil {
+		t.Fatalf("[%s][Mismatch] unexpected error while encrypting: %v", passphraseTestPrefix, err)
+	}
+
+	if _, err := newTestPassphraseCipher(t, testPassphrase).Decrypt(rawCT); !errors.Is(
+		err,
+		ciphers.ErrUnsupportedKDF,
+	) {
+		t.Fatalf("[%s][Mismatch] want ErrUnsupportedKDF, got %v", passphraseTestPrefix, err)
+	}
+
+	passCT, err := newTestPassphraseCipher(t, testPassphrase).Encrypt([]byte("pass"))
+	if err != nil {
+		t.Fatalf("[%s][Mismatch] unexpected error while encrypting: %v", passphraseTestPrefix, err)
+	}
+
+	if _, err := raw.Decrypt(passCT); !errors.Is(err, ciphers.ErrUnsupportedKDF) {
+		t.Fatalf("[%s][Mismatch] want ErrUnsupportedKDF, got %v", passphraseTestPrefix, err)
+	}
+}
+
+func TestPassphrase_NewPassphraseAESGCM_Validation(t *testing.T) {
+	if _, err := ciphers.NewPassphraseAESGCM("", testArgon2Params); !errors.Is(err, ciphers.ErrInvalidKey) {
+		t.Fatalf("[%s][New] want ErrInvalidKey for empty passphrase, got %v", passphraseTestPrefix, err)
+	}
+
+	if _, err := ciphers.NewPassphraseAESGCM(testPassphrase, ciphers.Argon2Params{}); !errors.Is(
+		err,
+		ciphers.ErrInvalidKDFParams,
+	) {
+		t.Fatalf("[%s][New] want ErrInvalidKDFParams for zero params, got %v", passphraseTestPrefix, err)
+	}
+}
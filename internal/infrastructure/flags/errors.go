@@ -0,0 +1,11 @@
+package flags
+
+import "errors"
+
+var (
+	ErrCipherKeyNotProvided = errors.New("cipher key is not provided")
+	ErrInsecureKeyFile      = errors.New("key file must not be accessible by group or others")
+	ErrKeyFileNotRegular    = errors.New("key file must be a regular file")
+	ErrKeyPromptMismatch    = errors.New("entered keys do not match")
+	ErrRekeyNothingChanges  = errors.New("new key and kdf must differ from the current ones")
+)
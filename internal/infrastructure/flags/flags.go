@@ -3,6 +3,9 @@ package flags
 import (
 	"flag"
 	"fmt"
+	"os"
+	"os/user"
+	"strings"
 
 	"github.com/k6zma/avito-lab1/pkg/validators"
 )
@@ -14,7 +17,69 @@ const (
 
 	cipherKeyFlagName     = "cipher_key"
 	cipherKetDefaultValue = ""
-	cipherKeyFlagDesc     = "Key for encryption/decryption of students data using AES-GCM - it's required to be 32 characters long"
+	cipherKeyFlagDesc     = "Key for encryption/decryption of students data using AES-GCM - it's required to be 32 characters long with kdf none, or a passphrase of at least 8 characters with kdf argon2id (prefer " + CipherKeyEnv + ", cipher_key_file or the interactive prompt, flags are visible to other users)"
+
+	cipherKeyFileFlagName         = "cipher_key_file"
+	cipherKeyFileFlagDefaultValue = ""
+	cipherKeyFileFlagDesc         = "Path to a file with the cipher key, used when cipher_key flag and " + CipherKeyEnv + " are empty - it must not be readable by group or others"
+
+	cipherKeyPrompt = "Cipher key"
+
+	kdfFlagName         = "kdf"
+	kdfFlagDefaultValue = KDFNone
+	kdfFlagDesc         = "Key derivation for cipher_key: none (cipher_key is the raw 32 character AES key) or argon2id (cipher_key is a passphrase, salt is stored in the data file header)"
+
+	persisterFlagName         = "persister"
+	persisterFlagDefaultValue = PersisterJSON
+	persisterFlagDesc         = "Students data persistence mode: json (full snapshot on every change) or wal (append-only log with periodic compaction)"
+
+	storageFlagName         = "storage"
+	storageFlagDefaultValue = StorageMemory
+	storageFlagDesc         = "Students storage backend: memory (encrypted file selected by persister flag) or sqlite (database file at data_path, not encrypted with cipher_key)"
+
+	averagingFlagName         = "averaging"
+	averagingFlagDefaultValue = AveragingMean
+	averagingFlagDesc         = "How grades are averaged: mean (every grade counts once) or weighted (grades count by their weight, e.g. exams with weight 2 count double)"
+
+	gradeScaleFlagName         = "grade_scale"
+	gradeScaleFlagDefaultValue = GradeScalePercent
+	gradeScaleFlagDesc         = "Scale averages are mapped to: percent (0-100 only), letter (A-F), five_point (2-5) or gpa (0.0-4.0)"
+
+	uniqueNamesFlagName         = "unique_names"
+	uniqueNamesFlagDefaultValue = false
+	uniqueNamesFlagDesc         = "Reject adding or renaming a student to a name and surname that another student already has"
+
+	actorFlagName         = "actor"
+	actorFlagDefaultValue = ""
+	actorFlagDesc         = "Name recorded in the audit log for changes made by this run, defaults to the OS user (the HTTP API also accepts an X-Actor header per request)"
+
+	unknownActor = "unknown"
+
+	purgeAfterDaysFlagName         = "purge_after_days"
+	purgeAfterDaysFlagDefaultValue = 30
+	purgeAfterDaysFlagDesc         = "Days a deleted student stays in the trash, after that the TUI purges it on start and serve once an hour (0 keeps the trash until it is purged by hand)"
+)
+
+const (
+	PersisterJSON = "json"
+	PersisterWAL  = "wal"
+
+	StorageMemory = "memory"
+	StorageSQLite = "sqlite"
+
+	AveragingMean     = "mean"
+	AveragingWeighted = "weighted"
+
+	GradeScalePercent   = "percent"
+	GradeScaleLetter    = "letter"
+	GradeScaleFivePoint = "five_point"
+	GradeScaleGPA       = "gpa"
+
+	KDFNone     = "none"
+	KDFArgon2id = "argon2id"
+
+	rawCipherKeyRule  = "len=32"
+	passphraseKeyRule = "min=8"
 )
 
 var configPathFlag = flag.String(
@@ -29,26 +94,170 @@ var cipherKeyFlag = flag.String(
 	cipherKeyFlagDesc,
 )
 
+var cipherKeyFileFlag = flag.String(
+	cipherKeyFileFlagName,
+	cipherKeyFileFlagDefaultValue,
+	cipherKeyFileFlagDesc,
+)
+
+var persisterFlag = flag.String(
+	persisterFlagName,
+	persisterFlagDefaultValue,
+	persisterFlagDesc,
+)
+
+var storageFlag = flag.String(
+	storageFlagName,
+	storageFlagDefaultValue,
+	storageFlagDesc,
+)
+
+var kdfFlag = flag.String(
+	kdfFlagName,
+	kdfFlagDefaultValue,
+	kdfFlagDesc,
+)
+
+var averagingFlag = flag.String(
+	averagingFlagName,
+	averagingFlagDefaultValue,
+	averagingFlagDesc,
+)
+
+var gradeScaleFlag = flag.String(
+	gradeScaleFlagName,
+	gradeScaleFlagDefaultValue,
+	gradeScaleFlagDesc,
+)
+
+var uniqueNamesFlag = flag.Bool(
+	uniqueNamesFlagName,
+	uniqueNamesFlagDefaultValue,
+	uniqueNamesFlagDesc,
+)
+
+var actorFlag = flag.String(
+	actorFlagName,
+	actorFlagDefaultValue,
+	actorFlagDesc,
+)
+
+var purgeAfterDaysFlag = flag.Int(
+	purgeAfterDaysFlagName,
+	purgeAfterDaysFlagDefaultValue,
+	purgeAfterDaysFlagDesc,
+)
+
 type StudyFlags struct {
-	ConfigPath string `validate:"required,filepath"`
-	CipherKey  string `validate:"required,len=32"`
+	ConfigPath     string `validate:"required,filepath"`
+	CipherKey      string `validate:"required"`
+	KDF            string `validate:"required,oneof=none argon2id"`
+	Persister      string `validate:"required,oneof=json wal"`
+	Storage        string `validate:"required,oneof=memory sqlite"`
+	Averaging      string `validate:"required,oneof=mean weighted"`
+	GradeScale     string `validate:"required,oneof=percent letter five_point gpa"`
+	UniqueNames    bool
+	Actor          string `validate:"required,max=64"`
+	PurgeAfterDays int    `validate:"gte=0,lte=3650"`
+	Command        string
+	Args           []string
+	Rekey          *RekeyFlags
 }
 
 func GetFlags() (*StudyFlags, error) {
 	flag.Parse()
 
 	result := &StudyFlags{
-		ConfigPath: *configPathFlag,
-		CipherKey:  *cipherKeyFlag,
+		ConfigPath:     *configPathFlag,
+		Persister:      *persisterFlag,
+		Storage:        *storageFlag,
+		KDF:            *kdfFlag,
+		Averaging:      *averagingFlag,
+		GradeScale:     *gradeScaleFlag,
+		UniqueNames:    *uniqueNamesFlag,
+		Actor:          resolveActor(*actorFlag),
+		PurgeAfterDays: *purgeAfterDaysFlag,
+		Command:        flag.Arg(0),
+	}
+
+	if flag.NArg() > 1 {
+		result.Args = flag.Args()[1:]
+	}
+
+	if result.Command == RekeyCommand {
+		if err := applyRekeyFlags(result); err != nil {
+			return nil, err
+		}
+	} else {
+		key, err := cipherKeySource(cipherKeyPrompt).resolve()
+		if err != nil {
+			return nil, fmt.Errorf("error while reading cipher key in studify app: %w", err)
+		}
+
+		result.CipherKey = key
 	}
 
 	if err := validators.Validate.Struct(result); err != nil {
 		return nil, fmt.Errorf("error while validating flags in studify app: %w", err)
 	}
 
+	if err := validateCipherKey(result.KDF, result.CipherKey); err != nil {
+		return nil, fmt.Errorf("error while validating flags in studify app: %w", err)
+	}
+
 	return result, nil
 }
 
+func resolveActor(actor string) string {
+	if actor = strings.TrimSpace(actor); actor != "" {
+		return actor
+	}
+
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+
+	return unknownActor
+}
+
+func validateCipherKey(kdf string, key string) error {
+	rule := rawCipherKeyRule
+	if kdf == KDFArgon2id {
+		rule = passphraseKeyRule
+	}
+
+	if err := validators.Validate.Var(key, rule); err != nil {
+		return fmt.Errorf("invalid cipher key for kdf %s: %w", kdf, err)
+	}
+
+	return nil
+}
+
+func applyRekeyFlags(cfg *StudyFlags) error {
+	rekey, err := GetRekeyFlags(cfg.Args, cfg.KDF)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Storage == StorageSQLite {
+		return fmt.Errorf("rekey command is not supported for %s storage", StorageSQLite)
+	}
+
+	if *cipherKeyFlag != "" && *cipherKeyFlag != rekey.OldKey {
+		return fmt.Errorf("%s flag must match %s flag for rekey command", cipherKeyFlagName, rekeyOldKeyFlagName)
+	}
+
+	cfg.CipherKey = rekey.OldKey
+	cfg.KDF = rekey.OldKDF
+	cfg.Rekey = rekey
+
+	return nil
+}
+
 func ResetForTests(fs *flag.FlagSet) {
 	flag.CommandLine = fs
 
@@ -58,4 +267,21 @@ func ResetForTests(fs *flag.FlagSet) {
 		dataFilePathFlagDesc,
 	)
 	cipherKeyFlag = flag.String(cipherKeyFlagName, cipherKetDefaultValue, cipherKeyFlagDesc)
+	cipherKeyFileFlag = flag.String(
+		cipherKeyFileFlagName,
+		cipherKeyFileFlagDefaultValue,
+		cipherKeyFileFlagDesc,
+	)
+	persisterFlag = flag.String(persisterFlagName, persisterFlagDefaultValue, persisterFlagDesc)
+	storageFlag = flag.String(storageFlagName, storageFlagDefaultValue, storageFlagDesc)
+	kdfFlag = flag.String(kdfFlagName, kdfFlagDefaultValue, kdfFlagDesc)
+	averagingFlag = flag.String(averagingFlagName, averagingFlagDefaultValue, averagingFlagDesc)
+	gradeScaleFlag = flag.String(gradeScaleFlagName, gradeScaleFlagDefaultValue, gradeScaleFlagDesc)
+	uniqueNamesFlag = flag.Bool(uniqueNamesFlagName, uniqueNamesFlagDefaultValue, uniqueNamesFlagDesc)
+	actorFlag = flag.String(actorFlagName, actorFlagDefaultValue, actorFlagDesc)
+	purgeAfterDaysFlag = flag.Int(
+		purgeAfterDaysFlagName,
+		purgeAfterDaysFlagDefaultValue,
+		purgeAfterDaysFlagDesc,
+	)
 }
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/k6zma/avito-lab1/internal/infrastructure/flags"
@@ -181,3 +182,247 @@ func TestGetFlags_IdempotentValues(t *testing.T) {
 		)
 	}
 }
+
+func TestGetFlags_CommandAndArgs(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+
+	os.Args = []string{
+		"studify",
+		fmt.Sprintf("-%s=%s", "cipher_key", cipherKey),
+		"grades", "add", "some-id", "90,85",
+	}
+
+	got, err := flags.GetFlags()
+	if err != nil {
+		t.Fatalf("[%s][CommandAndArgs] unexpected error: %v", flagsTestPrefix, err)
+	}
+
+	if got.Command != "grades" {
+		t.Fatalf(
+			"[%s][CommandAndArgs] command mismatch: got=%q want=%q",
+			flagsTestPrefix, got.Command, "grades",
+		)
+	}
+
+	if fmt.Sprint(got.Args) != fmt.Sprint([]string{"add", "some-id", "90,85"}) {
+		t.Fatalf(
+			"[%s][CommandAndArgs] args mismatch: got=%v",
+			flagsTestPrefix, got.Args,
+		)
+	}
+}
+
+type getFlagsKDFCase struct {
+	name    string
+	kdf     string
+	key     string
+	wantErr bool
+}
+
+func TestGetFlags_KDF(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	tests := []getFlagsKDFCase{
+		{"raw key without kdf", flags.KDFNone, cipherKey, false},
+		{"passphrase without kdf", flags.KDFNone, "correct horse", true},
+		{"passphrase with argon2id", flags.KDFArgon2id, "correct horse", false},
+		{"raw key with argon2id", flags.KDFArgon2id, cipherKey, false},
+		{"short passphrase with argon2id", flags.KDFArgon2id, "short", true},
+		{"unknown kdf", "scrypt", "correct horse", true},
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	for i, tc := range tests {
+		t.Run(
+			fmt.Sprintf("[%s]-GetFlags-KDF-%s-№%d", flagsTestPrefix, tc.name, i+1),
+			func(t *testing.T) {
+				flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+
+				os.Args = []string{
+					"studify",
+					fmt.Sprintf("-%s=%s", "kdf", tc.kdf),
+					fmt.Sprintf("-%s=%s", "cipher_key", tc.key),
+				}
+
+				got, err := flags.GetFlags()
+				gotErr := err != nil
+
+				if gotErr != tc.wantErr {
+					t.Fatalf(
+						"[%s][GetFlags] got error=%v, want error=%v (err=%v)",
+						flagsTestPrefix, gotErr, tc.wantErr, err,
+					)
+				}
+
+				if !tc.wantErr && got.KDF != tc.kdf {
+					t.Fatalf("[%s][GetFlags] KDF mismatch: got=%q want=%q", flagsTestPrefix, got.KDF, tc.kdf)
+				}
+			},
+		)
+	}
+}
+
+type getFlagsGradingCase struct {
+	name      string
+	averaging string
+	scale     string
+	wantErr   bool
+}
+
+func TestGetFlags_GradingPolicy(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	tests := []getFlagsGradingCase{
+		{"defaults", flags.AveragingMean, flags.GradeScalePercent, false},
+		{"weighted letter", flags.AveragingWeighted, flags.GradeScaleLetter, false},
+		{"mean five point", flags.AveragingMean, flags.GradeScaleFivePoint, false},
+		{"weighted gpa", flags.AveragingWeighted, flags.GradeScaleGPA, false},
+		{"unknown averaging", "median", flags.GradeScalePercent, true},
+		{"unknown scale", flags.AveragingMean, "ects", true},
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	for i, tc := range tests {
+		t.Run(
+			fmt.Sprintf("[%s]-GetFlags-Grading-%s-№%d", flagsTestPrefix, tc.name, i+1),
+			func(t *testing.T) {
+				flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+
+				os.Args = []string{
+					"studify",
+					fmt.Sprintf("-%s=%s", "cipher_key", cipherKey),
+					fmt.Sprintf("-%s=%s", "averaging", tc.averaging),
+					fmt.Sprintf("-%s=%s", "grade_scale", tc.scale),
+				}
+
+				got, err := flags.GetFlags()
+				gotErr := err != nil
+
+				if gotErr != tc.wantErr {
+					t.Fatalf(
+						"[%s][GetFlags] got error=%v, want error=%v (err=%v)",
+						flagsTestPrefix, gotErr, tc.wantErr, err,
+					)
+				}
+
+				if !tc.wantErr && (got.Averaging != tc.averaging || got.GradeScale != tc.scale) {
+					t.Fatalf(
+						"[%s][GetFlags] grading mismatch: got=%q/%q want=%q/%q",
+						flagsTestPrefix, got.Averaging, got.GradeScale, tc.averaging, tc.scale,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestGetFlags_UniqueNames(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	for i, args := range [][]string{nil, {"-unique_names"}} {
+		t.Run(fmt.Sprintf("[%s]-GetFlags-UniqueNames-№%d", flagsTestPrefix, i+1), func(t *testing.T) {
+			flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+
+			os.Args = append([]string{"studify", "-cipher_key=" + cipherKey}, args...)
+
+			got, err := flags.GetFlags()
+			if err != nil {
+				t.Fatalf("[%s][GetFlags] unexpected error: %v", flagsTestPrefix, err)
+			}
+
+			if want := len(args) > 0; got.UniqueNames != want {
+				t.Fatalf("[%s][GetFlags] UniqueNames=%v, want=%v", flagsTestPrefix, got.UniqueNames, want)
+			}
+		})
+	}
+}
+
+func TestGetFlags_Actor(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "explicit", args: []string{"-actor", " dean "}, want: "dean"},
+		{name: "default", args: nil},
+		{name: "too-long", args: []string{"-actor", strings.Repeat("a", 65)}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("[%s]-GetFlags-Actor-%s-№%d", flagsTestPrefix, tt.name, i+1), func(t *testing.T) {
+			flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+
+			os.Args = append([]string{"studify", "-cipher_key=" + cipherKey}, tt.args...)
+
+			got, err := flags.GetFlags()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("[%s][GetFlags] expected error for actor, got nil", flagsTestPrefix)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("[%s][GetFlags] unexpected error: %v", flagsTestPrefix, err)
+			}
+
+			if got.Actor == "" || (tt.want != "" && got.Actor != tt.want) {
+				t.Fatalf("[%s][GetFlags] Actor=%q, want=%q", flagsTestPrefix, got.Actor, tt.want)
+			}
+		})
+	}
+}
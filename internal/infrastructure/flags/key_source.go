@@ -0,0 +1,135 @@
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	CipherKeyEnv    = "STUDIFY_CIPHER_KEY"
+	NewCipherKeyEnv = "STUDIFY_NEW_CIPHER_KEY"
+
+	maxKeyFileSize = 4 << 10
+)
+
+type keySource struct {
+	flagName  string
+	flagValue string
+	env       string
+	fileFlag  string
+	file      string
+	prompt    string
+	confirm   bool
+}
+
+func cipherKeySource(prompt string) keySource {
+	return keySource{
+		flagName:  cipherKeyFlagName,
+		flagValue: *cipherKeyFlag,
+		env:       CipherKeyEnv,
+		fileFlag:  cipherKeyFileFlagName,
+		file:      *cipherKeyFileFlag,
+		prompt:    prompt,
+	}
+}
+
+func (s keySource) resolve() (string, error) {
+	if s.flagValue != "" {
+		return s.flagValue, nil
+	}
+
+	if v := os.Getenv(s.env); v != "" {
+		return v, nil
+	}
+
+	if s.file != "" {
+		return readKeyFile(s.file)
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf(
+			"%w: use --%s, %s, --%s or run studify in a terminal",
+			ErrCipherKeyNotProvided,
+			s.flagName,
+			s.env,
+			s.fileFlag,
+		)
+	}
+
+	key, err := promptKey(s.prompt)
+	if err != nil {
+		return "", err
+	}
+
+	if !s.confirm {
+		return key, nil
+	}
+
+	again, err := promptKey("Repeat " + strings.ToLower(s.prompt[:1]) + s.prompt[1:])
+	if err != nil {
+		return "", err
+	}
+
+	if key != again {
+		return "", ErrKeyPromptMismatch
+	}
+
+	return key, nil
+}
+
+func readKeyFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat key file: %w", err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%w: %s", ErrKeyFileNotRegular, path)
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0 {
+		return "", fmt.Errorf("%w: %s has mode %04o", ErrInsecureKeyFile, path, info.Mode().Perm())
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open key file: %w", err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxKeyFileSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	if len(data) > maxKeyFileSize {
+		return "", fmt.Errorf("key file %s is larger than %d bytes", path, maxKeyFileSize)
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func promptKey(prompt string) (string, error) {
+	if _, err := fmt.Fprintf(os.Stderr, "%s: ", prompt); err != nil {
+		return "", fmt.Errorf("failed to write key prompt: %w", err)
+	}
+
+	key, err := term.ReadPassword(int(os.Stdin.Fd()))
+
+	_, _ = fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return "", errors.Join(ErrCipherKeyNotProvided, fmt.Errorf("failed to read key from terminal: %w", err))
+	}
+
+	return string(key), nil
+}
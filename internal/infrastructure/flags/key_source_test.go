@@ -0,0 +1,132 @@
+package flags_test
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/term"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/flags"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type keySourceCase struct {
+	name     string
+	flag     string
+	env      string
+	fileKey  string
+	fileMode os.FileMode
+	wantKey  string
+	wantErr  error
+}
+
+func TestGetFlags_CipherKeySources(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skipf("[%s][KeySources] stdin is a terminal, missing key would prompt", flagsTestPrefix)
+	}
+
+	tests := []keySourceCase{
+		{name: "flag wins over env", flag: cipherKey, env: newCipherKey, wantKey: cipherKey},
+		{name: "env wins over file", env: cipherKey, fileKey: newCipherKey, fileMode: 0o600, wantKey: cipherKey},
+		{name: "file with newline", fileKey: cipherKey + "\n", fileMode: 0o600, wantKey: cipherKey},
+		{name: "group readable file", fileKey: cipherKey, fileMode: 0o640, wantErr: flags.ErrInsecureKeyFile},
+		{name: "no sources", wantErr: flags.ErrCipherKeyNotProvided},
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	for i, tc := range tests {
+		t.Run(
+			fmt.Sprintf("[%s]-GetFlags-KeySources-%s-№%d", flagsTestPrefix, tc.name, i+1),
+			func(t *testing.T) {
+				flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+				t.Setenv(flags.CipherKeyEnv, tc.env)
+
+				args := []string{"studify"}
+
+				if tc.flag != "" {
+					args = append(args, fmt.Sprintf("-%s=%s", "cipher_key", tc.flag))
+				}
+
+				if tc.fileKey != "" {
+					path := filepath.Join(t.TempDir(), "key")
+
+					if err := os.WriteFile(path, []byte(tc.fileKey), tc.fileMode); err != nil {
+						t.Fatalf("[%s][KeySources] failed to write key file: %v", flagsTestPrefix, err)
+					}
+
+					if err := os.Chmod(path, tc.fileMode); err != nil {
+						t.Fatalf("[%s][KeySources] failed to chmod key file: %v", flagsTestPrefix, err)
+					}
+
+					args = append(args, fmt.Sprintf("-%s=%s", "cipher_key_file", path))
+				}
+
+				os.Args = args
+
+				got, err := flags.GetFlags()
+				if tc.wantErr != nil {
+					if !errors.Is(err, tc.wantErr) {
+						t.Fatalf("[%s][KeySources] want %v, got %v", flagsTestPrefix, tc.wantErr, err)
+					}
+
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("[%s][KeySources] unexpected error: %v", flagsTestPrefix, err)
+				}
+
+				if got.CipherKey != tc.wantKey {
+					t.Fatalf(
+						"[%s][KeySources] key mismatch: got=%q want=%q",
+						flagsTestPrefix, got.CipherKey, tc.wantKey,
+					)
+				}
+			},
+		)
+	}
+}
+
+func TestGetFlags_RekeyKeysFromEnv(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+	t.Setenv(flags.CipherKeyEnv, cipherKey)
+	t.Setenv(flags.NewCipherKeyEnv, newCipherKey)
+
+	os.Args = []string{"studify", "rekey"}
+
+	got, err := flags.GetFlags()
+	if err != nil {
+		t.Fatalf("[%s][RekeyEnv] unexpected error: %v", flagsTestPrefix, err)
+	}
+
+	if got.CipherKey != cipherKey || got.Rekey.OldKey != cipherKey || got.Rekey.NewKey != newCipherKey {
+		t.Fatalf("[%s][RekeyEnv] unexpected keys: %+v %+v", flagsTestPrefix, got, got.Rekey)
+	}
+}
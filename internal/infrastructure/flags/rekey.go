@@ -0,0 +1,109 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	RekeyCommand = "rekey"
+
+	rekeyOldKeyFlagName = "old-key"
+	rekeyOldKeyFlagDesc = "Current key or passphrase used to decrypt students data, falls back to the cipher_key sources"
+
+	rekeyNewKeyFlagName = "new-key"
+	rekeyNewKeyFlagDesc = "New key or passphrase used to re-encrypt students data (prefer " + NewCipherKeyEnv + ", new-key-file or the interactive prompt)"
+
+	rekeyNewKeyFileFlagName = "new-key-file"
+	rekeyNewKeyFileFlagDesc = "Path to a file with the new key, used when new-key flag and " + NewCipherKeyEnv + " are empty - it must not be readable by group or others"
+
+	rekeyOldKDFFlagName = "old_kdf"
+	rekeyOldKDFFlagDesc = "Key derivation the data is encrypted with now: none or argon2id, defaults to kdf"
+
+	rekeyNewKDFFlagName = "new_kdf"
+	rekeyNewKDFFlagDesc = "Key derivation to re-encrypt the data with: none or argon2id, defaults to kdf"
+
+	rekeyOldKeyPrompt = "Current cipher key"
+	rekeyNewKeyPrompt = "New cipher key"
+)
+
+// RekeyFlags holds the keys of a rekey and their key derivations, so a store
+// can move between a raw key and a passphrase. The same key is only rejected
+// when the key derivation does not change either.
+type RekeyFlags struct {
+	OldKey string `validate:"required"`
+	NewKey string `validate:"required"`
+	OldKDF string `validate:"required,oneof=none argon2id"`
+	NewKDF string `validate:"required,oneof=none argon2id"`
+}
+
+func GetRekeyFlags(args []string, kdf string) (*RekeyFlags, error) {
+	fs := flag.NewFlagSet(RekeyCommand, flag.ContinueOnError)
+
+	oldKey := fs.String(rekeyOldKeyFlagName, "", rekeyOldKeyFlagDesc)
+	newKey := fs.String(rekeyNewKeyFlagName, "", rekeyNewKeyFlagDesc)
+	newKeyFile := fs.String(rekeyNewKeyFileFlagName, "", rekeyNewKeyFileFlagDesc)
+	oldKDF := fs.String(rekeyOldKDFFlagName, kdf, rekeyOldKDFFlagDesc)
+	newKDF := fs.String(rekeyNewKDFFlagName, kdf, rekeyNewKDFFlagDesc)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("error while parsing rekey flags in studify app: %w", err)
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments for rekey command: %v", fs.Args())
+	}
+
+	result := &RekeyFlags{
+		OldKey: *oldKey,
+		OldKDF: *oldKDF,
+		NewKDF: *newKDF,
+	}
+
+	if result.OldKey == "" {
+		key, err := cipherKeySource(rekeyOldKeyPrompt).resolve()
+		if err != nil {
+			return nil, fmt.Errorf("error while reading current key for rekey command: %w", err)
+		}
+
+		result.OldKey = key
+	}
+
+	key, err := keySource{
+		flagName:  rekeyNewKeyFlagName,
+		flagValue: *newKey,
+		env:       NewCipherKeyEnv,
+		fileFlag:  rekeyNewKeyFileFlagName,
+		file:      *newKeyFile,
+		prompt:    rekeyNewKeyPrompt,
+		confirm:   true,
+	}.resolve()
+	if err != nil {
+		return nil, fmt.Errorf("error while reading new key for rekey command: %w", err)
+	}
+
+	result.NewKey = key
+
+	if err := validators.Validate.Struct(result); err != nil {
+		return nil, fmt.Errorf("error while validating rekey flags in studify app: %w", err)
+	}
+
+	if result.OldKey == result.NewKey && result.OldKDF == result.NewKDF {
+		return nil, fmt.Errorf(
+			"error while validating rekey flags in studify app: %w",
+			ErrRekeyNothingChanges,
+		)
+	}
+
+	if err := validateCipherKey(result.OldKDF, result.OldKey); err != nil {
+		return nil, fmt.Errorf("error while validating rekey flags in studify app: %w", err)
+	}
+
+	if err := validateCipherKey(result.NewKDF, result.NewKey); err != nil {
+		return nil, fmt.Errorf("error while validating rekey flags in studify app: %w", err)
+	}
+
+	return result, nil
+}
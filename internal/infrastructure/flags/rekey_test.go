@@ -0,0 +1,212 @@
+package flags_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/flags"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	newCipherKey = "0123456789abcdefghijklmnopqrstuv"
+)
+
+type getRekeyFlagsCase struct {
+	name    string
+	kdf     string
+	args    []string
+	wantErr bool
+}
+
+func TestGetRekeyFlags_TableDriven(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+	t.Setenv(flags.CipherKeyEnv, "")
+	t.Setenv(flags.NewCipherKeyEnv, "")
+
+	tests := []getRekeyFlagsCase{
+		{"ok", flags.KDFNone, []string{"--old-key", cipherKey, "--new-key", newCipherKey}, false},
+		{"missing old key", flags.KDFNone, []string{"--new-key", newCipherKey}, true},
+		{"missing new key", flags.KDFNone, []string{"--old-key", cipherKey}, true},
+		{"short new key", flags.KDFNone, []string{"--old-key", cipherKey, "--new-key", "short"}, true},
+		{"same keys", flags.KDFNone, []string{"--old-key", cipherKey, "--new-key", cipherKey}, true},
+		{
+			"unexpected positional",
+			flags.KDFNone,
+			[]string{"--old-key", cipherKey, "--new-key", newCipherKey, "x"},
+			true,
+		},
+		{
+			"passphrases",
+			flags.KDFArgon2id,
+			[]string{"--old-key", "correct horse", "--new-key", "battery staple"},
+			false,
+		},
+		{
+			"short passphrase",
+			flags.KDFArgon2id,
+			[]string{"--old-key", "correct horse", "--new-key", "short"},
+			true,
+		},
+		{
+			"raw key to passphrase",
+			flags.KDFNone,
+			[]string{"--old-key", cipherKey, "--new-key", "battery staple", "--new_kdf", flags.KDFArgon2id},
+			false,
+		},
+		{
+			"same key new kdf",
+			flags.KDFNone,
+			[]string{"--old-key", cipherKey, "--new-key", cipherKey, "--new_kdf", flags.KDFArgon2id},
+			false,
+		},
+		{
+			"passphrase to raw key",
+			flags.KDFNone,
+			[]string{"--old-key", "correct horse", "--new-key", newCipherKey, "--old_kdf", flags.KDFArgon2id},
+			false,
+		},
+		{
+			"raw key checked as passphrase",
+			flags.KDFNone,
+			[]string{"--old-key", cipherKey, "--new-key", "short", "--new_kdf", flags.KDFArgon2id},
+			true,
+		},
+		{
+			"unknown kdf",
+			flags.KDFNone,
+			[]string{"--old-key", cipherKey, "--new-key", newCipherKey, "--new_kdf", "scrypt"},
+			true,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(
+			fmt.Sprintf("[%s]-GetRekeyFlags-%s-№%d", flagsTestPrefix, tc.name, i+1),
+			func(t *testing.T) {
+				got, err := flags.GetRekeyFlags(tc.args, tc.kdf)
+				gotErr := err != nil
+
+				if gotErr != tc.wantErr {
+					t.Fatalf(
+						"[%s][GetRekeyFlags] got error=%v, want error=%v (err=%v)",
+						flagsTestPrefix, gotErr, tc.wantErr, err,
+					)
+				}
+
+				if !tc.wantErr && (got.OldKey != tc.args[1] || got.NewKey != tc.args[3]) {
+					t.Fatalf("[%s][GetRekeyFlags] keys mismatch: %+v", flagsTestPrefix, got)
+				}
+			},
+		)
+	}
+}
+
+func TestGetFlags_RekeyUsesOldKey(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+
+	os.Args = []string{
+		"studify",
+		"rekey",
+		"--old-key", cipherKey,
+		"--new-key", newCipherKey,
+	}
+
+	got, err := flags.GetFlags()
+	if err != nil {
+		t.Fatalf("[%s][Rekey] unexpected error: %v", flagsTestPrefix, err)
+	}
+
+	if got.CipherKey != cipherKey || got.Rekey == nil || got.Rekey.NewKey != newCipherKey {
+		t.Fatalf("[%s][Rekey] unexpected flags: %+v", flagsTestPrefix, got)
+	}
+
+	flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+
+	os.Args = []string{
+		"studify",
+		"-storage=sqlite",
+		"rekey",
+		"--old-key", cipherKey,
+		"--new-key", newCipherKey,
+	}
+
+	if _, err := flags.GetFlags(); err == nil {
+		t.Fatalf("[%s][Rekey] expected error for sqlite storage", flagsTestPrefix)
+	}
+}
+
+func TestGetFlags_RekeyChangesKDF(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	origArgs := os.Args
+
+	defer func() {
+		os.Args = origArgs
+	}()
+
+	flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+	t.Setenv(flags.CipherKeyEnv, "")
+	t.Setenv(flags.NewCipherKeyEnv, "")
+
+	os.Args = []string{
+		"studify",
+		"-kdf=argon2id",
+		"rekey",
+		"--old-key", "correct horse",
+		"--new-key", newCipherKey,
+		"--new_kdf", flags.KDFNone,
+	}
+
+	got, err := flags.GetFlags()
+	if err != nil {
+		t.Fatalf("[%s][RekeyKDF] unexpected error: %v", flagsTestPrefix, err)
+	}
+
+	if got.KDF != flags.KDFArgon2id || got.Rekey.OldKDF != flags.KDFArgon2id || got.Rekey.NewKDF != flags.KDFNone {
+		t.Fatalf("[%s][RekeyKDF] old kdf must default to kdf and new kdf must be kept: %+v %+v", flagsTestPrefix, got, got.Rekey)
+	}
+
+	flags.ResetForTests(flag.NewFlagSet("studify", flag.ContinueOnError))
+
+	os.Args = []string{
+		"studify",
+		"rekey",
+		"--old-key", "correct horse",
+		"--new-key", newCipherKey,
+		"--old_kdf", flags.KDFArgon2id,
+	}
+
+	got, err = flags.GetFlags()
+	if err != nil {
+		t.Fatalf("[%s][RekeyKDF] unexpected error: %v", flagsTestPrefix, err)
+	}
+
+	if got.KDF != flags.KDFArgon2id || got.CipherKey != "correct horse" || got.Rekey.NewKDF != flags.KDFNone {
+		t.Fatalf("[%s][RekeyKDF] store must be opened with the old kdf: %+v %+v", flagsTestPrefix, got, got.Rekey)
+	}
+}
@@ -0,0 +1,44 @@
+package flags
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	ServeCommand = "serve"
+
+	serveAddrFlagName         = "addr"
+	serveAddrFlagDefaultValue = ":8080"
+	serveAddrFlagDesc         = "Address (host:port) for the HTTP REST API server to listen on"
+)
+
+type ServeFlags struct {
+	Addr string `validate:"required,hostname_port"`
+}
+
+func GetServeFlags(args []string) (*ServeFlags, error) {
+	fs := flag.NewFlagSet(ServeCommand, flag.ContinueOnError)
+
+	addr := fs.String(serveAddrFlagName, serveAddrFlagDefaultValue, serveAddrFlagDesc)
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("error while parsing serve flags in studify app: %w", err)
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments for serve command: %v", fs.Args())
+	}
+
+	result := &ServeFlags{
+		Addr: *addr,
+	}
+
+	if err := validators.Validate.Struct(result); err != nil {
+		return nil, fmt.Errorf("error while validating serve flags in studify app: %w", err)
+	}
+
+	return result, nil
+}
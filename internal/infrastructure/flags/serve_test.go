@@ -0,0 +1,57 @@
+package flags_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/flags"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type getServeFlagsCase struct {
+	name     string
+	args     []string
+	wantAddr string
+	wantErr  bool
+}
+
+func TestGetServeFlags_TableDriven(t *testing.T) {
+	if validators.Validate == nil {
+		if err := validators.InitValidators(); err != nil {
+			t.Fatalf("[%s][InitValidators] failed to init validators: %v", flagsTestPrefix, err)
+		}
+	}
+
+	tests := []getServeFlagsCase{
+		{"default address", nil, ":8080", false},
+		{"custom port", []string{"--addr", ":9090"}, ":9090", false},
+		{"host and port", []string{"-addr=localhost:8081"}, "localhost:8081", false},
+		{"missing port", []string{"--addr", "localhost"}, "", true},
+		{"port out of range", []string{"--addr", ":70000"}, "", true},
+		{"unexpected positional", []string{"extra"}, "", true},
+	}
+
+	for i, tc := range tests {
+		t.Run(
+			fmt.Sprintf("[%s]-GetServeFlags-%s-№%d", flagsTestPrefix, tc.name, i+1),
+			func(t *testing.T) {
+				got, err := flags.GetServeFlags(tc.args)
+				gotErr := err != nil
+
+				if gotErr != tc.wantErr {
+					t.Fatalf(
+						"[%s][GetServeFlags] got error=%v, want error=%v (err=%v)",
+						flagsTestPrefix, gotErr, tc.wantErr, err,
+					)
+				}
+
+				if !tc.wantErr && got.Addr != tc.wantAddr {
+					t.Fatalf(
+						"[%s][GetServeFlags] addr mismatch: got=%q want=%q",
+						flagsTestPrefix, got.Addr, tc.wantAddr,
+					)
+				}
+			},
+		)
+	}
+}
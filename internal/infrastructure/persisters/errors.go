@@ -5,4 +5,9 @@ import "errors"
 var (
 	ErrMismatchPayloadAndWriteLen = errors.New("mismatch between payload length and write length")
 	ErrInvalidCipher              = errors.New("invalid cipher provided")
+	ErrCorruptedJournal           = errors.New("journal record is corrupted")
+	ErrUnknownJournalOp           = errors.New("unknown journal operation")
+	ErrRecordTooLarge             = errors.New("record is too large")
+	ErrRekeyVerificationFailed    = errors.New("re-encrypted data does not match source data")
+	ErrAuditHeadMismatch          = errors.New("audit log does not match its head")
 )
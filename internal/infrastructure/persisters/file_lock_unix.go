@@ -0,0 +1,35 @@
+//go:build unix
+
+package persisters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock shared with other processes on the lock
+// file at path, it blocks until the lock is free. The lock file itself is
+// never removed, removing it would let two processes lock different files.
+func lockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create directory with lock file: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		closeRecordFile(file)
+
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	return func() {
+		// closing the file releases the lock
+		closeRecordFile(file)
+	}, nil
+}
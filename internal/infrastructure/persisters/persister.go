@@ -1,6 +1,8 @@
 package persisters
 
 import (
+	"github.com/google/uuid"
+
 	"github.com/k6zma/avito-lab1/internal/domain/models"
 )
 
@@ -8,3 +10,42 @@ type StudentPersister interface {
 	Save(students []*models.Student) error
 	Load() ([]*models.Student, error)
 }
+
+type CoursePersister interface {
+	Save(courses []*models.Course) error
+	Load() ([]*models.Course, error)
+}
+
+type JournalOp string
+
+const (
+	JournalOpPut    JournalOp = "put"
+	JournalOpDelete JournalOp = "delete"
+)
+
+type JournalEntry struct {
+	Op      JournalOp       `json:"op"`
+	ID      uuid.UUID       `json:"id"`
+	Student *models.Student `json:"student,omitempty"`
+}
+
+type StudentJournal interface {
+	StudentPersister
+	Append(entries ...JournalEntry) error
+	ShouldCompact() bool
+}
+
+func PutEntry(student *models.Student) JournalEntry {
+	return JournalEntry{
+		Op:      JournalOpPut,
+		ID:      student.ID,
+		Student: student.Clone(),
+	}
+}
+
+func DeleteEntry(id uuid.UUID) JournalEntry {
+	return JournalEntry{
+		Op: JournalOpDelete,
+		ID: id,
+	}
+}
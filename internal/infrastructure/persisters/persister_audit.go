@@ -0,0 +1,273 @@
+package persisters
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+const (
+	auditFileSuffix = ".audit"
+	auditHeadSuffix = ".head"
+	auditLockSuffix = ".lock"
+)
+
+// AuditHead is the sequence number and hash of the last audit entry and the
+// size of the log it ends, kept in a file of its own so entries cut off the
+// end of the log are noticed and appends do not have to read the log.
+type AuditHead struct {
+	Seq  uint64 `json:"seq"`
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+type AuditPersister interface {
+	// AppendChained appends the entries chain builds on top of the last
+	// stored entry, other processes can not append in between. The tail is
+	// zero for an empty log.
+	AppendChained(chain func(tail AuditHead) ([]models.AuditEntry, error)) error
+	Save(entries []models.AuditEntry) error
+	Load() ([]models.AuditEntry, error)
+	LoadWithHead() ([]models.AuditEntry, *AuditHead, error)
+}
+
+// RecordAuditPersister keeps audit entries as encrypted records appended to
+// one file and the head of the log in another one. Every call holds a lock
+// file shared with other processes. Save replaces the whole log and is only
+// meant for rekeying.
+type RecordAuditPersister struct {
+	records  recordPersister[models.AuditEntry]
+	headPath string
+	lockPath string
+	cipher   ciphers.Cipher
+}
+
+func NewRecordAuditPersister(path string, c ciphers.Cipher) *RecordAuditPersister {
+	return newRecordAuditPersister(path, path+auditLockSuffix, c)
+}
+
+func newRecordAuditPersister(path, lockPath string, c ciphers.Cipher) *RecordAuditPersister {
+	return &RecordAuditPersister{
+		records: recordPersister[models.AuditEntry]{
+			path:   path,
+			kind:   "audit",
+			cipher: c,
+		},
+		headPath: auditHeadPath(path),
+		lockPath: lockPath,
+		cipher:   c,
+	}
+}
+
+func AuditPath(studentsPath string) string {
+	return studentsPath + auditFileSuffix
+}
+
+func auditHeadPath(auditPath string) string {
+	return auditPath + auditHeadSuffix
+}
+
+func (p *RecordAuditPersister) AppendChained(
+	chain func(tail AuditHead) ([]models.AuditEntry, error),
+) error {
+	unlock, err := lockFile(p.lockPath)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	tail, err := p.tailLocked()
+	if err != nil {
+		return err
+	}
+
+	entries, err := chain(tail)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := p.records.Append(entries...); err != nil {
+		return err
+	}
+
+	return p.saveHead(entries)
+}
+
+// tailLocked reads the end of the log from the head. Only the records a
+// crash left after the head are read, a log without a head is read whole
+// once and gets one with the next append.
+func (p *RecordAuditPersister) tailLocked() (AuditHead, error) {
+	head, err := p.readHead()
+	if err != nil {
+		return AuditHead{}, err
+	}
+
+	if head == nil {
+		entries, err := p.records.Load()
+		if err != nil {
+			return AuditHead{}, err
+		}
+
+		for _, e := range entries {
+			if e.Anchored {
+				return AuditHead{}, fmt.Errorf("%w: the head is missing", ErrAuditHeadMismatch)
+			}
+		}
+
+		return lastAuditEntry(entries, AuditHead{}), nil
+	}
+
+	size, err := p.size()
+	if err != nil {
+		return AuditHead{}, err
+	}
+
+	if size < head.Size {
+		return AuditHead{}, fmt.Errorf(
+			"%w: the log is %d bytes, the head ends at %d",
+			ErrAuditHeadMismatch,
+			size,
+			head.Size,
+		)
+	}
+
+	if size == head.Size {
+		return *head, nil
+	}
+
+	payloads, err := readRecordsFrom(p.records.path, p.cipher, head.Size)
+	if err != nil {
+		return AuditHead{}, fmt.Errorf("failed to read audit log tail: %w", err)
+	}
+
+	entries := make([]models.AuditEntry, 0, len(payloads))
+
+	for i, payload := range payloads {
+		var e models.AuditEntry
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return AuditHead{}, fmt.Errorf("%w: audit tail record %d: %w", ErrCorruptedJournal, i, err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	return lastAuditEntry(entries, *head), nil
+}
+
+func (p *RecordAuditPersister) Save(entries []models.AuditEntry) error {
+	unlock, err := lockFile(p.lockPath)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	if err := p.records.Save(entries); err != nil {
+		return err
+	}
+
+	return p.saveHead(entries)
+}
+
+func (p *RecordAuditPersister) Load() ([]models.AuditEntry, error) {
+	entries, _, err := p.LoadWithHead()
+
+	return entries, err
+}
+
+// LoadWithHead returns a nil head for logs written before the head was kept.
+func (p *RecordAuditPersister) LoadWithHead() ([]models.AuditEntry, *AuditHead, error) {
+	unlock, err := lockFile(p.lockPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer unlock()
+
+	entries, err := p.records.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	head, err := p.readHead()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return entries, head, nil
+}
+
+func (p *RecordAuditPersister) readHead() (*AuditHead, error) {
+	if _, err := os.Stat(p.headPath); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	var head AuditHead
+	if err := readSnapshotFile(p.headPath, p.cipher, &head); err != nil {
+		return nil, fmt.Errorf("failed to read audit head: %w", err)
+	}
+
+	return &head, nil
+}
+
+func (p *RecordAuditPersister) size() (int64, error) {
+	info, err := os.Stat(p.records.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat audit log: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// saveHead runs after the entries are appended, a crash in between leaves the
+// head behind the log, which still verifies and is caught up by the next
+// append.
+func (p *RecordAuditPersister) saveHead(entries []models.AuditEntry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(p.headPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove audit head: %w", err)
+		}
+
+		return nil
+	}
+
+	size, err := p.size()
+	if err != nil {
+		return err
+	}
+
+	if err := writeSnapshotFile(p.headPath, p.cipher, lastAuditEntry(entries, AuditHead{Size: size})); err != nil {
+		return fmt.Errorf("failed to write audit head: %w", err)
+	}
+
+	return nil
+}
+
+// lastAuditEntry returns head with the sequence number and hash of the last
+// entry, or head itself when there are none.
+func lastAuditEntry(entries []models.AuditEntry, head AuditHead) AuditHead {
+	if len(entries) == 0 {
+		return head
+	}
+
+	last := entries[len(entries)-1]
+
+	head.Seq = last.Seq
+	head.Hash = last.Hash
+
+	return head
+}
@@ -0,0 +1,41 @@
+package persisters
+
+import (
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+const coursesFileSuffix = ".courses"
+
+type jsonCourseSnapshot struct {
+	Courses []*models.Course `json:"courses"`
+}
+
+type JSONCoursePersister struct {
+	path   string
+	cipher ciphers.Cipher
+}
+
+func NewJSONCoursePersister(path string, c ciphers.Cipher) *JSONCoursePersister {
+	return &JSONCoursePersister{
+		path:   path,
+		cipher: c,
+	}
+}
+
+func CoursesPath(studentsPath string) string {
+	return studentsPath + coursesFileSuffix
+}
+
+func (p *JSONCoursePersister) Save(courses []*models.Course) error {
+	return writeSnapshotFile(p.path, p.cipher, jsonCourseSnapshot{Courses: courses})
+}
+
+func (p *JSONCoursePersister) Load() ([]*models.Course, error) {
+	var snap jsonCourseSnapshot
+	if err := readSnapshotFile(p.path, p.cipher, &snap); err != nil {
+		return nil, err
+	}
+
+	return snap.Courses, nil
+}
@@ -0,0 +1,282 @@
+package persisters
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+const historyFileSuffix = ".history"
+
+type HistoryPersister interface {
+	Append(revisions ...models.StudentRevision) error
+	Save(revisions []models.StudentRevision) error
+	Load() ([]models.StudentRevision, error)
+	Students() (map[uuid.UUID]struct{}, error)
+	Revisions(id uuid.UUID) ([]models.StudentRevision, error)
+	Forget(ids ...uuid.UUID) error
+	Compact() error
+}
+
+// RecordHistoryPersister keeps student revisions as encrypted records
+// appended to one file. It indexes the records by student in memory, so
+// Revisions reads only the records of one student. Forget drops students
+// from the index and Compact then removes their records from the file. Save
+// replaces the whole file, it is used by rekeying.
+type RecordHistoryPersister struct {
+	recordPersister[models.StudentRevision]
+
+	// index holds the offsets of the records of every student, it covers
+	// the first indexed bytes of the file.
+	index     map[uuid.UUID][]int64
+	indexed   int64
+	built     bool
+	forgotten bool
+}
+
+func NewRecordHistoryPersister(path string, c ciphers.Cipher) *RecordHistoryPersister {
+	return &RecordHistoryPersister{
+		recordPersister: recordPersister[models.StudentRevision]{
+			path:   path,
+			kind:   "history",
+			cipher: c,
+		},
+	}
+}
+
+func HistoryPath(studentsPath string) string {
+	return studentsPath + historyFileSuffix
+}
+
+func (p *RecordHistoryPersister) Save(revisions []models.StudentRevision) error {
+	if err := p.recordPersister.Save(revisions); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.built = false
+
+	return nil
+}
+
+// Students returns the ids of the students that have revisions.
+func (p *RecordHistoryPersister) Students() (map[uuid.UUID]struct{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.syncIndexLocked(); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[uuid.UUID]struct{}, len(p.index))
+	for id := range p.index {
+		ids[id] = struct{}{}
+	}
+
+	return ids, nil
+}
+
+// Revisions returns the revisions of one student from the oldest one.
+func (p *RecordHistoryPersister) Revisions(id uuid.UUID) ([]models.StudentRevision, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.syncIndexLocked(); err != nil {
+		return nil, err
+	}
+
+	return p.readLocked(p.index[id])
+}
+
+// Forget hides the revisions of the given students until Compact removes
+// them from the file.
+func (p *RecordHistoryPersister) Forget(ids ...uuid.UUID) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.syncIndexLocked(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, ok := p.index[id]; ok {
+			delete(p.index, id)
+
+			p.forgotten = true
+		}
+	}
+
+	return nil
+}
+
+// Compact rewrites the file without the revisions of forgotten students. It
+// does nothing when no student was forgotten.
+func (p *RecordHistoryPersister) Compact() error {
+	if p.cipher == nil {
+		return ErrInvalidCipher
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.syncIndexLocked(); err != nil {
+		return err
+	}
+
+	if !p.forgotten {
+		return nil
+	}
+
+	var offsets []int64
+	for _, ids := range p.index {
+		offsets = append(offsets, ids...)
+	}
+
+	slices.Sort(offsets)
+
+	payloads, err := p.readPayloadsLocked(offsets)
+	if err != nil {
+		return err
+	}
+
+	tmp := p.path + ".tmp"
+
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale history temp file: %w", err)
+	}
+
+	if len(payloads) > 0 {
+		if err := appendRecords(tmp, p.cipher, payloads); err != nil {
+			return fmt.Errorf("failed to write compacted history: %w", err)
+		}
+
+		if err := os.Rename(tmp, p.path); err != nil {
+			return fmt.Errorf("failed to replace history log: %w", err)
+		}
+	} else if err := os.Remove(p.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove history log: %w", err)
+	}
+
+	p.built = false
+	p.forgotten = false
+
+	return p.syncIndexLocked()
+}
+
+// syncIndexLocked indexes the records appended since the last call, or the
+// whole file when the index is not built yet or the file got shorter.
+func (p *RecordHistoryPersister) syncIndexLocked() error {
+	if p.cipher == nil {
+		return ErrInvalidCipher
+	}
+
+	size, err := p.sizeLocked()
+	if err != nil {
+		return err
+	}
+
+	if !p.built || size < p.indexed {
+		p.index = make(map[uuid.UUID][]int64)
+		p.indexed = 0
+		p.built = true
+	}
+
+	if size == p.indexed {
+		return nil
+	}
+
+	records, err := scanRecordsFrom(p.path, p.cipher, p.indexed)
+	if err != nil {
+		p.built = false
+
+		return fmt.Errorf("failed to read history log: %w", err)
+	}
+
+	for _, rec := range records {
+		var rev models.StudentRevision
+		if err := json.Unmarshal(rec.payload, &rev); err != nil {
+			p.built = false
+
+			return fmt.Errorf("%w: history record at offset %d: %w", ErrCorruptedJournal, rec.offset, err)
+		}
+
+		p.index[rev.StudentID] = append(p.index[rev.StudentID], rec.offset)
+	}
+
+	// A torn tail is cut off while scanning, so the size is taken again.
+	if p.indexed, err = p.sizeLocked(); err != nil {
+		p.built = false
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *RecordHistoryPersister) readLocked(offsets []int64) ([]models.StudentRevision, error) {
+	payloads, err := p.readPayloadsLocked(offsets)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]models.StudentRevision, 0, len(payloads))
+
+	for i, payload := range payloads {
+		var rev models.StudentRevision
+		if err := json.Unmarshal(payload, &rev); err != nil {
+			return nil, fmt.Errorf("%w: history record at offset %d: %w", ErrCorruptedJournal, offsets[i], err)
+		}
+
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, nil
+}
+
+func (p *RecordHistoryPersister) readPayloadsLocked(offsets []int64) ([][]byte, error) {
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+
+	file, err := os.Open(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history log: %w", err)
+	}
+
+	defer closeRecordFile(file)
+
+	payloads := make([][]byte, 0, len(offsets))
+
+	for _, offset := range offsets {
+		payload, err := readRecordAt(file, p.cipher, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history log: %w", err)
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	return payloads, nil
+}
+
+func (p *RecordHistoryPersister) sizeLocked() (int64, error) {
+	info, err := os.Stat(p.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat history log: %w", err)
+	}
+
+	return info.Size(), nil
+}
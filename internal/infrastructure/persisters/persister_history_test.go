@@ -0,0 +1,89 @@
+package persisters_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	historyTestPrefix = "RecordHistoryPersister"
+)
+
+func TestRecordHistoryPersister_IndexesStudents(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", historyTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init cipher: %v", historyTestPrefix, err)
+	}
+
+	path := persisters.HistoryPath(filepath.Join(t.TempDir(), "students.json"))
+	p := persisters.NewRecordHistoryPersister(path, cipher)
+
+	kept := newTestStudent(t, "Mikhail", "Gunin", 19)
+	purged := newTestStudent(t, "Alexander", "Gunin", 20)
+	now := time.Now()
+
+	if err := p.Append(
+		models.NewStudentRevision(kept, now),
+		models.NewStudentRevision(purged, now),
+	); err != nil {
+		t.Fatalf("[%s][Append] unexpected error: %v", historyTestPrefix, err)
+	}
+
+	// Another persister on the same file stands for a second process.
+	kept.Version = 2
+	kept.Age = 21
+
+	if err := persisters.NewRecordHistoryPersister(path, cipher).Append(
+		models.NewStudentRevision(kept, now),
+	); err != nil {
+		t.Fatalf("[%s][Append] unexpected error from second persister: %v", historyTestPrefix, err)
+	}
+
+	revisions, err := p.Revisions(kept.ID)
+	if err != nil || len(revisions) != 2 || revisions[1].Student.Age != 21 {
+		t.Fatalf("[%s][Revisions] want both revisions of kept student, got=%+v err=%v", historyTestPrefix, revisions, err)
+	}
+
+	if err := p.Forget(purged.ID); err != nil {
+		t.Fatalf("[%s][Forget] unexpected error: %v", historyTestPrefix, err)
+	}
+
+	if revisions, err := p.Revisions(purged.ID); err != nil || len(revisions) != 0 {
+		t.Fatalf("[%s][Forget] forgotten student must have no revisions, got=%+v err=%v", historyTestPrefix, revisions, err)
+	}
+
+	if err := p.Compact(); err != nil {
+		t.Fatalf("[%s][Compact] unexpected error: %v", historyTestPrefix, err)
+	}
+
+	stored, err := persisters.NewRecordHistoryPersister(path, cipher).Load()
+	if err != nil || len(stored) != 2 {
+		t.Fatalf("[%s][Compact] want 2 revisions in the file, got=%d err=%v", historyTestPrefix, len(stored), err)
+	}
+
+	for _, rev := range stored {
+		if rev.StudentID != kept.ID {
+			t.Fatalf("[%s][Compact] forgotten revisions must leave the file: %+v", historyTestPrefix, rev)
+		}
+	}
+
+	ids, err := p.Students()
+	if _, ok := ids[kept.ID]; err != nil || len(ids) != 1 || !ok {
+		t.Fatalf("[%s][Students] want only kept student, got=%v err=%v", historyTestPrefix, ids, err)
+	}
+
+	revisions, err = p.Revisions(kept.ID)
+	if err != nil || len(revisions) != 2 || revisions[0].Student.Age != 19 {
+		t.Fatalf("[%s][Compact] index must follow the compacted file, got=%+v err=%v", historyTestPrefix, revisions, err)
+	}
+}
@@ -31,16 +31,29 @@ func NewJSONStudentPersister(path string, c ciphers.Cipher) *JSONStudentPersiste
 }
 
 func (p *JSONStudentPersister) Save(students []*models.Student) error {
-	if p.cipher == nil {
+	return writeSnapshotFile(p.path, p.cipher, jsonSnapshot{Students: students})
+}
+
+func (p *JSONStudentPersister) Load() ([]*models.Student, error) {
+	var snap jsonSnapshot
+	if err := readSnapshotFile(p.path, p.cipher, &snap); err != nil {
+		return nil, err
+	}
+
+	return snap.Students, nil
+}
+
+func writeSnapshotFile(path string, c ciphers.Cipher, snapshot any) error {
+	if c == nil {
 		return ErrInvalidCipher
 	}
 
-	dir := filepath.Dir(p.path)
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0o750); err != nil {
 		return fmt.Errorf("failed to create directory with json file: %w", err)
 	}
 
-	tmp, err := os.CreateTemp(dir, ".students-*.tmp")
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*.tmp")
 	if err != nil {
 		return fmt.Errorf("failed to create temp file to save snapshot data: %w", err)
 	}
@@ -55,7 +68,7 @@ func (p *JSONStudentPersister) Save(students []*models.Student) error {
 		}
 	}(tmp.Name())
 
-	payload, err := json.Marshal(jsonSnapshot{Students: students})
+	payload, err := json.Marshal(snapshot)
 	if err != nil {
 		if closeErr := tmp.Close(); closeErr != nil {
 			slog.Error(
@@ -68,7 +81,7 @@ func (p *JSONStudentPersister) Save(students []*models.Student) error {
 		return fmt.Errorf("failed to marshal json with snapshot data: %w", err)
 	}
 
-	ciphertext, err := p.cipher.Encrypt(payload)
+	ciphertext, err := c.Encrypt(payload)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt snapshot: %w", err)
 	}
@@ -107,32 +120,32 @@ func (p *JSONStudentPersister) Save(students []*models.Student) error {
 		return fmt.Errorf("failed to close temp file with snapshot data: %w", err)
 	}
 
-	if err := os.Rename(tmp.Name(), p.path); err != nil {
+	if err := os.Rename(tmp.Name(), path); err != nil {
 		return fmt.Errorf("failed to move snapshot file into final destination: %w", err)
 	}
 
 	return nil
 }
 
-func (p *JSONStudentPersister) Load() ([]*models.Student, error) {
-	if p.cipher == nil {
-		return nil, ErrInvalidCipher
+func readSnapshotFile(path string, c ciphers.Cipher, snapshot any) error {
+	if c == nil {
+		return ErrInvalidCipher
 	}
 
-	file, err := os.Open(p.path)
+	file, err := os.Open(path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil, nil
+			return nil
 		}
 
-		return nil, fmt.Errorf("failed to open json snapshot file: %w", err)
+		return fmt.Errorf("failed to open json snapshot file: %w", err)
 	}
 
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
 			slog.Error(
 				"failed to close json snapshot file after load",
-				slog.String("path", p.path),
+				slog.String("path", path),
 				slog.Any("err", closeErr),
 			)
 		}
@@ -140,22 +153,28 @@ func (p *JSONStudentPersister) Load() ([]*models.Student, error) {
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read json snapshot file: %w", err)
+		return fmt.Errorf("failed to read json snapshot file: %w", err)
 	}
 
 	if len(data) == 0 {
-		return nil, nil
+		return nil
 	}
 
-	plaintext, err := p.cipher.Decrypt(data)
+	plaintext, err := c.Decrypt(data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt snapshot: %w", err)
+		return fmt.Errorf("failed to decrypt snapshot %s: %w", path, err)
 	}
 
-	var snap jsonSnapshot
-	if err := json.Unmarshal(plaintext, &snap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal json snapshot: %w", err)
+	if !ciphers.IsEnveloped(data) {
+		slog.Info(
+			"json snapshot uses legacy unversioned format, it will be upgraded on next save",
+			slog.String("path", path),
+		)
 	}
 
-	return snap.Students, nil
+	if err := json.Unmarshal(plaintext, snapshot); err != nil {
+		return fmt.Errorf("failed to unmarshal json snapshot: %w", err)
+	}
+
+	return nil
 }
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -21,6 +22,27 @@ const (
 	testKey = "12345678901234567890123456789012"
 )
 
+func unassignedGrades(values ...int) []models.Grade {
+	return models.NewGrades(models.UnassignedCourseID, time.Time{}, values...)
+}
+
+// newTestStudent builds a valid student with grades in the unassigned course.
+func newTestStudent(t *testing.T, name, surname string, age int, grades ...int) *models.Student {
+	t.Helper()
+
+	st, err := models.NewStudentBuilder().
+		SetName(name).
+		SetSurname(surname).
+		SetAge(age).
+		SetGrades(unassignedGrades(grades...)).
+		Build()
+	if err != nil {
+		t.Fatalf("[%s] failed to build student %s %s: %v", persisterTestPrefix, name, surname, err)
+	}
+
+	return st
+}
+
 func TestPersister_Load_NoFile(t *testing.T) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][Load_NoFile] failed to init validators: %v", persisterTestPrefix, err)
@@ -73,7 +95,7 @@ func TestPersister_SaveAndLoad_RoundTrip(t *testing.T) {
 		SetName("Mikhail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{90, 95}).
+		SetGrades(unassignedGrades(90, 95)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -87,7 +109,7 @@ func TestPersister_SaveAndLoad_RoundTrip(t *testing.T) {
 		SetName("Alexander").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{80}).
+		SetGrades(unassignedGrades(80)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -365,3 +387,80 @@ func TestPersister_Load_NilCipher(t *testing.T) {
 		t.Fatalf("want ErrInvalidCipher, got %v", err)
 	}
 }
+
+func TestPersister_Load_WrongKey(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Load_WrongKey] failed to init validators: %v", persisterTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init cipher: %v", persisterTestPrefix, err)
+	}
+
+	otherCipher, err := ciphers.NewAESGCM("abcdefghijklmnopqrstuvwxyz123456")
+	if err != nil {
+		t.Fatalf("[%s] failed to init cipher: %v", persisterTestPrefix, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	if err := persisters.NewJSONStudentPersister(path, cipher).Save(nil); err != nil {
+		t.Fatalf("[%s][Load_WrongKey] failed to save snapshot: %v", persisterTestPrefix, err)
+	}
+
+	_, err = persisters.NewJSONStudentPersister(path, otherCipher).Load()
+	if !errors.Is(err, ciphers.ErrWrongKey) {
+		t.Fatalf("[%s][Load_WrongKey] want ErrWrongKey, got %v", persisterTestPrefix, err)
+	}
+}
+
+func TestPersister_Load_LegacyFlatGrades(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][LegacyGrades] failed to init validators: %v", persisterTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init cipher: %v", persisterTestPrefix, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "students.json")
+	id := uuid.New()
+
+	legacy := `{"students":[{"id":"` + id.String() +
+		`","name":"Mikhail","surname":"Gunin","age":19,"grades":[90,75]}]}`
+
+	ciphertext, err := cipher.Encrypt([]byte(legacy))
+	if err != nil {
+		t.Fatalf("[%s][LegacyGrades] failed to encrypt legacy snapshot: %v", persisterTestPrefix, err)
+	}
+
+	if err := os.WriteFile(path, ciphertext, 0o600); err != nil {
+		t.Fatalf("[%s][LegacyGrades] failed to write legacy snapshot: %v", persisterTestPrefix, err)
+	}
+
+	loaded, err := persisters.NewJSONStudentPersister(path, cipher).Load()
+	if err != nil {
+		t.Fatalf("[%s][LegacyGrades] failed to load legacy snapshot: %v", persisterTestPrefix, err)
+	}
+
+	if len(loaded) != 1 || len(loaded[0].Grades) != 2 {
+		t.Fatalf("[%s][LegacyGrades] unexpected students: %+v", persisterTestPrefix, loaded)
+	}
+
+	for _, g := range loaded[0].Grades {
+		if g.CourseID != models.UnassignedCourseID {
+			t.Fatalf(
+				"[%s][LegacyGrades] grade %d must be in unassigned course, got course=%s",
+				persisterTestPrefix,
+				g.Value,
+				g.CourseID,
+			)
+		}
+	}
+
+	if err := validators.Validate.Struct(loaded[0]); err != nil {
+		t.Fatalf("[%s][LegacyGrades] migrated student is invalid: %v", persisterTestPrefix, err)
+	}
+}
@@ -0,0 +1,172 @@
+package persisters
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+const (
+	DefaultWALCompactEvery = 1000
+
+	walFileSuffix = ".wal"
+)
+
+type WALStudentPersister struct {
+	snapshot     *JSONStudentPersister
+	logPath      string
+	cipher       ciphers.Cipher
+	compactEvery int
+	records      int
+	mu           sync.Mutex
+}
+
+func NewWALStudentPersister(
+	path string,
+	c ciphers.Cipher,
+	compactEvery int,
+) *WALStudentPersister {
+	if compactEvery <= 0 {
+		compactEvery = DefaultWALCompactEvery
+	}
+
+	return &WALStudentPersister{
+		snapshot:     NewJSONStudentPersister(path, c),
+		logPath:      path + walFileSuffix,
+		cipher:       c,
+		compactEvery: compactEvery,
+	}
+}
+
+func (p *WALStudentPersister) Append(entries ...JournalEntry) error {
+	if p.cipher == nil {
+		return ErrInvalidCipher
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	payloads := make([][]byte, 0, len(entries))
+
+	for _, entry := range entries {
+		payload, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal journal entry: %w", err)
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := appendRecords(p.logPath, p.cipher, payloads); err != nil {
+		return fmt.Errorf("failed to append journal entries: %w", err)
+	}
+
+	p.records += len(entries)
+
+	return nil
+}
+
+func (p *WALStudentPersister) ShouldCompact() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.records >= p.compactEvery
+}
+
+func (p *WALStudentPersister) Save(students []*models.Student) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.snapshot.Save(students); err != nil {
+		return fmt.Errorf("failed to write compacted snapshot: %w", err)
+	}
+
+	if err := os.Remove(p.logPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove compacted journal: %w", err)
+	}
+
+	p.records = 0
+
+	return nil
+}
+
+func (p *WALStudentPersister) Load() ([]*models.Student, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	base, err := p.snapshot.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load base snapshot: %w", err)
+	}
+
+	payloads, err := readRecords(p.logPath, p.cipher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	if len(payloads) == 0 {
+		p.records = 0
+
+		return base, nil
+	}
+
+	order := make([]uuid.UUID, 0, len(base))
+	state := make(map[uuid.UUID]*models.Student, len(base))
+
+	for _, st := range base {
+		if st == nil {
+			continue
+		}
+
+		order = append(order, st.ID)
+		state[st.ID] = st
+	}
+
+	for i, payload := range payloads {
+		var entry JournalEntry
+		if err := json.Unmarshal(payload, &entry); err != nil {
+			return nil, fmt.Errorf("%w: record %d: %w", ErrCorruptedJournal, i, err)
+		}
+
+		switch entry.Op {
+		case JournalOpPut:
+			if entry.Student == nil {
+				return nil, fmt.Errorf("%w: record %d has no student", ErrCorruptedJournal, i)
+			}
+
+			if _, ok := state[entry.ID]; !ok {
+				order = append(order, entry.ID)
+			}
+
+			state[entry.ID] = entry.Student
+		case JournalOpDelete:
+			delete(state, entry.ID)
+		default:
+			return nil, fmt.Errorf("%w: %q in record %d", ErrUnknownJournalOp, entry.Op, i)
+		}
+	}
+
+	students := make([]*models.Student, 0, len(state))
+
+	for _, id := range order {
+		if st, ok := state[id]; ok {
+			students = append(students, st)
+			delete(state, id)
+		}
+	}
+
+	p.records = len(payloads)
+
+	return students, nil
+}
@@ -0,0 +1,265 @@
+package persisters_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	walTestPrefix = "WALStudentPersister"
+)
+
+func newWALTestPersister(t *testing.T, compactEvery int) (*persisters.WALStudentPersister, string) {
+	t.Helper()
+
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", walTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init cipher: %v", walTestPrefix, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	return persisters.NewWALStudentPersister(path, cipher, compactEvery), path
+}
+
+func newWALTestPersisterAt(
+	t *testing.T,
+	path string,
+	compactEvery int,
+) *persisters.WALStudentPersister {
+	t.Helper()
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init cipher: %v", walTestPrefix, err)
+	}
+
+	return persisters.NewWALStudentPersister(path, cipher, compactEvery)
+}
+
+func studentsByID(list []*models.Student) map[uuid.UUID]*models.Student {
+	out := make(map[uuid.UUID]*models.Student, len(list))
+	for _, st := range list {
+		out[st.ID] = st
+	}
+
+	return out
+}
+
+func TestWALPersister_AppendAndReplay(t *testing.T) {
+	p, path := newWALTestPersister(t, 100)
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19, 90)
+	second := newTestStudent(t, "Alexander", "Gunin", 19)
+
+	updated := first.Clone()
+	updated.Grades = append(updated.Grades, unassignedGrades(100)...)
+
+	if err := p.Append(persisters.PutEntry(first), persisters.PutEntry(second)); err != nil {
+		t.Fatalf("[%s][Replay] failed to append puts: %v", walTestPrefix, err)
+	}
+
+	if err := p.Append(persisters.PutEntry(updated)); err != nil {
+		t.Fatalf("[%s][Replay] failed to append update: %v", walTestPrefix, err)
+	}
+
+	if err := p.Append(persisters.DeleteEntry(second.ID)); err != nil {
+		t.Fatalf("[%s][Replay] failed to append delete: %v", walTestPrefix, err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("[%s][Replay] snapshot must not be written by appends, stat err=%v", walTestPrefix, err)
+	}
+
+	reloaded := newWALTestPersisterAt(t, path, 100)
+
+	loaded, err := reloaded.Load()
+	if err != nil {
+		t.Fatalf("[%s][Replay] failed to load: %v", walTestPrefix, err)
+	}
+
+	if len(loaded) != 1 {
+		t.Fatalf("[%s][Replay] want 1 student after replay, got=%d", walTestPrefix, len(loaded))
+	}
+
+	if loaded[0].ID != first.ID || len(loaded[0].Grades) != 2 || loaded[0].Grades[1].Value != 100 {
+		t.Fatalf("[%s][Replay] unexpected replayed student: %+v", walTestPrefix, loaded[0])
+	}
+
+	if reloaded.ShouldCompact() {
+		t.Fatalf("[%s][Replay] compaction requested below threshold", walTestPrefix)
+	}
+}
+
+func TestWALPersister_CompactionRemovesLog(t *testing.T) {
+	p, path := newWALTestPersister(t, 2)
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19)
+	second := newTestStudent(t, "Alexander", "Gunin", 19)
+
+	if err := p.Append(persisters.PutEntry(first)); err != nil {
+		t.Fatalf("[%s][Compaction] failed to append: %v", walTestPrefix, err)
+	}
+
+	if p.ShouldCompact() {
+		t.Fatalf("[%s][Compaction] compaction requested too early", walTestPrefix)
+	}
+
+	if err := p.Append(persisters.PutEntry(second)); err != nil {
+		t.Fatalf("[%s][Compaction] failed to append: %v", walTestPrefix, err)
+	}
+
+	if !p.ShouldCompact() {
+		t.Fatalf("[%s][Compaction] compaction must be requested after 2 records", walTestPrefix)
+	}
+
+	if err := p.Save([]*models.Student{first, second}); err != nil {
+		t.Fatalf("[%s][Compaction] failed to compact: %v", walTestPrefix, err)
+	}
+
+	if p.ShouldCompact() {
+		t.Fatalf("[%s][Compaction] compaction must be reset after save", walTestPrefix)
+	}
+
+	if _, err := os.Stat(path + ".wal"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("[%s][Compaction] journal must be removed after compaction, err=%v", walTestPrefix, err)
+	}
+
+	loaded, err := p.Load()
+	if err != nil {
+		t.Fatalf("[%s][Compaction] failed to load: %v", walTestPrefix, err)
+	}
+
+	if len(loaded) != 2 {
+		t.Fatalf("[%s][Compaction] want 2 students, got=%d", walTestPrefix, len(loaded))
+	}
+}
+
+func TestWALPersister_TornTailIsDropped(t *testing.T) {
+	p, path := newWALTestPersister(t, 100)
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19)
+	if err := p.Append(persisters.PutEntry(first)); err != nil {
+		t.Fatalf("[%s][TornTail] failed to append: %v", walTestPrefix, err)
+	}
+
+	f, err := os.OpenFile(path+".wal", os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatalf("[%s][TornTail] failed to open journal: %v", walTestPrefix, err)
+	}
+
+	if _, err := f.Write([]byte{0, 0, 1, 0, 42, 42}); err != nil {
+		t.Fatalf("[%s][TornTail] failed to write torn record: %v", walTestPrefix, err)
+	}
+
+	_ = f.Close()
+
+	loaded, err := p.Load()
+	if err != nil {
+		t.Fatalf("[%s][TornTail] unexpected error on torn tail: %v", walTestPrefix, err)
+	}
+
+	if len(loaded) != 1 || loaded[0].ID != first.ID {
+		t.Fatalf("[%s][TornTail] unexpected students after torn tail: %v", walTestPrefix, loaded)
+	}
+
+	second := newTestStudent(t, "Alexander", "Gunin", 19)
+	if err := p.Append(persisters.PutEntry(second)); err != nil {
+		t.Fatalf("[%s][TornTail] failed to append after torn tail: %v", walTestPrefix, err)
+	}
+
+	loaded, err = p.Load()
+	if err != nil {
+		t.Fatalf("[%s][TornTail] failed to load after new append: %v", walTestPrefix, err)
+	}
+
+	if len(loaded) != 2 {
+		t.Fatalf("[%s][TornTail] want 2 students, got=%d", walTestPrefix, len(loaded))
+	}
+}
+
+func TestWALPersister_ReplayOverCompactedSnapshotIsIdempotent(t *testing.T) {
+	p, path := newWALTestPersister(t, 100)
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19, 50)
+	second := newTestStudent(t, "Alexander", "Gunin", 19)
+
+	updated := first.Clone()
+	updated.Grades = unassignedGrades(100)
+
+	entries := []persisters.JournalEntry{
+		persisters.PutEntry(first),
+		persisters.PutEntry(second),
+		persisters.PutEntry(updated),
+		persisters.DeleteEntry(second.ID),
+	}
+
+	if err := p.Append(entries...); err != nil {
+		t.Fatalf("[%s][Idempotent] failed to append: %v", walTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init cipher: %v", walTestPrefix, err)
+	}
+
+	// Simulates a crash after the compacted snapshot was renamed but before the log was removed.
+	if err := persisters.NewJSONStudentPersister(path, cipher).
+		Save([]*models.Student{updated}); err != nil {
+		t.Fatalf("[%s][Idempotent] failed to write snapshot: %v", walTestPrefix, err)
+	}
+
+	loaded, err := p.Load()
+	if err != nil {
+		t.Fatalf("[%s][Idempotent] failed to load: %v", walTestPrefix, err)
+	}
+
+	byID := studentsByID(loaded)
+	if len(byID) != 1 || byID[first.ID] == nil || byID[first.ID].Grades[0].Value != 100 {
+		t.Fatalf("[%s][Idempotent] unexpected state after replay: %v", walTestPrefix, loaded)
+	}
+}
+
+func TestWALPersister_CorruptedRecordFails(t *testing.T) {
+	p, path := newWALTestPersister(t, 100)
+
+	if err := p.Append(persisters.PutEntry(newTestStudent(t, "Mikhail", "Gunin", 19))); err != nil {
+		t.Fatalf("[%s][Corrupted] failed to append: %v", walTestPrefix, err)
+	}
+
+	data, err := os.ReadFile(path + ".wal")
+	if err != nil {
+		t.Fatalf("[%s][Corrupted] failed to read journal: %v", walTestPrefix, err)
+	}
+
+	data[len(data)-1]++
+
+	if err := os.WriteFile(path+".wal", data, 0o600); err != nil {
+		t.Fatalf("[%s][Corrupted] failed to write journal: %v", walTestPrefix, err)
+	}
+
+	if _, err := p.Load(); !errors.Is(err, persisters.ErrCorruptedJournal) {
+		t.Fatalf("[%s][Corrupted] want ErrCorruptedJournal, got %v", walTestPrefix, err)
+	}
+}
+
+func TestWALPersister_NilCipher(t *testing.T) {
+	p := persisters.NewWALStudentPersister(filepath.Join(t.TempDir(), "s.json"), nil, 10)
+
+	if err := p.Append(persisters.DeleteEntry(uuid.New())); !errors.Is(err, persisters.ErrInvalidCipher) {
+		t.Fatalf("want ErrInvalidCipher, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package persisters
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+const (
+	recordHeaderSize = 4
+	maxRecordSize    = 64 << 20
+)
+
+func appendRecords(path string, c ciphers.Cipher, payloads [][]byte) error {
+	var buf []byte
+
+	for _, payload := range payloads {
+		ciphertext, err := c.Encrypt(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt record: %w", err)
+		}
+
+		if len(ciphertext) > maxRecordSize {
+			return fmt.Errorf("%w: %d bytes", ErrRecordTooLarge, len(ciphertext))
+		}
+
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(ciphertext)))
+		buf = append(buf, ciphertext...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory with record file: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open record file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		closeRecordFile(file)
+
+		return fmt.Errorf("failed to stat record file: %w", err)
+	}
+
+	if n, err := file.Write(buf); err != nil || n != len(buf) {
+		if truncErr := file.Truncate(info.Size()); truncErr != nil {
+			slog.Error(
+				"failed to roll back partially written records",
+				slog.String("path", path),
+				slog.Any("error", truncErr),
+			)
+		}
+
+		closeRecordFile(file)
+
+		if err == nil {
+			return ErrMismatchPayloadAndWriteLen
+		}
+
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+
+	if err := file.Sync(); err != nil {
+		closeRecordFile(file)
+
+		return fmt.Errorf("failed to sync record file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close record file: %w", err)
+	}
+
+	return nil
+}
+
+func readRecords(path string, c ciphers.Cipher) ([][]byte, error) {
+	return readRecordsFrom(path, c, 0)
+}
+
+// readRecordsFrom reads the records that start at offset from or later, from
+// must be the start of a record.
+func readRecordsFrom(path string, c ciphers.Cipher, from int64) ([][]byte, error) {
+	records, err := scanRecordsFrom(path, c, from)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := make([][]byte, 0, len(records))
+	for _, rec := range records {
+		payloads = append(payloads, rec.payload)
+	}
+
+	return payloads, nil
+}
+
+// record is a decrypted record with the offset of its header in the file.
+type record struct {
+	offset  int64
+	payload []byte
+}
+
+func scanRecordsFrom(path string, c ciphers.Cipher, from int64) ([]record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to read record file: %w", err)
+	}
+
+	data, err := readRecordFileFrom(file, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		records []record
+		offset  int
+	)
+
+	for offset < len(data) {
+		if len(data)-offset < recordHeaderSize {
+			break
+		}
+
+		size := int(binary.BigEndian.Uint32(data[offset:]))
+		if size > maxRecordSize {
+			return nil, fmt.Errorf(
+				"%w: record at offset %d is too large",
+				ErrCorruptedJournal,
+				from+int64(offset),
+			)
+		}
+
+		if len(data)-offset-recordHeaderSize < size {
+			break
+		}
+
+		start := offset + recordHeaderSize
+
+		payload, err := c.Decrypt(data[start : start+size])
+		if errors.Is(err, ciphers.ErrCorruptedPayload) {
+			return nil, fmt.Errorf(
+				"%w: record at offset %d: %w",
+				ErrCorruptedJournal,
+				from+int64(offset),
+				err,
+			)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt record at offset %d: %w", from+int64(offset), err)
+		}
+
+		records = append(records, record{offset: from + int64(offset), payload: payload})
+		offset = start + size
+	}
+
+	if offset < len(data) {
+		slog.Warn(
+			"dropping torn record from the end of record file",
+			slog.String("path", path),
+			slog.Int64("valid_size", from+int64(offset)),
+			slog.Int64("file_size", from+int64(len(data))),
+		)
+
+		if err := os.Truncate(path, from+int64(offset)); err != nil {
+			return nil, fmt.Errorf("failed to truncate torn record: %w", err)
+		}
+	}
+
+	return records, nil
+}
+
+// readRecordAt reads the one record whose header starts at offset.
+func readRecordAt(file *os.File, c ciphers.Cipher, offset int64) ([]byte, error) {
+	var header [recordHeaderSize]byte
+
+	if _, err := file.ReadAt(header[:], offset); err != nil {
+		return nil, fmt.Errorf("%w: record header at offset %d: %w", ErrCorruptedJournal, offset, err)
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxRecordSize {
+		return nil, fmt.Errorf("%w: record at offset %d is too large", ErrCorruptedJournal, offset)
+	}
+
+	ciphertext := make([]byte, size)
+
+	if _, err := file.ReadAt(ciphertext, offset+recordHeaderSize); err != nil {
+		return nil, fmt.Errorf("%w: record at offset %d: %w", ErrCorruptedJournal, offset, err)
+	}
+
+	payload, err := c.Decrypt(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: record at offset %d: %w", ErrCorruptedJournal, offset, err)
+	}
+
+	return payload, nil
+}
+
+func readRecordFileFrom(file *os.File, from int64) ([]byte, error) {
+	defer closeRecordFile(file)
+
+	if _, err := file.Seek(from, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek record file: %w", err)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record file: %w", err)
+	}
+
+	return data, nil
+}
+
+func closeRecordFile(file io.Closer) {
+	if err := file.Close(); err != nil {
+		slog.Error("failed to close record file", slog.Any("error", err))
+	}
+}
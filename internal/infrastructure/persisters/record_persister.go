@@ -0,0 +1,126 @@
+package persisters
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+// recordPersister keeps items as encrypted records appended to one file, the
+// same framing the WAL persister uses. kind only names the items in errors.
+type recordPersister[T any] struct {
+	path   string
+	kind   string
+	cipher ciphers.Cipher
+	mu     sync.Mutex
+}
+
+func (p *recordPersister[T]) Append(items ...T) error {
+	if p.cipher == nil {
+		return ErrInvalidCipher
+	}
+
+	payloads, err := p.marshal(items)
+	if err != nil {
+		return err
+	}
+
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := appendRecords(p.path, p.cipher, payloads); err != nil {
+		return fmt.Errorf("failed to append %s entries: %w", p.kind, err)
+	}
+
+	return nil
+}
+
+// Save replaces the whole file. Regular writes go through Append.
+func (p *recordPersister[T]) Save(items []T) error {
+	if p.cipher == nil {
+		return ErrInvalidCipher
+	}
+
+	payloads, err := p.marshal(items)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tmp := p.path + ".tmp"
+
+	if err := os.Remove(tmp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale %s temp file: %w", p.kind, err)
+	}
+
+	if len(payloads) == 0 {
+		if err := os.Remove(p.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s log: %w", p.kind, err)
+		}
+
+		return nil
+	}
+
+	if err := appendRecords(tmp, p.cipher, payloads); err != nil {
+		return fmt.Errorf("failed to write %s entries: %w", p.kind, err)
+	}
+
+	if err := os.Rename(tmp, p.path); err != nil {
+		return fmt.Errorf("failed to replace %s log: %w", p.kind, err)
+	}
+
+	return nil
+}
+
+func (p *recordPersister[T]) Load() ([]T, error) {
+	if p.cipher == nil {
+		return nil, ErrInvalidCipher
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payloads, err := readRecords(p.path, p.cipher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s log: %w", p.kind, err)
+	}
+
+	items := make([]T, 0, len(payloads))
+
+	for i, payload := range payloads {
+		var item T
+		if err := json.Unmarshal(payload, &item); err != nil {
+			return nil, fmt.Errorf("%w: %s record %d: %w", ErrCorruptedJournal, p.kind, i, err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (p *recordPersister[T]) marshal(items []T) ([][]byte, error) {
+	payloads := make([][]byte, 0, len(items))
+
+	for _, item := range items {
+		payload, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s entry: %w", p.kind, err)
+		}
+
+		payloads = append(payloads, payload)
+	}
+
+	return payloads, nil
+}
@@ -0,0 +1,234 @@
+package persisters
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+)
+
+const (
+	rekeyStagedSuffix = ".rekey"
+	rekeyBackupSuffix = ".rekey-old"
+)
+
+type snapshotPersister[T any] interface {
+	Save(items []T) error
+	Load() ([]T, error)
+}
+
+// stagedFile is a data file re-encrypted next to the original one.
+type stagedFile struct {
+	path   string
+	staged string
+}
+
+// Rekeyer re-encrypts the data files in two phases, so the data never ends
+// up split between the old and the new key. Students, Courses, Audit and
+// History write each file with the new key next to the original one and
+// read it back, Commit moves the staged files into place only once all of
+// them were verified and Abort removes them when anything failed.
+type Rekeyer struct {
+	cipher ciphers.Cipher
+	files  []stagedFile
+}
+
+func NewRekeyer(c ciphers.Cipher) *Rekeyer {
+	return &Rekeyer{cipher: c}
+}
+
+// Students stages the students snapshot at path. A journal is compacted
+// with the old key first, so the snapshot alone holds every student.
+func (r *Rekeyer) Students(src StudentPersister, path string) (int, error) {
+	if j, ok := src.(StudentJournal); ok {
+		items, err := j.Load()
+		if err != nil {
+			return 0, fmt.Errorf("failed to rekey students: failed to load data with old key: %w", err)
+		}
+
+		if err := j.Save(items); err != nil {
+			return 0, fmt.Errorf("failed to rekey students: failed to compact journal: %w", err)
+		}
+	}
+
+	n, err := stage(r, src, NewJSONStudentPersister(stagedPath(path), r.cipher), path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rekey students: %w", err)
+	}
+
+	return n, nil
+}
+
+func (r *Rekeyer) Courses(src CoursePersister, path string) (int, error) {
+	n, err := stage(r, src, NewJSONCoursePersister(stagedPath(path), r.cipher), path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rekey courses: %w", err)
+	}
+
+	return n, nil
+}
+
+// Audit stages the audit log together with its head, the staged log shares
+// the lock file of the original one.
+func (r *Rekeyer) Audit(src AuditPersister, path string) (int, error) {
+	r.files = append(r.files, stagedFile{
+		path:   auditHeadPath(path),
+		staged: auditHeadPath(stagedPath(path)),
+	})
+
+	dst := newRecordAuditPersister(stagedPath(path), path+auditLockSuffix, r.cipher)
+
+	n, err := stage(r, src, dst, path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rekey audit log: %w", err)
+	}
+
+	return n, nil
+}
+
+func (r *Rekeyer) History(src HistoryPersister, path string) (int, error) {
+	n, err := stage(r, src, NewRecordHistoryPersister(stagedPath(path), r.cipher), path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to rekey student history: %w", err)
+	}
+
+	return n, nil
+}
+
+// Commit replaces the original files with the staged ones. The originals
+// are moved aside first and put back if any rename fails.
+func (r *Rekeyer) Commit() error {
+	var done []stagedFile
+
+	for _, f := range r.files {
+		if err := commitStaged(f); err != nil {
+			for i := len(done) - 1; i >= 0; i-- {
+				rollbackStaged(done[i])
+			}
+
+			r.Abort()
+
+			return fmt.Errorf("failed to move re-encrypted %s into place: %w", f.path, err)
+		}
+
+		done = append(done, f)
+	}
+
+	for _, f := range done {
+		removeRekeyFile(backupPath(f.path))
+	}
+
+	r.files = nil
+
+	return nil
+}
+
+// Abort removes every staged file and leaves the originals untouched.
+func (r *Rekeyer) Abort() {
+	for _, f := range r.files {
+		removeRekeyFile(f.staged)
+	}
+
+	r.files = nil
+}
+
+func stage[T any](r *Rekeyer, src, dst snapshotPersister[T], path string) (int, error) {
+	items, err := src.Load()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load data with old key: %w", err)
+	}
+
+	want, err := json.Marshal(items)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal data before rekey: %w", err)
+	}
+
+	r.files = append(r.files, stagedFile{path: path, staged: stagedPath(path)})
+
+	if err := dst.Save(items); err != nil {
+		return 0, fmt.Errorf("failed to save data with new key: %w", err)
+	}
+
+	if err := verifyRekey(dst, want); err != nil {
+		return 0, err
+	}
+
+	return len(items), nil
+}
+
+func verifyRekey[T any](dst snapshotPersister[T], want []byte) error {
+	reloaded, err := dst.Load()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRekeyVerificationFailed, err)
+	}
+
+	got, err := json.Marshal(reloaded)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrRekeyVerificationFailed, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		return ErrRekeyVerificationFailed
+	}
+
+	return nil
+}
+
+// commitStaged moves the original aside and the staged file into its place,
+// or puts the original back when the second rename fails. A record file with
+// no items is not written at all, then the original is only moved aside.
+func commitStaged(f stagedFile) error {
+	if err := os.Rename(f.path, backupPath(f.path)); err != nil &&
+		!errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.Rename(f.staged, f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		rollbackStaged(f)
+
+		return err
+	}
+
+	return nil
+}
+
+// rollbackStaged puts the original file back, a file that did not exist
+// before the rekey is removed again.
+func rollbackStaged(f stagedFile) {
+	err := os.Rename(backupPath(f.path), f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		err = os.Remove(f.path)
+	}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error(
+			"failed to restore data file after failed rekey",
+			slog.String("path", f.path),
+			slog.String("backup", backupPath(f.path)),
+			slog.Any("error", err),
+		)
+	}
+}
+
+func removeRekeyFile(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Error(
+			"failed to remove rekey temp file",
+			slog.String("path", path),
+			slog.Any("error", err),
+		)
+	}
+}
+
+func stagedPath(path string) string {
+	return path + rekeyStagedSuffix
+}
+
+func backupPath(path string) string {
+	return path + rekeyBackupSuffix
+}
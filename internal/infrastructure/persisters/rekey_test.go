@@ -0,0 +1,276 @@
+package persisters_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	rekeyTestPrefix = "Rekey"
+
+	newTestKey = "abcdefghijklmnopqrstuvwxyz123456"
+)
+
+func newRekeyTestCiphers(t *testing.T) (ciphers.Cipher, ciphers.Cipher) {
+	t.Helper()
+
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", rekeyTestPrefix, err)
+	}
+
+	oldCipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init old cipher: %v", rekeyTestPrefix, err)
+	}
+
+	newCipher, err := ciphers.NewAESGCM(newTestKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init new cipher: %v", rekeyTestPrefix, err)
+	}
+
+	return oldCipher, newCipher
+}
+
+func TestRekey_JSONSnapshot(t *testing.T) {
+	oldCipher, newCipher := newRekeyTestCiphers(t)
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	students := []*models.Student{
+		newTestStudent(t, "Mikhail", "Gunin", 19, 90, 100),
+		newTestStudent(t, "Alexander", "Gunin", 19),
+	}
+
+	if err := persisters.NewJSONStudentPersister(path, oldCipher).Save(students); err != nil {
+		t.Fatalf("[%s][JSON] failed to save with old key: %v", rekeyTestPrefix, err)
+	}
+
+	r := persisters.NewRekeyer(newCipher)
+
+	n, err := r.Students(persisters.NewJSONStudentPersister(path, oldCipher), path)
+	if err != nil {
+		t.Fatalf("[%s][JSON] unexpected rekey error: %v", rekeyTestPrefix, err)
+	}
+
+	if _, err := persisters.NewJSONStudentPersister(path, oldCipher).Load(); err != nil {
+		t.Fatalf("[%s][JSON] data must stay under old key until commit: %v", rekeyTestPrefix, err)
+	}
+
+	if err := r.Commit(); err != nil {
+		t.Fatalf("[%s][JSON] unexpected commit error: %v", rekeyTestPrefix, err)
+	}
+
+	if n != len(students) {
+		t.Fatalf("[%s][JSON] rekeyed count mismatch: got=%d want=%d", rekeyTestPrefix, n, len(students))
+	}
+
+	if _, err := persisters.NewJSONStudentPersister(path, oldCipher).Load(); err == nil {
+		t.Fatalf("[%s][JSON] old key must not decrypt data after rekey", rekeyTestPrefix)
+	}
+
+	loaded, err := persisters.NewJSONStudentPersister(path, newCipher).Load()
+	if err != nil {
+		t.Fatalf("[%s][JSON] failed to load with new key: %v", rekeyTestPrefix, err)
+	}
+
+	byID := studentsByID(loaded)
+	if len(byID) != 2 || len(byID[students[0].ID].Grades) != 2 {
+		t.Fatalf("[%s][JSON] unexpected students after rekey: %v", rekeyTestPrefix, loaded)
+	}
+}
+
+func TestRekey_WALCompactsJournal(t *testing.T) {
+	oldCipher, newCipher := newRekeyTestCiphers(t)
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19, 90)
+	second := newTestStudent(t, "Alexander", "Gunin", 19)
+
+	src := persisters.NewWALStudentPersister(path, oldCipher, 100)
+	if err := src.Append(
+		persisters.PutEntry(first),
+		persisters.PutEntry(second),
+		persisters.DeleteEntry(second.ID),
+	); err != nil {
+		t.Fatalf("[%s][WAL] failed to append: %v", rekeyTestPrefix, err)
+	}
+
+	r := persisters.NewRekeyer(newCipher)
+
+	n, err := r.Students(src, path)
+	if err != nil {
+		t.Fatalf("[%s][WAL] unexpected rekey error: %v", rekeyTestPrefix, err)
+	}
+
+	if err := r.Commit(); err != nil {
+		t.Fatalf("[%s][WAL] unexpected commit error: %v", rekeyTestPrefix, err)
+	}
+
+	if n != 1 {
+		t.Fatalf("[%s][WAL] rekeyed count mismatch: got=%d want=1", rekeyTestPrefix, n)
+	}
+
+	if _, err := os.Stat(path + ".wal"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("[%s][WAL] journal must be removed after rekey, err=%v", rekeyTestPrefix, err)
+	}
+
+	loaded, err := persisters.NewWALStudentPersister(path, newCipher, 100).Load()
+	if err != nil {
+		t.Fatalf("[%s][WAL] failed to load with new key: %v", rekeyTestPrefix, err)
+	}
+
+	if len(loaded) != 1 || loaded[0].ID != first.ID {
+		t.Fatalf("[%s][WAL] unexpected students after rekey: %v", rekeyTestPrefix, loaded)
+	}
+}
+
+func TestRekey_WrongOldKeyKeepsData(t *testing.T) {
+	oldCipher, newCipher := newRekeyTestCiphers(t)
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	students := []*models.Student{newTestStudent(t, "Mikhail", "Gunin", 19)}
+
+	if err := persisters.NewJSONStudentPersister(path, oldCipher).Save(students); err != nil {
+		t.Fatalf("[%s][WrongKey] failed to save with old key: %v", rekeyTestPrefix, err)
+	}
+
+	r := persisters.NewRekeyer(newCipher)
+
+	if _, err := r.Students(persisters.NewJSONStudentPersister(path, newCipher), path); err == nil {
+		t.Fatalf("[%s][WrongKey] expected error when old key is wrong", rekeyTestPrefix)
+	}
+
+	r.Abort()
+
+	loaded, err := persisters.NewJSONStudentPersister(path, oldCipher).Load()
+	if err != nil || len(loaded) != 1 {
+		t.Fatalf("[%s][WrongKey] data must stay readable with old key: %v (err=%v)", rekeyTestPrefix, loaded, err)
+	}
+}
+
+func TestRekeyCourses_JSONSnapshot(t *testing.T) {
+	oldCipher, newCipher := newRekeyTestCiphers(t)
+	path := persisters.CoursesPath(filepath.Join(t.TempDir(), "students.json"))
+
+	math, err := models.NewCourse("Math")
+	if err != nil {
+		t.Fatalf("[%s][Courses] failed to build course: %v", rekeyTestPrefix, err)
+	}
+
+	if err := persisters.NewJSONCoursePersister(path, oldCipher).Save(
+		[]*models.Course{math},
+	); err != nil {
+		t.Fatalf("[%s][Courses] failed to save with old key: %v", rekeyTestPrefix, err)
+	}
+
+	r := persisters.NewRekeyer(newCipher)
+
+	n, err := r.Courses(persisters.NewJSONCoursePersister(path, oldCipher), path)
+	if err != nil || n != 1 {
+		t.Fatalf("[%s][Courses] unexpected rekey result: n=%d err=%v", rekeyTestPrefix, n, err)
+	}
+
+	if err := r.Commit(); err != nil {
+		t.Fatalf("[%s][Courses] unexpected commit error: %v", rekeyTestPrefix, err)
+	}
+
+	loaded, err := persisters.NewJSONCoursePersister(path, newCipher).Load()
+	if err != nil || len(loaded) != 1 || *loaded[0] != *math {
+		t.Fatalf("[%s][Courses] unexpected courses after rekey: %v (err=%v)", rekeyTestPrefix, loaded, err)
+	}
+
+	if filepath.Base(path) != "students.json.courses" {
+		t.Fatalf("[%s][Courses] unexpected courses path: %s", rekeyTestPrefix, path)
+	}
+}
+
+func TestRekeyAudit_RecordFile(t *testing.T) {
+	oldCipher, newCipher := newRekeyTestCiphers(t)
+	path := persisters.AuditPath(filepath.Join(t.TempDir(), "students.json"))
+
+	entries := []models.AuditEntry{
+		{Seq: 1, Actor: "dean", Action: models.AuditActionRegister, Hash: "a"},
+		{Seq: 2, Actor: "dean", Action: models.AuditActionDelete, PrevHash: "a", Hash: "b"},
+	}
+
+	if err := persisters.NewRecordAuditPersister(path, oldCipher).Save(entries); err != nil {
+		t.Fatalf("[%s][Audit] failed to append with old key: %v", rekeyTestPrefix, err)
+	}
+
+	r := persisters.NewRekeyer(newCipher)
+
+	n, err := r.Audit(persisters.NewRecordAuditPersister(path, oldCipher), path)
+	if err != nil || n != 2 {
+		t.Fatalf("[%s][Audit] unexpected rekey result: n=%d err=%v", rekeyTestPrefix, n, err)
+	}
+
+	if err := r.Commit(); err != nil {
+		t.Fatalf("[%s][Audit] unexpected commit error: %v", rekeyTestPrefix, err)
+	}
+
+	loaded, err := persisters.NewRecordAuditPersister(path, newCipher).Load()
+	if err != nil || len(loaded) != 2 || loaded[1].PrevHash != "a" || loaded[1].Hash != "b" {
+		t.Fatalf("[%s][Audit] unexpected entries after rekey: %+v (err=%v)", rekeyTestPrefix, loaded, err)
+	}
+
+	if _, err := persisters.NewRecordAuditPersister(path, oldCipher).Load(); err == nil {
+		t.Fatalf("[%s][Audit] old key must not decrypt the rekeyed log", rekeyTestPrefix)
+	}
+
+	_, head, err := persisters.NewRecordAuditPersister(path, newCipher).LoadWithHead()
+	if err != nil || head == nil || head.Seq != 2 || head.Hash != "b" {
+		t.Fatalf("[%s][Audit] head must be rekeyed with the log: %+v (err=%v)", rekeyTestPrefix, head, err)
+	}
+
+	if filepath.Base(path) != "students.json.audit" {
+		t.Fatalf("[%s][Audit] unexpected audit path: %s", rekeyTestPrefix, path)
+	}
+}
+
+func TestRekey_FailureKeepsEveryFileUnderOldKey(t *testing.T) {
+	oldCipher, newCipher := newRekeyTestCiphers(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "students.json")
+	auditPath := persisters.AuditPath(path)
+
+	students := []*models.Student{newTestStudent(t, "Mikhail", "Gunin", 19, 90)}
+
+	if err := persisters.NewJSONStudentPersister(path, oldCipher).Save(students); err != nil {
+		t.Fatalf("[%s][Failure] failed to save with old key: %v", rekeyTestPrefix, err)
+	}
+
+	// an audit log the old key can not read makes the second step fail
+	if err := persisters.NewRecordAuditPersister(auditPath, newCipher).Save(
+		[]models.AuditEntry{{Seq: 1, Actor: "dean", Hash: "a"}},
+	); err != nil {
+		t.Fatalf("[%s][Failure] failed to append audit entry: %v", rekeyTestPrefix, err)
+	}
+
+	r := persisters.NewRekeyer(newCipher)
+
+	if _, err := r.Students(persisters.NewJSONStudentPersister(path, oldCipher), path); err != nil {
+		t.Fatalf("[%s][Failure] unexpected students rekey error: %v", rekeyTestPrefix, err)
+	}
+
+	if _, err := r.Audit(persisters.NewRecordAuditPersister(auditPath, oldCipher), auditPath); err == nil {
+		t.Fatalf("[%s][Failure] expected audit rekey error", rekeyTestPrefix)
+	}
+
+	r.Abort()
+
+	loaded, err := persisters.NewJSONStudentPersister(path, oldCipher).Load()
+	if err != nil || len(loaded) != 1 {
+		t.Fatalf("[%s][Failure] students must stay under old key: %v (err=%v)", rekeyTestPrefix, loaded, err)
+	}
+
+	staged, err := filepath.Glob(filepath.Join(dir, "*.rekey*"))
+	if err != nil || len(staged) != 0 {
+		t.Fatalf("[%s][Failure] staged files must be removed, got %v (err=%v)", rekeyTestPrefix, staged, err)
+	}
+}
@@ -0,0 +1,259 @@
+package repositories
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+)
+
+// AuditStorage is an append-only audit log. Every entry stores the hash of
+// the previous one, so removing or reordering entries breaks the chain. With
+// a persister List and Verify read the log from it and appends are chained
+// to the stored head, so processes sharing the log never fork the chain.
+type AuditStorage struct {
+	entries   []models.AuditEntry
+	persister persisters.AuditPersister
+	mu        sync.RWMutex
+}
+
+func NewAuditStorageWithPersister(p persisters.AuditPersister) (*AuditStorage, error) {
+	s := &AuditStorage{
+		persister: p,
+	}
+
+	if p == nil {
+		return s, nil
+	}
+
+	if _, err := p.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load audit log: %w", err)
+	}
+
+	return s, nil
+}
+
+func (s *AuditStorage) Append(entries ...models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.persister == nil {
+		var tail persisters.AuditHead
+
+		if n := len(s.entries); n > 0 {
+			tail = persisters.AuditHead{Seq: s.entries[n-1].Seq, Hash: s.entries[n-1].Hash}
+		}
+
+		chained, err := chainAuditEntries(tail, entries, false)
+		if err != nil {
+			return err
+		}
+
+		s.entries = append(s.entries, chained...)
+
+		return nil
+	}
+
+	err := s.persister.AppendChained(func(tail persisters.AuditHead) ([]models.AuditEntry, error) {
+		return chainAuditEntries(tail, entries, true)
+	})
+	if errors.Is(err, persisters.ErrAuditHeadMismatch) {
+		return fmt.Errorf("persist audit entries failed: %w: %w", repositories.ErrAuditChainBroken, err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("persist audit entries failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AuditStorage) List(filter repositories.AuditFilter) ([]models.AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, _, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]models.AuditEntry, 0, len(entries))
+
+	for _, e := range entries {
+		if filter.StudentID != uuid.Nil && e.StudentID != filter.StudentID {
+			continue
+		}
+
+		if filter.Actor != "" && e.Actor != filter.Actor {
+			continue
+		}
+
+		if filter.Action != "" && e.Action != filter.Action {
+			continue
+		}
+
+		e.Before = e.Before.Clone()
+		e.After = e.After.Clone()
+
+		out = append(out, e)
+	}
+
+	return out, nil
+}
+
+// Verify checks the chain and that the log still reaches the stored head. A
+// head behind the log is fine, the process may have stopped between writing
+// the entries and the head. A missing head is only accepted for logs written
+// before the head was kept, anchored entries need one.
+func (s *AuditStorage) Verify() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries, head, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	var prevHash string
+
+	for i, e := range entries {
+		if e.Seq != uint64(i)+1 {
+			return fmt.Errorf(
+				"%w: entry #%d has sequence number %d",
+				repositories.ErrAuditChainBroken,
+				i+1,
+				e.Seq,
+			)
+		}
+
+		if e.PrevHash != prevHash {
+			return fmt.Errorf(
+				"%w: entry #%d does not follow the previous entry",
+				repositories.ErrAuditChainBroken,
+				e.Seq,
+			)
+		}
+
+		hash, err := auditEntryHash(e)
+		if err != nil {
+			return err
+		}
+
+		if hash != e.Hash {
+			return fmt.Errorf(
+				"%w: entry #%d was modified",
+				repositories.ErrAuditChainBroken,
+				e.Seq,
+			)
+		}
+
+		prevHash = e.Hash
+	}
+
+	if head == nil {
+		for _, e := range entries {
+			if e.Anchored {
+				return fmt.Errorf(
+					"%w: the head of the log is missing, entries after #%d may be removed",
+					repositories.ErrAuditChainBroken,
+					len(entries),
+				)
+			}
+		}
+
+		return nil
+	}
+
+	if head.Seq == 0 {
+		return nil
+	}
+
+	if head.Seq > uint64(len(entries)) {
+		return fmt.Errorf(
+			"%w: entries after #%d were removed, the log ended at #%d",
+			repositories.ErrAuditChainBroken,
+			len(entries),
+			head.Seq,
+		)
+	}
+
+	if entries[head.Seq-1].Hash != head.Hash {
+		return fmt.Errorf(
+			"%w: entry #%d does not match the log head",
+			repositories.ErrAuditChainBroken,
+			head.Seq,
+		)
+	}
+
+	return nil
+}
+
+func (s *AuditStorage) load() ([]models.AuditEntry, *persisters.AuditHead, error) {
+	if s.persister == nil {
+		return s.entries, nil, nil
+	}
+
+	entries, head, err := s.persister.LoadWithHead()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load audit log: %w", err)
+	}
+
+	return entries, head, nil
+}
+
+// chainAuditEntries numbers the entries after the tail and links each to the
+// hash of the one before it. Entries of a log that keeps a head are anchored.
+func chainAuditEntries(
+	tail persisters.AuditHead,
+	entries []models.AuditEntry,
+	anchored bool,
+) ([]models.AuditEntry, error) {
+	seq, prevHash := tail.Seq, tail.Hash
+
+	chained := make([]models.AuditEntry, 0, len(entries))
+
+	for _, e := range entries {
+		seq++
+
+		e.Seq = seq
+		e.PrevHash = prevHash
+		e.Anchored = anchored
+
+		hash, err := auditEntryHash(e)
+		if err != nil {
+			return nil, err
+		}
+
+		e.Hash = hash
+		prevHash = hash
+
+		chained = append(chained, e)
+	}
+
+	return chained, nil
+}
+
+func auditEntryHash(e models.AuditEntry) (string, error) {
+	e.Hash = ""
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit entry for hashing: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,384 @@
+package repositories_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	auditRepoTestPrefix = "AuditStorage"
+)
+
+func TestAuditStorage_ChainSurvivesReload(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Reload] failed to init validators: %v", auditRepoTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Reload] failed to init cipher: %v", auditRepoTestPrefix, err)
+	}
+
+	path := persisters.AuditPath(filepath.Join(t.TempDir(), "students.json"))
+
+	log, err := repositories.NewAuditStorageWithPersister(persisters.NewRecordAuditPersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][Reload] failed to create audit storage: %v", auditRepoTestPrefix, err)
+	}
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+	older := st.Clone()
+	older.Age = 20
+
+	at := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	if err := log.Append(
+		models.NewAuditEntry(models.AuditActionRegister, "dean", nil, st, at),
+		models.NewAuditEntry(models.AuditActionUpdate, "registrar", st, older, at.Add(time.Minute)),
+	); err != nil {
+		t.Fatalf("[%s][Reload] failed to append entries: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Append(models.NewAuditEntry(models.AuditActionDelete, "dean", older, nil, at.Add(time.Hour))); err != nil {
+		t.Fatalf("[%s][Reload] failed to append entry: %v", auditRepoTestPrefix, err)
+	}
+
+	reopened, err := repositories.NewAuditStorageWithPersister(persisters.NewRecordAuditPersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][Reload] failed to reopen audit storage: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := reopened.Verify(); err != nil {
+		t.Fatalf("[%s][Reload] chain must verify after reload: %v", auditRepoTestPrefix, err)
+	}
+
+	all, err := reopened.List(domainRepos.AuditFilter{})
+	if err != nil {
+		t.Fatalf("[%s][Reload] failed to list entries: %v", auditRepoTestPrefix, err)
+	}
+
+	if len(all) != 3 || all[2].Seq != 3 || all[2].PrevHash != all[1].Hash {
+		t.Fatalf("[%s][Reload] unexpected entries after reload: %+v", auditRepoTestPrefix, all)
+	}
+
+	if all[1].Before.Age != 19 || all[1].After.Age != 20 || all[2].After != nil {
+		t.Fatalf("[%s][Reload] before/after snapshots were not kept: %+v", auditRepoTestPrefix, all[1:])
+	}
+
+	byDean, err := reopened.List(domainRepos.AuditFilter{StudentID: st.ID, Actor: "dean"})
+	if err != nil {
+		t.Fatalf("[%s][Reload] failed to filter entries: %v", auditRepoTestPrefix, err)
+	}
+
+	if len(byDean) != 2 || byDean[0].Action != models.AuditActionRegister || byDean[1].Action != models.AuditActionDelete {
+		t.Fatalf("[%s][Reload] unexpected filtered entries: %+v", auditRepoTestPrefix, byDean)
+	}
+}
+
+func TestAuditStorage_DetectsTampering(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Tamper] failed to init validators: %v", auditRepoTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Tamper] failed to init cipher: %v", auditRepoTestPrefix, err)
+	}
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+	at := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		tamper func(entries []models.AuditEntry) []models.AuditEntry
+	}{
+		{
+			name: "changed-actor",
+			tamper: func(entries []models.AuditEntry) []models.AuditEntry {
+				entries[0].Actor = "someone-else"
+				return entries
+			},
+		},
+		{
+			name: "changed-snapshot",
+			tamper: func(entries []models.AuditEntry) []models.AuditEntry {
+				entries[1].After.Age = 99
+				return entries
+			},
+		},
+		{
+			name: "removed-entry",
+			tamper: func(entries []models.AuditEntry) []models.AuditEntry {
+				return entries[1:]
+			},
+		},
+		{
+			name: "reordered-entries",
+			tamper: func(entries []models.AuditEntry) []models.AuditEntry {
+				entries[0], entries[1] = entries[1], entries[0]
+				return entries
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := persisters.NewRecordAuditPersister(filepath.Join(t.TempDir(), "students.json.audit"), cipher)
+
+			log, err := repositories.NewAuditStorageWithPersister(p)
+			if err != nil {
+				t.Fatalf("[%s][Tamper] failed to create audit storage: %v", auditRepoTestPrefix, err)
+			}
+
+			older := st.Clone()
+			older.Age = 20
+
+			if err := log.Append(
+				models.NewAuditEntry(models.AuditActionRegister, "dean", nil, st, at),
+				models.NewAuditEntry(models.AuditActionUpdate, "dean", st, older, at.Add(time.Minute)),
+			); err != nil {
+				t.Fatalf("[%s][Tamper] failed to append entries: %v", auditRepoTestPrefix, err)
+			}
+
+			entries, err := p.Load()
+			if err != nil {
+				t.Fatalf("[%s][Tamper] failed to load entries: %v", auditRepoTestPrefix, err)
+			}
+
+			if err := p.Save(tt.tamper(entries)); err != nil {
+				t.Fatalf("[%s][Tamper] failed to rewrite entries: %v", auditRepoTestPrefix, err)
+			}
+
+			reopened, err := repositories.NewAuditStorageWithPersister(p)
+			if err != nil {
+				t.Fatalf("[%s][Tamper] failed to reopen audit storage: %v", auditRepoTestPrefix, err)
+			}
+
+			if err := reopened.Verify(); !errors.Is(err, domainRepos.ErrAuditChainBroken) {
+				t.Fatalf("[%s][Tamper] want ErrAuditChainBroken, got=%v", auditRepoTestPrefix, err)
+			}
+		})
+	}
+}
+
+func TestAuditStorage_SharedLogKeepsOneChain(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Shared] failed to init validators: %v", auditRepoTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Shared] failed to init cipher: %v", auditRepoTestPrefix, err)
+	}
+
+	path := persisters.AuditPath(filepath.Join(t.TempDir(), "students.json"))
+
+	first, err := repositories.NewAuditStorageWithPersister(persisters.NewRecordAuditPersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][Shared] failed to create audit storage: %v", auditRepoTestPrefix, err)
+	}
+
+	second, err := repositories.NewAuditStorageWithPersister(persisters.NewRecordAuditPersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][Shared] failed to create audit storage: %v", auditRepoTestPrefix, err)
+	}
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+	at := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	for i, log := range []*repositories.AuditStorage{first, second, first, second} {
+		entry := models.NewAuditEntry(models.AuditActionUpdate, "dean", st, st, at.Add(time.Duration(i)*time.Minute))
+
+		if err := log.Append(entry); err != nil {
+			t.Fatalf("[%s][Shared] failed to append entry #%d: %v", auditRepoTestPrefix, i+1, err)
+		}
+	}
+
+	for _, log := range []*repositories.AuditStorage{first, second} {
+		if err := log.Verify(); err != nil {
+			t.Fatalf("[%s][Shared] chain must verify: %v", auditRepoTestPrefix, err)
+		}
+
+		all, err := log.List(domainRepos.AuditFilter{})
+		if err != nil {
+			t.Fatalf("[%s][Shared] failed to list entries: %v", auditRepoTestPrefix, err)
+		}
+
+		if len(all) != 4 || all[3].Seq != 4 {
+			t.Fatalf("[%s][Shared] want 4 chained entries, got=%+v", auditRepoTestPrefix, all)
+		}
+	}
+}
+
+func TestAuditStorage_DetectsTruncation(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Truncate] failed to init validators: %v", auditRepoTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Truncate] failed to init cipher: %v", auditRepoTestPrefix, err)
+	}
+
+	path := persisters.AuditPath(filepath.Join(t.TempDir(), "students.json"))
+
+	log, err := repositories.NewAuditStorageWithPersister(persisters.NewRecordAuditPersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][Truncate] failed to create audit storage: %v", auditRepoTestPrefix, err)
+	}
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+	at := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	if err := log.Append(
+		models.NewAuditEntry(models.AuditActionRegister, "dean", nil, st, at),
+		models.NewAuditEntry(models.AuditActionUpdate, "dean", st, st, at.Add(time.Minute)),
+	); err != nil {
+		t.Fatalf("[%s][Truncate] failed to append entries: %v", auditRepoTestPrefix, err)
+	}
+
+	prefix, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("[%s][Truncate] failed to read audit log: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Append(models.NewAuditEntry(models.AuditActionDelete, "dean", st, nil, at.Add(time.Hour))); err != nil {
+		t.Fatalf("[%s][Truncate] failed to append entry: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := os.WriteFile(path, prefix, 0o600); err != nil {
+		t.Fatalf("[%s][Truncate] failed to truncate audit log: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Verify(); !errors.Is(err, domainRepos.ErrAuditChainBroken) {
+		t.Fatalf("[%s][Truncate] want ErrAuditChainBroken, got=%v", auditRepoTestPrefix, err)
+	}
+}
+
+func TestAuditStorage_DetectsTruncationWithoutHead(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][TruncateHead] failed to init validators: %v", auditRepoTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][TruncateHead] failed to init cipher: %v", auditRepoTestPrefix, err)
+	}
+
+	path := persisters.AuditPath(filepath.Join(t.TempDir(), "students.json"))
+
+	log, err := repositories.NewAuditStorageWithPersister(persisters.NewRecordAuditPersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][TruncateHead] failed to create audit storage: %v", auditRepoTestPrefix, err)
+	}
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+	at := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	if err := log.Append(models.NewAuditEntry(models.AuditActionRegister, "dean", nil, st, at)); err != nil {
+		t.Fatalf("[%s][TruncateHead] failed to append entry: %v", auditRepoTestPrefix, err)
+	}
+
+	prefix, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("[%s][TruncateHead] failed to read audit log: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Append(models.NewAuditEntry(models.AuditActionDelete, "dean", st, nil, at.Add(time.Hour))); err != nil {
+		t.Fatalf("[%s][TruncateHead] failed to append entry: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := os.WriteFile(path, prefix, 0o600); err != nil {
+		t.Fatalf("[%s][TruncateHead] failed to truncate audit log: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := os.Remove(path + ".head"); err != nil {
+		t.Fatalf("[%s][TruncateHead] failed to remove audit head: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Verify(); !errors.Is(err, domainRepos.ErrAuditChainBroken) {
+		t.Fatalf("[%s][TruncateHead] want ErrAuditChainBroken from verify, got=%v", auditRepoTestPrefix, err)
+	}
+
+	err = log.Append(models.NewAuditEntry(models.AuditActionRestore, "dean", nil, st, at.Add(2*time.Hour)))
+	if !errors.Is(err, domainRepos.ErrAuditChainBroken) {
+		t.Fatalf("[%s][TruncateHead] append must not cover up the missing head, got=%v", auditRepoTestPrefix, err)
+	}
+}
+
+func TestAuditStorage_AppendReadsOnlyTheHead(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Head] failed to init validators: %v", auditRepoTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Head] failed to init cipher: %v", auditRepoTestPrefix, err)
+	}
+
+	path := persisters.AuditPath(filepath.Join(t.TempDir(), "students.json"))
+
+	log, err := repositories.NewAuditStorageWithPersister(persisters.NewRecordAuditPersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][Head] failed to create audit storage: %v", auditRepoTestPrefix, err)
+	}
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+	at := time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC)
+
+	if err := log.Append(models.NewAuditEntry(models.AuditActionRegister, "dean", nil, st, at)); err != nil {
+		t.Fatalf("[%s][Head] failed to append entry: %v", auditRepoTestPrefix, err)
+	}
+
+	staleHead, err := os.ReadFile(path + ".head")
+	if err != nil {
+		t.Fatalf("[%s][Head] failed to read audit head: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Append(models.NewAuditEntry(models.AuditActionUpdate, "dean", st, st, at.Add(time.Minute))); err != nil {
+		t.Fatalf("[%s][Head] failed to append entry: %v", auditRepoTestPrefix, err)
+	}
+
+	// A crash between the entries and the head leaves the head behind.
+	if err := os.WriteFile(path+".head", staleHead, 0o600); err != nil {
+		t.Fatalf("[%s][Head] failed to restore stale head: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Append(models.NewAuditEntry(models.AuditActionDelete, "dean", st, nil, at.Add(time.Hour))); err != nil {
+		t.Fatalf("[%s][Head] failed to append after a stale head: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Verify(); err != nil {
+		t.Fatalf("[%s][Head] chain must verify after catching up the head: %v", auditRepoTestPrefix, err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("[%s][Head] failed to read audit log: %v", auditRepoTestPrefix, err)
+	}
+
+	// Appends chain from the head, so a damaged first record is left to verify.
+	data[8] ^= 0xff
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("[%s][Head] failed to damage audit log: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Append(models.NewAuditEntry(models.AuditActionRestore, "dean", nil, st, at.Add(2*time.Hour))); err != nil {
+		t.Fatalf("[%s][Head] append must not read the whole log: %v", auditRepoTestPrefix, err)
+	}
+
+	if err := log.Verify(); err == nil {
+		t.Fatalf("[%s][Head] verify must notice the damaged record", auditRepoTestPrefix)
+	}
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type CourseStorage struct {
+	courses   map[uuid.UUID]*models.Course
+	persister persisters.CoursePersister
+	mu        sync.RWMutex
+}
+
+func NewCourseStorageWithPersister(p persisters.CoursePersister) (*CourseStorage, error) {
+	s := &CourseStorage{
+		courses:   make(map[uuid.UUID]*models.Course),
+		persister: p,
+	}
+
+	s.courses[models.UnassignedCourseID] = models.UnassignedCourse()
+
+	if p == nil {
+		return s, nil
+	}
+
+	courses, err := p.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load courses snapshot: %w", err)
+	}
+
+	for _, c := range courses {
+		if c == nil {
+			return nil, repositories.ErrInvalidCourseID
+		}
+
+		if err := validators.Validate.Struct(c); err != nil {
+			return nil, fmt.Errorf("failed to validate course from snapshot: %w", err)
+		}
+
+		s.courses[c.ID] = c.Clone()
+	}
+
+	return s, nil
+}
+
+func (s *CourseStorage) Create(course *models.Course) (uuid.UUID, error) {
+	cp := course.Clone()
+
+	if err := validators.Validate.Struct(cp); err != nil {
+		return uuid.Nil, fmt.Errorf("input course is invalid: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.courses[cp.ID]; ok {
+		return uuid.Nil, repositories.ErrCourseAlreadyExists
+	}
+
+	for _, c := range s.courses {
+		if strings.EqualFold(c.Name, cp.Name) {
+			return uuid.Nil, repositories.ErrCourseAlreadyExists
+		}
+	}
+
+	s.courses[cp.ID] = cp
+
+	if s.persister != nil {
+		if err := s.persister.Save(s.snapshotLocked()); err != nil {
+			delete(s.courses, cp.ID)
+
+			return uuid.Nil, fmt.Errorf("persist course data after create failed: %w", err)
+		}
+	}
+
+	return cp.ID, nil
+}
+
+func (s *CourseStorage) GetByID(id uuid.UUID) (*models.Course, error) {
+	if id == uuid.Nil {
+		return nil, repositories.ErrInvalidCourseID
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	course, ok := s.courses[id]
+	if !ok {
+		return nil, repositories.ErrCourseNotFound
+	}
+
+	return course.Clone(), nil
+}
+
+func (s *CourseStorage) List() ([]*models.Course, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.snapshotLocked(), nil
+}
+
+func (s *CourseStorage) snapshotLocked() []*models.Course {
+	courses := make([]*models.Course, 0, len(s.courses))
+
+	for _, c := range s.courses {
+		courses = append(courses, c.Clone())
+	}
+
+	slices.SortFunc(courses, func(a, b *models.Course) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return courses
+}
@@ -0,0 +1,195 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/sqlite"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	courseRepoTestPrefix = "CourseRepositoryImpl"
+)
+
+type courseBackend struct {
+	name string
+	open func(t *testing.T) (domainRepos.CourseRepository, domainRepos.StudentRepository)
+}
+
+func courseBackends() []courseBackend {
+	return []courseBackend{
+		{
+			name: "memory",
+			open: func(t *testing.T) (domainRepos.CourseRepository, domainRepos.StudentRepository) {
+				t.Helper()
+
+				courses, err := repositories.NewCourseStorageWithPersister(nil)
+				if err != nil {
+					t.Fatalf("[%s] failed to create course storage: %v", courseRepoTestPrefix, err)
+				}
+
+				students, err := repositories.NewStudentStorageWithPersister(nil)
+				if err != nil {
+					t.Fatalf("[%s] failed to create student storage: %v", courseRepoTestPrefix, err)
+				}
+
+				return courses, students
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T) (domainRepos.CourseRepository, domainRepos.StudentRepository) {
+				t.Helper()
+
+				db, err := sqlite.Open(
+					context.Background(),
+					filepath.Join(t.TempDir(), "students.db"),
+				)
+				if err != nil {
+					t.Fatalf("[%s] failed to open sqlite database: %v", courseRepoTestPrefix, err)
+				}
+
+				t.Cleanup(func() {
+					_ = db.Close()
+				})
+
+				return repositories.NewSQLiteCourseStorage(db), repositories.NewSQLiteStudentStorage(db)
+			},
+		},
+	}
+}
+
+func TestCourseRepository_CreateGetList(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", courseRepoTestPrefix, err)
+	}
+
+	for _, backend := range courseBackends() {
+		t.Run(fmt.Sprintf("[%s]-backend-%s", courseRepoTestPrefix, backend.name), func(t *testing.T) {
+			courses, students := backend.open(t)
+
+			math, err := models.NewCourse("Math")
+			if err != nil {
+				t.Fatalf("[%s][Create] failed to build course: %v", courseRepoTestPrefix, err)
+			}
+
+			id, err := courses.Create(math)
+			if err != nil || id != math.ID {
+				t.Fatalf("[%s][Create] unexpected result: id=%s err=%v", courseRepoTestPrefix, id, err)
+			}
+
+			dup, err := models.NewCourse("math")
+			if err != nil {
+				t.Fatalf("[%s][Create] failed to build course: %v", courseRepoTestPrefix, err)
+			}
+
+			if _, err := courses.Create(dup); !errors.Is(err, domainRepos.ErrCourseAlreadyExists) {
+				t.Fatalf(
+					"[%s][Create(duplicate)] want ErrCourseAlreadyExists, got=%v",
+					courseRepoTestPrefix,
+					err,
+				)
+			}
+
+			got, err := courses.GetByID(math.ID)
+			if err != nil || got.Name != "Math" {
+				t.Fatalf("[%s][GetByID] unexpected result: %+v err=%v", courseRepoTestPrefix, got, err)
+			}
+
+			if _, err := courses.GetByID(dup.ID); !errors.Is(err, domainRepos.ErrCourseNotFound) {
+				t.Fatalf("[%s][GetByID(missing)] want ErrCourseNotFound, got=%v", courseRepoTestPrefix, err)
+			}
+
+			list, err := courses.List()
+			if err != nil || len(list) != 2 {
+				t.Fatalf("[%s][List] unexpected result: %+v err=%v", courseRepoTestPrefix, list, err)
+			}
+
+			if list[0].ID != math.ID || list[1].ID != models.UnassignedCourseID {
+				t.Fatalf("[%s][List] must be sorted by name: %+v", courseRepoTestPrefix, list)
+			}
+
+			st, err := models.NewStudentBuilder().
+				SetName("Mikhail").
+				SetSurname("Gunin").
+				SetAge(19).
+				SetGrades(models.NewGrades(math.ID, time.Now().UTC(), 90)).
+				Build()
+			if err != nil {
+				t.Fatalf("[%s][Grades] failed to build student: %v", courseRepoTestPrefix, err)
+			}
+
+			if _, err := students.Create(st); err != nil {
+				t.Fatalf("[%s][Grades] failed to create student: %v", courseRepoTestPrefix, err)
+			}
+
+			if err := students.AddGrades(st.ID, unassignedGrades(70)...); err != nil {
+				t.Fatalf("[%s][Grades] failed to add grades: %v", courseRepoTestPrefix, err)
+			}
+
+			back, err := students.GetByID(st.ID)
+			if err != nil || len(back.Grades) != 2 {
+				t.Fatalf("[%s][Grades] unexpected student: %+v err=%v", courseRepoTestPrefix, back, err)
+			}
+
+			if back.Grades[0].CourseID != math.ID || back.Grades[1].CourseID != models.UnassignedCourseID {
+				t.Fatalf("[%s][Grades] course ids mismatch: %+v", courseRepoTestPrefix, back.Grades)
+			}
+
+			if !back.Grades[0].Date.Equal(st.Grades[0].Date) || !back.Grades[1].Date.IsZero() {
+				t.Fatalf("[%s][Grades] dates mismatch: %+v", courseRepoTestPrefix, back.Grades)
+			}
+		})
+	}
+}
+
+func TestCourseStorage_PersistsCourses(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", courseRepoTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s] failed to init cipher: %v", courseRepoTestPrefix, err)
+	}
+
+	path := persisters.CoursesPath(filepath.Join(t.TempDir(), "students.json"))
+
+	repo, err := repositories.NewCourseStorageWithPersister(persisters.NewJSONCoursePersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][Persist] failed to create course storage: %v", courseRepoTestPrefix, err)
+	}
+
+	history, err := models.NewCourse("History")
+	if err != nil {
+		t.Fatalf("[%s][Persist] failed to build course: %v", courseRepoTestPrefix, err)
+	}
+
+	if _, err := repo.Create(history); err != nil {
+		t.Fatalf("[%s][Persist] failed to create course: %v", courseRepoTestPrefix, err)
+	}
+
+	reopened, err := repositories.NewCourseStorageWithPersister(persisters.NewJSONCoursePersister(path, cipher))
+	if err != nil {
+		t.Fatalf("[%s][Persist] failed to reopen course storage: %v", courseRepoTestPrefix, err)
+	}
+
+	got, err := reopened.GetByID(history.ID)
+	if err != nil || got.Name != "History" {
+		t.Fatalf("[%s][Persist] unexpected course after reopen: %+v err=%v", courseRepoTestPrefix, got, err)
+	}
+
+	if _, err := reopened.GetByID(models.UnassignedCourseID); err != nil {
+		t.Fatalf("[%s][Persist] unassigned course must always exist: %v", courseRepoTestPrefix, err)
+	}
+}
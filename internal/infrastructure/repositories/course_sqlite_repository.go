@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type SQLiteCourseStorage struct {
+	db *sql.DB
+}
+
+func NewSQLiteCourseStorage(db *sql.DB) *SQLiteCourseStorage {
+	return &SQLiteCourseStorage{
+		db: db,
+	}
+}
+
+func (s *SQLiteCourseStorage) Create(course *models.Course) (uuid.UUID, error) {
+	cp := course.Clone()
+
+	if err := validators.Validate.Struct(cp); err != nil {
+		return uuid.Nil, fmt.Errorf("input course is invalid: %w", err)
+	}
+
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin sqlite transaction: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var one int
+
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT 1 FROM courses WHERE id = ? OR name = ? COLLATE NOCASE`,
+		cp.ID.String(),
+		cp.Name,
+	).Scan(&one)
+
+	switch {
+	case err == nil:
+		return uuid.Nil, repositories.ErrCourseAlreadyExists
+	case !errors.Is(err, sql.ErrNoRows):
+		return uuid.Nil, fmt.Errorf("failed to check course existence: %w", err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO courses (id, name) VALUES (?, ?)`,
+		cp.ID.String(), cp.Name,
+	); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to insert course row: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit sqlite transaction: %w", err)
+	}
+
+	return cp.ID, nil
+}
+
+func (s *SQLiteCourseStorage) GetByID(id uuid.UUID) (*models.Course, error) {
+	if id == uuid.Nil {
+		return nil, repositories.ErrInvalidCourseID
+	}
+
+	courses, err := s.selectCourses(`WHERE id = ?`, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(courses) == 0 {
+		return nil, repositories.ErrCourseNotFound
+	}
+
+	return courses[0], nil
+}
+
+func (s *SQLiteCourseStorage) List() ([]*models.Course, error) {
+	return s.selectCourses(`ORDER BY name COLLATE BINARY`)
+}
+
+func (s *SQLiteCourseStorage) selectCourses(clause string, args ...any) ([]*models.Course, error) {
+	rows, err := s.db.QueryContext(
+		context.Background(),
+		`SELECT id, name FROM courses `+clause,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query courses: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var courses []*models.Course
+
+	for rows.Next() {
+		var (
+			rawID  string
+			course models.Course
+		)
+
+		if err := rows.Scan(&rawID, &course.Name); err != nil {
+			return nil, fmt.Errorf("failed to scan course row: %w", err)
+		}
+
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", repositories.ErrInvalidCourseID, err)
+		}
+
+		course.ID = id
+		courses = append(courses, &course)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate course rows: %w", err)
+	}
+
+	return courses, nil
+}
@@ -0,0 +1,161 @@
+package repositories
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+)
+
+// History reads the revisions of a persisted storage from the history file,
+// outside the storage lock. Only a storage without a history persister keeps
+// them in the history map.
+func (s *StudentStorage) History(id uuid.UUID) ([]models.StudentRevision, error) {
+	if id == uuid.Nil {
+		return nil, repositories.ErrInvalidStudentID
+	}
+
+	s.mu.RLock()
+
+	if _, ok := s.students[id]; !ok {
+		s.mu.RUnlock()
+
+		return nil, repositories.ErrStudentNotFound
+	}
+
+	stored := s.history[id]
+
+	s.mu.RUnlock()
+
+	if s.historyStore != nil {
+		var err error
+
+		stored, err = s.historyStore.Revisions(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load student history: %w", err)
+		}
+	}
+
+	revisions := make([]models.StudentRevision, 0, len(stored))
+
+	for _, rev := range stored {
+		if rev.Student == nil {
+			continue
+		}
+
+		rev.Student = rev.Student.Clone()
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, nil
+}
+
+// seedHistory gives a baseline revision to every loaded student that has
+// none, students stored before the history was kept would have no state to
+// look up otherwise. The baseline is dated when it is seeded, the earliest
+// moment the state is known for. Revisions left by students whose purge did
+// not reach the history file are dropped.
+func (s *StudentStorage) seedHistory() error {
+	known := make(map[uuid.UUID]struct{})
+
+	if s.historyStore != nil {
+		var err error
+
+		known, err = s.historyStore.Students()
+		if err != nil {
+			return fmt.Errorf("failed to load student history: %w", err)
+		}
+	}
+
+	var baseline []models.StudentRevision
+
+	now := time.Now()
+
+	for _, st := range s.orderedLocked() {
+		if _, ok := known[st.ID]; !ok {
+			baseline = append(baseline, models.NewStudentRevision(st, now))
+		}
+	}
+
+	if s.historyStore == nil {
+		for _, rev := range baseline {
+			s.history[rev.StudentID] = append(s.history[rev.StudentID], rev)
+		}
+
+		return nil
+	}
+
+	if err := s.historyStore.Append(baseline...); err != nil {
+		return fmt.Errorf("failed to seed student history: %w", err)
+	}
+
+	var orphans []uuid.UUID
+
+	for id := range known {
+		if _, ok := s.students[id]; !ok {
+			orphans = append(orphans, id)
+		}
+	}
+
+	if len(orphans) == 0 {
+		return nil
+	}
+
+	if err := s.historyStore.Forget(orphans...); err != nil {
+		return fmt.Errorf("failed to drop history of purged students: %w", err)
+	}
+
+	s.compactHistory()
+
+	return nil
+}
+
+// recordHistoryLocked keeps the revisions of a committed batch. The students
+// are already persisted at this point, so a failure to write the history is
+// only logged instead of failing the write. Purged students are only dropped
+// from the history index here, compactHistory removes their revisions from
+// the file once the storage lock is released.
+func (s *StudentStorage) recordHistoryLocked(revisions []models.StudentRevision, purged []uuid.UUID) {
+	if s.historyStore == nil {
+		for _, rev := range revisions {
+			s.history[rev.StudentID] = append(s.history[rev.StudentID], rev)
+		}
+
+		for _, id := range purged {
+			delete(s.history, id)
+		}
+
+		return
+	}
+
+	err := s.historyStore.Append(revisions...)
+	if err == nil && len(purged) > 0 {
+		err = s.historyStore.Forget(purged...)
+	}
+
+	if err != nil {
+		slog.Error(
+			"failed to persist student history",
+			slog.Any("error", err),
+		)
+	}
+}
+
+// compactHistory removes the revisions of purged students from the history
+// file. It must run without the storage lock, the file is rewritten whole.
+func (s *StudentStorage) compactHistory() {
+	if s.historyStore == nil {
+		return
+	}
+
+	if err := s.historyStore.Compact(); err != nil {
+		slog.Error(
+			"failed to compact student history",
+			slog.Any("error", err),
+		)
+	}
+}
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+)
+
+type fullNameKey struct {
+	name    string
+	surname string
+}
+
+type idSet map[uuid.UUID]struct{}
+
+// studentIndex maps names to student IDs. It is guarded by the storage mutex
+// and changed only together with the students map.
+type studentIndex struct {
+	byFullName map[fullNameKey]idSet
+	bySurname  map[string]idSet
+}
+
+func newStudentIndex() studentIndex {
+	return studentIndex{
+		byFullName: make(map[fullNameKey]idSet),
+		bySurname:  make(map[string]idSet),
+	}
+}
+
+func keyOf(st *models.Student) fullNameKey {
+	return fullNameKey{name: st.Name, surname: st.Surname}
+}
+
+// add skips students in the trash, so lookups by name never see them.
+func (idx studentIndex) add(st *models.Student) {
+	if st.Deleted() {
+		return
+	}
+
+	addID(idx.byFullName, keyOf(st), st.ID)
+	addID(idx.bySurname, st.Surname, st.ID)
+}
+
+func (idx studentIndex) remove(st *models.Student) {
+	removeID(idx.byFullName, keyOf(st), st.ID)
+	removeID(idx.bySurname, st.Surname, st.ID)
+}
+
+// fullNameTaken reports whether a student other than st already has its full name.
+func (idx studentIndex) fullNameTaken(st *models.Student) bool {
+	for id := range idx.byFullName[keyOf(st)] {
+		if id != st.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (idx studentIndex) surnamesWithPrefix(prefix string) idSet {
+	ids := make(idSet)
+
+	for surname, set := range idx.bySurname {
+		if !strings.HasPrefix(surname, prefix) {
+			continue
+		}
+
+		for id := range set {
+			ids[id] = struct{}{}
+		}
+	}
+
+	return ids
+}
+
+func addID[K comparable](m map[K]idSet, key K, id uuid.UUID) {
+	set, ok := m[key]
+	if !ok {
+		set = make(idSet)
+		m[key] = set
+	}
+
+	set[id] = struct{}{}
+}
+
+func removeID[K comparable](m map[K]idSet, key K, id uuid.UUID) {
+	set, ok := m[key]
+	if !ok {
+		return
+	}
+
+	delete(set, id)
+
+	if len(set) == 0 {
+		delete(m, key)
+	}
+}
+
+func duplicateFullNameError(st *models.Student) error {
+	return fmt.Errorf("%w: %s %s", repositories.ErrDuplicateFullName, st.Name, st.Surname)
+}
+
+// checkFullNameLocked enforces the unique full name option for a student
+// about to be stored. Renaming into a taken name is rejected, while keeping
+// a name that was already duplicated before the option was enabled is not.
+func (s *StudentStorage) checkFullNameLocked(st *models.Student) error {
+	if !s.uniqueFullName || st.Deleted() {
+		return nil
+	}
+
+	if prev, ok := s.students[st.ID]; ok && !prev.Deleted() && keyOf(prev) == keyOf(st) {
+		return nil
+	}
+
+	if s.index.fullNameTaken(st) {
+		return duplicateFullNameError(st)
+	}
+
+	return nil
+}
+
+// studentsLocked returns the stored students with the given IDs, not copies,
+// in creation order.
+func (s *StudentStorage) studentsLocked(ids idSet) []*models.Student {
+	students := make([]*models.Student, 0, len(ids))
+
+	for id := range ids {
+		students = append(students, s.students[id])
+	}
+
+	slices.SortFunc(students, func(a, b *models.Student) int {
+		return cmp.Compare(s.seq[a.ID], s.seq[b.ID])
+	})
+
+	return students
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import "github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+
+type StudentStorageOption func(*studentStorageOptions)
+
+type studentStorageOptions struct {
+	uniqueFullName bool
+	history        persisters.HistoryPersister
+}
+
+// WithUniqueFullName rejects writes that would give two students the same
+// name and surname. Duplicates stored before the option was enabled are kept.
+func WithUniqueFullName() StudentStorageOption {
+	return func(o *studentStorageOptions) {
+		o.uniqueFullName = true
+	}
+}
+
+// WithHistoryPersister keeps the revisions of an in-memory storage across
+// restarts. They are read from the file when asked for instead of being held
+// in memory. SQLite storage keeps them in its own table and ignores it.
+func WithHistoryPersister(p persisters.HistoryPersister) StudentStorageOption {
+	return func(o *studentStorageOptions) {
+		o.history = p
+	}
+}
+
+func applyStudentStorageOptions(opts []StudentStorageOption) studentStorageOptions {
+	var o studentStorageOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+)
+
+// noGradesAVG sorts students without grades below every real average.
+const noGradesAVG = -1
+
+func validateStudentQuery(q repositories.StudentQuery) error {
+	switch q.SortBy {
+	case "", repositories.SortByCreated, repositories.SortByName, repositories.SortBySurname,
+		repositories.SortByAge, repositories.SortByAVG:
+	default:
+		return fmt.Errorf("%w: unknown sort key %q", repositories.ErrInvalidStudentQuery, q.SortBy)
+	}
+
+	if q.Offset < 0 || q.Limit < 0 {
+		return fmt.Errorf("%w: offset and limit must not be negative", repositories.ErrInvalidStudentQuery)
+	}
+
+	return nil
+}
+
+func matchesStudentQuery(st *models.Student, q repositories.StudentQuery) bool {
+	if !strings.HasPrefix(st.Name, q.NamePrefix) || !strings.HasPrefix(st.Surname, q.SurnamePrefix) {
+		return false
+	}
+
+	if (q.MinAge != nil && st.Age < *q.MinAge) || (q.MaxAge != nil && st.Age > *q.MaxAge) {
+		return false
+	}
+
+	if q.HasGrades != nil && *q.HasGrades != (len(st.Grades) > 0) {
+		return false
+	}
+
+	if q.MinAVG == nil && q.MaxAVG == nil {
+		return true
+	}
+
+	if len(st.Grades) == 0 {
+		return false
+	}
+
+	avg := studentAVG(st, q.Averaging)
+
+	return (q.MinAVG == nil || avg >= *q.MinAVG) && (q.MaxAVG == nil || avg <= *q.MaxAVG)
+}
+
+// sortStudents expects students in creation order and keeps it for ties.
+func sortStudents(students []*models.Student, q repositories.StudentQuery) {
+	key, desc := q.SortBy, q.Desc
+
+	compare := func(a, b *models.Student) int {
+		switch key {
+		case repositories.SortByName:
+			return cmp.Compare(a.Name, b.Name)
+		case repositories.SortBySurname:
+			return cmp.Compare(a.Surname, b.Surname)
+		case repositories.SortByAge:
+			return cmp.Compare(a.Age, b.Age)
+		case repositories.SortByAVG:
+			return cmp.Compare(studentAVG(a, q.Averaging), studentAVG(b, q.Averaging))
+		default:
+			return 0
+		}
+	}
+
+	if desc && (key == "" || key == repositories.SortByCreated) {
+		slices.Reverse(students)
+
+		return
+	}
+
+	slices.SortStableFunc(students, func(a, b *models.Student) int {
+		if desc {
+			return compare(b, a)
+		}
+
+		return compare(a, b)
+	})
+}
+
+// sortByDeletedAt orders the trash most recently deleted first.
+func sortByDeletedAt(students []*models.Student) {
+	slices.SortStableFunc(students, func(a, b *models.Student) int {
+		return b.DeletedAt.Compare(*a.DeletedAt)
+	})
+}
+
+func pageStudents(students []*models.Student, offset, limit int) []*models.Student {
+	if offset >= len(students) {
+		return nil
+	}
+
+	students = students[offset:]
+
+	if limit > 0 && limit < len(students) {
+		students = students[:limit]
+	}
+
+	return students
+}
+
+func studentAVG(st *models.Student, averaging grading.Averaging) float64 {
+	if len(st.Grades) == 0 {
+		return noGradesAVG
+	}
+
+	return grading.Policy{Averaging: averaging}.Average(st.Grades)
+}
@@ -1,8 +1,12 @@
 package repositories
 
 import (
+	"cmp"
 	"fmt"
+	"log/slog"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -13,21 +17,35 @@ import (
 )
 
 type StudentStorage struct {
-	students  map[uuid.UUID]*models.Student
-	persister persisters.StudentPersister
-	mu        sync.RWMutex
+	students       map[uuid.UUID]*models.Student
+	seq            map[uuid.UUID]uint64
+	nextSeq        uint64
+	index          studentIndex
+	uniqueFullName bool
+	persister      persisters.StudentPersister
+	history        map[uuid.UUID][]models.StudentRevision
+	historyStore   persisters.HistoryPersister
+	mu             sync.RWMutex
 }
 
 func NewStudentStorageWithPersister(
 	p persisters.StudentPersister,
+	opts ...StudentStorageOption,
 ) (*StudentStorage, error) {
+	o := applyStudentStorageOptions(opts)
+
 	s := &StudentStorage{
-		students:  make(map[uuid.UUID]*models.Student),
-		persister: p,
+		students:       make(map[uuid.UUID]*models.Student),
+		seq:            make(map[uuid.UUID]uint64),
+		index:          newStudentIndex(),
+		uniqueFullName: o.uniqueFullName,
+		persister:      p,
+		history:        make(map[uuid.UUID][]models.StudentRevision),
+		historyStore:   o.history,
 	}
 
 	if p == nil {
-		return s, nil
+		return s, s.seedHistory()
 	}
 
 	sts, err := p.Load()
@@ -44,7 +62,24 @@ func NewStudentStorageWithPersister(
 			return nil, fmt.Errorf("failed to validate student from snapshot: %w", err)
 		}
 
-		s.students[st.ID] = st.Clone()
+		if prev, ok := s.students[st.ID]; ok {
+			s.index.remove(prev)
+		}
+
+		cp := st.Clone()
+
+		// Snapshots written before versioning carry no version.
+		if cp.Version == 0 {
+			cp.Version = 1
+		}
+
+		s.students[st.ID] = cp
+		s.index.add(cp)
+		s.track(st.ID)
+	}
+
+	if err := s.seedHistory(); err != nil {
+		return nil, err
 	}
 
 	return s, nil
@@ -57,30 +92,51 @@ func (s *StudentStorage) Create(student *models.Student) (uuid.UUID, error) {
 		return uuid.Nil, fmt.Errorf("input student is invalid: %w", err)
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	var id uuid.UUID
 
-	if _, ok := s.students[cp.ID]; ok {
-		return uuid.Nil, repositories.ErrStudentAlreadyExists
+	err := s.batch("create", func(tx *storageTx) error {
+		var err error
+
+		id, err = tx.create(cp)
+
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, err
 	}
 
-	s.students[cp.ID] = cp
+	return id, nil
+}
 
-	if s.persister != nil {
-		students := make([]*models.Student, 0, len(s.students))
+func (s *StudentStorage) CreateMany(students []*models.Student) ([]uuid.UUID, error) {
+	cps, err := cloneValidStudents(students)
+	if err != nil {
+		return nil, err
+	}
 
-		for _, st := range s.students {
-			students = append(students, st.Clone())
-		}
+	if len(cps) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(cps))
 
-		if err := s.persister.Save(students); err != nil {
-			delete(s.students, cp.ID)
+	err = s.batch("create-many", func(tx *storageTx) error {
+		for _, cp := range cps {
+			id, err := tx.create(cp)
+			if err != nil {
+				return err
+			}
 
-			return uuid.Nil, fmt.Errorf("persist student data after create failed: %w", err)
+			ids = append(ids, id)
 		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return cp.ID, nil
+	return ids, nil
 }
 
 func (s *StudentStorage) Update(student *models.Student) error {
@@ -90,63 +146,109 @@ func (s *StudentStorage) Update(student *models.Student) error {
 		return fmt.Errorf("input student is invalid: %w", err)
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.batch("update", func(tx *storageTx) error {
+		return tx.update(cp)
+	})
+}
 
-	prev, ok := s.students[cp.ID]
-	if !ok {
-		return repositories.ErrStudentNotFound
+func (s *StudentStorage) UpdateMany(students []*models.Student) error {
+	cps, err := cloneValidStudents(students)
+	if err != nil {
+		return err
 	}
 
-	s.students[cp.ID] = cp
+	return s.batch("update-many", func(tx *storageTx) error {
+		for _, cp := range cps {
+			if err := tx.update(cp); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
 
-	if s.persister != nil {
-		students := make([]*models.Student, 0, len(s.students))
+func (s *StudentStorage) DeleteByID(id uuid.UUID) error {
+	if id == uuid.Nil {
+		return repositories.ErrInvalidStudentID
+	}
+
+	return s.batch("delete", func(tx *storageTx) error {
+		return tx.DeleteByID(id)
+	})
+}
+
+func (s *StudentStorage) DeleteMany(ids []uuid.UUID) error {
+	if slices.Contains(ids, uuid.Nil) {
+		return repositories.ErrInvalidStudentID
+	}
 
-		for _, st := range s.students {
-			students = append(students, st.Clone())
+	return s.batch("delete-many", func(tx *storageTx) error {
+		for _, id := range ids {
+			if err := tx.DeleteByID(id); err != nil {
+				return err
+			}
 		}
 
-		if err := s.persister.Save(students); err != nil {
-			s.students[cp.ID] = prev
+		return nil
+	})
+}
+
+func (s *StudentStorage) ListDeleted() ([]*models.Student, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var deleted []*models.Student
 
-			return fmt.Errorf("persist student data after update failed: %w", err)
+	for _, st := range s.orderedLocked() {
+		if st.Deleted() {
+			deleted = append(deleted, st.Clone())
 		}
 	}
 
-	return nil
+	sortByDeletedAt(deleted)
+
+	return deleted, nil
 }
 
-func (s *StudentStorage) DeleteByID(id uuid.UUID) error {
+func (s *StudentStorage) Restore(id uuid.UUID) error {
 	if id == uuid.Nil {
 		return repositories.ErrInvalidStudentID
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	prev, ok := s.students[id]
-	if !ok {
-		return repositories.ErrStudentNotFound
-	}
-
-	delete(s.students, id)
+	return s.batch("restore", func(tx *storageTx) error {
+		return tx.restore(id)
+	})
+}
 
-	if s.persister != nil {
-		students := make([]*models.Student, 0, len(s.students))
+func (s *StudentStorage) PurgeDeleted(cutoff time.Time) ([]*models.Student, error) {
+	var purged []*models.Student
 
-		for _, st := range s.students {
-			students = append(students, st.Clone())
+	err := s.batch("purge", func(tx *storageTx) error {
+		for _, st := range s.orderedLocked() {
+			if st.Deleted() && !st.DeletedAt.After(cutoff) {
+				purged = append(purged, st.Clone())
+				tx.purge(st.ID)
+			}
 		}
 
-		if err := s.persister.Save(students); err != nil {
-			s.students[id] = prev
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			return fmt.Errorf("persist student data after delete failed: %w", err)
-		}
+	if len(purged) > 0 {
+		s.compactHistory()
 	}
 
-	return nil
+	return purged, nil
+}
+
+func (s *StudentStorage) Batch(fn func(tx repositories.StudentTx) error) error {
+	return s.batch("batch", func(tx *storageTx) error {
+		return fn(tx)
+	})
 }
 
 func (s *StudentStorage) GetByID(id uuid.UUID) (*models.Student, error) {
@@ -157,7 +259,7 @@ func (s *StudentStorage) GetByID(id uuid.UUID) (*models.Student, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	student, ok := s.students[id]
+	student, ok := s.active(id)
 	if !ok {
 		return nil, repositories.ErrStudentNotFound
 	}
@@ -180,57 +282,234 @@ func (s *StudentStorage) GetByFullName(
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for _, student := range s.students {
-		if student.Name == name && student.Surname == surname {
-			return student.Clone(), nil
-		}
+	found := s.studentsLocked(s.index.byFullName[fullNameKey{name: name, surname: surname}])
+	if len(found) == 0 {
+		return nil, repositories.ErrStudentNotFound
 	}
 
-	return nil, repositories.ErrStudentNotFound
+	return found[0].Clone(), nil
+}
+
+func (s *StudentStorage) SearchByName(query string) ([]*models.Student, error) {
+	q, err := normalizeSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ordered := s.activeLocked()
+
+	fullNames := make([]string, 0, len(ordered))
+	for _, st := range ordered {
+		fullNames = append(fullNames, st.Name+" "+st.Surname)
+	}
+
+	ranked := rankByName(q, fullNames)
+
+	students := make([]*models.Student, 0, len(ranked))
+	for _, i := range ranked {
+		students = append(students, ordered[i].Clone())
+	}
+
+	return students, nil
 }
 
 func (s *StudentStorage) List() ([]*models.Student, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	students := make([]*models.Student, 0, len(s.students))
-	for _, student := range s.students {
-		students = append(students, student.Clone())
+	students := s.activeLocked()
+
+	for i, st := range students {
+		students[i] = st.Clone()
 	}
 
 	return students, nil
 }
 
-func (s *StudentStorage) AddGrades(id uuid.UUID, grades ...int) error {
+func (s *StudentStorage) Query(q repositories.StudentQuery) (repositories.StudentPage, error) {
+	if err := validateStudentQuery(q); err != nil {
+		return repositories.StudentPage{}, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var candidates []*models.Student
+
+	if q.SurnamePrefix != "" {
+		candidates = s.studentsLocked(s.index.surnamesWithPrefix(q.SurnamePrefix))
+	} else {
+		candidates = s.activeLocked()
+	}
+
+	var matched []*models.Student
+
+	for _, st := range candidates {
+		if matchesStudentQuery(st, q) {
+			matched = append(matched, st)
+		}
+	}
+
+	sortStudents(matched, q)
+
+	page := repositories.StudentPage{
+		Students: []*models.Student{},
+		Total:    len(matched),
+	}
+
+	for _, st := range pageStudents(matched, q.Offset, q.Limit) {
+		page.Students = append(page.Students, st.Clone())
+	}
+
+	return page, nil
+}
+
+func (s *StudentStorage) AddGrades(id uuid.UUID, grades ...models.Grade) error {
+	return s.batch("add-grades", func(tx *storageTx) error {
+		return tx.AddGrades(id, grades...)
+	})
+}
+
+func (s *StudentStorage) batch(op string, fn func(tx *storageTx) error) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	current, ok := s.students[id]
+	tx := &storageTx{
+		s:    s,
+		undo: make(map[uuid.UUID]undoEntry),
+	}
+
+	committed := false
+
+	defer func() {
+		tx.done = true
+
+		// A panicking callback must not leave the maps half changed.
+		if r := recover(); r != nil {
+			if !committed {
+				tx.rollback()
+			}
+
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.rollback()
+
+		return err
+	}
+
+	if len(tx.entries) == 0 {
+		return nil
+	}
+
+	if err := s.persistLocked(tx.entries...); err != nil {
+		tx.rollback()
+
+		return fmt.Errorf("persist student data after %s failed: %w", op, err)
+	}
+
+	committed = true
+
+	s.recordHistoryLocked(tx.revisions, tx.purged)
+
+	return nil
+}
+
+func (s *StudentStorage) persistLocked(entries ...persisters.JournalEntry) error {
+	if s.persister == nil {
+		return nil
+	}
+
+	journal, ok := s.persister.(persisters.StudentJournal)
 	if !ok {
-		return repositories.ErrStudentNotFound
+		return s.persister.Save(s.snapshotLocked())
 	}
 
-	cp := current.Clone()
+	if err := journal.Append(entries...); err != nil {
+		return err
+	}
 
-	if err := cp.AddGrades(grades...); err != nil {
-		return fmt.Errorf("error while adding grades for student in storage: %w", err)
+	if journal.ShouldCompact() {
+		if err := journal.Save(s.snapshotLocked()); err != nil {
+			slog.Error(
+				"failed to compact students journal, it will be retried on next write",
+				slog.Any("error", err),
+			)
+		}
 	}
 
-	s.students[id] = cp
+	return nil
+}
 
-	if s.persister != nil {
-		students := make([]*models.Student, 0, len(s.students))
+func cloneValidStudents(students []*models.Student) ([]*models.Student, error) {
+	cps := make([]*models.Student, 0, len(students))
 
-		for _, st := range s.students {
-			students = append(students, st.Clone())
+	for i, st := range students {
+		if st == nil {
+			return nil, fmt.Errorf("input student #%d is nil: %w", i+1, repositories.ErrInvalidStudentSnapshot)
 		}
 
-		if err := s.persister.Save(students); err != nil {
-			s.students[id] = current
+		cp := st.Clone()
 
-			return fmt.Errorf("persist student data after add-grades failed: %w", err)
+		if err := validators.Validate.Struct(cp); err != nil {
+			return nil, fmt.Errorf("input student #%d is invalid: %w", i+1, err)
 		}
+
+		cps = append(cps, cp)
 	}
 
-	return nil
+	return cps, nil
+}
+
+func (s *StudentStorage) snapshotLocked() []*models.Student {
+	ordered := s.orderedLocked()
+
+	for i, st := range ordered {
+		ordered[i] = st.Clone()
+	}
+
+	return ordered
+}
+
+// orderedLocked returns the stored students, not copies, in creation order.
+func (s *StudentStorage) orderedLocked() []*models.Student {
+	students := make([]*models.Student, 0, len(s.students))
+
+	for _, st := range s.students {
+		students = append(students, st)
+	}
+
+	slices.SortFunc(students, func(a, b *models.Student) int {
+		return cmp.Compare(s.seq[a.ID], s.seq[b.ID])
+	})
+
+	return students
+}
+
+// activeLocked is orderedLocked without the students in the trash.
+func (s *StudentStorage) activeLocked() []*models.Student {
+	return slices.DeleteFunc(s.orderedLocked(), (*models.Student).Deleted)
+}
+
+func (s *StudentStorage) active(id uuid.UUID) (*models.Student, bool) {
+	st, ok := s.students[id]
+	if !ok || st.Deleted() {
+		return nil, false
+	}
+
+	return st, true
+}
+
+func (s *StudentStorage) track(id uuid.UUID) {
+	if _, ok := s.seq[id]; ok {
+		return
+	}
+
+	s.seq[id] = s.nextSeq
+	s.nextSeq++
 }
@@ -0,0 +1,386 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type countingPersister struct {
+	saves    int
+	failSave bool
+	last     []*models.Student
+}
+
+var errSaveFailed = errors.New("save failed")
+
+func (p *countingPersister) Save(students []*models.Student) error {
+	if p.failSave {
+		return errSaveFailed
+	}
+
+	p.saves++
+	p.last = students
+
+	return nil
+}
+
+func (p *countingPersister) Load() ([]*models.Student, error) {
+	return nil, nil
+}
+
+func TestRepository_CreateMany(t *testing.T) {
+	forEachBackend(t, testRepository_CreateMany)
+}
+
+func testRepository_CreateMany(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][CreateMany] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19, 90)
+	second := newTestStudent(t, "Alexander", "Gunin", 19, 70, 80)
+
+	ids, err := repo.CreateMany([]*models.Student{first, second})
+	if err != nil {
+		t.Fatalf("[%s][CreateMany] unexpected error: %v", repoImplTestPrefix, err)
+	}
+
+	if len(ids) != 2 || ids[0] != first.ID || ids[1] != second.ID {
+		t.Fatalf("[%s][CreateMany] unexpected ids: %v", repoImplTestPrefix, ids)
+	}
+
+	got, err := repo.GetByID(second.ID)
+	if err != nil || len(got.Grades) != 2 {
+		t.Fatalf("[%s][CreateMany] failed to read created student: %+v (err=%v)", repoImplTestPrefix, got, err)
+	}
+
+	third := newTestStudent(t, "Ivan", "Gunin", 19)
+	duplicate := newTestStudent(t, "Oleg", "Gunin", 19)
+	duplicate.ID = first.ID
+
+	if _, err := repo.CreateMany([]*models.Student{third, duplicate}); !errors.Is(
+		err,
+		domainRepos.ErrStudentAlreadyExists,
+	) {
+		t.Fatalf("[%s][CreateMany] want ErrStudentAlreadyExists, got %v", repoImplTestPrefix, err)
+	}
+
+	invalid := newTestStudent(t, "Petr", "Gunin", 19)
+	invalid.Name = "petr"
+
+	if _, err := repo.CreateMany([]*models.Student{third, invalid}); !validators.IsValidationError(err) {
+		t.Fatalf("[%s][CreateMany] want validation error, got %v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.GetByID(third.ID); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][CreateMany] failed batch must not leave students behind, err=%v", repoImplTestPrefix, err)
+	}
+
+	list, err := repo.List()
+	if err != nil || len(list) != 2 {
+		t.Fatalf("[%s][CreateMany] want 2 students after failed batches, got %d (err=%v)", repoImplTestPrefix, len(list), err)
+	}
+}
+
+func TestRepository_CreateMany_PersistsOnce(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][CreateMany_PersistsOnce] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	persister := &countingPersister{}
+
+	repo, err := repositories.NewStudentStorageWithPersister(persister)
+	if err != nil {
+		t.Fatalf("[%s][CreateMany_PersistsOnce] failed to init repository: %v", repoImplTestPrefix, err)
+	}
+
+	batch := make([]*models.Student, 0, 50)
+	for range 50 {
+		batch = append(batch, newTestStudent(t, "Mikhail", "Gunin", 19))
+	}
+
+	if _, err := repo.CreateMany(batch); err != nil {
+		t.Fatalf("[%s][CreateMany_PersistsOnce] unexpected error: %v", repoImplTestPrefix, err)
+	}
+
+	if persister.saves != 1 || len(persister.last) != 50 {
+		t.Fatalf(
+			"[%s][CreateMany_PersistsOnce] want one save of 50 students, got saves=%d students=%d",
+			repoImplTestPrefix,
+			persister.saves,
+			len(persister.last),
+		)
+	}
+
+	persister.failSave = true
+
+	if _, err := repo.CreateMany([]*models.Student{newTestStudent(t, "Ivan", "Gunin", 19)}); !errors.Is(err, errSaveFailed) {
+		t.Fatalf("[%s][CreateMany_PersistsOnce] want save error, got %v", repoImplTestPrefix, err)
+	}
+
+	list, err := repo.List()
+	if err != nil || len(list) != 50 {
+		t.Fatalf("[%s][CreateMany_PersistsOnce] failed save must roll back, got %d (err=%v)", repoImplTestPrefix, len(list), err)
+	}
+}
+
+func TestRepository_UpdateMany_DeleteMany(t *testing.T) {
+	forEachBackend(t, testRepository_UpdateMany_DeleteMany)
+}
+
+func testRepository_UpdateMany_DeleteMany(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][UpdateMany] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19, 90)
+	second := newTestStudent(t, "Alexander", "Gunin", 19)
+
+	if _, err := repo.CreateMany([]*models.Student{first, second}); err != nil {
+		t.Fatalf("[%s][UpdateMany] failed to seed students: %v", repoImplTestPrefix, err)
+	}
+
+	firstUpd := storedStudent(t, repo, first.ID)
+	firstUpd.Age = 20
+	firstUpd.Grades = unassignedGrades(100)
+
+	secondUpd := storedStudent(t, repo, second.ID)
+	secondUpd.Age = 21
+
+	missing := newTestStudent(t, "Ivan", "Gunin", 19)
+
+	if err := repo.UpdateMany([]*models.Student{firstUpd, missing}); !errors.Is(
+		err,
+		domainRepos.ErrStudentNotFound,
+	) {
+		t.Fatalf("[%s][UpdateMany] want ErrStudentNotFound, got %v", repoImplTestPrefix, err)
+	}
+
+	got, err := repo.GetByID(first.ID)
+	if err != nil || got.Age != 19 {
+		t.Fatalf("[%s][UpdateMany] failed batch must not update students: %+v (err=%v)", repoImplTestPrefix, got, err)
+	}
+
+	if err := repo.UpdateMany([]*models.Student{firstUpd, secondUpd}); err != nil {
+		t.Fatalf("[%s][UpdateMany] unexpected error: %v", repoImplTestPrefix, err)
+	}
+
+	got, err = repo.GetByID(first.ID)
+	if err != nil || got.Age != 20 || len(got.Grades) != 1 || got.Grades[0].Value != 100 {
+		t.Fatalf("[%s][UpdateMany] unexpected updated student: %+v (err=%v)", repoImplTestPrefix, got, err)
+	}
+
+	if err := repo.DeleteMany([]uuid.UUID{first.ID, missing.ID}); !errors.Is(
+		err,
+		domainRepos.ErrStudentNotFound,
+	) {
+		t.Fatalf("[%s][DeleteMany] want ErrStudentNotFound, got %v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.GetByID(first.ID); err != nil {
+		t.Fatalf("[%s][DeleteMany] failed batch must not delete students, err=%v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.DeleteMany([]uuid.UUID{first.ID, second.ID}); err != nil {
+		t.Fatalf("[%s][DeleteMany] unexpected error: %v", repoImplTestPrefix, err)
+	}
+
+	list, err := repo.List()
+	if err != nil || len(list) != 0 {
+		t.Fatalf("[%s][DeleteMany] want empty storage, got %d (err=%v)", repoImplTestPrefix, len(list), err)
+	}
+}
+
+func TestRepository_Batch(t *testing.T) {
+	forEachBackend(t, testRepository_Batch)
+}
+
+func testRepository_Batch(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Batch] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	existing := newTestStudent(t, "Mikhail", "Gunin", 19, 50)
+
+	if _, err := repo.Create(existing); err != nil {
+		t.Fatalf("[%s][Batch] failed to seed student: %v", repoImplTestPrefix, err)
+	}
+
+	created := newTestStudent(t, "Alexander", "Gunin", 19)
+	errAbort := errors.New("abort")
+
+	var leaked domainRepos.StudentTx
+
+	err := repo.Batch(func(tx domainRepos.StudentTx) error {
+		leaked = tx
+
+		if _, err := tx.Create(created); err != nil {
+			return err
+		}
+
+		if err := tx.AddGrades(existing.ID, unassignedGrades(100)...); err != nil {
+			return err
+		}
+
+		got, err := tx.GetByID(existing.ID)
+		if err != nil || len(got.Grades) != 2 {
+			t.Fatalf("[%s][Batch] tx must see its own writes: %+v (err=%v)", repoImplTestPrefix, got, err)
+		}
+
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("[%s][Batch] want abort error, got %v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.GetByID(created.ID); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Batch] aborted create must be rolled back, err=%v", repoImplTestPrefix, err)
+	}
+
+	got, err := repo.GetByID(existing.ID)
+	if err != nil || len(got.Grades) != 1 {
+		t.Fatalf("[%s][Batch] aborted add-grades must be rolled back: %+v (err=%v)", repoImplTestPrefix, got, err)
+	}
+
+	if _, err := leaked.GetByID(existing.ID); !errors.Is(err, domainRepos.ErrBatchClosed) {
+		t.Fatalf("[%s][Batch] finished tx must be closed, got %v", repoImplTestPrefix, err)
+	}
+
+	err = repo.Batch(func(tx domainRepos.StudentTx) error {
+		if _, err := tx.Create(created); err != nil {
+			return err
+		}
+
+		return tx.DeleteByID(existing.ID)
+	})
+	if err != nil {
+		t.Fatalf("[%s][Batch] unexpected error: %v", repoImplTestPrefix, err)
+	}
+
+	list, err := repo.List()
+	if err != nil || len(list) != 1 || list[0].ID != created.ID {
+		t.Fatalf("[%s][Batch] unexpected students after commit: %v (err=%v)", repoImplTestPrefix, list, err)
+	}
+}
+
+func TestRepository_Batch_RollsBackOnSaveFailure(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Batch_SaveFailure] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	persister := &countingPersister{}
+
+	repo, err := repositories.NewStudentStorageWithPersister(persister)
+	if err != nil {
+		t.Fatalf("[%s][Batch_SaveFailure] failed to init repository: %v", repoImplTestPrefix, err)
+	}
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19, 50)
+	second := newTestStudent(t, "Alexander", "Gunin", 19)
+
+	if _, err := repo.CreateMany([]*models.Student{first, second}); err != nil {
+		t.Fatalf("[%s][Batch_SaveFailure] failed to seed students: %v", repoImplTestPrefix, err)
+	}
+
+	persister.failSave = true
+
+	upd := storedStudent(t, repo, first.ID)
+	upd.Age = 30
+
+	if err := repo.UpdateMany([]*models.Student{upd}); !errors.Is(err, errSaveFailed) {
+		t.Fatalf("[%s][Batch_SaveFailure] UpdateMany want save error, got %v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.DeleteMany([]uuid.UUID{first.ID, second.ID}); !errors.Is(err, errSaveFailed) {
+		t.Fatalf("[%s][Batch_SaveFailure] DeleteMany want save error, got %v", repoImplTestPrefix, err)
+	}
+
+	err = repo.Batch(func(tx domainRepos.StudentTx) error {
+		if err := tx.AddGrades(first.ID, unassignedGrades(100)...); err != nil {
+			return err
+		}
+
+		return tx.DeleteByID(second.ID)
+	})
+	if !errors.Is(err, errSaveFailed) {
+		t.Fatalf("[%s][Batch_SaveFailure] Batch want save error, got %v", repoImplTestPrefix, err)
+	}
+
+	got, err := repo.GetByID(first.ID)
+	if err != nil || got.Age != 19 || len(got.Grades) != 1 {
+		t.Fatalf("[%s][Batch_SaveFailure] first student must be unchanged: %+v (err=%v)", repoImplTestPrefix, got, err)
+	}
+
+	if _, err := repo.GetByID(second.ID); err != nil {
+		t.Fatalf("[%s][Batch_SaveFailure] second student must be restored, err=%v", repoImplTestPrefix, err)
+	}
+
+	if persister.saves != 1 {
+		t.Fatalf("[%s][Batch_SaveFailure] want exactly one successful save, got %d", repoImplTestPrefix, persister.saves)
+	}
+}
+
+func TestRepository_Batch_RollsBackOnPanic(t *testing.T) {
+	forEachBackend(t, testRepository_Batch_RollsBackOnPanic)
+}
+
+func testRepository_Batch_RollsBackOnPanic(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Batch_Panic] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	existing := newTestStudent(t, "Mikhail", "Gunin", 19, 50)
+
+	if _, err := repo.Create(existing); err != nil {
+		t.Fatalf("[%s][Batch_Panic] failed to seed student: %v", repoImplTestPrefix, err)
+	}
+
+	created := newTestStudent(t, "Alexander", "Gunin", 19)
+
+	recovered := func() (r any) {
+		defer func() {
+			r = recover()
+		}()
+
+		_ = repo.Batch(func(tx domainRepos.StudentTx) error {
+			if _, err := tx.Create(created); err != nil {
+				return err
+			}
+
+			if err := tx.AddGrades(existing.ID, unassignedGrades(100)...); err != nil {
+				return err
+			}
+
+			panic("boom")
+		})
+
+		return nil
+	}()
+	if recovered != "boom" {
+		t.Fatalf("[%s][Batch_Panic] want the panic to propagate, got %v", repoImplTestPrefix, recovered)
+	}
+
+	if _, err := repo.GetByID(created.ID); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Batch_Panic] create must be rolled back, err=%v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.GetByFullName("Alexander", "Gunin"); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Batch_Panic] name index must be rolled back, err=%v", repoImplTestPrefix, err)
+	}
+
+	got, err := repo.GetByID(existing.ID)
+	if err != nil || len(got.Grades) != 1 {
+		t.Fatalf("[%s][Batch_Panic] add-grades must be rolled back: %+v (err=%v)", repoImplTestPrefix, got, err)
+	}
+
+	if _, err := repo.Create(created); err != nil {
+		t.Fatalf("[%s][Batch_Panic] storage must stay usable after a panic: %v", repoImplTestPrefix, err)
+	}
+}
@@ -0,0 +1,303 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/sqlite"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+func TestRepository_History(t *testing.T) {
+	forEachBackend(t, testRepository_History)
+}
+
+func testRepository_History(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][History] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+
+	id, err := repo.Create(st)
+	if err != nil {
+		t.Fatalf("[%s][History] failed to create student: %v", repoImplTestPrefix, err)
+	}
+
+	older := storedStudent(t, repo, id)
+	older.Age = 20
+
+	if err := repo.Update(older); err != nil {
+		t.Fatalf("[%s][History] failed to update student: %v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.AddGrades(id, unassignedGrades(90)...); err != nil {
+		t.Fatalf("[%s][History] failed to add grades: %v", repoImplTestPrefix, err)
+	}
+
+	errStop := errors.New("stop")
+
+	if err := repo.Batch(func(tx domainRepos.StudentTx) error {
+		if err := tx.AddGrades(id, unassignedGrades(10)...); err != nil {
+			return err
+		}
+
+		return errStop
+	}); !errors.Is(err, errStop) {
+		t.Fatalf("[%s][History] want batch error, got=%v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.DeleteByID(id); err != nil {
+		t.Fatalf("[%s][History] failed to delete student: %v", repoImplTestPrefix, err)
+	}
+
+	revisions, err := repo.History(id)
+	if err != nil {
+		t.Fatalf("[%s][History] failed to get history of trashed student: %v", repoImplTestPrefix, err)
+	}
+
+	if len(revisions) != 4 {
+		t.Fatalf("[%s][History] want 4 revisions, got=%d: %+v", repoImplTestPrefix, len(revisions), revisions)
+	}
+
+	for i, rev := range revisions {
+		if rev.StudentID != id || rev.Version != i+1 || rev.Student.Version != i+1 {
+			t.Fatalf("[%s][History] revision #%d: unexpected version or id: %+v", repoImplTestPrefix, i, rev)
+		}
+
+		if i > 0 && rev.At.Before(revisions[i-1].At) {
+			t.Fatalf("[%s][History] revisions must be ordered from the oldest one", repoImplTestPrefix)
+		}
+	}
+
+	if revisions[0].Student.Age != 19 || revisions[1].Student.Age != 20 || len(revisions[1].Student.Grades) != 0 {
+		t.Fatalf("[%s][History] update revisions do not keep their state: %+v", repoImplTestPrefix, revisions[:2])
+	}
+
+	if len(revisions[2].Student.Grades) != 1 || !revisions[3].Student.Deleted() {
+		t.Fatalf("[%s][History] unexpected grades or trash revisions: %+v", repoImplTestPrefix, revisions[2:])
+	}
+
+	revisions[0].Student.Age = 99
+
+	again, err := repo.History(id)
+	if err != nil || again[0].Student.Age != 19 {
+		t.Fatalf("[%s][History] history must return copies: %+v err=%v", repoImplTestPrefix, again, err)
+	}
+
+	if _, err := repo.History(uuid.New()); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][History] want ErrStudentNotFound for unknown student, got=%v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.PurgeDeleted(time.Now()); err != nil {
+		t.Fatalf("[%s][History] failed to purge trash: %v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.History(id); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][History] purged student must lose its history, got=%v", repoImplTestPrefix, err)
+	}
+}
+
+func TestRepository_HistorySurvivesReload(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][HistoryReload] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][HistoryReload] failed to init cipher: %v", repoImplTestPrefix, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	open := func() *repositories.StudentStorage {
+		repo, err := repositories.NewStudentStorageWithPersister(
+			persisters.NewJSONStudentPersister(path, cipher),
+			repositories.WithHistoryPersister(
+				persisters.NewRecordHistoryPersister(persisters.HistoryPath(path), cipher),
+			),
+		)
+		if err != nil {
+			t.Fatalf("[%s][HistoryReload] failed to open repository: %v", repoImplTestPrefix, err)
+		}
+
+		return repo
+	}
+
+	repo := open()
+
+	kept := newTestStudent(t, "Mikhail", "Gunin", 19)
+	purged := newTestStudent(t, "Alexander", "Gunin", 20)
+
+	if _, err := repo.CreateMany([]*models.Student{kept, purged}); err != nil {
+		t.Fatalf("[%s][HistoryReload] failed to create students: %v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.AddGrades(kept.ID, unassignedGrades(75)...); err != nil {
+		t.Fatalf("[%s][HistoryReload] failed to add grades: %v", repoImplTestPrefix, err)
+	}
+
+	reopened := open()
+
+	revisions, err := reopened.History(kept.ID)
+	if err != nil || len(revisions) != 2 || len(revisions[1].Student.Grades) != 1 {
+		t.Fatalf("[%s][HistoryReload] unexpected history after reload: %+v err=%v", repoImplTestPrefix, revisions, err)
+	}
+
+	if err := reopened.DeleteByID(purged.ID); err != nil {
+		t.Fatalf("[%s][HistoryReload] failed to delete student: %v", repoImplTestPrefix, err)
+	}
+
+	if _, err := reopened.PurgeDeleted(time.Now()); err != nil {
+		t.Fatalf("[%s][HistoryReload] failed to purge trash: %v", repoImplTestPrefix, err)
+	}
+
+	stored, err := persisters.NewRecordHistoryPersister(persisters.HistoryPath(path), cipher).Load()
+	if err != nil {
+		t.Fatalf("[%s][HistoryReload] failed to load history file: %v", repoImplTestPrefix, err)
+	}
+
+	for _, rev := range stored {
+		if rev.StudentID != kept.ID {
+			t.Fatalf("[%s][HistoryReload] purged student revisions must leave the file: %+v", repoImplTestPrefix, rev)
+		}
+	}
+
+	if len(stored) != 2 {
+		t.Fatalf("[%s][HistoryReload] want 2 stored revisions, got=%d", repoImplTestPrefix, len(stored))
+	}
+}
+
+func TestRepository_HistorySeedsStoredStudents(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to init cipher: %v", repoImplTestPrefix, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+	st.Version = 3
+
+	if err := persisters.NewJSONStudentPersister(path, cipher).Save([]*models.Student{st}); err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to save students snapshot: %v", repoImplTestPrefix, err)
+	}
+
+	open := func() *repositories.StudentStorage {
+		repo, err := repositories.NewStudentStorageWithPersister(
+			persisters.NewJSONStudentPersister(path, cipher),
+			repositories.WithHistoryPersister(
+				persisters.NewRecordHistoryPersister(persisters.HistoryPath(path), cipher),
+			),
+		)
+		if err != nil {
+			t.Fatalf("[%s][HistorySeed] failed to open repository: %v", repoImplTestPrefix, err)
+		}
+
+		return repo
+	}
+
+	open()
+
+	revisions, err := open().History(st.ID)
+	if err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to get history: %v", repoImplTestPrefix, err)
+	}
+
+	if len(revisions) != 1 || revisions[0].Version != 3 || revisions[0].Student.Age != 19 {
+		t.Fatalf("[%s][HistorySeed] want one baseline revision, got=%+v", repoImplTestPrefix, revisions)
+	}
+
+	if _, ok := models.RevisionAt(revisions, time.Now()); !ok {
+		t.Fatalf("[%s][HistorySeed] baseline revision must answer lookups from now on", repoImplTestPrefix)
+	}
+}
+
+func TestSQLiteRepository_HistorySeedsStoredStudents(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	ctx := context.Background()
+
+	db, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "students.db"))
+	if err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to open sqlite database: %v", repoImplTestPrefix, err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	repo := repositories.NewSQLiteStudentStorage(db)
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+
+	grades := models.NewGrades(models.UnassignedCourseID, time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC), 90)
+	grades[0].Author = "dean"
+	grades[0].Comment = "oral exam"
+	grades[0].Weight = 2
+
+	st.Grades = append(grades, unassignedGrades(75)...)
+
+	id, err := repo.Create(st)
+	if err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to create student: %v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.DeleteByID(id); err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to delete student: %v", repoImplTestPrefix, err)
+	}
+
+	// Students stored before the revisions table existed have no revisions.
+	if _, err := db.ExecContext(ctx, `DELETE FROM student_revisions`); err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to clear revisions: %v", repoImplTestPrefix, err)
+	}
+
+	migrations, err := sqlite.Migrations()
+	if err != nil {
+		t.Fatalf("[%s][HistorySeed] failed to load migrations: %v", repoImplTestPrefix, err)
+	}
+
+	for _, m := range migrations {
+		if m.Name != "seed_student_revisions" {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, m.SQL); err != nil {
+			t.Fatalf("[%s][HistorySeed] failed to seed revisions: %v", repoImplTestPrefix, err)
+		}
+	}
+
+	revisions, err := repo.History(id)
+	if err != nil || len(revisions) != 1 {
+		t.Fatalf("[%s][HistorySeed] want one baseline revision, got=%+v err=%v", repoImplTestPrefix, revisions, err)
+	}
+
+	trashed, err := repo.ListDeleted()
+	if err != nil || len(trashed) != 1 {
+		t.Fatalf("[%s][HistorySeed] failed to list trash: %+v err=%v", repoImplTestPrefix, trashed, err)
+	}
+
+	want, _ := json.Marshal(trashed[0])
+	got, _ := json.Marshal(revisions[0].Student)
+
+	if string(got) != string(want) || revisions[0].Version != trashed[0].Version {
+		t.Fatalf("[%s][HistorySeed] baseline must hold the stored state:\n got=%s\nwant=%s", repoImplTestPrefix, got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package repositories_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+func expectFullName(t *testing.T, repo domainRepos.StudentRepository, name, surname string, want *models.Student) {
+	t.Helper()
+
+	got, err := repo.GetByFullName(name, surname)
+
+	switch {
+	case want == nil && !errors.Is(err, domainRepos.ErrStudentNotFound):
+		t.Fatalf("[%s][Index] %s %s: want ErrStudentNotFound, got=%v", repoImplTestPrefix, name, surname, err)
+	case want != nil && err != nil:
+		t.Fatalf("[%s][Index] %s %s: unexpected error: %v", repoImplTestPrefix, name, surname, err)
+	case want != nil && got.ID != want.ID:
+		t.Fatalf("[%s][Index] %s %s: got id=%s want=%s", repoImplTestPrefix, name, surname, got.ID, want.ID)
+	}
+}
+
+func TestRepository_FullNameIndexFollowsWrites(t *testing.T) {
+	forEachBackend(t, testRepository_FullNameIndexFollowsWrites)
+}
+
+func testRepository_FullNameIndexFollowsWrites(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Index] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	first := newTestStudent(t, "Mikhail", "Gunin", 19)
+	second := newTestStudent(t, "Mikhail", "Gunin", 21)
+
+	if _, err := repo.CreateMany([]*models.Student{first, second}); err != nil {
+		t.Fatalf("[%s][Index] failed to create students: %v", repoImplTestPrefix, err)
+	}
+
+	expectFullName(t, repo, "Mikhail", "Gunin", first)
+
+	renamed := storedStudent(t, repo, first.ID)
+	renamed.Name = "Alexander"
+
+	if err := repo.Update(renamed); err != nil {
+		t.Fatalf("[%s][Index] failed to rename student: %v", repoImplTestPrefix, err)
+	}
+
+	expectFullName(t, repo, "Alexander", "Gunin", first)
+	expectFullName(t, repo, "Mikhail", "Gunin", second)
+
+	errStop := errors.New("stop")
+
+	err := repo.Batch(func(tx domainRepos.StudentTx) error {
+		back, err := tx.GetByID(second.ID)
+		if err != nil {
+			return err
+		}
+
+		back.Surname = "Petrov"
+
+		if err := tx.Update(back); err != nil {
+			return err
+		}
+
+		if err := tx.DeleteByID(first.ID); err != nil {
+			return err
+		}
+
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("[%s][Index] want batch error, got=%v", repoImplTestPrefix, err)
+	}
+
+	expectFullName(t, repo, "Alexander", "Gunin", first)
+	expectFullName(t, repo, "Mikhail", "Gunin", second)
+	expectFullName(t, repo, "Mikhail", "Petrov", nil)
+
+	if err := repo.DeleteByID(first.ID); err != nil {
+		t.Fatalf("[%s][Index] failed to delete student: %v", repoImplTestPrefix, err)
+	}
+
+	expectFullName(t, repo, "Alexander", "Gunin", nil)
+
+	page, err := repo.Query(domainRepos.StudentQuery{SurnamePrefix: "Gun"})
+	if err != nil {
+		t.Fatalf("[%s][Index] failed to query by surname: %v", repoImplTestPrefix, err)
+	}
+
+	if page.Total != 1 || page.Students[0].ID != second.ID {
+		t.Fatalf("[%s][Index] unexpected surname query result: %+v", repoImplTestPrefix, page)
+	}
+}
+
+func TestRepository_UniqueFullName(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Unique] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	for _, backend := range repositoryBackends() {
+		t.Run(fmt.Sprintf("[%s]-unique-%s", repoImplTestPrefix, backend.name), func(t *testing.T) {
+			repo := backend.open(t, repositories.WithUniqueFullName())
+
+			mikhail := newTestStudent(t, "Mikhail", "Gunin", 19)
+			alexander := newTestStudent(t, "Alexander", "Gunin", 20)
+
+			if _, err := repo.CreateMany([]*models.Student{mikhail, alexander}); err != nil {
+				t.Fatalf("[%s][Unique] failed to create students: %v", repoImplTestPrefix, err)
+			}
+
+			if _, err := repo.Create(newTestStudent(t, "Mikhail", "Gunin", 30)); !errors.Is(
+				err,
+				domainRepos.ErrDuplicateFullName,
+			) {
+				t.Fatalf("[%s][Unique] want ErrDuplicateFullName on create, got=%v", repoImplTestPrefix, err)
+			}
+
+			renamed := storedStudent(t, repo, alexander.ID)
+			renamed.Name = "Mikhail"
+
+			if err := repo.Update(renamed); !errors.Is(err, domainRepos.ErrDuplicateFullName) {
+				t.Fatalf("[%s][Unique] want ErrDuplicateFullName on rename, got=%v", repoImplTestPrefix, err)
+			}
+
+			expectFullName(t, repo, "Alexander", "Gunin", alexander)
+
+			older := storedStudent(t, repo, mikhail.ID)
+			older.Age = 20
+
+			if err := repo.Update(older); err != nil {
+				t.Fatalf("[%s][Unique] keeping the name must be allowed, got=%v", repoImplTestPrefix, err)
+			}
+
+			twins := []*models.Student{
+				newTestStudent(t, "Anna", "Petrova", 22),
+				newTestStudent(t, "Anna", "Petrova", 22),
+			}
+
+			if _, err := repo.CreateMany(twins); !errors.Is(err, domainRepos.ErrDuplicateFullName) {
+				t.Fatalf("[%s][Unique] want ErrDuplicateFullName inside batch, got=%v", repoImplTestPrefix, err)
+			}
+
+			expectFullName(t, repo, "Anna", "Petrova", nil)
+
+			if err := repo.DeleteByID(mikhail.ID); err != nil {
+				t.Fatalf("[%s][Unique] failed to delete student: %v", repoImplTestPrefix, err)
+			}
+
+			if err := repo.Update(renamed); err != nil {
+				t.Fatalf("[%s][Unique] name must be free after delete, got=%v", repoImplTestPrefix, err)
+			}
+		})
+	}
+}
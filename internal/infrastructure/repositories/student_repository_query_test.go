@@ -0,0 +1,414 @@
+package repositories_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type queryCase struct {
+	name      string
+	query     domainRepos.StudentQuery
+	wantNames []string
+	wantTotal int
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func seedQueryStudents(t *testing.T, repo domainRepos.StudentRepository) {
+	t.Helper()
+
+	seed := []struct {
+		name, surname string
+		age           int
+		grades        []int
+	}{
+		{"Mikhail", "Gunin", 19, []int{60, 80}},
+		{"Anna", "Petrova", 22, []int{100}},
+		{"Boris", "Gusev", 19, nil},
+		{"Maria", "Ivanova", 25, []int{50, 70, 90}},
+		{"Alexander", "Gunin", 20, []int{95, 85}},
+	}
+
+	for _, s := range seed {
+		st, err := models.NewStudentBuilder().
+			SetName(s.name).
+			SetSurname(s.surname).
+			SetAge(s.age).
+			SetGrades(unassignedGrades(s.grades...)).
+			Build()
+		if err != nil {
+			t.Fatalf("[%s][Query] failed to build student: %v", repoImplTestPrefix, err)
+		}
+
+		if _, err := repo.Create(st); err != nil {
+			t.Fatalf("[%s][Query] failed to create student: %v", repoImplTestPrefix, err)
+		}
+	}
+}
+
+func TestRepository_Query(t *testing.T) {
+	forEachBackend(t, testRepository_Query)
+}
+
+func testRepository_Query(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Query] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	seedQueryStudents(t, repo)
+
+	tests := []queryCase{
+		{
+			name:      "creation order by default",
+			wantNames: []string{"Mikhail", "Anna", "Boris", "Maria", "Alexander"},
+			wantTotal: 5,
+		},
+		{
+			name:      "creation order descending",
+			query:     domainRepos.StudentQuery{SortBy: domainRepos.SortByCreated, Desc: true},
+			wantNames: []string{"Alexander", "Maria", "Boris", "Anna", "Mikhail"},
+			wantTotal: 5,
+		},
+		{
+			name:      "surname prefix keeps ties in creation order",
+			query:     domainRepos.StudentQuery{SurnamePrefix: "Gu", SortBy: domainRepos.SortBySurname},
+			wantNames: []string{"Mikhail", "Alexander", "Boris"},
+			wantTotal: 3,
+		},
+		{
+			name:      "prefix is case-sensitive",
+			query:     domainRepos.StudentQuery{NamePrefix: "m"},
+			wantTotal: 0,
+		},
+		{
+			name:      "age range sorted by age descending",
+			query:     domainRepos.StudentQuery{MinAge: ptr(19), MaxAge: ptr(22), SortBy: domainRepos.SortByAge, Desc: true},
+			wantNames: []string{"Anna", "Alexander", "Mikhail", "Boris"},
+			wantTotal: 4,
+		},
+		{
+			name:      "average range excludes students without grades",
+			query:     domainRepos.StudentQuery{MinAVG: ptr(70.0), MaxAVG: ptr(90.0), SortBy: domainRepos.SortByAVG},
+			wantNames: []string{"Mikhail", "Maria", "Alexander"},
+			wantTotal: 3,
+		},
+		{
+			name:      "students without grades sort last by average descending",
+			query:     domainRepos.StudentQuery{SortBy: domainRepos.SortByAVG, Desc: true},
+			wantNames: []string{"Anna", "Alexander", "Mikhail", "Maria", "Boris"},
+			wantTotal: 5,
+		},
+		{
+			name:      "without grades",
+			query:     domainRepos.StudentQuery{HasGrades: ptr(false)},
+			wantNames: []string{"Boris"},
+			wantTotal: 1,
+		},
+		{
+			name:      "page by name",
+			query:     domainRepos.StudentQuery{SortBy: domainRepos.SortByName, Offset: 1, Limit: 2},
+			wantNames: []string{"Anna", "Boris"},
+			wantTotal: 5,
+		},
+		{
+			name:      "offset past the end",
+			query:     domainRepos.StudentQuery{Offset: 10, Limit: 2},
+			wantTotal: 5,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-query-%s-№%d", repoImplTestPrefix, tc.name, i+1), func(t *testing.T) {
+			page, err := repo.Query(tc.query)
+			if err != nil {
+				t.Fatalf("[%s][Query] unexpected error: %v", repoImplTestPrefix, err)
+			}
+
+			names := make([]string, 0, len(page.Students))
+			for _, st := range page.Students {
+				names = append(names, st.Name)
+			}
+
+			if page.Total != tc.wantTotal || !slices.Equal(names, tc.wantNames) {
+				t.Fatalf(
+					"[%s][Query] got names=%v total=%d, want names=%v total=%d",
+					repoImplTestPrefix,
+					names,
+					page.Total,
+					tc.wantNames,
+					tc.wantTotal,
+				)
+			}
+		})
+	}
+
+	for _, q := range []domainRepos.StudentQuery{{SortBy: "grade"}, {Offset: -1}, {Limit: -1}} {
+		if _, err := repo.Query(q); !errors.Is(err, domainRepos.ErrInvalidStudentQuery) {
+			t.Fatalf("[%s][Query] want ErrInvalidStudentQuery for %+v, got=%v", repoImplTestPrefix, q, err)
+		}
+	}
+}
+
+func TestStudentStorage_KeepsCreationOrderAcrossReload(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Order] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Order] failed to init cipher: %v", repoImplTestPrefix, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	repo, err := repositories.NewStudentStorageWithPersister(
+		persisters.NewJSONStudentPersister(path, cipher),
+	)
+	if err != nil {
+		t.Fatalf("[%s][Order] failed to create repository: %v", repoImplTestPrefix, err)
+	}
+
+	seedQueryStudents(t, repo)
+
+	before, err := repo.List()
+	if err != nil {
+		t.Fatalf("[%s][Order] failed to list students: %v", repoImplTestPrefix, err)
+	}
+
+	reloaded, err := repositories.NewStudentStorageWithPersister(
+		persisters.NewJSONStudentPersister(path, cipher),
+	)
+	if err != nil {
+		t.Fatalf("[%s][Order] failed to reload repository: %v", repoImplTestPrefix, err)
+	}
+
+	after, err := reloaded.List()
+	if err != nil {
+		t.Fatalf("[%s][Order] failed to list reloaded students: %v", repoImplTestPrefix, err)
+	}
+
+	if len(before) != len(after) {
+		t.Fatalf("[%s][Order] length mismatch: before=%d after=%d", repoImplTestPrefix, len(before), len(after))
+	}
+
+	for i := range before {
+		if before[i].ID != after[i].ID {
+			t.Fatalf("[%s][Order] student #%d moved after reload", repoImplTestPrefix, i+1)
+		}
+	}
+}
+
+func TestRepository_SearchByName(t *testing.T) {
+	forEachBackend(t, testRepository_SearchByName)
+}
+
+func testRepository_SearchByName(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][SearchByName] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	seedQueryStudents(t, repo)
+
+	tests := []struct {
+		query     string
+		wantNames []string
+	}{
+		{"gunin", []string{"Mikhail Gunin", "Alexander Gunin"}},
+		{"MIK", []string{"Mikhail Gunin"}},
+		{"a pet", []string{"Anna Petrova"}},
+		{"Mikhial Gunin", []string{"Mikhail Gunin"}},
+		{"petorva", []string{"Anna Petrova"}},
+		{"gunin mikhail", []string{"Mikhail Gunin"}},
+		{"zzz", []string{}},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-search-%s-№%d", repoImplTestPrefix, tc.query, i+1), func(t *testing.T) {
+			found, err := repo.SearchByName(tc.query)
+			if err != nil {
+				t.Fatalf("[%s][SearchByName] unexpected error: %v", repoImplTestPrefix, err)
+			}
+
+			names := make([]string, 0, len(found))
+			for _, st := range found {
+				names = append(names, st.Name+" "+st.Surname)
+			}
+
+			if !slices.Equal(names, tc.wantNames) {
+				t.Fatalf(
+					"[%s][SearchByName] query %q: got=%v want=%v",
+					repoImplTestPrefix,
+					tc.query,
+					names,
+					tc.wantNames,
+				)
+			}
+		})
+	}
+
+	for _, q := range []string{"", "   ", strings.Repeat("a", 129)} {
+		if _, err := repo.SearchByName(q); !errors.Is(err, domainRepos.ErrInvalidStudentQuery) {
+			t.Fatalf("[%s][SearchByName] want ErrInvalidStudentQuery for %q, got=%v", repoImplTestPrefix, q, err)
+		}
+	}
+}
+
+func TestRepository_SearchByName_ManyMatches(t *testing.T) {
+	forEachBackend(t, testRepository_SearchByName_ManyMatches)
+}
+
+// testRepository_SearchByName_ManyMatches matches more students than SQLite
+// takes bound variables in one statement, 32766 in current builds.
+func testRepository_SearchByName_ManyMatches(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][SearchMany] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	const n = 33000
+
+	students := make([]*models.Student, 0, n)
+
+	for range n {
+		st, err := models.NewStudentBuilder().
+			SetName("Mikhail").
+			SetSurname("Gunin").
+			SetAge(19).
+			SetGrades(unassignedGrades(70)).
+			Build()
+		if err != nil {
+			t.Fatalf("[%s][SearchMany] failed to build student: %v", repoImplTestPrefix, err)
+		}
+
+		students = append(students, st)
+	}
+
+	if _, err := repo.CreateMany(students); err != nil {
+		t.Fatalf("[%s][SearchMany] failed to create students: %v", repoImplTestPrefix, err)
+	}
+
+	found, err := repo.SearchByName("gunin")
+	if err != nil {
+		t.Fatalf("[%s][SearchMany] unexpected error: %v", repoImplTestPrefix, err)
+	}
+
+	if len(found) != n {
+		t.Fatalf("[%s][SearchMany] got=%d students want=%d", repoImplTestPrefix, len(found), n)
+	}
+
+	for _, st := range found {
+		if len(st.Grades) != 1 {
+			t.Fatalf("[%s][SearchMany] student %s lost grades: %v", repoImplTestPrefix, st.ID, st.Grades)
+		}
+	}
+}
+
+func TestRepository_Query_WeightedAVG(t *testing.T) {
+	forEachBackend(t, testRepository_Query_WeightedAVG)
+}
+
+func testRepository_Query_WeightedAVG(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][WeightedAVG] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	weighted := unassignedGrades(100, 0)
+	weighted[0].Weight = 3
+
+	seed := []struct {
+		name   string
+		grades []models.Grade
+	}{
+		// mean 50, weighted 75
+		{"Mikhail", weighted},
+		// 60 either way
+		{"Anna", unassignedGrades(60, 60)},
+	}
+
+	for _, s := range seed {
+		st, err := models.NewStudentBuilder().
+			SetName(s.name).
+			SetSurname("Gunin").
+			SetAge(19).
+			SetGrades(s.grades).
+			Build()
+		if err != nil {
+			t.Fatalf("[%s][WeightedAVG] failed to build student: %v", repoImplTestPrefix, err)
+		}
+
+		if _, err := repo.Create(st); err != nil {
+			t.Fatalf("[%s][WeightedAVG] failed to create student: %v", repoImplTestPrefix, err)
+		}
+	}
+
+	tests := []queryCase{
+		{
+			name:      "mean-sort",
+			query:     domainRepos.StudentQuery{SortBy: domainRepos.SortByAVG},
+			wantNames: []string{"Mikhail", "Anna"},
+			wantTotal: 2,
+		},
+		{
+			name: "weighted-sort",
+			query: domainRepos.StudentQuery{
+				SortBy:    domainRepos.SortByAVG,
+				Averaging: grading.AveragingWeighted,
+			},
+			wantNames: []string{"Anna", "Mikhail"},
+			wantTotal: 2,
+		},
+		{
+			name:      "mean-min-avg",
+			query:     domainRepos.StudentQuery{MinAVG: ptr(70.0)},
+			wantNames: []string{},
+			wantTotal: 0,
+		},
+		{
+			name: "weighted-min-avg",
+			query: domainRepos.StudentQuery{
+				MinAVG:    ptr(70.0),
+				Averaging: grading.AveragingWeighted,
+			},
+			wantNames: []string{"Mikhail"},
+			wantTotal: 1,
+		},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-weighted-%s-№%d", repoImplTestPrefix, tc.name, i+1), func(t *testing.T) {
+			page, err := repo.Query(tc.query)
+			if err != nil {
+				t.Fatalf("[%s][WeightedAVG] unexpected error: %v", repoImplTestPrefix, err)
+			}
+
+			names := make([]string, 0, len(page.Students))
+			for _, st := range page.Students {
+				names = append(names, st.Name)
+			}
+
+			if !slices.Equal(names, tc.wantNames) || page.Total != tc.wantTotal {
+				t.Fatalf(
+					"[%s][WeightedAVG] got=%v total=%d want=%v total=%d",
+					repoImplTestPrefix,
+					names,
+					page.Total,
+					tc.wantNames,
+					tc.wantTotal,
+				)
+			}
+		})
+	}
+}
@@ -1,14 +1,22 @@
 package repositories_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
 	"github.com/k6zma/avito-lab1/internal/infrastructure/ciphers"
 	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
 	"github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/sqlite"
 	"github.com/k6zma/avito-lab1/pkg/validators"
 )
 
@@ -18,6 +26,11 @@ const (
 	testKey = "12345678901234567890123456789012"
 )
 
+type repositoryBackend struct {
+	name string
+	open func(t *testing.T, opts ...repositories.StudentStorageOption) domainRepos.StudentRepository
+}
+
 type smokeCase struct {
 	name      string
 	build     func() *models.Student
@@ -25,25 +38,106 @@ type smokeCase struct {
 	wantFound bool
 }
 
-func TestRepository_Create_And_GetByID(t *testing.T) {
-	if err := validators.InitValidators(); err != nil {
-		t.Fatalf("[%s][Create_And_GetByID] failed to init validators: %v", repoImplTestPrefix, err)
+func unassignedGrades(values ...int) []models.Grade {
+	return models.NewGrades(models.UnassignedCourseID, time.Time{}, values...)
+}
+
+// newTestStudent builds a valid student with grades in the unassigned course.
+func newTestStudent(t *testing.T, name, surname string, age int, grades ...int) *models.Student {
+	t.Helper()
+
+	st, err := models.NewStudentBuilder().
+		SetName(name).
+		SetSurname(surname).
+		SetAge(age).
+		SetGrades(unassignedGrades(grades...)).
+		Build()
+	if err != nil {
+		t.Fatalf("[%s] failed to build student %s %s: %v", repoImplTestPrefix, name, surname, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
+	return st
+}
+
+// storedStudent returns the student as the repository keeps it, so updates
+// built from it carry the current version.
+func storedStudent(t *testing.T, repo domainRepos.StudentRepository, id uuid.UUID) *models.Student {
+	t.Helper()
+
+	st, err := repo.GetByID(id)
 	if err != nil {
-		t.Fatalf(
-			"[%s][Create_And_GetByID] error while creating repository with nil persister: %v",
-			repoImplTestPrefix,
-			err,
-		)
+		t.Fatalf("[%s] failed to get stored student %s: %v", repoImplTestPrefix, id, err)
+	}
+
+	return st
+}
+
+func repositoryBackends() []repositoryBackend {
+	return []repositoryBackend{
+		{
+			name: "memory",
+			open: func(t *testing.T, opts ...repositories.StudentStorageOption) domainRepos.StudentRepository {
+				t.Helper()
+
+				repo, err := repositories.NewStudentStorageWithPersister(nil, opts...)
+				if err != nil {
+					t.Fatalf(
+						"[%s] error while creating repository with nil persister: %v",
+						repoImplTestPrefix,
+						err,
+					)
+				}
+
+				return repo
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T, opts ...repositories.StudentStorageOption) domainRepos.StudentRepository {
+				t.Helper()
+
+				db, err := sqlite.Open(
+					context.Background(),
+					filepath.Join(t.TempDir(), "students.db"),
+				)
+				if err != nil {
+					t.Fatalf("[%s] error while opening sqlite database: %v", repoImplTestPrefix, err)
+				}
+
+				t.Cleanup(func() {
+					_ = db.Close()
+				})
+
+				return repositories.NewSQLiteStudentStorage(db, opts...)
+			},
+		},
+	}
+}
+
+func forEachBackend(t *testing.T, test func(t *testing.T, repo domainRepos.StudentRepository)) {
+	t.Helper()
+
+	for _, backend := range repositoryBackends() {
+		t.Run(fmt.Sprintf("[%s]-backend-%s", repoImplTestPrefix, backend.name), func(t *testing.T) {
+			test(t, backend.open(t))
+		})
+	}
+}
+
+func TestRepository_Create_And_GetByID(t *testing.T) {
+	forEachBackend(t, testRepository_Create_And_GetByID)
+}
+
+func testRepository_Create_And_GetByID(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Create_And_GetByID] failed to init validators: %v", repoImplTestPrefix, err)
 	}
 
 	st, err := models.NewStudentBuilder().
 		SetName("Mikhail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{90, 95}).
+		SetGrades(unassignedGrades(90, 95)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -106,19 +200,14 @@ func TestRepository_Create_And_GetByID(t *testing.T) {
 }
 
 func TestRepository_Create_DuplicateID(t *testing.T) {
+	forEachBackend(t, testRepository_Create_DuplicateID)
+}
+
+func testRepository_Create_DuplicateID(t *testing.T, repo domainRepos.StudentRepository) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][Create_DuplicateID] failed to init validators: %v", repoImplTestPrefix, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
-	if err != nil {
-		t.Fatalf(
-			"[%s][Create_DuplicateID] error while creating repository with nil persister: %v",
-			repoImplTestPrefix,
-			err,
-		)
-	}
-
 	st1, err := models.NewStudentBuilder().
 		SetName("Mikhail").
 		SetSurname("Gunin").
@@ -136,7 +225,7 @@ func TestRepository_Create_DuplicateID(t *testing.T) {
 		SetName("Alexander").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{100}).
+		SetGrades(unassignedGrades(100)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -166,19 +255,14 @@ func TestRepository_Create_DuplicateID(t *testing.T) {
 }
 
 func TestRepository_Create_InvalidStudent(t *testing.T) {
+	forEachBackend(t, testRepository_Create_InvalidStudent)
+}
+
+func testRepository_Create_InvalidStudent(t *testing.T, repo domainRepos.StudentRepository) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][Create_Invalid] failed to init validators: %v", repoImplTestPrefix, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
-	if err != nil {
-		t.Fatalf(
-			"[%s][Create_Invalid] error while creating repository with nil persister: %v",
-			repoImplTestPrefix,
-			err,
-		)
-	}
-
 	s, err := models.NewStudentBuilder().
 		SetName("Mikhail").
 		SetSurname("Gunin").
@@ -204,24 +288,19 @@ func TestRepository_Create_InvalidStudent(t *testing.T) {
 }
 
 func TestRepository_GetByFullName(t *testing.T) {
+	forEachBackend(t, testRepository_GetByFullName)
+}
+
+func testRepository_GetByFullName(t *testing.T, repo domainRepos.StudentRepository) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][GetByFullName] failed to init validators: %v", repoImplTestPrefix, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
-	if err != nil {
-		t.Fatalf(
-			"[%s][GetByFullName] error while creating repository with nil persister: %v",
-			repoImplTestPrefix,
-			err,
-		)
-	}
-
 	st, err := models.NewStudentBuilder().
 		SetName("Mikhail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{100}).
+		SetGrades(unassignedGrades(100)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -255,24 +334,19 @@ func TestRepository_GetByFullName(t *testing.T) {
 }
 
 func TestRepository_Update_Success_And_Validation(t *testing.T) {
+	forEachBackend(t, testRepository_Update_Success_And_Validation)
+}
+
+func testRepository_Update_Success_And_Validation(t *testing.T, repo domainRepos.StudentRepository) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][Update] failed to init validators: %v", repoImplTestPrefix, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
-	if err != nil {
-		t.Fatalf(
-			"[%s][Update] error while creating repository with nil persister: %v",
-			repoImplTestPrefix,
-			err,
-		)
-	}
-
 	orig, err := models.NewStudentBuilder().
 		SetName("Mikahil").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{70}).
+		SetGrades(unassignedGrades(70)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -291,7 +365,7 @@ func TestRepository_Update_Success_And_Validation(t *testing.T) {
 		SetName("Alexander").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{70, 85}).
+		SetGrades(unassignedGrades(70, 85)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -302,6 +376,8 @@ func TestRepository_Update_Success_And_Validation(t *testing.T) {
 	}
 
 	upd.ID = id
+	upd.Version = storedStudent(t, repo, id).Version
+
 	if err := repo.Update(upd); err != nil {
 		t.Fatalf(
 			"[%s][Update(valid)] unexpected error while updating student in storage: %v",
@@ -330,7 +406,7 @@ func TestRepository_Update_Success_And_Validation(t *testing.T) {
 		SetName("Mihail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{70}).
+		SetGrades(unassignedGrades(70)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -353,24 +429,19 @@ func TestRepository_Update_Success_And_Validation(t *testing.T) {
 }
 
 func TestRepository_AddGrades(t *testing.T) {
+	forEachBackend(t, testRepository_AddGrades)
+}
+
+func testRepository_AddGrades(t *testing.T, repo domainRepos.StudentRepository) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][AddGrades] failed to init validators: %v", repoImplTestPrefix, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
-	if err != nil {
-		t.Fatalf(
-			"[%s][AddGrades] error while creating repository with nil persister: %v",
-			repoImplTestPrefix,
-			err,
-		)
-	}
-
 	st, err := models.NewStudentBuilder().
 		SetName("Mikhail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{60}).
+		SetGrades(unassignedGrades(60)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -389,7 +460,7 @@ func TestRepository_AddGrades(t *testing.T) {
 		)
 	}
 
-	if err := repo.AddGrades(id, 80, 90); err != nil {
+	if err := repo.AddGrades(id, unassignedGrades(80, 90)...); err != nil {
 		t.Fatalf(
 			"[%s][AddGrades(valid)] unexpected error while adding grades for student: %v",
 			repoImplTestPrefix,
@@ -411,27 +482,49 @@ func TestRepository_AddGrades(t *testing.T) {
 			repoImplTestPrefix, len(after.Grades), 3, after.Grades)
 	}
 
-	if err := repo.AddGrades(id, 150); err == nil {
+	if err := repo.AddGrades(id, unassignedGrades(150)...); err == nil {
 		t.Fatalf(
 			"[%s][AddGrades(invalid)] expected validation error for grade=150, got nil",
 			repoImplTestPrefix,
 		)
 	}
-}
 
-func TestRepository_DeleteByID(t *testing.T) {
-	if err := validators.InitValidators(); err != nil {
-		t.Fatalf("[%s][DeleteByID] failed to init validators: %v", repoImplTestPrefix, err)
+	detailed := models.Grade{
+		CourseID: models.UnassignedCourseID,
+		Value:    95,
+		Date:     time.Date(2025, time.May, 20, 9, 0, 0, 0, time.UTC),
+		Author:   "Ivanova",
+		Comment:  "final exam",
+		Weight:   2,
+	}
+
+	if err := repo.AddGrades(id, detailed); err != nil {
+		t.Fatalf("[%s][AddGrades(detailed)] unexpected error: %v", repoImplTestPrefix, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
+	after, err = repo.GetByID(id)
 	if err != nil {
+		t.Fatalf("[%s][GetByID(after detailed)] unexpected error: %v", repoImplTestPrefix, err)
+	}
+
+	if got := after.Grades[len(after.Grades)-1]; got != detailed {
 		t.Fatalf(
-			"[%s][DeleteByID] error while creating repository with nil persister: %v",
+			"[%s][AddGrades(detailed)] grade details mismatch: got=%+v want=%+v",
 			repoImplTestPrefix,
-			err,
+			got,
+			detailed,
 		)
 	}
+}
+
+func TestRepository_DeleteByID(t *testing.T) {
+	forEachBackend(t, testRepository_DeleteByID)
+}
+
+func testRepository_DeleteByID(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][DeleteByID] failed to init validators: %v", repoImplTestPrefix, err)
+	}
 
 	st, err := models.NewStudentBuilder().
 		SetName("Mikhail").
@@ -473,24 +566,19 @@ func TestRepository_DeleteByID(t *testing.T) {
 }
 
 func TestRepository_List_ReturnsCopies(t *testing.T) {
+	forEachBackend(t, testRepository_List_ReturnsCopies)
+}
+
+func testRepository_List_ReturnsCopies(t *testing.T, repo domainRepos.StudentRepository) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][List_ReturnsCopies] failed to init validators: %v", repoImplTestPrefix, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
-	if err != nil {
-		t.Fatalf(
-			"[%s][List_ReturnsCopies] error while creating repository with nil persister: %v",
-			repoImplTestPrefix,
-			err,
-		)
-	}
-
 	a, err := models.NewStudentBuilder().
 		SetName("Eleven").
 		SetSurname("Doctor").
 		SetAge(100).
-		SetGrades([]int{100}).
+		SetGrades(unassignedGrades(100)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -513,7 +601,7 @@ func TestRepository_List_ReturnsCopies(t *testing.T) {
 		SetName("Mikhail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{80}).
+		SetGrades(unassignedGrades(80)).
 		Build()
 	if err != nil {
 		t.Fatalf(
@@ -564,19 +652,14 @@ func TestRepository_List_ReturnsCopies(t *testing.T) {
 }
 
 func TestRepository_Smoke_TableDriven(t *testing.T) {
+	forEachBackend(t, testRepository_Smoke_TableDriven)
+}
+
+func testRepository_Smoke_TableDriven(t *testing.T, repo domainRepos.StudentRepository) {
 	if err := validators.InitValidators(); err != nil {
 		t.Fatalf("[%s][Smoke] failed to init validators: %v", repoImplTestPrefix, err)
 	}
 
-	repo, err := repositories.NewStudentStorageWithPersister(nil)
-	if err != nil {
-		t.Fatalf(
-			"[%s][Smoke] error while creating repository with nil persister: %v",
-			repoImplTestPrefix,
-			err,
-		)
-	}
-
 	tests := []smokeCase{
 		{
 			name: "ok create and get",
@@ -585,7 +668,7 @@ func TestRepository_Smoke_TableDriven(t *testing.T) {
 					SetName("Mikhail").
 					SetSurname("Gunin").
 					SetAge(19).
-					SetGrades([]int{75}).
+					SetGrades(unassignedGrades(75)).
 					Build()
 				if err != nil {
 					t.Fatalf(
@@ -695,7 +778,7 @@ func TestRepository_Persists_On_Mutations(t *testing.T) {
 		SetName("Mikhail").
 		SetSurname("Gunin").
 		SetAge(19).
-		SetGrades([]int{50}).
+		SetGrades(unassignedGrades(50)).
 		Build()
 	if err != nil {
 		t.Fatalf("[%s][Persists_On_Mutations] failed to build student: %v", repoImplTestPrefix, err)
@@ -729,6 +812,7 @@ func TestRepository_Persists_On_Mutations(t *testing.T) {
 
 	upd := *st
 	upd.Age = 21
+	upd.Version = 1
 
 	if err := repo.Update(&upd); err != nil {
 		t.Fatalf(
@@ -751,7 +835,7 @@ func TestRepository_Persists_On_Mutations(t *testing.T) {
 		t.Fatalf("[%s][Persists_On_Mutations] want Age=21, got=%v", repoImplTestPrefix, loaded)
 	}
 
-	if err := repo.AddGrades(id, 60); err != nil {
+	if err := repo.AddGrades(id, unassignedGrades(60)...); err != nil {
 		t.Fatalf(
 			"[%s][Persists_On_Mutations] unexpected error while adding grades to student in storage: %v",
 			repoImplTestPrefix,
@@ -789,6 +873,27 @@ func TestRepository_Persists_On_Mutations(t *testing.T) {
 		)
 	}
 
+	if len(loaded) != 1 || !loaded[0].Deleted() {
+		t.Fatalf("[%s][Persists_On_Mutations] want student kept in trash, got=%v", repoImplTestPrefix, loaded)
+	}
+
+	if _, err := repo.PurgeDeleted(time.Now()); err != nil {
+		t.Fatalf(
+			"[%s][Persists_On_Mutations] unexpected error while purging students from storage: %v",
+			repoImplTestPrefix,
+			err,
+		)
+	}
+
+	loaded, err = persister.Load()
+	if err != nil {
+		t.Fatalf(
+			"[%s][Persists_On_Mutations] unexpected error while loading students from persister: %v",
+			repoImplTestPrefix,
+			err,
+		)
+	}
+
 	if len(loaded) != 0 {
 		t.Fatalf(
 			"[%s][Persists_On_Mutations] want 0 students, got=%d",
@@ -797,3 +902,90 @@ func TestRepository_Persists_On_Mutations(t *testing.T) {
 		)
 	}
 }
+
+func TestRepository_Persists_Through_Journal(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf(
+			"[%s][Persists_Through_Journal] failed to init validators: %v",
+			repoImplTestPrefix,
+			err,
+		)
+	}
+
+	cipher, err := ciphers.NewAESGCM(testKey)
+	if err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] init cipher: %v", repoImplTestPrefix, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "students.json")
+
+	repo, err := repositories.NewStudentStorageWithPersister(
+		persisters.NewWALStudentPersister(path, cipher, 3),
+	)
+	if err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] init repo: %v", repoImplTestPrefix, err)
+	}
+
+	st, err := models.NewStudentBuilder().
+		SetName("Mikhail").
+		SetSurname("Gunin").
+		SetAge(19).
+		SetGrades(unassignedGrades(50)).
+		Build()
+	if err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] failed to build student: %v", repoImplTestPrefix, err)
+	}
+
+	id, err := repo.Create(st)
+	if err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] create: %v", repoImplTestPrefix, err)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf(
+			"[%s][Persists_Through_Journal] snapshot must not be rewritten on create, stat err=%v",
+			repoImplTestPrefix,
+			err,
+		)
+	}
+
+	if err := repo.AddGrades(id, unassignedGrades(60)...); err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] add grades: %v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.AddGrades(id, unassignedGrades(70)...); err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] add grades: %v", repoImplTestPrefix, err)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf(
+			"[%s][Persists_Through_Journal] snapshot must be compacted after 3 records: %v",
+			repoImplTestPrefix,
+			err,
+		)
+	}
+
+	if err := repo.AddGrades(id, unassignedGrades(80)...); err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] add grades: %v", repoImplTestPrefix, err)
+	}
+
+	reloaded, err := repositories.NewStudentStorageWithPersister(
+		persisters.NewWALStudentPersister(path, cipher, 3),
+	)
+	if err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] reload repo: %v", repoImplTestPrefix, err)
+	}
+
+	got, err := reloaded.GetByID(id)
+	if err != nil {
+		t.Fatalf("[%s][Persists_Through_Journal] get after reload: %v", repoImplTestPrefix, err)
+	}
+
+	if fmt.Sprint(models.GradeValues(got.Grades)) != fmt.Sprint([]int{50, 60, 70, 80}) {
+		t.Fatalf(
+			"[%s][Persists_Through_Journal] grades mismatch after reload: got=%v",
+			repoImplTestPrefix,
+			got.Grades,
+		)
+	}
+}
@@ -0,0 +1,164 @@
+package repositories_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+func TestRepository_SoftDelete(t *testing.T) {
+	forEachBackend(t, testRepository_SoftDelete)
+}
+
+func testRepository_SoftDelete(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Trash] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	mikhail := newTestStudent(t, "Mikhail", "Gunin", 19)
+	alexander := newTestStudent(t, "Alexander", "Gunin", 20)
+
+	if _, err := repo.CreateMany([]*models.Student{mikhail, alexander}); err != nil {
+		t.Fatalf("[%s][Trash] failed to create students: %v", repoImplTestPrefix, err)
+	}
+
+	beforeDelete := time.Now().Add(-time.Second)
+
+	if err := repo.DeleteByID(mikhail.ID); err != nil {
+		t.Fatalf("[%s][Trash] failed to delete student: %v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.GetByID(mikhail.ID); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Trash] deleted student must be hidden from GetByID, got=%v", repoImplTestPrefix, err)
+	}
+
+	expectFullName(t, repo, "Mikhail", "Gunin", nil)
+
+	list, err := repo.List()
+	if err != nil || len(list) != 1 || list[0].ID != alexander.ID {
+		t.Fatalf("[%s][Trash] deleted student must be hidden from List: %v err=%v", repoImplTestPrefix, list, err)
+	}
+
+	page, err := repo.Query(domainRepos.StudentQuery{SurnamePrefix: "Gun"})
+	if err != nil || page.Total != 1 {
+		t.Fatalf("[%s][Trash] deleted student must be hidden from Query: %+v err=%v", repoImplTestPrefix, page, err)
+	}
+
+	found, err := repo.SearchByName("mikhail")
+	if err != nil || len(found) != 0 {
+		t.Fatalf("[%s][Trash] deleted student must be hidden from search: %v err=%v", repoImplTestPrefix, found, err)
+	}
+
+	updated := mikhail.Clone()
+	updated.Age = 30
+
+	if err := repo.Update(updated); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Trash] want ErrStudentNotFound on update, got=%v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.AddGrades(mikhail.ID, unassignedGrades(70)...); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Trash] want ErrStudentNotFound on add grades, got=%v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.DeleteByID(mikhail.ID); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Trash] want ErrStudentNotFound on second delete, got=%v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.Create(mikhail); !errors.Is(err, domainRepos.ErrStudentAlreadyExists) {
+		t.Fatalf("[%s][Trash] want ErrStudentAlreadyExists for trashed id, got=%v", repoImplTestPrefix, err)
+	}
+
+	trash, err := repo.ListDeleted()
+	if err != nil || len(trash) != 1 || trash[0].ID != mikhail.ID || trash[0].DeletedAt == nil {
+		t.Fatalf("[%s][Trash] unexpected trash: %v err=%v", repoImplTestPrefix, trash, err)
+	}
+
+	if trash[0].DeletedAt.Before(beforeDelete) {
+		t.Fatalf("[%s][Trash] unexpected deleted at: %v", repoImplTestPrefix, trash[0].DeletedAt)
+	}
+
+	if err := repo.Restore(alexander.ID); !errors.Is(err, domainRepos.ErrStudentNotDeleted) {
+		t.Fatalf("[%s][Trash] want ErrStudentNotDeleted, got=%v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.Restore(mikhail.ID); err != nil {
+		t.Fatalf("[%s][Trash] failed to restore student: %v", repoImplTestPrefix, err)
+	}
+
+	expectFullName(t, repo, "Mikhail", "Gunin", mikhail)
+
+	restored, err := repo.GetByID(mikhail.ID)
+	if err != nil || restored.Deleted() || restored.Age != 19 {
+		t.Fatalf("[%s][Trash] unexpected restored student: %+v err=%v", repoImplTestPrefix, restored, err)
+	}
+
+	if err := repo.DeleteMany([]uuid.UUID{mikhail.ID, alexander.ID}); err != nil {
+		t.Fatalf("[%s][Trash] failed to delete students: %v", repoImplTestPrefix, err)
+	}
+
+	purged, err := repo.PurgeDeleted(beforeDelete)
+	if err != nil || len(purged) != 0 {
+		t.Fatalf("[%s][Trash] nothing was deleted before cutoff: %v err=%v", repoImplTestPrefix, purged, err)
+	}
+
+	purged, err = repo.PurgeDeleted(time.Now())
+	if err != nil || len(purged) != 2 || purged[0].ID != mikhail.ID {
+		t.Fatalf("[%s][Trash] unexpected purged students: %v err=%v", repoImplTestPrefix, purged, err)
+	}
+
+	if trash, err := repo.ListDeleted(); err != nil || len(trash) != 0 {
+		t.Fatalf("[%s][Trash] trash must be empty after purge: %v err=%v", repoImplTestPrefix, trash, err)
+	}
+
+	if err := repo.Restore(mikhail.ID); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Trash] want ErrStudentNotFound after purge, got=%v", repoImplTestPrefix, err)
+	}
+
+	if _, err := repo.Create(mikhail); err != nil {
+		t.Fatalf("[%s][Trash] purged id must be free again, got=%v", repoImplTestPrefix, err)
+	}
+}
+
+func TestRepository_RestoreUniqueFullName(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Trash] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	for _, backend := range repositoryBackends() {
+		t.Run(fmt.Sprintf("[%s]-restore-unique-%s", repoImplTestPrefix, backend.name), func(t *testing.T) {
+			repo := backend.open(t, repositories.WithUniqueFullName())
+
+			first := newTestStudent(t, "Mikhail", "Gunin", 19)
+
+			if _, err := repo.Create(first); err != nil {
+				t.Fatalf("[%s][Trash] failed to create student: %v", repoImplTestPrefix, err)
+			}
+
+			if err := repo.DeleteByID(first.ID); err != nil {
+				t.Fatalf("[%s][Trash] failed to delete student: %v", repoImplTestPrefix, err)
+			}
+
+			second := newTestStudent(t, "Mikhail", "Gunin", 21)
+
+			if _, err := repo.Create(second); err != nil {
+				t.Fatalf("[%s][Trash] trashed student must not hold the name, got=%v", repoImplTestPrefix, err)
+			}
+
+			if err := repo.Restore(first.ID); !errors.Is(err, domainRepos.ErrDuplicateFullName) {
+				t.Fatalf("[%s][Trash] want ErrDuplicateFullName on restore, got=%v", repoImplTestPrefix, err)
+			}
+
+			if trash, err := repo.ListDeleted(); err != nil || len(trash) != 1 {
+				t.Fatalf("[%s][Trash] failed restore must keep the trash: %v err=%v", repoImplTestPrefix, trash, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package repositories_test
+
+import (
+	"errors"
+	"testing"
+
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+func TestRepository_VersionConflict(t *testing.T) {
+	forEachBackend(t, testRepository_VersionConflict)
+}
+
+func testRepository_VersionConflict(t *testing.T, repo domainRepos.StudentRepository) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s][Version] failed to init validators: %v", repoImplTestPrefix, err)
+	}
+
+	st := newTestStudent(t, "Mikhail", "Gunin", 19)
+
+	id, err := repo.Create(st)
+	if err != nil {
+		t.Fatalf("[%s][Version] failed to create student: %v", repoImplTestPrefix, err)
+	}
+
+	first := storedStudent(t, repo, id)
+	if first.Version != 1 {
+		t.Fatalf("[%s][Version] new student: got version=%d want=1", repoImplTestPrefix, first.Version)
+	}
+
+	second := first.Clone()
+
+	first.Age = 20
+
+	if err := repo.Update(first); err != nil {
+		t.Fatalf("[%s][Version] first update failed: %v", repoImplTestPrefix, err)
+	}
+
+	second.Name = "Alexander"
+
+	if err := repo.Update(second); !errors.Is(err, domainRepos.ErrVersionConflict) {
+		t.Fatalf("[%s][Version] want ErrVersionConflict for stale update, got=%v", repoImplTestPrefix, err)
+	}
+
+	got := storedStudent(t, repo, id)
+	if got.Version != 2 || got.Age != 20 || got.Name != "Mikhail" {
+		t.Fatalf("[%s][Version] stale update must not apply: %+v", repoImplTestPrefix, got)
+	}
+
+	if err := repo.AddGrades(id, unassignedGrades(90)...); err != nil {
+		t.Fatalf("[%s][Version] failed to add grades: %v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.Update(got); !errors.Is(err, domainRepos.ErrVersionConflict) {
+		t.Fatalf("[%s][Version] want ErrVersionConflict after add grades, got=%v", repoImplTestPrefix, err)
+	}
+
+	err = repo.Batch(func(tx domainRepos.StudentTx) error {
+		return tx.Update(got)
+	})
+	if !errors.Is(err, domainRepos.ErrVersionConflict) {
+		t.Fatalf("[%s][Version] want ErrVersionConflict inside batch, got=%v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.DeleteByID(id); err != nil {
+		t.Fatalf("[%s][Version] failed to delete student: %v", repoImplTestPrefix, err)
+	}
+
+	if err := repo.Restore(id); err != nil {
+		t.Fatalf("[%s][Version] failed to restore student: %v", repoImplTestPrefix, err)
+	}
+
+	if got := storedStudent(t, repo, id); got.Version != 5 || len(got.Grades) != 1 {
+		t.Fatalf("[%s][Version] every write must bump the version: %+v", repoImplTestPrefix, got)
+	}
+
+	missing := newTestStudent(t, "Ivan", "Petrov", 20)
+	missing.Version = 1
+
+	if err := repo.Update(missing); !errors.Is(err, domainRepos.ErrStudentNotFound) {
+		t.Fatalf("[%s][Version] want ErrStudentNotFound for missing student, got=%v", repoImplTestPrefix, err)
+	}
+}
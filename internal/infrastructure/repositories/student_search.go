@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sahilm/fuzzy"
+
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+)
+
+const maxSearchQueryLength = 128
+
+type nameMatch struct {
+	index int
+	fuzzy bool
+	score int
+}
+
+func normalizeSearchQuery(query string) (string, error) {
+	q := strings.Join(strings.Fields(query), " ")
+
+	if q == "" {
+		return "", fmt.Errorf("%w: search query is empty", repositories.ErrInvalidStudentQuery)
+	}
+
+	if utf8.RuneCountInString(q) > maxSearchQueryLength {
+		return "", fmt.Errorf(
+			"%w: search query is longer than %d characters",
+			repositories.ErrInvalidStudentQuery,
+			maxSearchQueryLength,
+		)
+	}
+
+	return q, nil
+}
+
+// rankByName returns indexes of matching full names, best match first.
+// Case-insensitive subsequence matches such as "mik gun" rank above matches
+// that only work with a few typos such as "Mikhial". Ties keep input order.
+func rankByName(query string, fullNames []string) []int {
+	matches := make([]nameMatch, 0, len(fullNames))
+	matched := make(map[int]struct{})
+
+	for _, m := range fuzzy.FindNoSort(query, fullNames) {
+		matches = append(matches, nameMatch{index: m.Index, fuzzy: true, score: m.Score})
+		matched[m.Index] = struct{}{}
+	}
+
+	for i, fullName := range fullNames {
+		if _, ok := matched[i]; ok {
+			continue
+		}
+
+		if dist, ok := typoDistance(query, fullName); ok {
+			matches = append(matches, nameMatch{index: i, score: -dist})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b nameMatch) int {
+		if a.fuzzy != b.fuzzy {
+			if a.fuzzy {
+				return -1
+			}
+
+			return 1
+		}
+
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.index, b.index))
+	})
+
+	out := make([]int, 0, len(matches))
+	for _, m := range matches {
+		out = append(out, m.index)
+	}
+
+	return out
+}
+
+// typoDistance matches every query word against the closest word of the full
+// name or its prefix of the same length, allowing more typos in longer words.
+func typoDistance(query, fullName string) (int, bool) {
+	words := strings.Fields(strings.ToLower(fullName))
+	total := 0
+
+	for _, token := range strings.Fields(strings.ToLower(query)) {
+		tr := []rune(token)
+		best := -1
+
+		for _, word := range words {
+			wr := []rune(word)
+
+			d := levenshtein(tr, wr)
+			if len(wr) > len(tr) {
+				d = min(d, levenshtein(tr, wr[:len(tr)]))
+			}
+
+			if best < 0 || d < best {
+				best = d
+			}
+		}
+
+		if best < 0 || best > allowedTypos(len(tr)) {
+			return 0, false
+		}
+
+		total += best
+	}
+
+	return total, true
+}
+
+func allowedTypos(runes int) int {
+	switch {
+	case runes < 3:
+		return 0
+	case runes <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
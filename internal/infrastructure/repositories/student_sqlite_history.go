@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+)
+
+func (s *SQLiteStudentStorage) History(id uuid.UUID) ([]models.StudentRevision, error) {
+	if id == uuid.Nil {
+		return nil, repositories.ErrInvalidStudentID
+	}
+
+	ctx := context.Background()
+
+	exists, err := studentExists(ctx, s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, repositories.ErrStudentNotFound
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT version, recorded_at, snapshot FROM student_revisions
+		WHERE student_id = ? ORDER BY version`,
+		id.String(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query student revisions: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	revisions := []models.StudentRevision{}
+
+	for rows.Next() {
+		var (
+			rev        = models.StudentRevision{StudentID: id}
+			recordedAt string
+			snapshot   string
+		)
+
+		if err := rows.Scan(&rev.Version, &recordedAt, &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to scan student revision: %w", err)
+		}
+
+		if rev.At, err = time.Parse(time.RFC3339Nano, recordedAt); err != nil {
+			return nil, fmt.Errorf("%w: %w", repositories.ErrInvalidStudentSnapshot, err)
+		}
+
+		if err := json.Unmarshal([]byte(snapshot), &rev.Student); err != nil {
+			return nil, fmt.Errorf("%w: %w", repositories.ErrInvalidStudentSnapshot, err)
+		}
+
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate student revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// recordRevision stores the student as the current transaction left it.
+func (tx *sqliteStudentTx) recordRevision(id uuid.UUID) error {
+	students, err := selectStudents(tx.ctx, tx.tx, `WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+
+	if len(students) == 0 {
+		return repositories.ErrStudentNotFound
+	}
+
+	rev := models.NewStudentRevision(students[0], time.Now())
+
+	snapshot, err := json.Marshal(rev.Student)
+	if err != nil {
+		return fmt.Errorf("failed to marshal student revision: %w", err)
+	}
+
+	if _, err := tx.tx.ExecContext(
+		tx.ctx,
+		`INSERT INTO student_revisions (student_id, version, recorded_at, snapshot)
+		VALUES (?, ?, ?, ?)`,
+		id.String(), rev.Version, formatTimestamp(rev.At), string(snapshot),
+	); err != nil {
+		return fmt.Errorf("failed to insert student revision: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,841 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type SQLiteStudentStorage struct {
+	db             *sql.DB
+	uniqueFullName bool
+}
+
+type sqlQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type sqlExecer interface {
+	sqlQueryer
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func NewSQLiteStudentStorage(db *sql.DB, opts ...StudentStorageOption) *SQLiteStudentStorage {
+	o := applyStudentStorageOptions(opts)
+
+	return &SQLiteStudentStorage{
+		db:             db,
+		uniqueFullName: o.uniqueFullName,
+	}
+}
+
+func (s *SQLiteStudentStorage) Create(student *models.Student) (uuid.UUID, error) {
+	var id uuid.UUID
+
+	err := s.inTx(func(tx *sqliteStudentTx) error {
+		var err error
+
+		id, err = tx.Create(student)
+
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("create student in sqlite failed: %w", err)
+	}
+
+	return id, nil
+}
+
+func (s *SQLiteStudentStorage) CreateMany(students []*models.Student) ([]uuid.UUID, error) {
+	cps, err := cloneValidStudents(students)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cps) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(cps))
+
+	err = s.inTx(func(tx *sqliteStudentTx) error {
+		for _, cp := range cps {
+			if err := tx.insert(cp); err != nil {
+				return err
+			}
+
+			ids = append(ids, cp.ID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create students in sqlite failed: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (s *SQLiteStudentStorage) Update(student *models.Student) error {
+	if err := s.inTx(func(tx *sqliteStudentTx) error {
+		return tx.Update(student)
+	}); err != nil {
+		return fmt.Errorf("update student in sqlite failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStudentStorage) UpdateMany(students []*models.Student) error {
+	cps, err := cloneValidStudents(students)
+	if err != nil {
+		return err
+	}
+
+	if err := s.inTx(func(tx *sqliteStudentTx) error {
+		for _, cp := range cps {
+			if err := tx.update(cp); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("update students in sqlite failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStudentStorage) DeleteByID(id uuid.UUID) error {
+	if id == uuid.Nil {
+		return repositories.ErrInvalidStudentID
+	}
+
+	if err := s.inTx(func(tx *sqliteStudentTx) error {
+		return tx.DeleteByID(id)
+	}); err != nil {
+		return fmt.Errorf("delete student in sqlite failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStudentStorage) DeleteMany(ids []uuid.UUID) error {
+	if slices.Contains(ids, uuid.Nil) {
+		return repositories.ErrInvalidStudentID
+	}
+
+	if err := s.inTx(func(tx *sqliteStudentTx) error {
+		for _, id := range ids {
+			if err := tx.DeleteByID(id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return fmt.Errorf("delete students in sqlite failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStudentStorage) ListDeleted() ([]*models.Student, error) {
+	return selectStudents(
+		context.Background(),
+		s.db,
+		`WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC, rowid`,
+	)
+}
+
+func (s *SQLiteStudentStorage) Restore(id uuid.UUID) error {
+	if id == uuid.Nil {
+		return repositories.ErrInvalidStudentID
+	}
+
+	if err := s.inTx(func(tx *sqliteStudentTx) error {
+		return tx.restore(id)
+	}); err != nil {
+		return fmt.Errorf("restore student in sqlite failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStudentStorage) PurgeDeleted(cutoff time.Time) ([]*models.Student, error) {
+	var purged []*models.Student
+
+	if err := s.inTx(func(tx *sqliteStudentTx) error {
+		var err error
+
+		purged, err = tx.purge(cutoff)
+
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("purge students in sqlite failed: %w", err)
+	}
+
+	return purged, nil
+}
+
+func (s *SQLiteStudentStorage) Batch(fn func(tx repositories.StudentTx) error) error {
+	if err := s.inTx(func(tx *sqliteStudentTx) error {
+		return fn(tx)
+	}); err != nil {
+		return fmt.Errorf("batch in sqlite failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStudentStorage) GetByID(id uuid.UUID) (*models.Student, error) {
+	if id == uuid.Nil {
+		return nil, repositories.ErrInvalidStudentID
+	}
+
+	ctx := context.Background()
+
+	students, err := selectStudents(ctx, s.db, `WHERE id = ? AND deleted_at IS NULL`, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(students) == 0 {
+		return nil, repositories.ErrStudentNotFound
+	}
+
+	return students[0], nil
+}
+
+func (s *SQLiteStudentStorage) GetByFullName(
+	name string,
+	surname string,
+) (*models.Student, error) {
+	if err := validators.Validate.Var(name, "required,capitalized"); err != nil {
+		return nil, fmt.Errorf("invalid student name: %w", err)
+	}
+
+	if err := validators.Validate.Var(surname, "required,capitalized"); err != nil {
+		return nil, fmt.Errorf("invalid student surname: %w", err)
+	}
+
+	ctx := context.Background()
+
+	students, err := selectStudents(
+		ctx,
+		s.db,
+		`WHERE name = ? AND surname = ? AND deleted_at IS NULL ORDER BY rowid LIMIT 1`,
+		name,
+		surname,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(students) == 0 {
+		return nil, repositories.ErrStudentNotFound
+	}
+
+	return students[0], nil
+}
+
+func (s *SQLiteStudentStorage) SearchByName(query string) ([]*models.Student, error) {
+	q, err := normalizeSearchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, name || ' ' || surname FROM students WHERE deleted_at IS NULL ORDER BY rowid`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query student names: %w", err)
+	}
+
+	var ids, fullNames []string
+
+	for rows.Next() {
+		var id, fullName string
+
+		if err := rows.Scan(&id, &fullName); err != nil {
+			_ = rows.Close()
+
+			return nil, fmt.Errorf("failed to scan student name: %w", err)
+		}
+
+		ids = append(ids, id)
+		fullNames = append(fullNames, fullName)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate student names: %w", err)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close student name rows: %w", err)
+	}
+
+	ranked := rankByName(q, fullNames)
+	if len(ranked) == 0 {
+		return []*models.Student{}, nil
+	}
+
+	matched := make([]string, 0, len(ranked))
+	for _, i := range ranked {
+		matched = append(matched, ids[i])
+	}
+
+	var found []*models.Student
+
+	for _, chunk := range chunkIDs(matched) {
+		students, err := selectStudents(
+			ctx,
+			s.db,
+			`WHERE id IN (`+placeholders(len(chunk))+`)`,
+			chunk...,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		found = append(found, students...)
+	}
+
+	byID := make(map[uuid.UUID]*models.Student, len(found))
+	for _, st := range found {
+		byID[st.ID] = st
+	}
+
+	students := make([]*models.Student, 0, len(ranked))
+	for _, i := range ranked {
+		if st, ok := byID[uuid.MustParse(ids[i])]; ok {
+			students = append(students, st)
+		}
+	}
+
+	return students, nil
+}
+
+func (s *SQLiteStudentStorage) List() ([]*models.Student, error) {
+	return selectStudents(context.Background(), s.db, `WHERE deleted_at IS NULL ORDER BY rowid`)
+}
+
+func (s *SQLiteStudentStorage) Query(q repositories.StudentQuery) (repositories.StudentPage, error) {
+	if err := validateStudentQuery(q); err != nil {
+		return repositories.StudentPage{}, err
+	}
+
+	ctx := context.Background()
+	where, args := studentQueryWhere(q)
+
+	var total int
+
+	if err := s.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*) FROM students `+where,
+		args...,
+	).Scan(&total); err != nil {
+		return repositories.StudentPage{}, fmt.Errorf("failed to count students: %w", err)
+	}
+
+	limit := q.Limit
+	if limit == 0 {
+		limit = -1
+	}
+
+	students, err := selectStudents(
+		ctx,
+		s.db,
+		where+` ORDER BY `+studentQueryOrder(q)+` LIMIT ? OFFSET ?`,
+		append(args, limit, q.Offset)...,
+	)
+	if err != nil {
+		return repositories.StudentPage{}, err
+	}
+
+	if students == nil {
+		students = []*models.Student{}
+	}
+
+	return repositories.StudentPage{
+		Students: students,
+		Total:    total,
+	}, nil
+}
+
+func (s *SQLiteStudentStorage) AddGrades(id uuid.UUID, grades ...models.Grade) error {
+	if err := s.inTx(func(tx *sqliteStudentTx) error {
+		return tx.AddGrades(id, grades...)
+	}); err != nil {
+		return fmt.Errorf("add grades in sqlite failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStudentStorage) inTx(fn func(tx *sqliteStudentTx) error) error {
+	ctx := context.Background()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin sqlite transaction: %w", err)
+	}
+
+	stx := &sqliteStudentTx{
+		ctx:            ctx,
+		tx:             tx,
+		uniqueFullName: s.uniqueFullName,
+	}
+
+	defer func() {
+		stx.done = true
+
+		// A panicking callback must not keep the transaction open.
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+
+			panic(r)
+		}
+	}()
+
+	if err := fn(stx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("failed to rollback sqlite transaction: %w", rbErr))
+		}
+
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sqlite transaction: %w", err)
+	}
+
+	return nil
+}
+
+func studentExists(ctx context.Context, q sqlQueryer, id uuid.UUID) (bool, error) {
+	var one int
+
+	err := q.QueryRowContext(ctx, `SELECT 1 FROM students WHERE id = ?`, id.String()).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check student existence: %w", err)
+	}
+
+	return true, nil
+}
+
+func insertStudent(ctx context.Context, tx sqlExecer, st *models.Student) error {
+	exists, err := studentExists(ctx, tx, st.ID)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return repositories.ErrStudentAlreadyExists
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO students (id, name, surname, age, version) VALUES (?, ?, ?, ?, 1)`,
+		st.ID.String(), st.Name, st.Surname, st.Age,
+	); err != nil {
+		return fmt.Errorf("failed to insert student row: %w", err)
+	}
+
+	return insertGrades(ctx, tx, st.ID, 0, st.Grades)
+}
+
+func updateStudent(ctx context.Context, tx sqlExecer, st *models.Student) error {
+	res, err := tx.ExecContext(
+		ctx,
+		`UPDATE students SET name = ?, surname = ?, age = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL AND version = ?`,
+		st.Name, st.Surname, st.Age, st.ID.String(), st.Version,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update student row: %w", err)
+	}
+
+	if err := expectAffected(res); errors.Is(err, repositories.ErrStudentNotFound) {
+		return studentVersionError(ctx, tx, st)
+	} else if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`DELETE FROM student_grades WHERE student_id = ?`,
+		st.ID.String(),
+	); err != nil {
+		return fmt.Errorf("failed to clear student grades: %w", err)
+	}
+
+	return insertGrades(ctx, tx, st.ID, 0, st.Grades)
+}
+
+// studentVersionError explains why an update matched no row: the student is
+// either gone or stored with another version.
+func studentVersionError(ctx context.Context, q sqlQueryer, st *models.Student) error {
+	var stored int
+
+	err := q.QueryRowContext(
+		ctx,
+		`SELECT version FROM students WHERE id = ? AND deleted_at IS NULL`,
+		st.ID.String(),
+	).Scan(&stored)
+	if errors.Is(err, sql.ErrNoRows) {
+		return repositories.ErrStudentNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read student version: %w", err)
+	}
+
+	return versionConflictError(st.ID, stored, st.Version)
+}
+
+func insertGrades(
+	ctx context.Context,
+	tx sqlExecer,
+	id uuid.UUID,
+	offset int,
+	grades []models.Grade,
+) error {
+	for i, g := range grades {
+		if _, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO student_grades
+			(student_id, position, course_id, value, graded_at, author, comment, weight)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			id.String(), offset+i, g.CourseID.String(), g.Value, formatGradeDate(g.Date),
+			g.Author, g.Comment, g.Weight,
+		); err != nil {
+			return fmt.Errorf("failed to insert student grade: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// studentAVGExpr averages the grades of a student in SQL the same way the
+// grading policy does in Go, a zero weight counts as the default one.
+func studentAVGExpr(averaging grading.Averaging) string {
+	if averaging != grading.AveragingWeighted {
+		return `(SELECT AVG(value) FROM student_grades g WHERE g.student_id = students.id)`
+	}
+
+	weight := fmt.Sprintf(`(CASE WHEN weight = 0 THEN %g ELSE weight END)`, models.DefaultGradeWeight)
+
+	return `(SELECT SUM(value * ` + weight + `) / SUM(` + weight + `)
+		FROM student_grades g WHERE g.student_id = students.id)`
+}
+
+func studentQueryWhere(q repositories.StudentQuery) (string, []any) {
+	var (
+		conds = []string{`deleted_at IS NULL`}
+		args  []any
+	)
+
+	add := func(cond string, arg ...any) {
+		conds = append(conds, cond)
+		args = append(args, arg...)
+	}
+
+	if q.NamePrefix != "" {
+		add(`instr(name, ?) = 1`, q.NamePrefix)
+	}
+
+	if q.SurnamePrefix != "" {
+		add(`instr(surname, ?) = 1`, q.SurnamePrefix)
+	}
+
+	if q.MinAge != nil {
+		add(`age >= ?`, *q.MinAge)
+	}
+
+	if q.MaxAge != nil {
+		add(`age <= ?`, *q.MaxAge)
+	}
+
+	if q.MinAVG != nil {
+		add(studentAVGExpr(q.Averaging)+` >= ?`, *q.MinAVG)
+	}
+
+	if q.MaxAVG != nil {
+		add(studentAVGExpr(q.Averaging)+` <= ?`, *q.MaxAVG)
+	}
+
+	if q.HasGrades != nil {
+		exists := `EXISTS (SELECT 1 FROM student_grades g WHERE g.student_id = students.id)`
+		if !*q.HasGrades {
+			exists = `NOT ` + exists
+		}
+
+		add(exists)
+	}
+
+	return `WHERE ` + strings.Join(conds, ` AND `), args
+}
+
+func studentQueryOrder(q repositories.StudentQuery) string {
+	dir := ``
+	if q.Desc {
+		dir = ` DESC`
+	}
+
+	switch q.SortBy {
+	case repositories.SortByName:
+		return `name` + dir + `, rowid`
+	case repositories.SortBySurname:
+		return `surname` + dir + `, rowid`
+	case repositories.SortByAge:
+		return `age` + dir + `, rowid`
+	case repositories.SortByAVG:
+		return fmt.Sprintf(`COALESCE(%s, %d)%s, rowid`, studentAVGExpr(q.Averaging), noGradesAVG, dir)
+	default:
+		return `rowid` + dir
+	}
+}
+
+func expectAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to read affected rows: %w", err)
+	}
+
+	if n == 0 {
+		return repositories.ErrStudentNotFound
+	}
+
+	return nil
+}
+
+func selectStudents(
+	ctx context.Context,
+	q sqlQueryer,
+	clause string,
+	args ...any,
+) ([]*models.Student, error) {
+	rows, err := q.QueryContext(
+		ctx,
+		`SELECT id, name, surname, age, version, deleted_at FROM students `+clause,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query students: %w", err)
+	}
+
+	var (
+		students []*models.Student
+		byID     = make(map[string]*models.Student)
+	)
+
+	for rows.Next() {
+		var (
+			rawID     string
+			deletedAt sql.NullString
+			st        models.Student
+		)
+
+		err := rows.Scan(&rawID, &st.Name, &st.Surname, &st.Age, &st.Version, &deletedAt)
+		if err != nil {
+			_ = rows.Close()
+
+			return nil, fmt.Errorf("failed to scan student row: %w", err)
+		}
+
+		id, err := uuid.Parse(rawID)
+		if err != nil {
+			_ = rows.Close()
+
+			return nil, fmt.Errorf("%w: %w", repositories.ErrInvalidStudentSnapshot, err)
+		}
+
+		if deletedAt.Valid {
+			at, err := time.Parse(time.RFC3339Nano, deletedAt.String)
+			if err != nil {
+				_ = rows.Close()
+
+				return nil, fmt.Errorf("%w: %w", repositories.ErrInvalidStudentSnapshot, err)
+			}
+
+			st.DeletedAt = &at
+		}
+
+		st.ID = id
+		students = append(students, &st)
+		byID[rawID] = &st
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate student rows: %w", err)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close student rows: %w", err)
+	}
+
+	if len(students) == 0 {
+		return students, nil
+	}
+
+	if err := loadGrades(ctx, q, byID); err != nil {
+		return nil, err
+	}
+
+	return students, nil
+}
+
+// loadGrades reads the grades of the loaded students only, a page never
+// reads the grades of the whole table.
+func loadGrades(ctx context.Context, q sqlQueryer, byID map[string]*models.Student) error {
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+
+	for _, chunk := range chunkIDs(ids) {
+		if err := loadGradesOf(ctx, q, byID, chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func loadGradesOf(
+	ctx context.Context,
+	q sqlQueryer,
+	byID map[string]*models.Student,
+	ids []any,
+) error {
+	rows, err := q.QueryContext(
+		ctx,
+		`SELECT student_id, course_id, value, graded_at, author, comment, weight
+		FROM student_grades
+		WHERE student_id IN (`+placeholders(len(ids))+`)
+		ORDER BY student_id, position`,
+		ids...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query student grades: %w", err)
+	}
+
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	for rows.Next() {
+		var (
+			id, rawCourseID, rawDate string
+			grade                    models.Grade
+		)
+
+		if err := rows.Scan(
+			&id,
+			&rawCourseID,
+			&grade.Value,
+			&rawDate,
+			&grade.Author,
+			&grade.Comment,
+			&grade.Weight,
+		); err != nil {
+			return fmt.Errorf("failed to scan student grade: %w", err)
+		}
+
+		st, ok := byID[id]
+		if !ok {
+			continue
+		}
+
+		courseID, err := uuid.Parse(rawCourseID)
+		if err != nil {
+			return fmt.Errorf("%w: %w", repositories.ErrInvalidStudentSnapshot, err)
+		}
+
+		date, err := parseGradeDate(rawDate)
+		if err != nil {
+			return fmt.Errorf("%w: %w", repositories.ErrInvalidStudentSnapshot, err)
+		}
+
+		grade.CourseID = courseID
+		grade.Date = date
+		st.Grades = append(st.Grades, grade)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate student grades: %w", err)
+	}
+
+	return nil
+}
+
+// maxSQLiteIDArgs keeps id lists below the bound variables limit of SQLite,
+// 999 in builds older than 3.32 and 32766 since.
+const maxSQLiteIDArgs = 500
+
+// chunkIDs splits ids into query arguments of at most maxSQLiteIDArgs each.
+func chunkIDs(ids []string) [][]any {
+	var chunks [][]any
+
+	for start := 0; start < len(ids); start += maxSQLiteIDArgs {
+		end := min(start+maxSQLiteIDArgs, len(ids))
+
+		chunk := make([]any, 0, end-start)
+		for _, id := range ids[start:end] {
+			chunk = append(chunk, id)
+		}
+
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat(`?, `, n), `, `)
+}
+
+// timestampLayout has a fixed width, so deleted_at and recorded_at values
+// compare as strings in the same order as the times they hold.
+const timestampLayout = "2006-01-02T15:04:05.000000000Z"
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+func formatGradeDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseGradeDate(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339Nano, raw)
+}
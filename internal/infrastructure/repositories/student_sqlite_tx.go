@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type sqliteStudentTx struct {
+	ctx            context.Context
+	tx             *sql.Tx
+	uniqueFullName bool
+	done           bool
+}
+
+func (tx *sqliteStudentTx) Create(student *models.Student) (uuid.UUID, error) {
+	if tx.done {
+		return uuid.Nil, repositories.ErrBatchClosed
+	}
+
+	cp := student.Clone()
+
+	if err := validators.Validate.Struct(cp); err != nil {
+		return uuid.Nil, fmt.Errorf("input student is invalid: %w", err)
+	}
+
+	if err := tx.insert(cp); err != nil {
+		return uuid.Nil, err
+	}
+
+	return cp.ID, nil
+}
+
+func (tx *sqliteStudentTx) Update(student *models.Student) error {
+	if tx.done {
+		return repositories.ErrBatchClosed
+	}
+
+	cp := student.Clone()
+
+	if err := validators.Validate.Struct(cp); err != nil {
+		return fmt.Errorf("input student is invalid: %w", err)
+	}
+
+	return tx.update(cp)
+}
+
+func (tx *sqliteStudentTx) DeleteByID(id uuid.UUID) error {
+	if tx.done {
+		return repositories.ErrBatchClosed
+	}
+
+	if id == uuid.Nil {
+		return repositories.ErrInvalidStudentID
+	}
+
+	res, err := tx.tx.ExecContext(
+		tx.ctx,
+		`UPDATE students SET deleted_at = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL`,
+		formatTimestamp(time.Now()),
+		id.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to move student row to trash: %w", err)
+	}
+
+	if err := expectAffected(res); err != nil {
+		return err
+	}
+
+	return tx.recordRevision(id)
+}
+
+func (tx *sqliteStudentTx) GetByID(id uuid.UUID) (*models.Student, error) {
+	if tx.done {
+		return nil, repositories.ErrBatchClosed
+	}
+
+	if id == uuid.Nil {
+		return nil, repositories.ErrInvalidStudentID
+	}
+
+	students, err := selectStudents(
+		tx.ctx,
+		tx.tx,
+		`WHERE id = ? AND deleted_at IS NULL`,
+		id.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(students) == 0 {
+		return nil, repositories.ErrStudentNotFound
+	}
+
+	return students[0], nil
+}
+
+func (tx *sqliteStudentTx) AddGrades(id uuid.UUID, grades ...models.Grade) error {
+	current, err := tx.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	if err := current.Clone().AddGrades(grades...); err != nil {
+		return fmt.Errorf("error while adding grades for student in storage: %w", err)
+	}
+
+	if _, err := tx.tx.ExecContext(
+		tx.ctx,
+		`UPDATE students SET version = version + 1 WHERE id = ?`,
+		id.String(),
+	); err != nil {
+		return fmt.Errorf("failed to bump student version: %w", err)
+	}
+
+	if err := insertGrades(tx.ctx, tx.tx, id, len(current.Grades), grades); err != nil {
+		return err
+	}
+
+	return tx.recordRevision(id)
+}
+
+func (tx *sqliteStudentTx) restore(id uuid.UUID) error {
+	if tx.done {
+		return repositories.ErrBatchClosed
+	}
+
+	students, err := selectStudents(tx.ctx, tx.tx, `WHERE id = ?`, id.String())
+	if err != nil {
+		return err
+	}
+
+	if len(students) == 0 {
+		return repositories.ErrStudentNotFound
+	}
+
+	if !students[0].Deleted() {
+		return repositories.ErrStudentNotDeleted
+	}
+
+	if err := tx.checkFullName(students[0]); err != nil {
+		return err
+	}
+
+	if _, err := tx.tx.ExecContext(
+		tx.ctx,
+		`UPDATE students SET deleted_at = NULL, version = version + 1 WHERE id = ?`,
+		id.String(),
+	); err != nil {
+		return fmt.Errorf("failed to restore student row: %w", err)
+	}
+
+	return tx.recordRevision(id)
+}
+
+func (tx *sqliteStudentTx) purge(cutoff time.Time) ([]*models.Student, error) {
+	if tx.done {
+		return nil, repositories.ErrBatchClosed
+	}
+
+	students, err := selectStudents(
+		tx.ctx,
+		tx.tx,
+		`WHERE deleted_at IS NOT NULL AND deleted_at <= ? ORDER BY rowid`,
+		formatTimestamp(cutoff),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, st := range students {
+		if _, err := tx.tx.ExecContext(
+			tx.ctx,
+			`DELETE FROM students WHERE id = ?`,
+			st.ID.String(),
+		); err != nil {
+			return nil, fmt.Errorf("failed to purge student row: %w", err)
+		}
+	}
+
+	return students, nil
+}
+
+func (tx *sqliteStudentTx) insert(cp *models.Student) error {
+	if err := tx.checkFullName(cp); err != nil {
+		return err
+	}
+
+	if err := insertStudent(tx.ctx, tx.tx, cp); err != nil {
+		return err
+	}
+
+	return tx.recordRevision(cp.ID)
+}
+
+func (tx *sqliteStudentTx) update(cp *models.Student) error {
+	if err := tx.checkFullName(cp); err != nil {
+		return err
+	}
+
+	if err := updateStudent(tx.ctx, tx.tx, cp); err != nil {
+		return err
+	}
+
+	return tx.recordRevision(cp.ID)
+}
+
+// checkFullName mirrors StudentStorage.checkFullNameLocked: only writes that
+// move a student onto a full name held by someone else are rejected.
+func (tx *sqliteStudentTx) checkFullName(st *models.Student) error {
+	if !tx.uniqueFullName {
+		return nil
+	}
+
+	var one int
+
+	err := tx.tx.QueryRowContext(
+		tx.ctx,
+		`SELECT 1 FROM students
+		WHERE name = ? AND surname = ? AND id <> ? AND deleted_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM students
+				WHERE id = ? AND name = ? AND surname = ? AND deleted_at IS NULL
+			)
+		LIMIT 1`,
+		st.Name, st.Surname, st.ID.String(),
+		st.ID.String(), st.Name, st.Surname,
+	).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to check student full name: %w", err)
+	}
+
+	return duplicateFullNameError(st)
+}
@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/internal/infrastructure/persisters"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+type storageTx struct {
+	s         *StudentStorage
+	undo      map[uuid.UUID]undoEntry
+	entries   []persisters.JournalEntry
+	revisions []models.StudentRevision
+	purged    []uuid.UUID
+	done      bool
+}
+
+type undoEntry struct {
+	student *models.Student
+	seq     uint64
+}
+
+func (tx *storageTx) Create(student *models.Student) (uuid.UUID, error) {
+	cp := student.Clone()
+
+	if err := validators.Validate.Struct(cp); err != nil {
+		return uuid.Nil, fmt.Errorf("input student is invalid: %w", err)
+	}
+
+	return tx.create(cp)
+}
+
+func (tx *storageTx) Update(student *models.Student) error {
+	cp := student.Clone()
+
+	if err := validators.Validate.Struct(cp); err != nil {
+		return fmt.Errorf("input student is invalid: %w", err)
+	}
+
+	return tx.update(cp)
+}
+
+func (tx *storageTx) DeleteByID(id uuid.UUID) error {
+	if tx.done {
+		return repositories.ErrBatchClosed
+	}
+
+	if id == uuid.Nil {
+		return repositories.ErrInvalidStudentID
+	}
+
+	current, ok := tx.s.active(id)
+	if !ok {
+		return repositories.ErrStudentNotFound
+	}
+
+	cp := current.Clone()
+	now := time.Now().UTC()
+	cp.DeletedAt = &now
+	cp.Version++
+
+	return tx.put(cp)
+}
+
+func (tx *storageTx) GetByID(id uuid.UUID) (*models.Student, error) {
+	if tx.done {
+		return nil, repositories.ErrBatchClosed
+	}
+
+	if id == uuid.Nil {
+		return nil, repositories.ErrInvalidStudentID
+	}
+
+	student, ok := tx.s.active(id)
+	if !ok {
+		return nil, repositories.ErrStudentNotFound
+	}
+
+	return student.Clone(), nil
+}
+
+func (tx *storageTx) AddGrades(id uuid.UUID, grades ...models.Grade) error {
+	if tx.done {
+		return repositories.ErrBatchClosed
+	}
+
+	current, ok := tx.s.active(id)
+	if !ok {
+		return repositories.ErrStudentNotFound
+	}
+
+	cp := current.Clone()
+
+	if err := cp.AddGrades(grades...); err != nil {
+		return fmt.Errorf("error while adding grades for student in storage: %w", err)
+	}
+
+	cp.Version++
+
+	return tx.put(cp)
+}
+
+func (tx *storageTx) create(cp *models.Student) (uuid.UUID, error) {
+	if tx.done {
+		return uuid.Nil, repositories.ErrBatchClosed
+	}
+
+	if _, ok := tx.s.students[cp.ID]; ok {
+		return uuid.Nil, repositories.ErrStudentAlreadyExists
+	}
+
+	cp.DeletedAt = nil
+	cp.Version = 1
+
+	if err := tx.put(cp); err != nil {
+		return uuid.Nil, err
+	}
+
+	return cp.ID, nil
+}
+
+func (tx *storageTx) update(cp *models.Student) error {
+	if tx.done {
+		return repositories.ErrBatchClosed
+	}
+
+	current, ok := tx.s.active(cp.ID)
+	if !ok {
+		return repositories.ErrStudentNotFound
+	}
+
+	if cp.Version != current.Version {
+		return versionConflictError(cp.ID, current.Version, cp.Version)
+	}
+
+	cp.DeletedAt = nil
+	cp.Version++
+
+	return tx.put(cp)
+}
+
+func (tx *storageTx) restore(id uuid.UUID) error {
+	if tx.done {
+		return repositories.ErrBatchClosed
+	}
+
+	current, ok := tx.s.students[id]
+	if !ok {
+		return repositories.ErrStudentNotFound
+	}
+
+	if !current.Deleted() {
+		return repositories.ErrStudentNotDeleted
+	}
+
+	cp := current.Clone()
+	cp.DeletedAt = nil
+	cp.Version++
+
+	return tx.put(cp)
+}
+
+func versionConflictError(id uuid.UUID, stored, got int) error {
+	return fmt.Errorf(
+		"%w: student %s is at version %d, got %d",
+		repositories.ErrVersionConflict,
+		id,
+		stored,
+		got,
+	)
+}
+
+// purge removes a student for good, unlike DeleteByID which only moves it to
+// the trash.
+func (tx *storageTx) purge(id uuid.UUID) {
+	tx.remember(id)
+	tx.s.index.remove(tx.s.students[id])
+	delete(tx.s.students, id)
+	delete(tx.s.seq, id)
+	tx.entries = append(tx.entries, persisters.DeleteEntry(id))
+	tx.purged = append(tx.purged, id)
+}
+
+func (tx *storageTx) put(cp *models.Student) error {
+	if err := tx.s.checkFullNameLocked(cp); err != nil {
+		return err
+	}
+
+	tx.remember(cp.ID)
+
+	if prev, ok := tx.s.students[cp.ID]; ok {
+		tx.s.index.remove(prev)
+	}
+
+	tx.s.students[cp.ID] = cp
+	tx.s.index.add(cp)
+	tx.s.track(cp.ID)
+	tx.entries = append(tx.entries, persisters.PutEntry(cp))
+	tx.revisions = append(tx.revisions, models.NewStudentRevision(cp, time.Now()))
+
+	return nil
+}
+
+func (tx *storageTx) remember(id uuid.UUID) {
+	if _, ok := tx.undo[id]; ok {
+		return
+	}
+
+	tx.undo[id] = undoEntry{
+		student: tx.s.students[id],
+		seq:     tx.s.seq[id],
+	}
+}
+
+func (tx *storageTx) rollback() {
+	for id, prev := range tx.undo {
+		if cur, ok := tx.s.students[id]; ok {
+			tx.s.index.remove(cur)
+		}
+
+		if prev.student == nil {
+			delete(tx.s.students, id)
+			delete(tx.s.seq, id)
+
+			continue
+		}
+
+		tx.s.students[id] = prev.student
+		tx.s.index.add(prev.student)
+		tx.s.seq[id] = prev.seq
+	}
+}
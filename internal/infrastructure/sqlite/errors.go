@@ -0,0 +1,8 @@
+package sqlite
+
+import "errors"
+
+var (
+	ErrInvalidMigrationName = errors.New("invalid migration file name")
+	ErrDuplicateMigration   = errors.New("duplicate migration version")
+)
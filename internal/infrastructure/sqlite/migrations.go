@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT    NOT NULL,
+    applied_at TEXT    NOT NULL
+)`
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles, "migrations")
+}
+
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	seen := make(map[int]string, len(entries))
+	out := make([]Migration, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+
+		rawVersion, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMigrationName, entry.Name())
+		}
+
+		version, err := strconv.Atoi(rawVersion)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMigrationName, entry.Name())
+		}
+
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("%w: %s and %s", ErrDuplicateMigration, prev, entry.Name())
+		}
+
+		seen[version] = entry.Name()
+
+		body, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		out = append(out, Migration{
+			Version: version,
+			Name:    name,
+			SQL:     string(body),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Version < out[j].Version
+	})
+
+	return out, nil
+}
+
+func Migrate(ctx context.Context, db *sql.DB) (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return 0, fmt.Errorf("failed to create schema migrations table: %w", err)
+	}
+
+	current, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	applied := 0
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		if err := applyMigration(ctx, db, m); err != nil {
+			return applied, err
+		}
+
+		applied++
+	}
+
+	return applied, nil
+}
+
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+
+	err := db.QueryRowContext(
+		ctx,
+		`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`,
+	).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %d transaction: %w", m.Version, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		_ = tx.Rollback()
+
+		return fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Name, err)
+	}
+
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version,
+		m.Name,
+		time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		_ = tx.Rollback()
+
+		return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+CREATE TABLE students (
+    id      TEXT    PRIMARY KEY,
+    name    TEXT    NOT NULL,
+    surname TEXT    NOT NULL,
+    age     INTEGER NOT NULL
+);
+
+CREATE INDEX idx_students_full_name ON students (name, surname);
+
+CREATE TABLE student_grades (
+    student_id TEXT    NOT NULL REFERENCES students (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    value      INTEGER NOT NULL,
+    PRIMARY KEY (student_id, position)
+);
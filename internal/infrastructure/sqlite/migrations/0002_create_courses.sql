@@ -0,0 +1,24 @@
+CREATE TABLE courses (
+    id   TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE COLLATE NOCASE
+);
+
+INSERT INTO courses (id, name) VALUES ('00000000-0000-4000-8000-000000000001', 'Unassigned');
+
+CREATE TABLE student_grades_v2 (
+    student_id TEXT    NOT NULL REFERENCES students (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    course_id  TEXT    NOT NULL REFERENCES courses (id),
+    value      INTEGER NOT NULL,
+    graded_at  TEXT    NOT NULL DEFAULT '',
+    PRIMARY KEY (student_id, position)
+);
+
+INSERT INTO student_grades_v2 (student_id, position, course_id, value)
+SELECT student_id, position, '00000000-0000-4000-8000-000000000001', value FROM student_grades;
+
+DROP TABLE student_grades;
+
+ALTER TABLE student_grades_v2 RENAME TO student_grades;
+
+CREATE INDEX idx_student_grades_course ON student_grades (course_id);
@@ -0,0 +1,7 @@
+ALTER TABLE student_grades ADD COLUMN author TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE student_grades ADD COLUMN comment TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE student_grades ADD COLUMN weight REAL NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_student_grades_graded_at ON student_grades (student_id, graded_at);
@@ -0,0 +1 @@
+CREATE INDEX idx_students_surname ON students (surname);
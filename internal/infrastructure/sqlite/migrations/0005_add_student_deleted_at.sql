@@ -0,0 +1,3 @@
+ALTER TABLE students ADD COLUMN deleted_at TEXT;
+
+CREATE INDEX idx_students_deleted_at ON students (deleted_at);
@@ -0,0 +1 @@
+ALTER TABLE students ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,7 @@
+CREATE TABLE student_revisions (
+    student_id  TEXT    NOT NULL REFERENCES students (id) ON DELETE CASCADE,
+    version     INTEGER NOT NULL,
+    recorded_at TEXT    NOT NULL,
+    snapshot    TEXT    NOT NULL,
+    PRIMARY KEY (student_id, version)
+);
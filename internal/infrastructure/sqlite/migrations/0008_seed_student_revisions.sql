@@ -0,0 +1,29 @@
+INSERT INTO student_revisions (student_id, version, recorded_at, snapshot)
+SELECT
+    s.id,
+    s.version,
+    strftime('%Y-%m-%dT%H:%M:%S', 'now') || '.000000000Z',
+    json_object(
+        'id', s.id,
+        'name', s.name,
+        'surname', s.surname,
+        'age', s.age,
+        'grades', (
+            SELECT json_group_array(
+                json_object(
+                    'course_id', g.course_id,
+                    'value', g.value,
+                    'date', CASE WHEN g.graded_at = '' THEN '0001-01-01T00:00:00Z' ELSE g.graded_at END,
+                    'author', g.author,
+                    'comment', g.comment,
+                    'weight', g.weight
+                ) ORDER BY g.position
+            )
+            FROM student_grades AS g
+            WHERE g.student_id = s.id
+        ),
+        'version', s.version,
+        'deleted_at', s.deleted_at
+    )
+FROM students AS s
+WHERE NOT EXISTS (SELECT 1 FROM student_revisions AS r WHERE r.student_id = s.id);
@@ -0,0 +1,142 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/internal/infrastructure/sqlite"
+)
+
+const (
+	sqliteTestPrefix = "SQLiteMigrations"
+)
+
+func TestMigrations_OrderedAndUnique(t *testing.T) {
+	migrations, err := sqlite.Migrations()
+	if err != nil {
+		t.Fatalf("[%s][Migrations] failed to load embedded migrations: %v", sqliteTestPrefix, err)
+	}
+
+	if len(migrations) == 0 {
+		t.Fatalf("[%s][Migrations] expected at least one embedded migration", sqliteTestPrefix)
+	}
+
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Fatalf(
+				"[%s][Migrations] versions must be sequential: got=%d want=%d (%s)",
+				sqliteTestPrefix,
+				m.Version,
+				i+1,
+				m.Name,
+			)
+		}
+
+		if m.SQL == "" {
+			t.Fatalf("[%s][Migrations] migration %d has empty body", sqliteTestPrefix, m.Version)
+		}
+	}
+}
+
+func TestOpen_AppliesMigrationsOnce(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "nested", "students.db")
+
+	db, err := sqlite.Open(ctx, path)
+	if err != nil {
+		t.Fatalf("[%s][Open] failed to open database: %v", sqliteTestPrefix, err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	migrations, err := sqlite.Migrations()
+	if err != nil {
+		t.Fatalf("[%s][Open] failed to load embedded migrations: %v", sqliteTestPrefix, err)
+	}
+
+	version, err := sqlite.SchemaVersion(ctx, db)
+	if err != nil {
+		t.Fatalf("[%s][Open] failed to read schema version: %v", sqliteTestPrefix, err)
+	}
+
+	if want := migrations[len(migrations)-1].Version; version != want {
+		t.Fatalf("[%s][Open] schema version mismatch: got=%d want=%d", sqliteTestPrefix, version, want)
+	}
+
+	applied, err := sqlite.Migrate(ctx, db)
+	if err != nil {
+		t.Fatalf("[%s][Migrate] unexpected error on second run: %v", sqliteTestPrefix, err)
+	}
+
+	if applied != 0 {
+		t.Fatalf("[%s][Migrate] second run must be a no-op, applied=%d", sqliteTestPrefix, applied)
+	}
+}
+
+func TestMigrate_MovesLegacyGradesIntoUnassignedCourse(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "students.db")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatalf("[%s][Migrate] failed to open database: %v", sqliteTestPrefix, err)
+	}
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	migrations, err := sqlite.Migrations()
+	if err != nil {
+		t.Fatalf("[%s][Migrate] failed to load embedded migrations: %v", sqliteTestPrefix, err)
+	}
+
+	legacy := []string{
+		`CREATE TABLE schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       TEXT    NOT NULL,
+			applied_at TEXT    NOT NULL
+		)`,
+		migrations[0].SQL,
+		`INSERT INTO schema_migrations (version, name, applied_at) VALUES (1, 'create_students', '')`,
+		`INSERT INTO students (id, name, surname, age) VALUES ('s1', 'Mikhail', 'Gunin', 19)`,
+		`INSERT INTO student_grades (student_id, position, value) VALUES ('s1', 0, 90), ('s1', 1, 75)`,
+	}
+
+	for _, stmt := range legacy {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("[%s][Migrate] failed to prepare legacy schema: %v", sqliteTestPrefix, err)
+		}
+	}
+
+	if _, err := sqlite.Migrate(ctx, db); err != nil {
+		t.Fatalf("[%s][Migrate] failed to migrate legacy schema: %v", sqliteTestPrefix, err)
+	}
+
+	var (
+		count  int
+		course string
+	)
+
+	err = db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*), MIN(c.name) FROM student_grades g JOIN courses c ON c.id = g.course_id`,
+	).Scan(&count, &course)
+	if err != nil {
+		t.Fatalf("[%s][Migrate] failed to query migrated grades: %v", sqliteTestPrefix, err)
+	}
+
+	if count != 2 || course != "Unassigned" {
+		t.Fatalf(
+			"[%s][Migrate] legacy grades mismatch: got count=%d course=%q, want count=2 course=%q",
+			sqliteTestPrefix,
+			count,
+			course,
+			"Unassigned",
+		)
+	}
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	ExitOK = iota
+	ExitFailure
+	ExitUsage
+	ExitNotFound
+	ExitInvalid
+	ExitConflict
+)
+
+const usageText = `Usage: studify [global flags] <command> [flags] [args]
+
+Commands:
+  add --name <name> --surname <surname> [--age <age>] [--grades 90,85] [--course <id>]
+  list [--grades] [--name <prefix>] [--surname <prefix>] [--min-age <n>] [--max-age <n>]
+       [--min-avg <x>] [--max-avg <x>] [--has-grades true|false]
+       [--sort created|name|surname|age|avg] [--desc] [--offset <n>] [--limit <n>]
+  search [--grades] <query...>
+  show <id>
+  grades add [--course <id>] [--author <name>] [--comment <text>] [--weight <w>] <id> <grades CSV>
+  avg [--days <n> | --since <YYYY-MM-DD>] <id>
+  courses add <name>
+  courses list
+  report [--top <n>]
+  audit [--student <id>] [--actor <name>] [--action <action>] [--limit <n>]
+  delete <id>
+  trash list [--grades]
+  trash restore <id>
+  trash purge [--days <n>]
+  import [--format csv] [--dry-run] <file|->
+  export [--format csv]
+  serve [--addr :8080]
+  rekey --old-key <key> --new-key <key> [--old_kdf none|argon2id] [--new_kdf none|argon2id]
+  help
+
+Every command except export accepts --output text|json.
+Search matches names partially, ignoring case and small typos, best match first.
+Grades without --course are recorded in the Unassigned course.
+CSV columns: id, name, surname, age, grades. Import keeps the ids and rejects rows whose
+id is already taken, rows without an id get a new one. Grades are values separated by ;
+or the JSON list export writes with course, date, author, comment and weight.
+Import rejects rows with grades in unknown courses and, with --unique_names, rows whose
+full name is taken by an active student or an earlier row.
+Audit lists changes newest first and exits with code 1 if the hash chain does not verify,
+actions are register, update, delete, add_grades, restore and purge.
+Delete moves a student to the trash; trash purge removes students deleted at least
+--days days ago for good (0, the default, empties the trash).
+Import saves all valid rows at once and exits with code 4 if any row was rejected.
+Run without a command to start the interactive TUI.
+`
+
+var errUsage = errors.New("usage error")
+
+type Runner struct {
+	svc         services.StudentServiceContract
+	courses     services.CourseServiceContract
+	reports     services.ReportServiceContract
+	audit       services.AuditServiceContract
+	out         io.Writer
+	errOut      io.Writer
+	uniqueNames bool
+}
+
+type command func(r *Runner, args []string) error
+
+func NewRunner(
+	svc services.StudentServiceContract,
+	courses services.CourseServiceContract,
+	reports services.ReportServiceContract,
+	audit services.AuditServiceContract,
+	out, errOut io.Writer,
+) *Runner {
+	return &Runner{
+		svc:     svc,
+		courses: courses,
+		reports: reports,
+		audit:   audit,
+		out:     out,
+		errOut:  errOut,
+	}
+}
+
+// WithUniqueNames returns a runner whose import rejects rows with the full
+// name of an active student or of an earlier row, as the storage does when
+// it is opened with unique names.
+func (r *Runner) WithUniqueNames(unique bool) *Runner {
+	cp := *r
+	cp.uniqueNames = unique
+
+	return &cp
+}
+
+func (r *Runner) commands() map[string]command {
+	return map[string]command{
+		"add":     (*Runner).runAdd,
+		"list":    (*Runner).runList,
+		"search":  (*Runner).runSearch,
+		"show":    (*Runner).runShow,
+		"grades":  (*Runner).runGrades,
+		"avg":     (*Runner).runAVG,
+		"delete":  (*Runner).runDelete,
+		"trash":   (*Runner).runTrash,
+		"courses": (*Runner).runCourses,
+		"report":  (*Runner).runReport,
+		"audit":   (*Runner).runAudit,
+		"import":  (*Runner).runImport,
+		"export":  (*Runner).runExport,
+	}
+}
+
+func (r *Runner) Run(name string, args []string) int {
+	if name == "help" || name == "-h" || name == "--help" {
+		r.usage()
+
+		return ExitOK
+	}
+
+	cmd, ok := r.commands()[name]
+	if !ok {
+		r.errorf("unknown command %q", name)
+		r.usage()
+
+		return ExitUsage
+	}
+
+	if err := cmd(r, args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+
+		r.errorf("%s: %v", name, err)
+
+		return exitCode(err)
+	}
+
+	return ExitOK
+}
+
+func (r *Runner) usage() {
+	_, _ = io.WriteString(r.errOut, usageText)
+}
+
+func (r *Runner) errorf(format string, args ...any) {
+	_, _ = fmt.Fprintf(r.errOut, "studify: "+format+"\n", args...)
+}
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	case errors.Is(err, repositories.ErrStudentNotFound),
+		errors.Is(err, repositories.ErrCourseNotFound):
+		return ExitNotFound
+	case errors.Is(err, repositories.ErrStudentAlreadyExists),
+		errors.Is(err, repositories.ErrDuplicateFullName),
+		errors.Is(err, repositories.ErrStudentNotDeleted),
+		errors.Is(err, repositories.ErrVersionConflict),
+		errors.Is(err, repositories.ErrCourseAlreadyExists):
+		return ExitConflict
+	case errors.Is(err, repositories.ErrInvalidStudentID),
+		errors.Is(err, repositories.ErrInvalidStudentQuery),
+		errors.Is(err, repositories.ErrInvalidCourseID),
+		errors.Is(err, errInvalidRows),
+		validators.IsValidationError(err):
+		return ExitInvalid
+	default:
+		return ExitFailure
+	}
+}
+
+func usageErrorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+func newFlagSet(r *Runner, name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(r.errOut)
+
+	output := fs.String("output", outputText, "Output format: text or json")
+
+	return fs, output
+}
+
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+
+			return nil, fmt.Errorf("%w: %w", errUsage, err)
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if f := fs.Lookup("output"); f != nil {
+		if format := f.Value.String(); format != outputText && format != outputJSON {
+			return nil, usageErrorf("unknown output format %q, expected text or json", format)
+		}
+	}
+
+	return positional, nil
+}
+
+func expectArgs(args []string, names ...string) error {
+	if len(names) == 0 && len(args) > 0 {
+		return usageErrorf("unexpected arguments: %s", strings.Join(args, " "))
+	}
+
+	if len(args) != len(names) {
+		return usageErrorf(
+			"expected %d argument(s) <%s>, got %d",
+			len(names),
+			strings.Join(names, "> <"),
+			len(args),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package cli_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
+	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	infrarepo "github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/internal/presentation/cli"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	cliTestPrefix = "StudifyCLI"
+)
+
+type runCase struct {
+	name     string
+	command  string
+	args     []string
+	wantCode int
+	wantOut  string
+}
+
+func newTestRunner(t *testing.T) (*cli.Runner, *bytes.Buffer, services.StudentServiceContract) {
+	t.Helper()
+
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", cliTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] failed to create repository: %v", cliTestPrefix, err)
+	}
+
+	courses, err := infrarepo.NewCourseStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] failed to create course repository: %v", cliTestPrefix, err)
+	}
+
+	audit, err := infrarepo.NewAuditStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] failed to create audit log: %v", cliTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, courses, grading.DefaultPolicy(), audit).
+		WithActor("registrar")
+	out := &bytes.Buffer{}
+
+	runner := cli.NewRunner(
+		svc,
+		services.NewCourseService(courses),
+		services.NewReportService(repo, grading.DefaultPolicy()),
+		services.NewAuditService(audit),
+		out,
+		&bytes.Buffer{},
+	)
+
+	return runner, out, svc
+}
+
+func TestRunner_Run_ExitCodes(t *testing.T) {
+	runner, out, svc := newTestRunner(t)
+
+	created, err := svc.Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades:  mappers.MapGradeValuesToDTOs("", []int{90, 60}),
+	})
+	if err != nil {
+		t.Fatalf("[%s] failed to register student: %v", cliTestPrefix, err)
+	}
+
+	missingID := "00000000-0000-4000-8000-000000000000"
+
+	tests := []runCase{
+		{"help", "help", nil, cli.ExitOK, ""},
+		{"unknown command", "unknown", nil, cli.ExitUsage, ""},
+		{
+			"add ok",
+			"add",
+			[]string{"--name", "Alexander", "--surname", "Gunin", "--age", "20"},
+			cli.ExitOK,
+			"Name: Alexander",
+		},
+		{
+			"add invalid name",
+			"add",
+			[]string{"--name", "alexander", "--surname", "Gunin"},
+			cli.ExitInvalid,
+			"",
+		},
+		{"add bad grades", "add", []string{"--name", "A", "--surname", "B", "--grades", "x"}, cli.ExitUsage, ""},
+		{"list", "list", []string{"--grades"}, cli.ExitOK, "90,60"},
+		{
+			"list page",
+			"list",
+			[]string{"--surname", "Gun", "--sort", "name", "--limit", "1"},
+			cli.ExitOK,
+			"Showing 1 of 2 students from offset 0",
+		},
+		{"list bad sort", "list", []string{"--sort", "grade"}, cli.ExitInvalid, ""},
+		{"list bad min age", "list", []string{"--min-age", "old"}, cli.ExitUsage, ""},
+		{"search typo", "search", []string{"--grades", "mikhial"}, cli.ExitOK, "90,60"},
+		{"search many words", "search", []string{"gunin", "alex"}, cli.ExitOK, "Alexander"},
+		{"search no args", "search", nil, cli.ExitUsage, ""},
+		{"search too long", "search", []string{strings.Repeat("a", 129)}, cli.ExitInvalid, ""},
+		{"show ok", "show", []string{created.ID}, cli.ExitOK, "Surname: Gunin"},
+		{"show missing", "show", []string{missingID}, cli.ExitNotFound, ""},
+		{"show bad uuid", "show", []string{"bad-uuid"}, cli.ExitInvalid, ""},
+		{"show no args", "show", nil, cli.ExitUsage, ""},
+		{"avg", "avg", []string{created.ID}, cli.ExitOK, "AVG: 75.00"},
+		{"report", "report", nil, cli.ExitOK, "Students: 2 (1 graded, 1 without grades)"},
+		{"report bad top", "report", []string{"--top", "-1"}, cli.ExitInvalid, ""},
+		{"grades add", "grades", []string{"add", created.ID, "100"}, cli.ExitOK, "90, 60, 100"},
+		{"grades without subcommand", "grades", []string{created.ID}, cli.ExitUsage, ""},
+		{"bad output format", "list", []string{"--output", "xml"}, cli.ExitUsage, ""},
+		{"delete missing", "delete", []string{missingID}, cli.ExitNotFound, ""},
+		{"delete ok", "delete", []string{created.ID}, cli.ExitOK, "trash restore " + created.ID},
+		{"show deleted", "show", []string{created.ID}, cli.ExitNotFound, ""},
+		{"trash list", "trash", []string{"list", "--grades"}, cli.ExitOK, "90,60,100"},
+		{"trash without subcommand", "trash", nil, cli.ExitUsage, ""},
+		{"trash restore ok", "trash", []string{"restore", created.ID}, cli.ExitOK, "Surname: Gunin"},
+		{"trash restore active", "trash", []string{"restore", created.ID}, cli.ExitConflict, ""},
+		{"trash restore missing", "trash", []string{"restore", missingID}, cli.ExitNotFound, ""},
+		{"trash purge bad days", "trash", []string{"purge", "--days", "-1"}, cli.ExitInvalid, ""},
+		{"delete again", "delete", []string{created.ID}, cli.ExitOK, "deleted"},
+		{"trash purge keeps fresh", "trash", []string{"purge", "--days", "30"}, cli.ExitOK, "Purged 0 students"},
+		{"trash purge", "trash", []string{"purge"}, cli.ExitOK, "Purged 1 students from trash"},
+		{"trash restore purged", "trash", []string{"restore", created.ID}, cli.ExitNotFound, ""},
+		{"audit", "audit", nil, cli.ExitOK, "Chain: verified, showing 7 of 7 entries"},
+		{"audit by action", "audit", []string{"--action", "delete"}, cli.ExitOK, "registrar"},
+		{"audit bad action", "audit", []string{"--action", "rename"}, cli.ExitInvalid, ""},
+		{"audit bad limit", "audit", []string{"--limit", "many"}, cli.ExitUsage, ""},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-run-%s-№%d", cliTestPrefix, tc.name, i+1), func(t *testing.T) {
+			out.Reset()
+
+			code := runner.Run(tc.command, tc.args)
+			if code != tc.wantCode {
+				t.Fatalf(
+					"[%s][Run] exit code mismatch: got=%d want=%d",
+					cliTestPrefix,
+					code,
+					tc.wantCode,
+				)
+			}
+
+			if tc.wantOut != "" && !strings.Contains(out.String(), tc.wantOut) {
+				t.Fatalf(
+					"[%s][Run] output %q does not contain %q",
+					cliTestPrefix,
+					out.String(),
+					tc.wantOut,
+				)
+			}
+		})
+	}
+}
+
+func TestRunner_Run_JSONOutput(t *testing.T) {
+	runner, out, _ := newTestRunner(t)
+
+	code := runner.Run("add", []string{
+		"--output", "json",
+		"--name", "Mikhail",
+		"--surname", "Gunin",
+		"--age", "19",
+		"--grades", "80,100",
+	})
+	if code != cli.ExitOK {
+		t.Fatalf("[%s][JSON] add exit code: got=%d want=%d", cliTestPrefix, code, cli.ExitOK)
+	}
+
+	var created dtos.DefaultStudentResponseDTO
+	if err := json.Unmarshal(out.Bytes(), &created); err != nil {
+		t.Fatalf("[%s][JSON] failed to decode add output: %v", cliTestPrefix, err)
+	}
+
+	if created.Name != "Mikhail" || created.AvgGrade == nil || *created.AvgGrade != 90 {
+		t.Fatalf("[%s][JSON] unexpected add output: %+v", cliTestPrefix, created)
+	}
+
+	out.Reset()
+
+	if code := runner.Run("avg", []string{created.ID, "--output=json"}); code != cli.ExitOK {
+		t.Fatalf("[%s][JSON] avg exit code: got=%d want=%d", cliTestPrefix, code, cli.ExitOK)
+	}
+
+	var avg dtos.AVGResponseDTO
+	if err := json.Unmarshal(out.Bytes(), &avg); err != nil {
+		t.Fatalf("[%s][JSON] failed to decode avg output: %v", cliTestPrefix, err)
+	}
+
+	if avg.ID != created.ID || avg.AVG != 90 {
+		t.Fatalf("[%s][JSON] unexpected avg output: %+v", cliTestPrefix, avg)
+	}
+}
+
+func TestRunner_Run_Courses(t *testing.T) {
+	runner, out, _ := newTestRunner(t)
+
+	if code := runner.Run("courses", []string{"add", "--output", "json", "Math"}); code != cli.ExitOK {
+		t.Fatalf("[%s][Courses] add exit code: got=%d want=%d", cliTestPrefix, code, cli.ExitOK)
+	}
+
+	var math dtos.CourseResponseDTO
+	if err := json.Unmarshal(out.Bytes(), &math); err != nil {
+		t.Fatalf("[%s][Courses] failed to decode course output: %v", cliTestPrefix, err)
+	}
+
+	out.Reset()
+
+	code := runner.Run("add", []string{
+		"--output", "json",
+		"--name", "Mikhail",
+		"--surname", "Gunin",
+		"--grades", "90",
+		"--course", math.ID,
+	})
+	if code != cli.ExitOK {
+		t.Fatalf("[%s][Courses] student add exit code: got=%d want=%d", cliTestPrefix, code, cli.ExitOK)
+	}
+
+	var created dtos.DefaultStudentResponseDTO
+	if err := json.Unmarshal(out.Bytes(), &created); err != nil {
+		t.Fatalf("[%s][Courses] failed to decode student output: %v", cliTestPrefix, err)
+	}
+
+	missingID := "00000000-0000-4000-8000-000000000000"
+
+	tests := []runCase{
+		{"list courses", "courses", []string{"list"}, cli.ExitOK, "Unassigned"},
+		{"duplicate course", "courses", []string{"add", "Math"}, cli.ExitConflict, ""},
+		{"unknown subcommand", "courses", []string{"remove"}, cli.ExitUsage, ""},
+		{"grades unassigned", "grades", []string{"add", created.ID, "70"}, cli.ExitOK, "90, 70"},
+		{
+			"grades unknown course",
+			"grades",
+			[]string{"add", "--course", missingID, created.ID, "70"},
+			cli.ExitNotFound,
+			"",
+		},
+		{
+			"grades bad course id",
+			"grades",
+			[]string{"add", "--course", "bad", created.ID, "70"},
+			cli.ExitInvalid,
+			"",
+		},
+		{"avg per course", "avg", []string{created.ID}, cli.ExitOK, "Math"},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-courses-%s-№%d", cliTestPrefix, tc.name, i+1), func(t *testing.T) {
+			out.Reset()
+
+			if code := runner.Run(tc.command, tc.args); code != tc.wantCode {
+				t.Fatalf(
+					"[%s][Courses] exit code mismatch: got=%d want=%d",
+					cliTestPrefix,
+					code,
+					tc.wantCode,
+				)
+			}
+
+			if tc.wantOut != "" && !strings.Contains(out.String(), tc.wantOut) {
+				t.Fatalf(
+					"[%s][Courses] output %q does not contain %q",
+					cliTestPrefix,
+					out.String(),
+					tc.wantOut,
+				)
+			}
+		})
+	}
+}
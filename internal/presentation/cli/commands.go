@@ -0,0 +1,429 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+)
+
+func (r *Runner) runAdd(args []string) error {
+	fs, output := newFlagSet(r, "add")
+
+	name := fs.String("name", "", "Student name (Capitalized)")
+	surname := fs.String("surname", "", "Student surname (Capitalized)")
+	age := fs.Int("age", 0, "Student age")
+	gradesCSV := fs.String("grades", "", "Grades separated by comma, e.g. 70,85,90")
+	course := fs.String("course", "", "Course ID for the grades")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest); err != nil {
+		return err
+	}
+
+	grades, err := parseGrades(*gradesCSV)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.svc.Register(dtos.StudentCreateDTO{
+		Name:    strings.TrimSpace(*name),
+		Surname: strings.TrimSpace(*surname),
+		Age:     *age,
+		Grades:  mappers.MapGradeValuesToDTOs(strings.TrimSpace(*course), grades),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register student: %w", err)
+	}
+
+	return r.printStudent(*output, resp)
+}
+
+func (r *Runner) runList(args []string) error {
+	fs, output := newFlagSet(r, "list")
+
+	var q dtos.StudentQueryDTO
+
+	fs.BoolVar(&q.IncludeGrades, "grades", false, "Include grades into the output")
+	fs.StringVar(&q.Name, "name", "", "Only students whose name starts with this prefix")
+	fs.StringVar(&q.Surname, "surname", "", "Only students whose surname starts with this prefix")
+	fs.Func("min-age", "Minimal age", optionalFlag(strconv.Atoi, &q.MinAge))
+	fs.Func("max-age", "Maximal age", optionalFlag(strconv.Atoi, &q.MaxAge))
+	fs.Func("min-avg", "Minimal average grade", optionalFlag(parseFloat, &q.MinAVG))
+	fs.Func("max-avg", "Maximal average grade", optionalFlag(parseFloat, &q.MaxAVG))
+	fs.Func(
+		"has-grades",
+		"Only students with (true) or without (false) grades",
+		optionalFlag(strconv.ParseBool, &q.HasGrades),
+	)
+	fs.StringVar(&q.Sort, "sort", "", "Sort by created, name, surname, age or avg")
+	fs.BoolVar(&q.Desc, "desc", false, "Sort in descending order")
+	fs.IntVar(&q.Offset, "offset", 0, "Skip this many students")
+	fs.IntVar(&q.Limit, "limit", 0, "Return at most this many students, 0 means all")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest); err != nil {
+		return err
+	}
+
+	page, err := r.svc.Query(q)
+	if err != nil {
+		return fmt.Errorf("failed to list students: %w", err)
+	}
+
+	return r.printList(*output, page)
+}
+
+func (r *Runner) runSearch(args []string) error {
+	fs, output := newFlagSet(r, "search")
+
+	includeGrades := fs.Bool("grades", false, "Include grades into the output")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) == 0 {
+		return usageErrorf("expected at least 1 argument <query>, got 0")
+	}
+
+	list, err := r.svc.SearchByName(dtos.SearchByNameDTO{
+		Query:         strings.Join(rest, " "),
+		IncludeGrades: *includeGrades,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to search students: %w", err)
+	}
+
+	return r.printList(*output, dtos.StudentPageDTO{Items: list, Total: len(list)})
+}
+
+func (r *Runner) runShow(args []string) error {
+	fs, output := newFlagSet(r, "show")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest, "id"); err != nil {
+		return err
+	}
+
+	resp, err := r.svc.GetByID(dtos.GetByIDDTO{ID: strings.TrimSpace(rest[0])})
+	if err != nil {
+		return fmt.Errorf("failed to get student: %w", err)
+	}
+
+	return r.printStudent(*output, resp)
+}
+
+func (r *Runner) runGrades(args []string) error {
+	if len(args) == 0 || args[0] != "add" {
+		return usageErrorf("expected subcommand: grades add [flags] <id> <grades CSV>")
+	}
+
+	fs, output := newFlagSet(r, "grades add")
+
+	course := fs.String("course", "", "Course ID for the grades")
+	author := fs.String("author", "", "Teacher who gave the grades")
+	comment := fs.String("comment", "", "Comment attached to the grades")
+	weight := fs.Float64("weight", 0, "Weight of the grades, defaults to 1")
+
+	rest, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest, "id", "grades CSV"); err != nil {
+		return err
+	}
+
+	grades, err := parseGrades(rest[1])
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.svc.AddGrades(dtos.AddGradesDTO{
+		ID:       strings.TrimSpace(rest[0]),
+		CourseID: strings.TrimSpace(*course),
+		Grades:   grades,
+		Author:   strings.TrimSpace(*author),
+		Comment:  strings.TrimSpace(*comment),
+		Weight:   *weight,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add grades: %w", err)
+	}
+
+	return r.printStudent(*output, resp)
+}
+
+func (r *Runner) runAVG(args []string) error {
+	fs, output := newFlagSet(r, "avg")
+
+	days := fs.Int("days", 0, "Only count grades from the last N days")
+	since := fs.String("since", "", "Only count grades given on or after this date (YYYY-MM-DD)")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest, "id"); err != nil {
+		return err
+	}
+
+	resp, err := r.svc.AVGByID(dtos.AVGQueryDTO{
+		ID:    strings.TrimSpace(rest[0]),
+		Days:  *days,
+		Since: strings.TrimSpace(*since),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to calculate average: %w", err)
+	}
+
+	return r.printAVG(*output, resp)
+}
+
+func (r *Runner) runDelete(args []string) error {
+	fs, output := newFlagSet(r, "delete")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest, "id"); err != nil {
+		return err
+	}
+
+	id := strings.TrimSpace(rest[0])
+
+	if err := r.svc.DeleteByID(dtos.GetByIDDTO{ID: id}); err != nil {
+		return fmt.Errorf("failed to delete student: %w", err)
+	}
+
+	return r.printDeleted(*output, id)
+}
+
+func (r *Runner) runTrash(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("expected subcommand: trash list | trash restore <id> | trash purge")
+	}
+
+	fs, output := newFlagSet(r, "trash "+args[0])
+
+	var (
+		withGrades bool
+		days       int
+	)
+
+	switch args[0] {
+	case "list":
+		fs.BoolVar(&withGrades, "grades", false, "Include grades into the output")
+	case "purge":
+		fs.IntVar(&days, "days", 0, "Purge only students deleted at least this many days ago")
+	}
+
+	rest, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		if err := expectArgs(rest); err != nil {
+			return err
+		}
+
+		list, err := r.svc.ListDeleted(withGrades)
+		if err != nil {
+			return fmt.Errorf("failed to list trash: %w", err)
+		}
+
+		return r.printTrash(*output, list)
+	case "restore":
+		if err := expectArgs(rest, "id"); err != nil {
+			return err
+		}
+
+		resp, err := r.svc.Restore(dtos.GetByIDDTO{ID: strings.TrimSpace(rest[0])})
+		if err != nil {
+			return fmt.Errorf("failed to restore student: %w", err)
+		}
+
+		return r.printStudent(*output, resp)
+	case "purge":
+		if err := expectArgs(rest); err != nil {
+			return err
+		}
+
+		purged, err := r.svc.PurgeDeleted(dtos.PurgeDeletedDTO{OlderThanDays: days})
+		if err != nil {
+			return fmt.Errorf("failed to purge trash: %w", err)
+		}
+
+		return r.printPurged(*output, purged)
+	default:
+		return usageErrorf("unknown subcommand %q, expected list, restore or purge", args[0])
+	}
+}
+
+func (r *Runner) runCourses(args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("expected subcommand: courses add <name> | courses list")
+	}
+
+	fs, output := newFlagSet(r, "courses "+args[0])
+
+	rest, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		if err := expectArgs(rest, "name"); err != nil {
+			return err
+		}
+
+		resp, err := r.courses.Create(dtos.CourseCreateDTO{Name: strings.TrimSpace(rest[0])})
+		if err != nil {
+			return fmt.Errorf("failed to create course: %w", err)
+		}
+
+		return r.printCourse(*output, resp)
+	case "list":
+		if err := expectArgs(rest); err != nil {
+			return err
+		}
+
+		list, err := r.courses.List()
+		if err != nil {
+			return fmt.Errorf("failed to list courses: %w", err)
+		}
+
+		return r.printCourses(*output, list)
+	default:
+		return usageErrorf("unknown subcommand %q, expected add or list", args[0])
+	}
+}
+
+func (r *Runner) runReport(args []string) error {
+	fs, output := newFlagSet(r, "report")
+
+	top := fs.Int("top", 0, "Number of students in top and bottom lists, defaults to 5")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest); err != nil {
+		return err
+	}
+
+	report, err := r.reports.Roster(dtos.ReportQueryDTO{Top: *top})
+	if err != nil {
+		return fmt.Errorf("failed to build report: %w", err)
+	}
+
+	return r.printReport(*output, report)
+}
+
+func optionalFlag[T any](parse func(string) (T, error), dst **T) func(string) error {
+	return func(raw string) error {
+		v, err := parse(raw)
+		if err != nil {
+			return err
+		}
+
+		*dst = &v
+
+		return nil
+	}
+}
+
+func parseFloat(raw string) (float64, error) {
+	return strconv.ParseFloat(raw, 64)
+}
+
+func parseGrades(csv string) ([]int, error) {
+	var grades []int
+
+	for _, p := range strings.Split(strings.TrimSpace(csv), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, usageErrorf("invalid grade %q: %v", p, err)
+		}
+
+		grades = append(grades, v)
+	}
+
+	return grades, nil
+}
+
+// auditActionUsage lists the audit actions for the --action flag help.
+func auditActionUsage() string {
+	actions := models.AuditActions()
+
+	names := make([]string, 0, len(actions))
+	for _, a := range actions[:len(actions)-1] {
+		names = append(names, string(a))
+	}
+
+	return fmt.Sprintf("Only %s or %s changes", strings.Join(names, ", "), actions[len(actions)-1])
+}
+
+func (r *Runner) runAudit(args []string) error {
+	fs, output := newFlagSet(r, "audit")
+
+	var q dtos.AuditQueryDTO
+
+	fs.StringVar(&q.StudentID, "student", "", "Only changes of this student ID")
+	fs.StringVar(&q.Actor, "actor", "", "Only changes made by this actor")
+	fs.StringVar(&q.Action, "action", "", auditActionUsage())
+	fs.IntVar(&q.Limit, "limit", 0, "Show at most this many latest changes, 0 means all")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest); err != nil {
+		return err
+	}
+
+	auditLog, err := r.audit.List(q)
+	if err != nil {
+		return fmt.Errorf("failed to list audit log: %w", err)
+	}
+
+	if err := r.printAudit(*output, auditLog); err != nil {
+		return err
+	}
+
+	if !auditLog.Verified {
+		return fmt.Errorf("audit log failed verification: %s", auditLog.Problem)
+	}
+
+	return nil
+}
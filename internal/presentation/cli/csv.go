@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	formatCSV = "csv"
+
+	csvColumnID      = "id"
+	csvColumnName    = "name"
+	csvColumnSurname = "surname"
+	csvColumnAge     = "age"
+	csvColumnGrades  = "grades"
+)
+
+type csvRow struct {
+	Line int
+	DTO  dtos.StudentCreateDTO
+}
+
+type csvRowError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+func readRosterCSV(r io.Reader) ([]csvRow, []csvRowError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, usageErrorf("csv file is empty, expected header %s,%s,%s,%s",
+			csvColumnName, csvColumnSurname, csvColumnAge, csvColumnGrades)
+	}
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	columns, err := csvColumns(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		rows    []csvRow
+		rowErrs []csvRowError
+		seenIDs = make(map[string]int)
+	)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(parseErr.Err, csv.ErrFieldCount) {
+				return nil, nil, fmt.Errorf("failed to read csv: %w", err)
+			}
+
+			rowErrs = append(rowErrs, csvRowError{
+				Line:  parseErr.Line,
+				Error: fmt.Sprintf("expected %d fields, got %d", len(header), len(record)),
+			})
+
+			continue
+		}
+
+		dto, err := csvRecordToDTO(columns, record)
+		if err == nil {
+			err = validators.Validate.Struct(dto)
+		}
+
+		if err == nil && dto.ID != "" {
+			if first, ok := seenIDs[dto.ID]; ok {
+				err = fmt.Errorf("id %s is already used on line %d", dto.ID, first)
+			} else {
+				seenIDs[dto.ID] = line
+			}
+		}
+
+		if err != nil {
+			rowErrs = append(rowErrs, csvRowError{Line: line, Error: describeRowError(err)})
+
+			continue
+		}
+
+		rows = append(rows, csvRow{Line: line, DTO: dto})
+	}
+
+	return rows, rowErrs, nil
+}
+
+func csvColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int, len(header))
+
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+
+		switch name {
+		case csvColumnID, csvColumnName, csvColumnSurname, csvColumnAge, csvColumnGrades:
+		default:
+			return nil, usageErrorf("unknown csv column %q", name)
+		}
+
+		if _, ok := columns[name]; ok {
+			return nil, usageErrorf("duplicate csv column %q", name)
+		}
+
+		columns[name] = i
+	}
+
+	for _, required := range []string{csvColumnName, csvColumnSurname} {
+		if _, ok := columns[required]; !ok {
+			return nil, usageErrorf("csv header must contain %q column", required)
+		}
+	}
+
+	return columns, nil
+}
+
+func csvRecordToDTO(columns map[string]int, record []string) (dtos.StudentCreateDTO, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+
+		return ""
+	}
+
+	dto := dtos.StudentCreateDTO{
+		ID:      field(csvColumnID),
+		Name:    field(csvColumnName),
+		Surname: field(csvColumnSurname),
+	}
+
+	if raw := field(csvColumnAge); raw != "" {
+		age, err := strconv.Atoi(raw)
+		if err != nil {
+			return dto, fmt.Errorf("invalid age %q", raw)
+		}
+
+		dto.Age = age
+	}
+
+	grades, err := parseCSVGrades(field(csvColumnGrades))
+	if err != nil {
+		return dto, err
+	}
+
+	dto.Grades = grades
+
+	return dto, nil
+}
+
+// parseCSVGrades reads the JSON list export writes or plain values separated
+// by ;, commas or spaces, which go to the unassigned course.
+func parseCSVGrades(raw string) ([]dtos.GradeDTO, error) {
+	if strings.HasPrefix(raw, "[") {
+		var grades []dtos.GradeDTO
+		if err := json.Unmarshal([]byte(raw), &grades); err != nil {
+			return nil, fmt.Errorf("invalid grades %q: %w", raw, err)
+		}
+
+		return grades, nil
+	}
+
+	var grades []dtos.GradeDTO
+
+	for _, value := range strings.FieldsFunc(raw, func(r rune) bool {
+		return r == ';' || r == ',' || unicode.IsSpace(r)
+	}) {
+		grade, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid grade %q", value)
+		}
+
+		grades = append(grades, dtos.GradeDTO{Value: grade})
+	}
+
+	return grades, nil
+}
+
+// formatCSVGrades writes the grades as a JSON list, so the course, date,
+// author, comment and weight of every grade survive an export and import.
+func formatCSVGrades(grades []dtos.GradeDTO) (string, error) {
+	if len(grades) == 0 {
+		return "", nil
+	}
+
+	raw, err := json.Marshal(grades)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal grades: %w", err)
+	}
+
+	return string(raw), nil
+}
+
+func describeRowError(err error) string {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err.Error()
+	}
+
+	msgs := make([]string, 0, len(fieldErrs))
+
+	for _, fe := range fieldErrs {
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+
+		field := fe.Namespace()
+		if _, nested, ok := strings.Cut(field, "."); ok {
+			field = nested
+		}
+
+		msgs = append(msgs, fmt.Sprintf("%s %v violates %s", strings.ToLower(field), fe.Value(), rule))
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func writeRosterCSV(w io.Writer, list []dtos.StudentListItemDTO) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{
+		csvColumnID,
+		csvColumnName,
+		csvColumnSurname,
+		csvColumnAge,
+		csvColumnGrades,
+	}); err != nil {
+		return fmt.Errorf("failed to write csv header: %w", err)
+	}
+
+	for _, s := range list {
+		grades, err := formatCSVGrades(s.Grades)
+		if err != nil {
+			return err
+		}
+
+		if err := writer.Write([]string{
+			s.ID,
+			s.Name,
+			s.Surname,
+			strconv.Itoa(s.Age),
+			grades,
+		}); err != nil {
+			return fmt.Errorf("failed to write csv row: %w", err)
+		}
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush csv: %w", err)
+	}
+
+	return nil
+}
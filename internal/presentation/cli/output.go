@@ -0,0 +1,365 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type deletedResponse struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+func (r *Runner) printStudent(format string, s dtos.DefaultStudentResponseDTO) error {
+	return r.print(format, s, func(w io.Writer) error {
+		lines := []string{
+			"ID: " + s.ID,
+			"Name: " + s.Name,
+			"Surname: " + s.Surname,
+			"Age: " + strconv.Itoa(s.Age),
+			"Version: " + strconv.Itoa(s.Version),
+		}
+
+		if len(s.Grades) > 0 {
+			lines = append(lines, "Grades: "+joinGrades(s.Grades, ", "))
+		}
+
+		if s.AvgGrade != nil {
+			lines = append(lines, fmt.Sprintf("AVG: %.2f", *s.AvgGrade)+mappedSuffix(s.AvgMapped))
+		}
+
+		_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+
+		return err
+	})
+}
+
+func (r *Runner) printList(format string, page dtos.StudentPageDTO) error {
+	list := page.Items
+
+	return r.print(format, list, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		if _, err := fmt.Fprintln(tw, "ID\tNAME\tSURNAME\tAGE\tGRADES"); err != nil {
+			return err
+		}
+
+		for _, s := range list {
+			if _, err := fmt.Fprintf(
+				tw,
+				"%s\t%s\t%s\t%d\t%s\n",
+				s.ID, s.Name, s.Surname, s.Age, joinGrades(s.Grades, ","),
+			); err != nil {
+				return err
+			}
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		if len(list) == page.Total {
+			return nil
+		}
+
+		_, err := fmt.Fprintf(
+			w,
+			"Showing %d of %d students from offset %d\n",
+			len(list), page.Total, page.Offset,
+		)
+
+		return err
+	})
+}
+
+func (r *Runner) printAVG(format string, avg dtos.AVGResponseDTO) error {
+	return r.print(format, avg, func(w io.Writer) error {
+		if _, err := fmt.Fprintf(w, "ID: %s\n", avg.ID); err != nil {
+			return err
+		}
+
+		if avg.Since != "" {
+			if _, err := fmt.Fprintf(w, "Since: %s\n", avg.Since); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(
+			w,
+			"AVG: %.2f%s (%d grades, %s average, %s scale)\n",
+			avg.AVG, mappedSuffix(avg.Mapped), avg.Count, avg.Averaging, avg.Scale,
+		); err != nil {
+			return err
+		}
+
+		if len(avg.Courses) == 0 {
+			return nil
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		if _, err := fmt.Fprintln(tw, "COURSE\tID\tGRADES\tAVG\tMAPPED"); err != nil {
+			return err
+		}
+
+		for _, c := range avg.Courses {
+			if _, err := fmt.Fprintf(
+				tw,
+				"%s\t%s\t%d\t%.2f\t%s\n",
+				c.CourseName, c.CourseID, c.Count, c.AVG, c.Mapped,
+			); err != nil {
+				return err
+			}
+		}
+
+		return tw.Flush()
+	})
+}
+
+func (r *Runner) printCourse(format string, c dtos.CourseResponseDTO) error {
+	return r.print(format, c, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "ID: %s\nName: %s\n", c.ID, c.Name)
+
+		return err
+	})
+}
+
+func (r *Runner) printCourses(format string, list []dtos.CourseResponseDTO) error {
+	return r.print(format, list, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		if _, err := fmt.Fprintln(tw, "ID\tNAME"); err != nil {
+			return err
+		}
+
+		for _, c := range list {
+			if _, err := fmt.Fprintf(tw, "%s\t%s\n", c.ID, c.Name); err != nil {
+				return err
+			}
+		}
+
+		return tw.Flush()
+	})
+}
+
+func (r *Runner) printReport(format string, report dtos.RosterReportDTO) error {
+	return r.print(format, report, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		lines := []string{
+			fmt.Sprintf(
+				"Students: %d (%d graded, %d without grades)",
+				report.Students, report.Graded, len(report.NoGrades),
+			),
+			fmt.Sprintf("Policy: %s average, %s scale", report.Averaging, report.Scale),
+			fmt.Sprintf(
+				"Mean: %.2f\tMedian: %.2f\tStdDev: %.2f",
+				report.Mean, report.Median, report.StdDev,
+			),
+			"",
+			"Grade distribution:",
+		}
+
+		for _, b := range report.GradeDistribution {
+			lines = append(lines, fmt.Sprintf("  %s\t%d\t%s", b.Label, b.Count, strings.Repeat("#", b.Count)))
+		}
+
+		lines = append(lines, "", "Age distribution:")
+
+		for _, b := range report.AgeDistribution {
+			lines = append(lines, fmt.Sprintf("  %s\t%d\t%s", b.Label, b.Count, strings.Repeat("#", b.Count)))
+		}
+
+		lines = append(lines, "", fmt.Sprintf("Top %d:", len(report.Top)))
+		lines = append(lines, rankLines(report.Top)...)
+		lines = append(lines, "", fmt.Sprintf("Bottom %d:", len(report.Bottom)))
+		lines = append(lines, rankLines(report.Bottom)...)
+
+		if len(report.NoGrades) > 0 {
+			lines = append(lines, "", "Without grades:")
+
+			for _, s := range report.NoGrades {
+				lines = append(lines, fmt.Sprintf("  %s %s\t%s", s.Name, s.Surname, s.ID))
+			}
+		}
+
+		if _, err := io.WriteString(tw, strings.Join(lines, "\n")+"\n"); err != nil {
+			return err
+		}
+
+		return tw.Flush()
+	})
+}
+
+func rankLines(list []dtos.StudentAVGDTO) []string {
+	lines := make([]string, 0, len(list))
+
+	for i, s := range list {
+		lines = append(lines, fmt.Sprintf(
+			"  %d. %s %s\t%.2f%s\t%s",
+			i+1, s.Name, s.Surname, s.AVG, mappedSuffix(s.Mapped), s.ID,
+		))
+	}
+
+	return lines
+}
+
+func (r *Runner) printDeleted(format, id string) error {
+	return r.print(format, deletedResponse{ID: id, Deleted: true}, func(w io.Writer) error {
+		_, err := fmt.Fprintf(
+			w,
+			"Student %s deleted, restore it with: studify trash restore %s\n",
+			id,
+			id,
+		)
+
+		return err
+	})
+}
+
+func (r *Runner) printTrash(format string, list []dtos.DeletedStudentDTO) error {
+	return r.print(format, list, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		if _, err := fmt.Fprintln(tw, "ID\tNAME\tSURNAME\tAGE\tDELETED AT\tGRADES"); err != nil {
+			return err
+		}
+
+		for _, s := range list {
+			if _, err := fmt.Fprintf(
+				tw,
+				"%s\t%s\t%s\t%d\t%s\t%s\n",
+				s.ID, s.Name, s.Surname, s.Age, s.DeletedAt, joinGrades(s.Grades, ","),
+			); err != nil {
+				return err
+			}
+		}
+
+		return tw.Flush()
+	})
+}
+
+func (r *Runner) printPurged(format string, purged []dtos.DeletedStudentDTO) error {
+	return r.print(format, purged, func(w io.Writer) error {
+		for _, s := range purged {
+			if _, err := fmt.Fprintf(w, "Purged %s %s (%s)\n", s.Name, s.Surname, s.ID); err != nil {
+				return err
+			}
+		}
+
+		_, err := fmt.Fprintf(w, "Purged %d students from trash\n", len(purged))
+
+		return err
+	})
+}
+
+func (r *Runner) printImport(format string, report importReport) error {
+	return r.print(format, report, func(w io.Writer) error {
+		for _, e := range report.Errors {
+			if _, err := fmt.Fprintf(w, "line %d: %s\n", e.Line, e.Error); err != nil {
+				return err
+			}
+		}
+
+		summary := fmt.Sprintf(
+			"Imported %d students, rejected %d rows\n",
+			report.Imported,
+			report.Rejected,
+		)
+
+		if report.DryRun {
+			summary = fmt.Sprintf(
+				"Dry run: %d valid rows, rejected %d rows, nothing saved\n",
+				report.Valid,
+				report.Rejected,
+			)
+		}
+
+		_, err := io.WriteString(w, summary)
+
+		return err
+	})
+}
+
+func (r *Runner) printAudit(format string, log dtos.AuditLogDTO) error {
+	return r.print(format, log, func(w io.Writer) error {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		if _, err := fmt.Fprintln(tw, "SEQ\tAT\tACTOR\tACTION\tSTUDENT\tCHANGES"); err != nil {
+			return err
+		}
+
+		for _, e := range log.Entries {
+			if _, err := fmt.Fprintf(
+				tw,
+				"%d\t%s\t%s\t%s\t%s\t%s\n",
+				e.Seq, e.At, e.Actor, e.Action, e.StudentID, strings.Join(e.Changes, "; "),
+			); err != nil {
+				return err
+			}
+		}
+
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+
+		status := fmt.Sprintf("Chain: verified, showing %d of %d entries", len(log.Entries), log.Total)
+		if !log.Verified {
+			status = "Chain: BROKEN - " + log.Problem
+		}
+
+		_, err := fmt.Fprintln(w, status)
+
+		return err
+	})
+}
+
+func (r *Runner) print(format string, v any, text func(w io.Writer) error) error {
+	switch format {
+	case outputText:
+		if err := text(r.out); err != nil {
+			return fmt.Errorf("failed to write text output: %w", err)
+		}
+	case outputJSON:
+		enc := json.NewEncoder(r.out)
+		enc.SetIndent("", "  ")
+
+		if err := enc.Encode(v); err != nil {
+			return fmt.Errorf("failed to write json output: %w", err)
+		}
+	default:
+		return usageErrorf("unknown output format %q, expected text or json", format)
+	}
+
+	return nil
+}
+
+func mappedSuffix(mapped string) string {
+	if mapped == "" {
+		return ""
+	}
+
+	return " (" + mapped + ")"
+}
+
+func joinGrades(grades []dtos.GradeDTO, sep string) string {
+	ss := make([]string, len(grades))
+
+	for i, g := range grades {
+		ss[i] = strconv.Itoa(g.Value)
+	}
+
+	return strings.Join(ss, sep)
+}
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+)
+
+var errInvalidRows = errors.New("invalid rows in input")
+
+type importReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Valid    int           `json:"valid"`
+	Imported int           `json:"imported"`
+	Rejected int           `json:"rejected"`
+	IDs      []string      `json:"ids,omitempty"`
+	Errors   []csvRowError `json:"errors,omitempty"`
+}
+
+func (r *Runner) runImport(args []string) error {
+	fs, output := newFlagSet(r, "import")
+
+	format := fs.String("format", formatCSV, "Input format: csv")
+	dryRun := fs.Bool("dry-run", false, "Validate rows and report errors without saving students")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest, "file"); err != nil {
+		return err
+	}
+
+	if *format != formatCSV {
+		return usageErrorf("unknown import format %q, expected %s", *format, formatCSV)
+	}
+
+	var in io.Reader = os.Stdin
+
+	if rest[0] != "-" {
+		file, err := os.Open(rest[0])
+		if err != nil {
+			return fmt.Errorf("failed to open import file: %w", err)
+		}
+
+		defer func() {
+			_ = file.Close()
+		}()
+
+		in = file
+	}
+
+	rows, rowErrs, err := readRosterCSV(in)
+	if err != nil {
+		return err
+	}
+
+	rows, rowErrs, err = r.rejectConflicts(rows, rowErrs)
+	if err != nil {
+		return err
+	}
+
+	report := importReport{
+		DryRun:   *dryRun,
+		Valid:    len(rows),
+		Rejected: len(rowErrs),
+		Errors:   rowErrs,
+	}
+
+	if !*dryRun && len(rows) > 0 {
+		batch := make([]dtos.StudentCreateDTO, 0, len(rows))
+		for _, row := range rows {
+			batch = append(batch, row.DTO)
+		}
+
+		created, err := r.svc.RegisterMany(batch)
+		if err != nil {
+			return fmt.Errorf("failed to import students: %w", err)
+		}
+
+		report.Imported = len(created)
+
+		for _, st := range created {
+			report.IDs = append(report.IDs, st.ID)
+		}
+	}
+
+	if err := r.printImport(*output, report); err != nil {
+		return err
+	}
+
+	if len(rowErrs) > 0 {
+		return fmt.Errorf("%w: %d row(s) rejected", errInvalidRows, len(rowErrs))
+	}
+
+	return nil
+}
+
+// rejectConflicts moves the rows that can not be stored to the rejected ones:
+// rows whose id belongs to a stored student, one in the trash included, rows
+// with grades in unknown courses and, with unique names, rows whose full name
+// is taken by an active student or an earlier row. Import never overwrites
+// students.
+func (r *Runner) rejectConflicts(
+	rows []csvRow,
+	rowErrs []csvRowError,
+) ([]csvRow, []csvRowError, error) {
+	active, err := r.svc.List(false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list students: %w", err)
+	}
+
+	trashed, err := r.svc.ListDeleted(false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list deleted students: %w", err)
+	}
+
+	courses, err := r.courses.List()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list courses: %w", err)
+	}
+
+	taken := make(map[string]struct{}, len(active)+len(trashed))
+	names := make(map[[2]string]int, len(active))
+
+	for _, st := range active {
+		taken[st.ID] = struct{}{}
+		names[[2]string{st.Name, st.Surname}] = 0
+	}
+
+	for _, st := range trashed {
+		taken[st.ID] = struct{}{}
+	}
+
+	known := make(map[uuid.UUID]struct{}, len(courses))
+
+	for _, c := range courses {
+		if id, err := uuid.Parse(c.ID); err == nil {
+			known[id] = struct{}{}
+		}
+	}
+
+	kept := rows[:0]
+
+	for _, row := range rows {
+		msg := ""
+		name := [2]string{row.DTO.Name, row.DTO.Surname}
+
+		if _, ok := taken[row.DTO.ID]; ok {
+			msg = fmt.Sprintf("student %s already exists", row.DTO.ID)
+		} else if course, ok := unknownCourse(row.DTO.Grades, known); ok {
+			msg = fmt.Sprintf("course %s does not exist", course)
+		} else if line, ok := names[name]; r.uniqueNames && ok {
+			msg = duplicateNameMessage(row.DTO, line)
+		}
+
+		if msg != "" {
+			rowErrs = append(rowErrs, csvRowError{Line: row.Line, Error: msg})
+
+			continue
+		}
+
+		if _, ok := names[name]; !ok {
+			names[name] = row.Line
+		}
+
+		kept = append(kept, row)
+	}
+
+	slices.SortStableFunc(rowErrs, func(a, b csvRowError) int {
+		return cmp.Compare(a.Line, b.Line)
+	})
+
+	return kept, rowErrs, nil
+}
+
+// unknownCourse returns the course of the first grade that is not in known,
+// grades without a course go to the unassigned one.
+func unknownCourse(grades []dtos.GradeDTO, known map[uuid.UUID]struct{}) (string, bool) {
+	for _, g := range grades {
+		if g.CourseID == "" {
+			continue
+		}
+
+		id, err := uuid.Parse(g.CourseID)
+		if err != nil {
+			return g.CourseID, true
+		}
+
+		if _, ok := known[id]; !ok {
+			return g.CourseID, true
+		}
+	}
+
+	return "", false
+}
+
+// duplicateNameMessage names where the full name is taken, line 0 stands for
+// a stored student.
+func duplicateNameMessage(dto dtos.StudentCreateDTO, line int) string {
+	if line == 0 {
+		return fmt.Sprintf("student %s %s already exists", dto.Name, dto.Surname)
+	}
+
+	return fmt.Sprintf("student %s %s is already on line %d", dto.Name, dto.Surname, line)
+}
+
+func (r *Runner) runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(r.errOut)
+
+	format := fs.String("format", formatCSV, "Output format: csv")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if err := expectArgs(rest); err != nil {
+		return err
+	}
+
+	if *format != formatCSV {
+		return usageErrorf("unknown export format %q, expected %s", *format, formatCSV)
+	}
+
+	list, err := r.svc.List(true)
+	if err != nil {
+		return fmt.Errorf("failed to list students: %w", err)
+	}
+
+	return writeRosterCSV(r.out, list)
+}
@@ -0,0 +1,214 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/presentation/cli"
+)
+
+const rosterCSV = `name,surname,age,grades
+Mikhail,Gunin,19,90;60
+alexander,Gunin,20,
+Ivan,Petrov,abc,
+Anna,Ivanova,21,"100, 95"
+Oleg,Sidorov
+`
+
+func writeRoster(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "roster.csv")
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("[%s][Roster] failed to write roster: %v", cliTestPrefix, err)
+	}
+
+	return path
+}
+
+func TestRunner_Import_DryRunReportsRowErrors(t *testing.T) {
+	runner, out, svc := newTestRunner(t)
+	path := writeRoster(t, rosterCSV)
+
+	code := runner.Run("import", []string{"--dry-run", "--output", "json", path})
+	if code != cli.ExitInvalid {
+		t.Fatalf("[%s][Import] exit code mismatch: got=%d want=%d", cliTestPrefix, code, cli.ExitInvalid)
+	}
+
+	var report struct {
+		Valid    int `json:"valid"`
+		Imported int `json:"imported"`
+		Errors   []struct {
+			Line  int    `json:"line"`
+			Error string `json:"error"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("[%s][Import] failed to decode report: %v", cliTestPrefix, err)
+	}
+
+	if report.Valid != 2 || report.Imported != 0 || len(report.Errors) != 3 {
+		t.Fatalf("[%s][Import] unexpected report: %+v", cliTestPrefix, report)
+	}
+
+	wantLines := []int{3, 4, 6}
+	for i, e := range report.Errors {
+		if e.Line != wantLines[i] {
+			t.Fatalf("[%s][Import] error line mismatch: got=%d want=%d (%s)", cliTestPrefix, e.Line, wantLines[i], e.Error)
+		}
+	}
+
+	if !strings.Contains(report.Errors[0].Error, "capitalized") {
+		t.Fatalf("[%s][Import] validation error must name the rule: %q", cliTestPrefix, report.Errors[0].Error)
+	}
+
+	list, err := svc.List(false)
+	if err != nil || len(list) != 0 {
+		t.Fatalf("[%s][Import] dry run must not save students: %v (err=%v)", cliTestPrefix, list, err)
+	}
+}
+
+func TestRunner_ImportExport_RoundTrip(t *testing.T) {
+	runner, out, svc := newTestRunner(t)
+	path := writeRoster(t, rosterCSV)
+
+	if code := runner.Run("import", []string{path}); code != cli.ExitInvalid {
+		t.Fatalf("[%s][RoundTrip] import exit code mismatch: got=%d want=%d", cliTestPrefix, code, cli.ExitInvalid)
+	}
+
+	if !strings.Contains(out.String(), "Imported 2 students, rejected 3 rows") {
+		t.Fatalf("[%s][RoundTrip] unexpected import output: %q", cliTestPrefix, out.String())
+	}
+
+	first, err := svc.List(true)
+	if err != nil || len(first) != 2 {
+		t.Fatalf("[%s][RoundTrip] failed to list imported students: %v (err=%v)", cliTestPrefix, first, err)
+	}
+
+	if code := runner.Run("grades", []string{
+		"add", "--author", "dean", "--comment", "oral; exam", "--weight", "2", first[0].ID, "80",
+	}); code != cli.ExitOK {
+		t.Fatalf("[%s][RoundTrip] grades add exit code mismatch: got=%d want=%d", cliTestPrefix, code, cli.ExitOK)
+	}
+
+	out.Reset()
+
+	if code := runner.Run("export", []string{"--format", "csv"}); code != cli.ExitOK {
+		t.Fatalf("[%s][RoundTrip] export exit code mismatch: got=%d want=%d", cliTestPrefix, code, cli.ExitOK)
+	}
+
+	exported := out.String()
+	if !strings.HasPrefix(exported, "id,name,surname,age,grades\n") || !strings.Contains(exported, `""author"":""dean""`) {
+		t.Fatalf("[%s][RoundTrip] unexpected export: %q", cliTestPrefix, exported)
+	}
+
+	runner2, _, svc2 := newTestRunner(t)
+
+	if code := runner2.Run("import", []string{writeRoster(t, exported)}); code != cli.ExitOK {
+		t.Fatalf("[%s][RoundTrip] re-import exit code mismatch: got=%d want=%d", cliTestPrefix, code, cli.ExitOK)
+	}
+
+	first, _ = svc.List(true)
+	second, _ := svc2.List(true)
+
+	want, _ := json.Marshal(first)
+	got, _ := json.Marshal(second)
+
+	if string(got) != string(want) {
+		t.Fatalf("[%s][RoundTrip] re-imported students differ:\n got=%s\nwant=%s", cliTestPrefix, got, want)
+	}
+
+	out.Reset()
+
+	if code := runner.Run("import", []string{"--output", "json", writeRoster(t, exported)}); code != cli.ExitInvalid {
+		t.Fatalf("[%s][RoundTrip] import of taken ids exit code mismatch: got=%d want=%d", cliTestPrefix, code, cli.ExitInvalid)
+	}
+
+	var report struct {
+		Imported int `json:"imported"`
+		Rejected int `json:"rejected"`
+	}
+
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("[%s][RoundTrip] failed to decode report: %v", cliTestPrefix, err)
+	}
+
+	if report.Imported != 0 || report.Rejected != 2 {
+		t.Fatalf("[%s][RoundTrip] taken ids must be rejected: %+v", cliTestPrefix, report)
+	}
+}
+
+func TestRunner_Import_UnknownColumn(t *testing.T) {
+	runner, _, _ := newTestRunner(t)
+
+	code := runner.Run("import", []string{writeRoster(t, "name,surname,email\nIvan,Petrov,x@y\n")})
+	if code != cli.ExitUsage {
+		t.Fatalf("[%s][Import] exit code mismatch: got=%d want=%d", cliTestPrefix, code, cli.ExitUsage)
+	}
+}
+
+func TestRunner_Import_RejectsUnknownCoursesAndTakenNames(t *testing.T) {
+	runner, out, svc := newTestRunner(t)
+	runner = runner.WithUniqueNames(true)
+
+	if _, err := svc.Register(dtos.StudentCreateDTO{Name: "Mikhail", Surname: "Gunin", Age: 19}); err != nil {
+		t.Fatalf("[%s][ImportConflicts] failed to register student: %v", cliTestPrefix, err)
+	}
+
+	path := writeRoster(t, `name,surname,age,grades
+Mikhail,Gunin,19,
+Anna,Ivanova,21,"[{""course_id"":""00000000-0000-4000-8000-000000000001"",""value"":90}]"
+Ivan,Petrov,20,"[{""course_id"":""11111111-1111-4111-8111-111111111111"",""value"":80}]"
+Anna,Ivanova,22,
+Oleg,Sidorov,30,90
+`)
+
+	code := runner.Run("import", []string{"--output", "json", path})
+	if code != cli.ExitInvalid {
+		t.Fatalf("[%s][ImportConflicts] exit code mismatch: got=%d want=%d", cliTestPrefix, code, cli.ExitInvalid)
+	}
+
+	var report struct {
+		Imported int `json:"imported"`
+		Errors   []struct {
+			Line  int    `json:"line"`
+			Error string `json:"error"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("[%s][ImportConflicts] failed to decode report: %v", cliTestPrefix, err)
+	}
+
+	want := []struct {
+		line int
+		text string
+	}{
+		{2, "Mikhail Gunin already exists"},
+		{4, "course 11111111-1111-4111-8111-111111111111 does not exist"},
+		{5, "Anna Ivanova is already on line 3"},
+	}
+
+	if report.Imported != 2 || len(report.Errors) != len(want) {
+		t.Fatalf("[%s][ImportConflicts] unexpected report: %+v", cliTestPrefix, report)
+	}
+
+	for i, w := range want {
+		if report.Errors[i].Line != w.line || !strings.Contains(report.Errors[i].Error, w.text) {
+			t.Fatalf("[%s][ImportConflicts] error #%d mismatch: got=%+v want line %d with %q",
+				cliTestPrefix, i, report.Errors[i], w.line, w.text)
+		}
+	}
+
+	list, err := svc.List(false)
+	if err != nil || len(list) != 3 {
+		t.Fatalf("[%s][ImportConflicts] want 3 stored students, got=%v (err=%v)", cliTestPrefix, list, err)
+	}
+}
@@ -0,0 +1,406 @@
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/services"
+)
+
+// ActorHeader names who makes the request; it is recorded in the audit log
+// instead of the server's actor.
+const (
+	ActorHeader    = "X-Actor"
+	maxActorLength = 64
+)
+
+type Handler struct {
+	svc     services.StudentServiceContract
+	courses services.CourseServiceContract
+	log     *slog.Logger
+	mux     *http.ServeMux
+}
+
+func NewHandler(
+	svc services.StudentServiceContract,
+	courses services.CourseServiceContract,
+	log *slog.Logger,
+) *Handler {
+	h := &Handler{
+		svc:     svc,
+		courses: courses,
+		log:     log,
+		mux:     http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("POST /students", h.createStudent)
+	h.mux.HandleFunc("GET /students", h.listStudents)
+	h.mux.HandleFunc("GET /students/search", h.searchStudents)
+	h.mux.HandleFunc("GET /students/trash", h.listTrash)
+	h.mux.HandleFunc("DELETE /students/trash", h.purgeTrash)
+	h.mux.HandleFunc("POST /students/{id}/restore", h.restoreStudent)
+	h.mux.HandleFunc("GET /students/{id}", h.getStudent)
+	h.mux.HandleFunc("PUT /students/{id}", h.updateStudent)
+	h.mux.HandleFunc("DELETE /students/{id}", h.deleteStudent)
+	h.mux.HandleFunc("GET /students/{id}/grades", h.listGrades)
+	h.mux.HandleFunc("POST /students/{id}/grades", h.addGrades)
+	h.mux.HandleFunc("GET /students/{id}/avg", h.avgStudent)
+	h.mux.HandleFunc("POST /courses", h.createCourse)
+	h.mux.HandleFunc("GET /courses", h.listCourses)
+	h.mux.HandleFunc("GET /courses/{id}", h.getCourse)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if actor := r.Header.Get(ActorHeader); utf8.RuneCountInString(actor) > maxActorLength {
+		h.writeError(w, badRequestf(
+			"%s header is longer than %d characters",
+			ActorHeader,
+			maxActorLength,
+		))
+
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) actorSvc(r *http.Request) services.StudentServiceContract {
+	return h.svc.WithActor(r.Header.Get(ActorHeader))
+}
+
+func (h *Handler) createStudent(w http.ResponseWriter, r *http.Request) {
+	var in dtos.StudentCreateDTO
+	if err := decodeJSON(w, r, &in); err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	resp, err := h.actorSvc(r).Register(in)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *Handler) listStudents(w http.ResponseWriter, r *http.Request) {
+	in, err := parseStudentQuery(r.URL.Query())
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	page, err := h.svc.Query(in)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	h.writeJSON(w, http.StatusOK, page.Items)
+}
+
+func (h *Handler) searchStudents(w http.ResponseWriter, r *http.Request) {
+	in := dtos.SearchByNameDTO{Query: r.URL.Query().Get("q")}
+
+	if raw := r.URL.Query().Get("grades"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.writeError(w, badRequestf("invalid grades query parameter %q", raw))
+
+			return
+		}
+
+		in.IncludeGrades = v
+	}
+
+	list, err := h.svc.SearchByName(in)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) getStudent(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.GetByID(dtos.GetByIDDTO{ID: r.PathValue("id")})
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) updateStudent(w http.ResponseWriter, r *http.Request) {
+	var in dtos.StudentUpdateDTO
+	if err := decodeJSON(w, r, &in); err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	id := r.PathValue("id")
+	if in.ID != "" && in.ID != id {
+		h.writeError(w, badRequestf("body id %q does not match path id %q", in.ID, id))
+
+		return
+	}
+
+	in.ID = id
+
+	resp, err := h.actorSvc(r).Update(in)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) deleteStudent(w http.ResponseWriter, r *http.Request) {
+	if err := h.actorSvc(r).DeleteByID(dtos.GetByIDDTO{ID: r.PathValue("id")}); err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) listTrash(w http.ResponseWriter, r *http.Request) {
+	var withGrades bool
+
+	if raw := r.URL.Query().Get("grades"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.writeError(w, badRequestf("invalid grades query parameter %q", raw))
+
+			return
+		}
+
+		withGrades = v
+	}
+
+	list, err := h.svc.ListDeleted(withGrades)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) restoreStudent(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.actorSvc(r).Restore(dtos.GetByIDDTO{ID: r.PathValue("id")})
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) purgeTrash(w http.ResponseWriter, r *http.Request) {
+	var in dtos.PurgeDeletedDTO
+
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			h.writeError(w, badRequestf("invalid days query parameter %q", raw))
+
+			return
+		}
+
+		in.OlderThanDays = v
+	}
+
+	purged, err := h.actorSvc(r).PurgeDeleted(in)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, purged)
+}
+
+func (h *Handler) listGrades(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.svc.GetByID(dtos.GetByIDDTO{ID: r.PathValue("id")})
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	grades := resp.Grades
+	if grades == nil {
+		grades = []dtos.GradeDTO{}
+	}
+
+	h.writeJSON(w, http.StatusOK, grades)
+}
+
+func (h *Handler) addGrades(w http.ResponseWriter, r *http.Request) {
+	var in dtos.AddGradesDTO
+	if err := decodeJSON(w, r, &in); err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	id := r.PathValue("id")
+	if in.ID != "" && in.ID != id {
+		h.writeError(w, badRequestf("body id %q does not match path id %q", in.ID, id))
+
+		return
+	}
+
+	in.ID = id
+
+	resp, err := h.actorSvc(r).AddGrades(in)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) avgStudent(w http.ResponseWriter, r *http.Request) {
+	in := dtos.AVGQueryDTO{
+		ID:    r.PathValue("id"),
+		Since: r.URL.Query().Get("since"),
+	}
+
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			h.writeError(w, badRequestf("invalid days query parameter %q", raw))
+
+			return
+		}
+
+		in.Days = v
+	}
+
+	resp, err := h.svc.AVGByID(in)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler) createCourse(w http.ResponseWriter, r *http.Request) {
+	var in dtos.CourseCreateDTO
+	if err := decodeJSON(w, r, &in); err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	resp, err := h.courses.Create(in)
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusCreated, resp)
+}
+
+func (h *Handler) listCourses(w http.ResponseWriter, _ *http.Request) {
+	list, err := h.courses.List()
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, list)
+}
+
+func (h *Handler) getCourse(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.courses.GetByID(dtos.GetByIDDTO{ID: r.PathValue("id")})
+	if err != nil {
+		h.writeError(w, err)
+
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, resp)
+}
+
+func parseStudentQuery(values url.Values) (dtos.StudentQueryDTO, error) {
+	in := dtos.StudentQueryDTO{
+		Name:    values.Get("name"),
+		Surname: values.Get("surname"),
+		Sort:    values.Get("sort"),
+	}
+
+	parsers := []struct {
+		name  string
+		parse func(raw string) error
+	}{
+		{"grades", param(strconv.ParseBool, func(v bool) { in.IncludeGrades = v })},
+		{"desc", param(strconv.ParseBool, func(v bool) { in.Desc = v })},
+		{"offset", param(strconv.Atoi, func(v int) { in.Offset = v })},
+		{"limit", param(strconv.Atoi, func(v int) { in.Limit = v })},
+		{"min_age", param(strconv.Atoi, func(v int) { in.MinAge = &v })},
+		{"max_age", param(strconv.Atoi, func(v int) { in.MaxAge = &v })},
+		{"min_avg", param(parseFloat, func(v float64) { in.MinAVG = &v })},
+		{"max_avg", param(parseFloat, func(v float64) { in.MaxAVG = &v })},
+		{"has_grades", param(strconv.ParseBool, func(v bool) { in.HasGrades = &v })},
+	}
+
+	for _, p := range parsers {
+		raw := values.Get(p.name)
+		if raw == "" {
+			continue
+		}
+
+		if err := p.parse(raw); err != nil {
+			return dtos.StudentQueryDTO{}, badRequestf("invalid %s query parameter %q", p.name, raw)
+		}
+	}
+
+	return in, nil
+}
+
+func param[T any](parse func(string) (T, error), set func(T)) func(string) error {
+	return func(raw string) error {
+		v, err := parse(raw)
+		if err != nil {
+			return err
+		}
+
+		set(v)
+
+		return nil
+	}
+}
+
+func parseFloat(raw string) (float64, error) {
+	return strconv.ParseFloat(raw, 64)
+}
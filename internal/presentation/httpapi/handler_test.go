@@ -0,0 +1,403 @@
+package httpapi_test
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/grading"
+	"github.com/k6zma/avito-lab1/internal/domain/models"
+	domainRepos "github.com/k6zma/avito-lab1/internal/domain/repositories"
+	infrarepo "github.com/k6zma/avito-lab1/internal/infrastructure/repositories"
+	"github.com/k6zma/avito-lab1/internal/presentation/httpapi"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	httpTestPrefix = "StudifyHTTP"
+
+	missingID = "00000000-0000-4000-8000-000000000000"
+)
+
+type requestCase struct {
+	name     string
+	method   string
+	path     string
+	body     string
+	wantCode int
+}
+
+func newTestServer(t *testing.T) (*httptest.Server, services.StudentServiceContract) {
+	t.Helper()
+
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", httpTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] failed to create repository: %v", httpTestPrefix, err)
+	}
+
+	courses, err := infrarepo.NewCourseStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] failed to create course repository: %v", httpTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, courses, grading.DefaultPolicy(), nil)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	srv := httptest.NewServer(httpapi.NewHandler(svc, services.NewCourseService(courses), log))
+	t.Cleanup(srv.Close)
+
+	return srv, svc
+}
+
+func doRequest(t *testing.T, srv *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("[%s] failed to build request: %v", httpTestPrefix, err)
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("[%s] failed to do request: %v", httpTestPrefix, err)
+	}
+
+	t.Cleanup(func() {
+		_ = resp.Body.Close()
+	})
+
+	return resp
+}
+
+func TestHandler_StatusCodes(t *testing.T) {
+	srv, svc := newTestServer(t)
+
+	created, err := svc.Register(dtos.StudentCreateDTO{
+		Name:    "Mikhail",
+		Surname: "Gunin",
+		Age:     19,
+		Grades:  []dtos.GradeDTO{{Value: 90}, {Value: 60}},
+	})
+	if err != nil {
+		t.Fatalf("[%s] failed to register student: %v", httpTestPrefix, err)
+	}
+
+	studentPath := "/students/" + created.ID
+
+	tests := []requestCase{
+		{
+			"create ok",
+			http.MethodPost,
+			"/students",
+			`{"name":"Alexander","surname":"Gunin","age":20}`,
+			http.StatusCreated,
+		},
+		{
+			"create invalid name",
+			http.MethodPost,
+			"/students",
+			`{"name":"alexander","surname":"Gunin"}`,
+			http.StatusBadRequest,
+		},
+		{"create broken json", http.MethodPost, "/students", `{"name":`, http.StatusBadRequest},
+		{"create unknown field", http.MethodPost, "/students", `{"nick":"x"}`, http.StatusBadRequest},
+		{"list", http.MethodGet, "/students?grades=true", "", http.StatusOK},
+		{"list bad query", http.MethodGet, "/students?grades=maybe", "", http.StatusBadRequest},
+		{"list filtered", http.MethodGet, "/students?surname=Gu&min_age=18&sort=age&desc=true&limit=10", "", http.StatusOK},
+		{"list bad min age", http.MethodGet, "/students?min_age=old", "", http.StatusBadRequest},
+		{"list bad sort", http.MethodGet, "/students?sort=grade", "", http.StatusBadRequest},
+		{"search ok", http.MethodGet, "/students/search?q=gunin&grades=true", "", http.StatusOK},
+		{"search empty query", http.MethodGet, "/students/search", "", http.StatusBadRequest},
+		{"search bad grades", http.MethodGet, "/students/search?q=gunin&grades=maybe", "", http.StatusBadRequest},
+		{"get ok", http.MethodGet, studentPath, "", http.StatusOK},
+		{"get missing", http.MethodGet, "/students/" + missingID, "", http.StatusNotFound},
+		{"get bad uuid", http.MethodGet, "/students/bad-uuid", "", http.StatusBadRequest},
+		{"avg ok", http.MethodGet, studentPath + "/avg", "", http.StatusOK},
+		{"avg last days", http.MethodGet, studentPath + "/avg?days=30", "", http.StatusOK},
+		{"avg bad days", http.MethodGet, studentPath + "/avg?days=month", "", http.StatusBadRequest},
+		{"avg bad since", http.MethodGet, studentPath + "/avg?since=yesterday", "", http.StatusBadRequest},
+		{
+			"update ok",
+			http.MethodPut,
+			studentPath,
+			`{"name":"Mikhail","surname":"Gunin","age":20,"grades":[100],"version":1}`,
+			http.StatusOK,
+		},
+		{
+			"update stale version",
+			http.MethodPut,
+			studentPath,
+			`{"name":"Mikhail","surname":"Gunin","age":21,"version":1}`,
+			http.StatusConflict,
+		},
+		{
+			"update without version",
+			http.MethodPut,
+			studentPath,
+			`{"name":"Mikhail","surname":"Gunin","age":21}`,
+			http.StatusBadRequest,
+		},
+		{
+			"update id mismatch",
+			http.MethodPut,
+			studentPath,
+			`{"id":"` + missingID + `","name":"Mikhail","surname":"Gunin"}`,
+			http.StatusBadRequest,
+		},
+		{
+			"update missing",
+			http.MethodPut,
+			"/students/" + missingID,
+			`{"name":"Mikhail","surname":"Gunin","version":1}`,
+			http.StatusNotFound,
+		},
+		{"grades ok", http.MethodGet, studentPath + "/grades", "", http.StatusOK},
+		{"grades missing", http.MethodGet, "/students/" + missingID + "/grades", "", http.StatusNotFound},
+		{"grades bad uuid", http.MethodGet, "/students/bad-uuid/grades", "", http.StatusBadRequest},
+		{"add grades ok", http.MethodPost, studentPath + "/grades", `{"grades":[70]}`, http.StatusOK},
+		{
+			"add grades out of range",
+			http.MethodPost,
+			studentPath + "/grades",
+			`{"grades":[170]}`,
+			http.StatusBadRequest,
+		},
+		{
+			"add grades unknown course",
+			http.MethodPost,
+			studentPath + "/grades",
+			`{"course_id":"` + missingID + `","grades":[70]}`,
+			http.StatusNotFound,
+		},
+		{
+			"update with course grades",
+			http.MethodPut,
+			studentPath,
+			`{"name":"Mikhail","surname":"Gunin","grades":[{"course_id":"` +
+				models.UnassignedCourseID.String() + `","value":80,"date":"2025-03-14T10:30:00Z"}],"version":3}`,
+			http.StatusOK,
+		},
+		{"create course ok", http.MethodPost, "/courses", `{"name":"Math"}`, http.StatusCreated},
+		{"create course duplicate", http.MethodPost, "/courses", `{"name":"Math"}`, http.StatusConflict},
+		{"create course empty", http.MethodPost, "/courses", `{"name":""}`, http.StatusBadRequest},
+		{"list courses", http.MethodGet, "/courses", "", http.StatusOK},
+		{"get course", http.MethodGet, "/courses/" + models.UnassignedCourseID.String(), "", http.StatusOK},
+		{"get course missing", http.MethodGet, "/courses/" + missingID, "", http.StatusNotFound},
+		{"delete ok", http.MethodDelete, studentPath, "", http.StatusNoContent},
+		{"delete again", http.MethodDelete, studentPath, "", http.StatusNotFound},
+		{"trash", http.MethodGet, "/students/trash?grades=true", "", http.StatusOK},
+		{"trash bad grades", http.MethodGet, "/students/trash?grades=maybe", "", http.StatusBadRequest},
+		{"restore ok", http.MethodPost, studentPath + "/restore", "", http.StatusOK},
+		{"restore active", http.MethodPost, studentPath + "/restore", "", http.StatusConflict},
+		{"restore missing", http.MethodPost, "/students/" + missingID + "/restore", "", http.StatusNotFound},
+		{"restore bad uuid", http.MethodPost, "/students/bad-uuid/restore", "", http.StatusBadRequest},
+		{"delete after restore", http.MethodDelete, studentPath, "", http.StatusNoContent},
+		{"purge bad days", http.MethodDelete, "/students/trash?days=week", "", http.StatusBadRequest},
+		{"purge negative days", http.MethodDelete, "/students/trash?days=-1", "", http.StatusBadRequest},
+		{"purge", http.MethodDelete, "/students/trash", "", http.StatusOK},
+		{"restore purged", http.MethodPost, studentPath + "/restore", "", http.StatusNotFound},
+		{"method not allowed", http.MethodPatch, studentPath, "", http.StatusMethodNotAllowed},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-%s-№%d", httpTestPrefix, tc.name, i+1), func(t *testing.T) {
+			resp := doRequest(t, srv, tc.method, tc.path, tc.body)
+
+			if resp.StatusCode != tc.wantCode {
+				body, _ := io.ReadAll(resp.Body)
+
+				t.Fatalf(
+					"[%s][%s %s] status mismatch: got=%d want=%d (body=%s)",
+					httpTestPrefix,
+					tc.method,
+					tc.path,
+					resp.StatusCode,
+					tc.wantCode,
+					body,
+				)
+			}
+		})
+	}
+}
+
+func TestHandler_CreateAndAVG_ResponseBody(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	resp := doRequest(
+		t,
+		srv,
+		http.MethodPost,
+		"/students",
+		`{"name":"Mikhail","surname":"Gunin","age":19,"grades":[80,100]}`,
+	)
+
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("[%s][Create] content type mismatch: got=%q", httpTestPrefix, ct)
+	}
+
+	var created dtos.DefaultStudentResponseDTO
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("[%s][Create] failed to decode response: %v", httpTestPrefix, err)
+	}
+
+	if created.ID == "" || created.Name != "Mikhail" || len(created.Grades) != 2 {
+		t.Fatalf("[%s][Create] unexpected response: %+v", httpTestPrefix, created)
+	}
+
+	avgResp := doRequest(t, srv, http.MethodGet, "/students/"+created.ID+"/avg", "")
+
+	var avg dtos.AVGResponseDTO
+	if err := json.NewDecoder(avgResp.Body).Decode(&avg); err != nil {
+		t.Fatalf("[%s][AVG] failed to decode response: %v", httpTestPrefix, err)
+	}
+
+	if avg.ID != created.ID || avg.AVG != 90 {
+		t.Fatalf("[%s][AVG] unexpected response: %+v", httpTestPrefix, avg)
+	}
+}
+
+func TestHandler_ListGrades_ResponseBody(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	resp := doRequest(t, srv, http.MethodPost, "/students", `{"name":"Mikhail","surname":"Gunin","age":19}`)
+
+	var created dtos.DefaultStudentResponseDTO
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		t.Fatalf("[%s][Grades] failed to decode response: %v", httpTestPrefix, err)
+	}
+
+	gradesPath := "/students/" + created.ID + "/grades"
+
+	empty := doRequest(t, srv, http.MethodGet, gradesPath, "")
+
+	body, err := io.ReadAll(empty.Body)
+	if err != nil || strings.TrimSpace(string(body)) != "[]" {
+		t.Fatalf("[%s][Grades] want an empty list, got=%q (err=%v)", httpTestPrefix, body, err)
+	}
+
+	doRequest(t, srv, http.MethodPost, gradesPath, `{"grades":[70,95],"author":"dean"}`)
+
+	var grades []dtos.GradeDTO
+	if err := json.NewDecoder(doRequest(t, srv, http.MethodGet, gradesPath, "").Body).Decode(&grades); err != nil {
+		t.Fatalf("[%s][Grades] failed to decode response: %v", httpTestPrefix, err)
+	}
+
+	if len(grades) != 2 || grades[0].Value != 70 || grades[1].Value != 95 || grades[0].Author != "dean" {
+		t.Fatalf("[%s][Grades] unexpected grades: %+v", httpTestPrefix, grades)
+	}
+
+	if grades[0].CourseID != models.UnassignedCourseID.String() {
+		t.Fatalf("[%s][Grades] grades without a course must be unassigned: %+v", httpTestPrefix, grades[0])
+	}
+}
+
+func TestHandler_ListStudents_Pagination(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, body := range []string{
+		`{"name":"Mikhail","surname":"Gunin","age":19}`,
+		`{"name":"Anna","surname":"Petrova","age":22}`,
+		`{"name":"Boris","surname":"Gusev","age":20}`,
+	} {
+		doRequest(t, srv, http.MethodPost, "/students", body)
+	}
+
+	resp := doRequest(t, srv, http.MethodGet, "/students?sort=name&offset=1&limit=1", "")
+
+	if total := resp.Header.Get("X-Total-Count"); total != "3" {
+		t.Fatalf("[%s][List] total count mismatch: got=%q want=%q", httpTestPrefix, total, "3")
+	}
+
+	var list []dtos.StudentListItemDTO
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		t.Fatalf("[%s][List] failed to decode response: %v", httpTestPrefix, err)
+	}
+
+	if len(list) != 1 || list[0].Name != "Boris" {
+		t.Fatalf("[%s][List] unexpected page: %+v", httpTestPrefix, list)
+	}
+}
+
+func TestHandler_ActorHeader(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("[%s] failed to init validators: %v", httpTestPrefix, err)
+	}
+
+	repo, err := infrarepo.NewStudentStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] failed to create repository: %v", httpTestPrefix, err)
+	}
+
+	courses, err := infrarepo.NewCourseStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] failed to create course repository: %v", httpTestPrefix, err)
+	}
+
+	audit, err := infrarepo.NewAuditStorageWithPersister(nil)
+	if err != nil {
+		t.Fatalf("[%s] failed to create audit log: %v", httpTestPrefix, err)
+	}
+
+	svc := services.NewStudentService(repo, courses, grading.DefaultPolicy(), audit).WithActor("server")
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	srv := httptest.NewServer(httpapi.NewHandler(svc, services.NewCourseService(courses), log))
+	t.Cleanup(srv.Close)
+
+	post := func(actor string) int {
+		req, err := http.NewRequest(
+			http.MethodPost,
+			srv.URL+"/students",
+			strings.NewReader(`{"name":"Mikhail","surname":"Gunin","age":19}`),
+		)
+		if err != nil {
+			t.Fatalf("[%s][Actor] failed to build request: %v", httpTestPrefix, err)
+		}
+
+		if actor != "" {
+			req.Header.Set(httpapi.ActorHeader, actor)
+		}
+
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("[%s][Actor] failed to do request: %v", httpTestPrefix, err)
+		}
+
+		_ = resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	if code := post("dean"); code != http.StatusCreated {
+		t.Fatalf("[%s][Actor] create with actor: got status=%d", httpTestPrefix, code)
+	}
+
+	if code := post(""); code != http.StatusCreated {
+		t.Fatalf("[%s][Actor] create without actor: got status=%d", httpTestPrefix, code)
+	}
+
+	if code := post(strings.Repeat("a", 65)); code != http.StatusBadRequest {
+		t.Fatalf("[%s][Actor] create with long actor: got status=%d want=%d", httpTestPrefix, code, http.StatusBadRequest)
+	}
+
+	entries, err := audit.List(domainRepos.AuditFilter{})
+	if err != nil {
+		t.Fatalf("[%s][Actor] failed to list audit entries: %v", httpTestPrefix, err)
+	}
+
+	if len(entries) != 2 || entries[0].Actor != "dean" || entries[1].Actor != "server" {
+		t.Fatalf("[%s][Actor] unexpected audit entries: %+v", httpTestPrefix, entries)
+	}
+}
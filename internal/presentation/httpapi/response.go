@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/goccy/go-json"
+
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const maxBodyBytes = 1 << 20
+
+var errBadRequest = errors.New("bad request")
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func badRequestf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errBadRequest, fmt.Sprintf(format, args...))
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(dst); err != nil {
+		return badRequestf("invalid json body: %v", err)
+	}
+
+	return nil
+}
+
+func statusCode(err error) int {
+	switch {
+	case errors.Is(err, errBadRequest),
+		errors.Is(err, repositories.ErrInvalidStudentID),
+		errors.Is(err, repositories.ErrInvalidCourseID),
+		errors.Is(err, repositories.ErrInvalidStudentQuery),
+		validators.IsValidationError(err):
+		return http.StatusBadRequest
+	case errors.Is(err, repositories.ErrStudentNotFound),
+		errors.Is(err, repositories.ErrCourseNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrStudentAlreadyExists),
+		errors.Is(err, repositories.ErrDuplicateFullName),
+		errors.Is(err, repositories.ErrStudentNotDeleted),
+		errors.Is(err, repositories.ErrVersionConflict),
+		errors.Is(err, repositories.ErrCourseAlreadyExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (h *Handler) writeError(w http.ResponseWriter, err error) {
+	code := statusCode(err)
+
+	msg := err.Error()
+	if code == http.StatusInternalServerError {
+		h.log.Error("http request failed", slog.Any("error", err))
+
+		msg = http.StatusText(code)
+	}
+
+	h.writeJSON(w, code, errorResponse{Error: msg})
+}
+
+func (h *Handler) writeJSON(w http.ResponseWriter, code int, v any) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		h.log.Error("failed to marshal http response", slog.Any("error", err))
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if _, err := w.Write(append(payload, '\n')); err != nil {
+		h.log.Error("failed to write http response", slog.Any("error", err))
+	}
+}
@@ -0,0 +1,33 @@
+package httpapi
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/k6zma/avito-lab1/internal/application/services"
+)
+
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 15 * time.Second
+	writeTimeout      = 15 * time.Second
+	idleTimeout       = 60 * time.Second
+)
+
+func NewServer(
+	addr string,
+	svc services.StudentServiceContract,
+	courses services.CourseServiceContract,
+	log *slog.Logger,
+) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           NewHandler(svc, courses, log),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelError),
+	}
+}
@@ -1,32 +1,58 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 
 	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
 	"github.com/k6zma/avito-lab1/internal/application/services"
+	"github.com/k6zma/avito-lab1/internal/domain/repositories"
 )
 
 type rootModel struct {
 	svc        services.StudentServiceContract
+	reports    services.ReportServiceContract
+	audit      services.AuditServiceContract
 	mode       mode
 	prevMode   mode
 	currentAct string
 	menu       menuModel
 	tbl        tableModel
 	form       createModel
+	edit       editModel
 	grades     addGradesModel
 	idInput    idInputModel
 	detail     detailModel
+	auditView  auditModel
+	confirm    confirmModel
+	trash      trashModel
+	history    historyModel
+	search     searchModel
+	pendingID  string
 	status     string
+	// origin is the screen an edit, add grades or delete action started
+	// from, the action goes back there when it is done or cancelled.
+	origin  mode
+	width   int
+	height  int
+	spinner spinner.Model
+	busy    *operation
+	queue   []pendingOp
+	seq     int
 }
 
-func Run(svc services.StudentServiceContract) error {
-	m := newRootModel(svc)
+func Run(
+	svc services.StudentServiceContract,
+	reports services.ReportServiceContract,
+	audit services.AuditServiceContract,
+) error {
+	m := newRootModel(svc, reports, audit)
 	_, err := tea.NewProgram(m, tea.WithAltScreen()).Run()
 	if err != nil {
 		return fmt.Errorf("failed to run TUI app: %w", err)
@@ -35,18 +61,31 @@ func Run(svc services.StudentServiceContract) error {
 	return nil
 }
 
-func newRootModel(svc services.StudentServiceContract) rootModel {
+func newRootModel(
+	svc services.StudentServiceContract,
+	reports services.ReportServiceContract,
+	audit services.AuditServiceContract,
+) rootModel {
 	return rootModel{
 		svc:      svc,
+		reports:  reports,
+		audit:    audit,
 		mode:     modeMenu,
 		prevMode: modeMenu,
+		spinner:  newSpinner(),
 		menu: newMenuModel([]string{
 			"Add student",
+			"Edit student",
 			"List students",
+			"Search by name",
 			"Show student (by ID)",
+			"Student history (by ID)",
 			"Average by ID",
 			"Add grades",
 			"Delete student",
+			"Roster report",
+			"Trash",
+			"Audit log",
 			"Quit",
 		}),
 	}
@@ -58,6 +97,43 @@ func (m rootModel) Init() tea.Cmd {
 
 func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
+	case opDoneMsg:
+		return m.finish(msg)
+
+	case spinner.TickMsg:
+		if m.busy == nil {
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+
+		m.spinner, cmd = m.spinner.Update(msg)
+
+		return m, cmd
+
+	case tea.KeyMsg:
+		if m.busy != nil {
+			return m.updateBusy(msg)
+		}
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+		var cmd tea.Cmd
+
+		m.menu, cmd = m.menu.Update(msg)
+
+		if m.mode != modeTable {
+			return m, cmd
+		}
+
+		m.tbl = m.tbl.resize(m.width, m.height)
+
+		var reload tea.Cmd
+
+		m, reload = m.reloadTable(m.tbl.status)
+
+		return m, tea.Batch(cmd, reload)
 
 	case menuChoiceMsg:
 		switch string(msg) {
@@ -67,18 +143,41 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			return m, m.form.Init()
 
-		case "List students":
-			list, err := m.svc.List(true)
-			if err != nil {
-				m.status = fmt.Sprintf("list error: %v", err)
+		case "Edit student":
+			m.mode = modeIDInput
+			m.currentAct = actionEdit
+			m.origin = modeMenu
+			m.idInput = newIDInputModel("Student ID in UUID")
 
-				return m, nil
-			}
+			return m, m.idInput.Init()
 
-			m.tbl = newTableModel(studentsToTable(list))
+		case "List students":
+			m.status = ""
+			m.tbl = newTableModel(m.width, m.height)
 			m.mode = modeTable
 
-			return m, nil
+			return m.reloadTable("")
+
+		case "Search by name":
+			svc := m.svc
+
+			return m.read("Loading students", func() applyFunc {
+				list, err := svc.List(false)
+
+				return func(m rootModel) (rootModel, tea.Cmd) {
+					if err != nil {
+						m.status = fmt.Sprintf("search error: %v", err)
+
+						return m, nil
+					}
+
+					m.status = ""
+					m.search = newSearchModel(list)
+					m.mode = modeSearch
+
+					return m, m.search.Init()
+				}
+			})
 
 		case "Show student (by ID)":
 			m.mode = modeIDInput
@@ -87,6 +186,13 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			return m, m.idInput.Init()
 
+		case "Student history (by ID)":
+			m.mode = modeIDInput
+			m.currentAct = actionHistory
+			m.idInput = newIDInputModel("Student ID in UUID")
+
+			return m, m.idInput.Init()
+
 		case "Average by ID":
 			m.mode = modeIDInput
 			m.currentAct = actionAVG
@@ -96,6 +202,7 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case "Add grades":
 			m.mode = modeAddGrades
+			m.origin = modeMenu
 			m.grades = newAddGradesModel()
 
 			return m, m.grades.Init()
@@ -103,14 +210,131 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "Delete student":
 			m.mode = modeIDInput
 			m.currentAct = actionDel
+			m.origin = modeMenu
 			m.idInput = newIDInputModel("Student ID in UUID")
 
 			return m, m.idInput.Init()
 
+		case "Roster report":
+			reports := m.reports
+
+			return m.read("Building the roster report", func() applyFunc {
+				r, err := reports.Roster(dtos.ReportQueryDTO{})
+
+				return func(m rootModel) (rootModel, tea.Cmd) {
+					if err != nil {
+						m.status = fmt.Sprintf("report error: %v", err)
+
+						return m, nil
+					}
+
+					m.detail = newDetailModel(reportLines(r))
+					m.prevMode = modeMenu
+					m.mode = modeDetail
+
+					return m, nil
+				}
+			})
+
+		case "Trash":
+			svc := m.svc
+
+			return m.read("Loading the trash", func() applyFunc {
+				deleted, err := svc.ListDeleted(true)
+
+				return func(m rootModel) (rootModel, tea.Cmd) {
+					if err != nil {
+						m.status = fmt.Sprintf("trash error: %v", err)
+
+						return m, nil
+					}
+
+					if len(deleted) == 0 {
+						m.status = "trash is empty"
+
+						return m, nil
+					}
+
+					m.status = ""
+					m.trash = newTrashModel(deleted)
+					m.mode = modeTrash
+
+					return m, nil
+				}
+			})
+
+		case "Audit log":
+			audit := m.audit
+
+			return m.read("Loading the audit log", func() applyFunc {
+				auditLog, err := audit.List(dtos.AuditQueryDTO{})
+
+				return func(m rootModel) (rootModel, tea.Cmd) {
+					if err != nil {
+						m.status = fmt.Sprintf("audit error: %v", err)
+
+						return m, nil
+					}
+
+					m.auditView = newAuditModel(auditLog)
+					m.mode = modeAudit
+
+					return m, nil
+				}
+			})
+
 		case "Quit":
 			return m, tea.Quit
 		}
 
+	case historyOpenMsg:
+		return m.openHistory(msg.ID)
+
+	case historyShowMsg:
+		m.detail = newDetailModel(revisionLines(msg.Revision))
+		m.prevMode = modeHistory
+		m.mode = modeDetail
+
+		return m, nil
+
+	case auditShowMsg:
+		m.detail = newDetailModel(auditEntryLines(msg.Entry))
+		m.prevMode = modeAudit
+		m.mode = modeDetail
+
+		return m, nil
+
+	case trashRestoreMsg:
+		m.currentAct = actionRestore
+		m.pendingID = msg.Student.ID
+		m.confirm = newConfirmModel(deletedStudentLines(msg.Student), "Restore this student?")
+		m.mode = modeConfirm
+
+		return m, nil
+
+	case confirmCancelMsg:
+		if m.currentAct == actionRestore {
+			m.mode = modeTrash
+		} else {
+			m.mode = m.origin
+		}
+
+		return m, nil
+
+	case confirmMsg:
+		id := m.pendingID
+		m.pendingID = ""
+
+		switch m.currentAct {
+		case actionDel:
+			return m.deleteStudent(id)
+
+		case actionRestore:
+			return m.restoreStudent(id)
+		}
+
+		return m, nil
+
 	case tableBackMsg:
 		if m.mode == modeDetail {
 			m.mode = m.prevMode
@@ -121,19 +345,41 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tableShowMsg:
-		id := strings.TrimSpace(msg.ID)
+		return m.showStudent(strings.TrimSpace(msg.ID), m.mode)
 
-		r, err := m.svc.GetByID(dtos.GetByIDDTO{ID: id})
-		if err != nil {
-			m.status = fmt.Sprintf("fetch failed: %v", err)
-			m.mode = modeMenu
+	case tableEditMsg:
+		return m.openEdit(strings.TrimSpace(msg.ID), m.mode)
+
+	case tableDeleteMsg:
+		return m.confirmDelete(msg.ID, modeTable)
+
+	case tableGradesMsg:
+		m.origin = modeTable
+		m.grades = newAddGradesModelFor(msg.ID)
+		m.mode = modeAddGrades
+
+		return m, m.grades.Init()
+
+	case tableQueryMsg:
+		return m.reloadTable("")
+
+	case searchQueryMsg:
+		return m, m.refineSearch(msg.Query)
 
+	case searchRefinedMsg:
+		if m.mode != modeSearch || msg.Query != m.search.query {
 			return m, nil
 		}
 
-		m.detail = newDetailModel(studentLines(r))
-		m.prevMode = modeTable
-		m.mode = modeDetail
+		m.search.refining = false
+
+		if msg.ExactID != "" {
+			m.search = m.search.pin(msg.ExactID)
+		}
+
+		if len(m.search.results) == 0 && len(msg.Similar) > 0 {
+			m.search = m.search.withSimilar(msg.Similar)
+		}
 
 		return m, nil
 
@@ -143,61 +389,63 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case createSubmittedMsg:
-		name := strings.TrimSpace(msg.Name)
-		surname := strings.TrimSpace(msg.Surname)
-		ageStr := strings.TrimSpace(msg.Age)
-		gradesStr := strings.TrimSpace(msg.GradesCSV)
-
-		age := 0
-		if ageStr != "" {
-			v, err := strconv.Atoi(ageStr)
-			if err != nil {
-				m.status = fmt.Sprintf("invalid age: %v", err)
+		svc, in := m.svc, msg.Input
+
+		return m.write("Saving the student", func() applyFunc {
+			resp, err := svc.Register(dtos.StudentCreateDTO{
+				Name:    in.Name,
+				Surname: in.Surname,
+				Age:     in.Age,
+				Grades:  mappers.MapGradeValuesToDTOs("", in.Grades),
+			})
+
+			return func(m rootModel) (rootModel, tea.Cmd) {
+				if err != nil {
+					m.status = fmt.Sprintf("register failed: %v", err)
+					m.mode = modeMenu
+
+					return m, nil
+				}
+
+				m.detail = newStudentDetailModel(resp.ID, studentLines(resp))
+				m.prevMode = modeMenu
+				m.mode = modeDetail
 
 				return m, nil
 			}
+		})
 
-			age = v
-		}
+	case editCancelMsg:
+		m.mode = m.origin
 
-		var grades []int
+		return m, nil
 
-		if gradesStr != "" {
-			for _, p := range strings.Split(gradesStr, ",") {
-				p = strings.TrimSpace(p)
-				if p == "" {
-					continue
-				}
+	case editSubmittedMsg:
+		svc, update := m.svc, msg.Update
+
+		return m.write("Saving changes", func() applyFunc {
+			resp, err := svc.Update(update)
 
-				v, err := strconv.Atoi(p)
+			return func(m rootModel) (rootModel, tea.Cmd) {
 				if err != nil {
-					m.status = fmt.Sprintf("invalid grade %q: %v", p, err)
+					m.edit.err = fmt.Sprintf("update failed: %v", err)
+					if errors.Is(err, repositories.ErrVersionConflict) {
+						m.edit.err = "the student was changed meanwhile, " +
+							"esc and reopen it to edit the latest version"
+					}
 
 					return m, nil
 				}
 
-				grades = append(grades, v)
+				return m.showResult(
+					resp.ID,
+					append([]string{"Student updated"}, studentLines(resp)...),
+				)
 			}
-		}
-
-		resp, err := m.svc.Register(dtos.StudentCreateDTO{
-			Name: name, Surname: surname, Age: age, Grades: grades,
 		})
-		if err != nil {
-			m.status = fmt.Sprintf("register failed: %v", err)
-			m.mode = modeMenu
-
-			return m, nil
-		}
-
-		m.detail = newDetailModel(studentLines(resp))
-		m.prevMode = modeMenu
-		m.mode = modeDetail
-
-		return m, nil
 
 	case addGradesCancelMsg:
-		m.mode = modeMenu
+		m.mode = m.origin
 
 		return m, nil
 
@@ -214,28 +462,33 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			v, err := strconv.Atoi(p)
 			if err != nil {
-				m.status = fmt.Sprintf("invalid grade %q: %v", p, err)
-				m.mode = modeMenu
-
-				return m, nil
+				return m.backWithStatus(fmt.Sprintf("invalid grade %q: %v", p, err))
 			}
 
 			grades = append(grades, v)
 		}
 
-		resp, err := m.svc.AddGrades(dtos.AddGradesDTO{ID: id, Grades: grades})
-		if err != nil {
-			m.status = fmt.Sprintf("add grades failed: %v", err)
-			m.mode = modeMenu
-
-			return m, nil
+		svc := m.svc
+		in := dtos.AddGradesDTO{
+			ID:       id,
+			CourseID: strings.TrimSpace(msg.CourseID),
+			Grades:   grades,
 		}
 
-		m.detail = newDetailModel(append([]string{"Grades added successfully"}, studentLines(resp)...))
-		m.prevMode = modeMenu
-		m.mode = modeDetail
+		return m.write("Adding grades", func() applyFunc {
+			resp, err := svc.AddGrades(in)
 
-		return m, nil
+			return func(m rootModel) (rootModel, tea.Cmd) {
+				if err != nil {
+					return m.backWithStatus(fmt.Sprintf("add grades failed: %v", err))
+				}
+
+				return m.showResult(
+					resp.ID,
+					append([]string{"Grades added successfully"}, studentLines(resp)...),
+				)
+			}
+		})
 
 	case idCancelMsg:
 		m.mode = modeMenu
@@ -247,49 +500,19 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch m.currentAct {
 		case actionAVG:
-			r, err := m.svc.AVGByID(dtos.GetByIDDTO{ID: id})
-			if err != nil {
-				m.status = fmt.Sprintf("avg error: %v", err)
-				m.mode = modeMenu
-				return m, nil
-			}
-
-			m.detail = newDetailModel([]string{
-				fmt.Sprintf("ID: %s", r.ID),
-				fmt.Sprintf("AVG: %.2f", r.AVG),
-			})
-
-			m.prevMode = modeMenu
-			m.mode = modeDetail
-
-			return m, nil
+			return m.showAVG(id)
 
 		case actionDel:
-			if err := m.svc.DeleteByID(dtos.GetByIDDTO{ID: id}); err != nil {
-				m.status = fmt.Sprintf("delete failed: %v", err)
-				m.mode = modeMenu
-
-				return m, nil
-			}
+			return m.confirmDelete(id, modeMenu)
 
-			m.status = ""
-			m.mode = modeMenu
+		case actionHistory:
+			return m.openHistory(id)
 
-			return m, nil
+		case actionEdit:
+			return m.openEdit(id, modeMenu)
 
 		case actionShow:
-			r, err := m.svc.GetByID(dtos.GetByIDDTO{ID: id})
-			if err != nil {
-				m.status = fmt.Sprintf("fetch failed: %v", err)
-				m.mode = modeMenu
-				return m, nil
-			}
-
-			m.detail = newDetailModel(studentLines(r))
-			m.prevMode = modeMenu
-			m.mode = modeDetail
-
-			return m, nil
+			return m.showStudent(id, modeMenu)
 		}
 	}
 
@@ -329,6 +552,42 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.detail, cmd = m.detail.Update(msg)
 
+		return m, cmd
+	case modeAudit:
+		var cmd tea.Cmd
+
+		m.auditView, cmd = m.auditView.Update(msg)
+
+		return m, cmd
+	case modeConfirm:
+		var cmd tea.Cmd
+
+		m.confirm, cmd = m.confirm.Update(msg)
+
+		return m, cmd
+	case modeTrash:
+		var cmd tea.Cmd
+
+		m.trash, cmd = m.trash.Update(msg)
+
+		return m, cmd
+	case modeHistory:
+		var cmd tea.Cmd
+
+		m.history, cmd = m.history.Update(msg)
+
+		return m, cmd
+	case modeEdit:
+		var cmd tea.Cmd
+
+		m.edit, cmd = m.edit.Update(msg)
+
+		return m, cmd
+	case modeSearch:
+		var cmd tea.Cmd
+
+		m.search, cmd = m.search.Update(msg)
+
 		return m, cmd
 	}
 
@@ -336,6 +595,15 @@ func (m rootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m rootModel) View() string {
+	out := m.screenView()
+	if m.busy != nil {
+		out += "\n" + m.busyView()
+	}
+
+	return out
+}
+
+func (m rootModel) screenView() string {
 	switch m.mode {
 	case modeMenu:
 		out := "\n" + m.menu.View()
@@ -354,7 +622,302 @@ func (m rootModel) View() string {
 		return m.idInput.View()
 	case modeDetail:
 		return m.detail.View()
+	case modeAudit:
+		return m.auditView.View()
+	case modeConfirm:
+		return m.confirm.View()
+	case modeTrash:
+		return m.trash.View()
+	case modeHistory:
+		return m.history.View()
+	case modeEdit:
+		return m.edit.View()
+	case modeSearch:
+		return m.search.View()
 	default:
 		return ""
 	}
 }
+
+// showStudent opens the detail of the student, going back from it leads to
+// the from screen.
+func (m rootModel) showStudent(id string, from mode) (rootModel, tea.Cmd) {
+	svc := m.svc
+
+	return m.read("Loading the student", func() applyFunc {
+		r, err := svc.GetByID(dtos.GetByIDDTO{ID: id})
+
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			if err != nil {
+				m.status = fmt.Sprintf("fetch failed: %v", err)
+				m.mode = modeMenu
+
+				return m, nil
+			}
+
+			m.detail = newStudentDetailModel(r.ID, studentLines(r))
+			m.prevMode = from
+			m.mode = modeDetail
+
+			return m, nil
+		}
+	})
+}
+
+func (m rootModel) showAVG(id string) (rootModel, tea.Cmd) {
+	svc := m.svc
+
+	return m.read("Computing the average", func() applyFunc {
+		r, err := svc.AVGByID(dtos.AVGQueryDTO{ID: id})
+
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			if err != nil {
+				m.status = fmt.Sprintf("avg error: %v", err)
+				m.mode = modeMenu
+
+				return m, nil
+			}
+
+			lines := []string{
+				fmt.Sprintf("ID: %s", r.ID),
+				fmt.Sprintf("AVG: %.2f%s (%d grades)", r.AVG, mappedSuffix(r.Mapped), r.Count),
+				fmt.Sprintf("Policy: %s average, %s scale", r.Averaging, r.Scale),
+			}
+
+			for _, c := range r.Courses {
+				lines = append(lines, fmt.Sprintf(
+					"  %s: %.2f%s (%d grades)",
+					c.CourseName, c.AVG, mappedSuffix(c.Mapped), c.Count,
+				))
+			}
+
+			m.detail = newStudentDetailModel(r.ID, lines)
+
+			m.prevMode = modeMenu
+			m.mode = modeDetail
+
+			return m, nil
+		}
+	})
+}
+
+func (m rootModel) openHistory(id string) (rootModel, tea.Cmd) {
+	svc := m.svc
+
+	return m.read("Loading the history", func() applyFunc {
+		revisions, err := svc.History(dtos.GetByIDDTO{ID: id})
+
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			if err != nil {
+				m.status = fmt.Sprintf("history error: %v", err)
+				m.mode = modeMenu
+
+				return m, nil
+			}
+
+			if len(revisions) == 0 {
+				m.status = "no revisions are stored for this student yet"
+				m.mode = modeMenu
+
+				return m, nil
+			}
+
+			m.status = ""
+			m.history = newHistoryModel(revisions)
+			m.mode = modeHistory
+
+			return m, nil
+		}
+	})
+}
+
+// openEdit loads the student into the edit form, cancelling the form goes
+// back to the screen it was opened from.
+func (m rootModel) openEdit(id string, from mode) (rootModel, tea.Cmd) {
+	m.origin = from
+	svc := m.svc
+
+	return m.read("Loading the student", func() applyFunc {
+		r, err := svc.GetByID(dtos.GetByIDDTO{ID: id})
+
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			if err != nil {
+				return m.backWithStatus(fmt.Sprintf("fetch failed: %v", err))
+			}
+
+			m.status = ""
+			m.edit = newEditModel(r)
+			m.mode = modeEdit
+
+			return m, m.edit.Init()
+		}
+	})
+}
+
+// confirmDelete asks before moving the student to the trash.
+func (m rootModel) confirmDelete(id string, from mode) (rootModel, tea.Cmd) {
+	m.origin = from
+	svc := m.svc
+
+	return m.read("Loading the student", func() applyFunc {
+		r, err := svc.GetByID(dtos.GetByIDDTO{ID: id})
+
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			if err != nil {
+				return m.backWithStatus(fmt.Sprintf("delete failed: %v", err))
+			}
+
+			m.status = ""
+			m.currentAct = actionDel
+			m.pendingID = r.ID
+			m.confirm = newConfirmModel(studentLines(r), "Move this student to the trash?")
+			m.mode = modeConfirm
+
+			return m, nil
+		}
+	})
+}
+
+func (m rootModel) deleteStudent(id string) (rootModel, tea.Cmd) {
+	svc := m.svc
+
+	return m.write("Moving the student to the trash", func() applyFunc {
+		r, err := svc.GetByID(dtos.GetByIDDTO{ID: id})
+		if err == nil {
+			err = svc.DeleteByID(dtos.GetByIDDTO{ID: id})
+		}
+
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			if err != nil {
+				return m.backWithStatus(fmt.Sprintf("delete failed: %v", err))
+			}
+
+			return m.backWithStatus(fmt.Sprintf(
+				"Student %s %s moved to the trash, restore it from the Trash screen",
+				r.Name, r.Surname,
+			))
+		}
+	})
+}
+
+func (m rootModel) restoreStudent(id string) (rootModel, tea.Cmd) {
+	svc := m.svc
+
+	return m.write("Restoring the student", func() applyFunc {
+		r, err := svc.Restore(dtos.GetByIDDTO{ID: id})
+
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			if err != nil {
+				m.status = fmt.Sprintf("restore failed: %v", err)
+				m.mode = modeMenu
+
+				return m, nil
+			}
+
+			m.status = ""
+			m.detail = newStudentDetailModel(
+				r.ID,
+				append([]string{"Restored from trash"}, studentLines(r)...),
+			)
+			m.prevMode = modeMenu
+			m.mode = modeDetail
+
+			return m, nil
+		}
+	})
+}
+
+// refineSearch looks up the exact full name match and, when nothing in the
+// roster matches, the typo tolerant search in the background. Typing is not
+// blocked, results of outdated queries are dropped by the search screen.
+func (m rootModel) refineSearch(query string) tea.Cmd {
+	svc := m.svc
+	similar := len(m.search.results) == 0 && strings.TrimSpace(query) != ""
+
+	return func() tea.Msg {
+		res := searchRefinedMsg{Query: query}
+
+		if name, surname, ok := splitFullName(query); ok {
+			r, err := svc.GetByFullName(dtos.GetByFullNameDTO{Name: name, Surname: surname})
+			if err == nil {
+				res.ExactID = r.ID
+			}
+		}
+
+		if similar {
+			list, err := svc.SearchByName(dtos.SearchByNameDTO{Query: query})
+			if err == nil {
+				res.Similar = list
+			}
+		}
+
+		return res
+	}
+}
+
+// reloadTable runs the table query again, stepping back to the last page
+// when the current one became empty, and shows status under the table. A
+// reload queued behind the operation in flight is replaced, so resizing the
+// window queues one reload at most.
+func (m rootModel) reloadTable(status string) (rootModel, tea.Cmd) {
+	q, err := m.tbl.query()
+	if err != nil {
+		m.tbl.status = fmt.Sprintf("invalid filter: %v", err)
+
+		return m, nil
+	}
+
+	svc := m.svc
+
+	return m.reload(tableReloadKey, "Loading students", func() applyFunc {
+		page, err := svc.Query(q)
+		if err == nil && page.Total > 0 && q.Offset >= page.Total {
+			q.Offset = (page.Total - 1) / q.Limit * q.Limit
+
+			page, err = svc.Query(q)
+		}
+
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			if err != nil {
+				m.tbl.status = fmt.Sprintf("list error: %v", err)
+
+				return m, nil
+			}
+
+			m.tbl.page = q.Offset / q.Limit
+			m.tbl.status = status
+			m.tbl = m.tbl.withPage(page)
+
+			return m, nil
+		}
+	})
+}
+
+// backWithStatus ends the current action on the screen it started from.
+func (m rootModel) backWithStatus(status string) (rootModel, tea.Cmd) {
+	if m.origin == modeTable {
+		m.mode = modeTable
+
+		return m.reloadTable(status)
+	}
+
+	m.status = status
+	m.mode = modeMenu
+
+	return m, nil
+}
+
+// showResult shows the changed student, going back from it leads to the
+// screen the action started from.
+func (m rootModel) showResult(id string, lines []string) (rootModel, tea.Cmd) {
+	m.status = ""
+	m.detail = newStudentDetailModel(id, lines)
+	m.prevMode = m.origin
+	m.mode = modeDetail
+
+	if m.origin == modeTable {
+		return m.reloadTable("")
+	}
+
+	return m, nil
+}
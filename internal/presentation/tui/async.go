@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// applyFunc applies the result of a service call to the model. Service calls
+// run inside commands, off the update loop, and only hand back an applyFunc,
+// so the model is still changed in Update alone.
+type applyFunc func(m rootModel) (rootModel, tea.Cmd)
+
+// operation is the service call the UI is waiting for. Keys are ignored
+// while it runs, so a form can not be submitted twice.
+type operation struct {
+	seq     int
+	key     string
+	label   string
+	write   bool
+	started time.Time
+	note    string
+}
+
+// pendingOp is a service call waiting to run. Reads with the same non-empty
+// key load the same screen, a newer one replaces the one already queued.
+type pendingOp struct {
+	key   string
+	label string
+	write bool
+	call  func() applyFunc
+}
+
+type opDoneMsg struct {
+	seq   int
+	apply applyFunc
+}
+
+func newSpinner() spinner.Model {
+	s := spinner.New()
+
+	s.Spinner = spinner.Dot
+	s.Style = focusedStyle
+
+	return s
+}
+
+// read runs a service call that only loads data. Esc stops waiting for it
+// and its result is dropped.
+func (m rootModel) read(label string, call func() applyFunc) (rootModel, tea.Cmd) {
+	return m.start(pendingOp{label: label, call: call})
+}
+
+// write runs a service call that changes data. The services take no context,
+// so a started write always runs to the end and esc only says so.
+func (m rootModel) write(label string, call func() applyFunc) (rootModel, tea.Cmd) {
+	return m.start(pendingOp{label: label, write: true, call: call})
+}
+
+// reload runs a read that loads the screen named by key, see pendingOp.
+func (m rootModel) reload(key, label string, call func() applyFunc) (rootModel, tea.Cmd) {
+	return m.start(pendingOp{key: key, label: label, call: call})
+}
+
+// start runs the call or queues it behind the operation in flight.
+func (m rootModel) start(op pendingOp) (rootModel, tea.Cmd) {
+	if m.busy != nil {
+		if i := m.queuedReload(op.key); i >= 0 {
+			m.queue = slices.Clone(m.queue)
+			m.queue[i] = op
+
+			return m, nil
+		}
+
+		m.queue = append(m.queue, op)
+
+		return m, nil
+	}
+
+	m.seq++
+	m.busy = &operation{
+		seq:     m.seq,
+		key:     op.key,
+		label:   op.label,
+		write:   op.write,
+		started: time.Now(),
+	}
+
+	seq, call := m.seq, op.call
+
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		return opDoneMsg{seq: seq, apply: call()}
+	})
+}
+
+// finish applies a result of the operation in flight and starts the next
+// queued one, results of cancelled operations are dropped.
+func (m rootModel) finish(msg opDoneMsg) (rootModel, tea.Cmd) {
+	if m.busy == nil || m.busy.seq != msg.seq {
+		return m, nil
+	}
+
+	m.busy = nil
+
+	m, cmd := msg.apply(m)
+
+	next, nextCmd := m.startQueued()
+
+	return next, tea.Batch(cmd, nextCmd)
+}
+
+// queuedReload returns the position of the queued read with the given key,
+// or -1 if there is none.
+func (m rootModel) queuedReload(key string) int {
+	if key == "" {
+		return -1
+	}
+
+	return slices.IndexFunc(m.queue, func(op pendingOp) bool {
+		return !op.write && op.key == key
+	})
+}
+
+func (m rootModel) startQueued() (rootModel, tea.Cmd) {
+	if m.busy != nil || len(m.queue) == 0 {
+		return m, nil
+	}
+
+	op := m.queue[0]
+	m.queue = m.queue[1:]
+
+	return m.start(op)
+}
+
+func (m rootModel) updateBusy(key tea.KeyMsg) (rootModel, tea.Cmd) {
+	switch key.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		if m.busy.write {
+			m.busy.note = "saving can not be interrupted, please wait"
+
+			return m, nil
+		}
+
+		// The queued reload of the same screen is dropped with the read.
+		if i := m.queuedReload(m.busy.key); i >= 0 {
+			m.queue = slices.Delete(slices.Clone(m.queue), i, i+1)
+		}
+
+		m.busy = nil
+
+		return m.startQueued()
+	}
+
+	return m, nil
+}
+
+func (m rootModel) busyView() string {
+	line := fmt.Sprintf(
+		"%s %s… %.1fs",
+		m.spinner.View(), m.busy.label, time.Since(m.busy.started).Seconds(),
+	)
+
+	switch {
+	case m.busy.note != "":
+		line += " | " + m.busy.note
+	case !m.busy.write:
+		line += " | esc to cancel"
+	}
+
+	if n := len(m.queue); n > 0 {
+		line += fmt.Sprintf(" | %d more queued", n)
+	}
+
+	return helpStyle.Render(line)
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+)
+
+type auditModel struct {
+	table   table.Model
+	entries []dtos.AuditEntryDTO
+	status  string
+}
+
+func newAuditModel(log dtos.AuditLogDTO) auditModel {
+	cols := []table.Column{
+		{Title: "Seq", Width: 5},
+		{Title: "At", Width: 20},
+		{Title: "Actor", Width: 12},
+		{Title: "Action", Width: 10},
+		{Title: "Student", Width: 36},
+		{Title: "Changes", Width: 40},
+	}
+
+	rows := make([]table.Row, 0, len(log.Entries))
+
+	for _, e := range log.Entries {
+		rows = append(rows, table.Row{
+			strconv.FormatUint(e.Seq, 10),
+			e.At,
+			e.Actor,
+			e.Action,
+			e.StudentID,
+			strings.Join(e.Changes, "; "),
+		})
+	}
+
+	t := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+
+	status := "Chain verified, " + strconv.Itoa(log.Total) + " entries"
+	if !log.Verified {
+		status = "Chain failed verification: " + log.Problem
+	}
+
+	return auditModel{
+		table:   styleTable(t),
+		entries: log.Entries,
+		status:  status,
+	}
+}
+
+func (m auditModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m auditModel) Update(msg tea.Msg) (auditModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			return m, func() tea.Msg {
+				return tableBackMsg{}
+			}
+		case "enter":
+			if i := m.table.Cursor(); i >= 0 && i < len(m.entries) {
+				entry := m.entries[i]
+
+				return m, func() tea.Msg { return auditShowMsg{Entry: entry} }
+			}
+
+			return m, nil
+		}
+	}
+
+	m.table, cmd = m.table.Update(msg)
+
+	return m, cmd
+}
+
+func (m auditModel) View() string {
+	return renderStatus(m.status) + "\n" + baseStyle.Render(m.table.View()) + "\n" +
+		helpStyle.Render("↑/↓ to move | esc/q to back | enter to show before/after")
+}
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var questionStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color(purple)).
+	PaddingTop(1)
+
+// confirmModel shows what is about to change and waits for an explicit y or n.
+type confirmModel struct {
+	body     string
+	question string
+}
+
+func newConfirmModel(lines []string, question string) confirmModel {
+	return confirmModel{
+		body:     strings.Join(lines, "\n"),
+		question: question,
+	}
+}
+
+func (m confirmModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m confirmModel) Update(msg tea.Msg) (confirmModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "y", "Y":
+			return m, func() tea.Msg { return confirmMsg{} }
+		case "n", "N", "esc", "q", "ctrl+c":
+			return m, func() tea.Msg { return confirmCancelMsg{} }
+		}
+	}
+
+	return m, nil
+}
+
+func (m confirmModel) View() string {
+	return m.body + "\n" + questionStyle.Render(m.question+" [y/n]") + "\n" +
+		helpStyle.Render("y to confirm | n/esc to cancel")
+}
@@ -7,7 +7,8 @@ import (
 )
 
 type detailModel struct {
-	body string
+	body      string
+	studentID string
 }
 
 func newDetailModel(lines []string) detailModel {
@@ -16,13 +17,28 @@ func newDetailModel(lines []string) detailModel {
 	}
 }
 
+// newStudentDetailModel is a detail screen of one student that can open the
+// history of that student.
+func newStudentDetailModel(id string, lines []string) detailModel {
+	m := newDetailModel(lines)
+	m.studentID = id
+
+	return m
+}
+
 func (m detailModel) Init() tea.Cmd {
 	return nil
 }
 
 func (m detailModel) Update(msg tea.Msg) (detailModel, tea.Cmd) {
-	switch msg.(type) {
+	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if msg.String() == "h" && m.studentID != "" {
+			id := m.studentID
+
+			return m, func() tea.Msg { return historyOpenMsg{ID: id} }
+		}
+
 		return m, func() tea.Msg { return tableBackMsg{} }
 	}
 
@@ -30,5 +46,10 @@ func (m detailModel) Update(msg tea.Msg) (detailModel, tea.Cmd) {
 }
 
 func (m detailModel) View() string {
-	return m.body + "\n\n" + helpStyle.Render("any key/esc to back")
+	help := "any key/esc to back"
+	if m.studentID != "" {
+		help = "h to history | any other key/esc to back"
+	}
+
+	return m.body + "\n\n" + helpStyle.Render(help)
 }
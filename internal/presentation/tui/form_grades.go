@@ -13,7 +13,7 @@ type addGradesModel struct {
 }
 
 func newAddGradesModel() addGradesModel {
-	m := addGradesModel{inputs: make([]textinput.Model, 2)}
+	m := addGradesModel{inputs: make([]textinput.Model, 3)}
 
 	var t textinput.Model
 
@@ -33,6 +33,8 @@ func newAddGradesModel() addGradesModel {
 			t.TextStyle = focusedStyle
 		case 1:
 			t.Placeholder = "Grades separated example: 80,90"
+		case 2:
+			t.Placeholder = "Course ID UUID (empty for Unassigned)"
 		}
 
 		m.inputs[i] = t
@@ -41,6 +43,18 @@ func newAddGradesModel() addGradesModel {
 	return m
 }
 
+// newAddGradesModelFor starts the form on the grades of a known student.
+func newAddGradesModelFor(id string) addGradesModel {
+	m := newAddGradesModel()
+
+	m.inputs[0].SetValue(id)
+	m.focusIndex = 1
+
+	focusInputs(m.inputs, m.focusIndex)
+
+	return m
+}
+
 func (m addGradesModel) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -57,11 +71,13 @@ func (m addGradesModel) Update(msg tea.Msg) (addGradesModel, tea.Cmd) {
 			if s == "enter" && m.focusIndex == len(m.inputs) {
 				id := strings.TrimSpace(m.inputs[0].Value())
 				grades := strings.TrimSpace(m.inputs[1].Value())
+				courseID := strings.TrimSpace(m.inputs[2].Value())
 
 				return m, func() tea.Msg {
 					return addGradesSubmittedMsg{
-						ID:     id,
-						Grades: grades,
+						ID:       id,
+						Grades:   grades,
+						CourseID: courseID,
 					}
 				}
 			}
@@ -10,6 +10,7 @@ import (
 type idInputModel struct {
 	textInput textinput.Model
 	label     string
+	hint      string
 }
 
 func newIDInputModel(label string) idInputModel {
@@ -25,6 +26,7 @@ func newIDInputModel(label string) idInputModel {
 	return idInputModel{
 		textInput: ti,
 		label:     label,
+		hint:      "type the ID",
 	}
 }
 
@@ -61,6 +63,6 @@ func (m idInputModel) View() string {
 		"%s:\n\n%s\n\n%s",
 		m.label,
 		m.textInput.View(),
-		helpStyle.Render("type the ID | enter to submit | esc to back"),
+		helpStyle.Render(m.hint+" | enter to submit | esc to back"),
 	)
 }
@@ -1,7 +1,10 @@
 package tui
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,45 +16,66 @@ var (
 	blurredStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	cursorStyle  = focusedStyle
 	noStyle      = lipgloss.NewStyle()
+	invalidStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196")).PaddingLeft(2)
 )
 
+const (
+	fieldName = iota
+	fieldSurname
+	fieldAge
+	fieldGrades
+)
+
+// studentInput is what the student forms hand over once every field passed
+// the same checks the domain model runs.
+type studentInput struct {
+	Name, Surname string
+	Age           int
+	Grades        []int
+}
+
 type createModel struct {
 	focusIndex int
 	inputs     []textinput.Model
+	errs       []string
 }
 
 func newCreateModel() createModel {
-	m := createModel{inputs: make([]textinput.Model, 4)}
+	return createModel{inputs: newStudentInputs("Grades CSV (e.g. 70,85,90)")}
+}
+
+func newStudentInputs(gradesPlaceholder string) []textinput.Model {
+	inputs := make([]textinput.Model, 4)
 
 	var t textinput.Model
 
-	for i := range m.inputs {
+	for i := range inputs {
 		t = textinput.New()
 
 		t.Cursor.Style = cursorStyle
 		t.CharLimit = 48
 
 		switch i {
-		case 0:
+		case fieldName:
 			t.Placeholder = "Name (Capitalized)"
 
 			t.Focus()
 
 			t.PromptStyle = focusedStyle
 			t.TextStyle = focusedStyle
-		case 1:
+		case fieldSurname:
 			t.Placeholder = "Surname (Capitalized)"
-		case 2:
+		case fieldAge:
 			t.Placeholder = "Age (number)"
-		case 3:
-			t.Placeholder = "Grades CSV (e.g. 70,85,90)"
+		case fieldGrades:
+			t.Placeholder = gradesPlaceholder
 			t.CharLimit = 128
 		}
 
-		m.inputs[i] = t
+		inputs[i] = t
 	}
 
-	return m
+	return inputs
 }
 
 func (m createModel) Init() tea.Cmd {
@@ -68,44 +92,19 @@ func (m createModel) Update(msg tea.Msg) (createModel, tea.Cmd) {
 			s := msg.String()
 
 			if s == "enter" && m.focusIndex == len(m.inputs) {
-				return m, func() tea.Msg {
-					return createSubmittedMsg{
-						Name:      strings.TrimSpace(m.inputs[0].Value()),
-						Surname:   strings.TrimSpace(m.inputs[1].Value()),
-						Age:       strings.TrimSpace(m.inputs[2].Value()),
-						GradesCSV: strings.TrimSpace(m.inputs[3].Value()),
-					}
-				}
-			}
+				in, errs := parseStudentInputs(m.inputs)
 
-			if s == "up" || s == "shift+tab" {
-				m.focusIndex--
-			} else {
-				m.focusIndex++
-			}
+				m.errs = errs
+				if errs != nil {
+					return m, nil
+				}
 
-			if m.focusIndex > len(m.inputs) {
-				m.focusIndex = 0
-			} else if m.focusIndex < 0 {
-				m.focusIndex = len(m.inputs)
+				return m, func() tea.Msg { return createSubmittedMsg{Input: in} }
 			}
 
-			cmds := make([]tea.Cmd, len(m.inputs))
-			for i := 0; i <= len(m.inputs)-1; i++ {
-				if i == m.focusIndex {
-					cmds[i] = m.inputs[i].Focus()
+			m.focusIndex = nextFocus(m.focusIndex, s, len(m.inputs))
 
-					m.inputs[i].PromptStyle = focusedStyle
-					m.inputs[i].TextStyle = focusedStyle
-				} else {
-					m.inputs[i].Blur()
-
-					m.inputs[i].PromptStyle = noStyle
-					m.inputs[i].TextStyle = noStyle
-				}
-			}
-
-			return m, tea.Batch(cmds...)
+			return m, focusInputs(m.inputs, m.focusIndex)
 		}
 	}
 
@@ -127,21 +126,162 @@ func (m createModel) View() string {
 	var b strings.Builder
 
 	b.WriteString("Create student\n\n")
+	b.WriteString(inputsView(m.inputs, m.errs))
 
-	for i := range m.inputs {
-		b.WriteString(m.inputs[i].View())
-		if i < len(m.inputs)-1 {
+	b.WriteString("\n\n" + submitButton(m.focusIndex == len(m.inputs)) + "\n")
+	b.WriteString(helpStyle.Render("tab/shift+tab to move | enter to submit | esc to back"))
+
+	return b.String()
+}
+
+// nextFocus moves the focus one step for the key and wraps around, last is
+// the index of the submit button.
+func nextFocus(index int, key string, last int) int {
+	if key == "up" || key == "shift+tab" {
+		index--
+	} else {
+		index++
+	}
+
+	if index > last {
+		return 0
+	} else if index < 0 {
+		return last
+	}
+
+	return index
+}
+
+func focusInputs(inputs []textinput.Model, index int) tea.Cmd {
+	cmds := make([]tea.Cmd, len(inputs))
+
+	for i := range inputs {
+		if i == index {
+			cmds[i] = inputs[i].Focus()
+
+			inputs[i].PromptStyle = focusedStyle
+			inputs[i].TextStyle = focusedStyle
+		} else {
+			inputs[i].Blur()
+
+			inputs[i].PromptStyle = noStyle
+			inputs[i].TextStyle = noStyle
+		}
+	}
+
+	return tea.Batch(cmds...)
+}
+
+func inputsView(inputs []textinput.Model, errs []string) string {
+	var b strings.Builder
+
+	for i := range inputs {
+		b.WriteString(inputs[i].View())
+
+		if i < len(errs) && errs[i] != "" {
+			b.WriteString("\n" + invalidStyle.Render(errs[i]))
+		}
+
+		if i < len(inputs)-1 {
 			b.WriteRune('\n')
 		}
 	}
 
-	button := blurredStyle.Render("[ Submit ]")
-	if m.focusIndex == len(m.inputs) {
-		button = focusedStyle.Render("[ Submit ]")
+	return b.String()
+}
+
+func submitButton(focused bool) string {
+	if focused {
+		return focusedStyle.Render("[ Submit ]")
 	}
 
-	b.WriteString("\n\n" + button + "\n")
-	b.WriteString(helpStyle.Render("tab/shift+tab to move | enter to submit | esc to back"))
+	return blurredStyle.Render("[ Submit ]")
+}
 
-	return b.String()
+// parseStudentInputs checks the fields the way the domain model does and
+// returns one message per field, errs is nil when everything is valid.
+func parseStudentInputs(inputs []textinput.Model) (studentInput, []string) {
+	var (
+		in    studentInput
+		errs  = make([]string, len(inputs))
+		valid = true
+	)
+
+	fail := func(field int, format string, args ...any) {
+		errs[field] = fmt.Sprintf(format, args...)
+		valid = false
+	}
+
+	in.Name = strings.TrimSpace(inputs[fieldName].Value())
+	if msg := checkCapitalized(in.Name); msg != "" {
+		fail(fieldName, "name %s", msg)
+	}
+
+	in.Surname = strings.TrimSpace(inputs[fieldSurname].Value())
+	if msg := checkCapitalized(in.Surname); msg != "" {
+		fail(fieldSurname, "surname %s", msg)
+	}
+
+	if s := strings.TrimSpace(inputs[fieldAge].Value()); s != "" {
+		age, err := strconv.Atoi(s)
+		if err != nil || age < 0 || age > 150 {
+			fail(fieldAge, "age must be a number from 0 to 150")
+		}
+
+		in.Age = age
+	}
+
+	grades, err := parseGradesCSV(inputs[fieldGrades].Value())
+	if err != nil {
+		fail(fieldGrades, "%v", err)
+	}
+
+	in.Grades = grades
+
+	if valid {
+		return in, nil
+	}
+
+	return in, errs
+}
+
+func checkCapitalized(s string) string {
+	if s == "" {
+		return "is required"
+	}
+
+	if !unicode.IsUpper([]rune(s)[0]) {
+		return "must start with a capital letter"
+	}
+
+	return ""
+}
+
+func parseGradesCSV(s string) ([]int, error) {
+	var grades []int
+
+	for _, p := range strings.Split(strings.TrimSpace(s), ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		v, err := parseGrade(p)
+		if err != nil {
+			return nil, err
+		}
+
+		grades = append(grades, v)
+	}
+
+	return grades, nil
+}
+
+func parseGrade(s string) (int, error) {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || v < 0 || v > 100 {
+		return 0, fmt.Errorf("grade %q must be a number from 0 to 100", s)
+	}
+
+	return v, nil
 }
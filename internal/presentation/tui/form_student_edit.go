@@ -0,0 +1,278 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+	"github.com/k6zma/avito-lab1/internal/application/mappers"
+)
+
+// editModel reuses the create form fields, the grades input only adds new
+// grades while the stored ones are edited one by one in the grade list, so
+// their course, date and author survive the update.
+type editModel struct {
+	createModel
+
+	id        string
+	version   int
+	grades    []dtos.GradeDTO
+	cursor    int
+	replace   textinput.Model
+	replacing bool
+	gradeErr  string
+	err       string
+}
+
+func newEditModel(s dtos.DefaultStudentResponseDTO) editModel {
+	m := editModel{
+		createModel: createModel{inputs: newStudentInputs("New grades CSV (e.g. 70,85)")},
+		id:          s.ID,
+		version:     s.Version,
+		grades:      append([]dtos.GradeDTO(nil), s.Grades...),
+	}
+
+	m.inputs[fieldName].SetValue(s.Name)
+	m.inputs[fieldSurname].SetValue(s.Surname)
+	m.inputs[fieldAge].SetValue(strconv.Itoa(s.Age))
+
+	m.replace = textinput.New()
+	m.replace.Placeholder = "New value (0-100)"
+	m.replace.Cursor.Style = cursorStyle
+	m.replace.CharLimit = 3
+
+	return m
+}
+
+func (m editModel) gradesIndex() int {
+	return len(m.inputs)
+}
+
+func (m editModel) submitIndex() int {
+	return len(m.inputs) + 1
+}
+
+func (m editModel) Update(msg tea.Msg) (editModel, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m.updateFocused(msg)
+	}
+
+	if m.replacing {
+		return m.updateReplace(key)
+	}
+
+	s := key.String()
+
+	switch s {
+	case "esc":
+		return m, func() tea.Msg { return editCancelMsg{} }
+	case "enter":
+		switch m.focusIndex {
+		case m.submitIndex():
+			return m.submit()
+		case m.gradesIndex():
+			if len(m.grades) > 0 {
+				return m.startReplace()
+			}
+		}
+	}
+
+	if m.focusIndex == m.gradesIndex() {
+		switch s {
+		case "left", "h":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+
+			return m, nil
+		case "right", "l":
+			if m.cursor < len(m.grades)-1 {
+				m.cursor++
+			}
+
+			return m, nil
+		case "x", "d", "delete", "backspace":
+			if len(m.grades) > 0 {
+				m.grades = slices.Delete(m.grades, m.cursor, m.cursor+1)
+				m.cursor = min(m.cursor, max(len(m.grades)-1, 0))
+			}
+
+			return m, nil
+		case "r":
+			return m.startReplace()
+		}
+	}
+
+	switch s {
+	case "tab", "shift+tab", "enter", "up", "down":
+		m.focusIndex = nextFocus(m.focusIndex, s, m.submitIndex())
+
+		return m, focusInputs(m.inputs, m.focusIndex)
+	}
+
+	return m.updateFocused(msg)
+}
+
+func (m editModel) updateFocused(msg tea.Msg) (editModel, tea.Cmd) {
+	if m.replacing {
+		var cmd tea.Cmd
+
+		m.replace, cmd = m.replace.Update(msg)
+
+		return m, cmd
+	}
+
+	return m, m.updateInputs(msg)
+}
+
+func (m editModel) startReplace() (editModel, tea.Cmd) {
+	if len(m.grades) == 0 {
+		return m, nil
+	}
+
+	m.replacing = true
+	m.gradeErr = ""
+	m.replace.SetValue(strconv.Itoa(m.grades[m.cursor].Value))
+	m.replace.CursorEnd()
+
+	return m, m.replace.Focus()
+}
+
+func (m editModel) updateReplace(key tea.KeyMsg) (editModel, tea.Cmd) {
+	switch key.String() {
+	case "esc":
+		m.replacing = false
+		m.gradeErr = ""
+		m.replace.Blur()
+
+		return m, nil
+	case "enter":
+		v, err := parseGrade(m.replace.Value())
+		if err != nil {
+			m.gradeErr = err.Error()
+
+			return m, nil
+		}
+
+		m.grades[m.cursor].Value = v
+		m.replacing = false
+		m.gradeErr = ""
+		m.replace.Blur()
+
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+
+	m.replace, cmd = m.replace.Update(key)
+
+	return m, cmd
+}
+
+func (m editModel) submit() (editModel, tea.Cmd) {
+	in, errs := parseStudentInputs(m.inputs)
+
+	m.errs = errs
+	if errs != nil {
+		return m, nil
+	}
+
+	grades := append(
+		append([]dtos.GradeDTO(nil), m.grades...),
+		mappers.MapGradeValuesToDTOs("", in.Grades)...,
+	)
+
+	update := dtos.StudentUpdateDTO{
+		ID:      m.id,
+		Name:    in.Name,
+		Surname: in.Surname,
+		Age:     in.Age,
+		Grades:  grades,
+		Version: m.version,
+	}
+
+	return m, func() tea.Msg { return editSubmittedMsg{Update: update} }
+}
+
+func (m editModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Edit student %s (version %d)\n\n", m.id, m.version))
+	b.WriteString(inputsView(m.inputs, m.errs))
+	b.WriteString("\n\n" + m.gradesView())
+
+	b.WriteString("\n\n" + submitButton(m.focusIndex == m.submitIndex()) + "\n")
+
+	if m.err != "" {
+		b.WriteString(errorStyle.Render(m.err) + "\n")
+	}
+
+	help := "tab/shift+tab to move | enter to submit | esc to back"
+
+	switch {
+	case m.replacing:
+		help = "enter to replace the grade | esc to keep it"
+	case m.focusIndex == m.gradesIndex():
+		help = "←/→ to pick a grade | r/enter to replace | x to remove | tab to move"
+	}
+
+	b.WriteString(helpStyle.Render(help))
+
+	return b.String()
+}
+
+func (m editModel) gradesView() string {
+	style := noStyle
+	if m.focusIndex == m.gradesIndex() {
+		style = focusedStyle
+	}
+
+	if len(m.grades) == 0 {
+		return style.Render("Grades: none")
+	}
+
+	parts := make([]string, len(m.grades))
+
+	for i, g := range m.grades {
+		parts[i] = strconv.Itoa(g.Value)
+		if i == m.cursor && m.focusIndex == m.gradesIndex() {
+			parts[i] = "[" + parts[i] + "]"
+		}
+	}
+
+	out := style.Render("Grades: " + strings.Join(parts, " "))
+
+	if g := m.grades[m.cursor]; m.focusIndex == m.gradesIndex() {
+		out += "\n" + blurredStyle.Render(gradeDetails(g))
+	}
+
+	if m.replacing {
+		out += "\n" + m.replace.View()
+	}
+
+	if m.gradeErr != "" {
+		out += "\n" + invalidStyle.Render(m.gradeErr)
+	}
+
+	return out
+}
+
+func gradeDetails(g dtos.GradeDTO) string {
+	details := "  course " + g.CourseID
+
+	if g.Date != "" {
+		details += ", " + g.Date
+	}
+
+	if g.Author != "" {
+		details += ", by " + g.Author
+	}
+
+	return details
+}
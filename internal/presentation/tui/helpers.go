@@ -5,57 +5,36 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/charmbracelet/bubbles/table"
-
 	"github.com/k6zma/avito-lab1/internal/application/dtos"
 )
 
-func studentsToTable(list []dtos.StudentListItemDTO) table.Model {
-	cols := []table.Column{
-		{Title: "#", Width: 3},
-		{Title: "ID", Width: 36},
-		{Title: "Name", Width: 14},
-		{Title: "Surname", Width: 16},
-		{Title: "Age", Width: 4},
-		{Title: "Grades", Width: 40},
+func studentLines(s dtos.DefaultStudentResponseDTO) []string {
+	lines := []string{
+		fmt.Sprintf("ID: %s", s.ID),
+		fmt.Sprintf("Name: %s", s.Name),
+		fmt.Sprintf("Surname: %s", s.Surname),
+		fmt.Sprintf("Age: %d", s.Age),
+		fmt.Sprintf("Version: %d", s.Version),
 	}
 
-	var rows []table.Row
-
-	for i, s := range list {
-		grades := ""
-
-		if len(s.Grades) > 0 {
-			ss := make([]string, len(s.Grades))
-
-			for i, g := range s.Grades {
-				ss[i] = strconv.Itoa(g)
-			}
+	if len(s.Grades) > 0 {
+		var ss []string
 
-			grades = strings.Join(ss, ",")
+		for _, g := range s.Grades {
+			ss = append(ss, strconv.Itoa(g.Value))
 		}
 
-		rows = append(rows, table.Row{
-			strconv.Itoa(i + 1),
-			s.ID,
-			s.Name,
-			s.Surname,
-			strconv.Itoa(s.Age),
-			grades,
-		})
+		lines = append(lines, "Grades: "+strings.Join(ss, ", "))
 	}
 
-	t := table.New(
-		table.WithColumns(cols),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(10),
-	)
+	if s.AvgGrade != nil {
+		lines = append(lines, fmt.Sprintf("AVG: %.2f%s", *s.AvgGrade, mappedSuffix(s.AvgMapped)))
+	}
 
-	return styleTable(t)
+	return lines
 }
 
-func studentLines(s dtos.DefaultStudentResponseDTO) []string {
+func deletedStudentLines(s dtos.DeletedStudentDTO) []string {
 	lines := []string{
 		fmt.Sprintf("ID: %s", s.ID),
 		fmt.Sprintf("Name: %s", s.Name),
@@ -64,22 +43,132 @@ func studentLines(s dtos.DefaultStudentResponseDTO) []string {
 	}
 
 	if len(s.Grades) > 0 {
-		var ss []string
+		lines = append(lines, "Grades: "+joinGradeValues(s.Grades))
+	}
 
-		for _, g := range s.Grades {
-			ss = append(ss, strconv.Itoa(g))
+	return append(lines, fmt.Sprintf("Deleted at: %s", s.DeletedAt))
+}
+
+func revisionLines(r dtos.StudentRevisionDTO) []string {
+	lines := []string{
+		fmt.Sprintf("Revision %d at %s (%s)", r.Version, r.At, revisionState(r)),
+		"Changes:",
+	}
+
+	for _, c := range r.Changes {
+		lines = append(lines, "  "+c)
+	}
+
+	return append(append(lines, ""), studentLines(r.Student)...)
+}
+
+func reportLines(r dtos.RosterReportDTO) []string {
+	lines := []string{
+		"Roster report",
+		fmt.Sprintf(
+			"Students: %d (%d graded, %d without grades)",
+			r.Students, r.Graded, len(r.NoGrades),
+		),
+		fmt.Sprintf("Policy: %s average, %s scale", r.Averaging, r.Scale),
+		fmt.Sprintf("Mean: %.2f  Median: %.2f  StdDev: %.2f", r.Mean, r.Median, r.StdDev),
+		"",
+		"Grade distribution:",
+	}
+
+	lines = append(lines, histogramLines(r.GradeDistribution)...)
+	lines = append(lines, "", "Age distribution:")
+	lines = append(lines, histogramLines(r.AgeDistribution)...)
+
+	lines = append(lines, "", fmt.Sprintf("Top %d:", len(r.Top)))
+	for i, s := range r.Top {
+		lines = append(lines, fmt.Sprintf(
+			"  %d. %s %s %.2f%s", i+1, s.Name, s.Surname, s.AVG, mappedSuffix(s.Mapped),
+		))
+	}
+
+	lines = append(lines, "", fmt.Sprintf("Bottom %d:", len(r.Bottom)))
+	for i, s := range r.Bottom {
+		lines = append(lines, fmt.Sprintf(
+			"  %d. %s %s %.2f%s", i+1, s.Name, s.Surname, s.AVG, mappedSuffix(s.Mapped),
+		))
+	}
+
+	if len(r.NoGrades) > 0 {
+		lines = append(lines, "", "Without grades:")
+
+		for _, s := range r.NoGrades {
+			lines = append(lines, fmt.Sprintf("  %s %s (%s)", s.Name, s.Surname, s.ID))
 		}
+	}
 
-		lines = append(lines, "Grades: "+strings.Join(ss, ", "))
+	return lines
+}
+
+func auditEntryLines(e dtos.AuditEntryDTO) []string {
+	lines := []string{
+		fmt.Sprintf("Audit entry #%d", e.Seq),
+		fmt.Sprintf("At: %s", e.At),
+		fmt.Sprintf("Actor: %s", e.Actor),
+		fmt.Sprintf("Action: %s", e.Action),
+		fmt.Sprintf("Student: %s", e.StudentID),
+		"",
+		"Changes:",
 	}
 
-	if s.AvgGrade != nil {
-		lines = append(lines, fmt.Sprintf("AVG: %.2f", *s.AvgGrade))
+	for _, c := range e.Changes {
+		lines = append(lines, "  "+c)
+	}
+
+	for _, side := range []struct {
+		title string
+		s     *dtos.StudentListItemDTO
+	}{{"Before", e.Before}, {"After", e.After}} {
+		if side.s == nil {
+			continue
+		}
+
+		lines = append(lines, "", side.title+":", fmt.Sprintf(
+			"  %s %s, age %d, grades [%s]",
+			side.s.Name, side.s.Surname, side.s.Age, joinGradeValues(side.s.Grades),
+		))
+	}
+
+	return append(lines, "", "Hash: "+e.Hash)
+}
+
+func joinGradeValues(grades []dtos.GradeDTO) string {
+	ss := make([]string, 0, len(grades))
+	for _, g := range grades {
+		ss = append(ss, strconv.Itoa(g.Value))
+	}
+
+	return strings.Join(ss, ", ")
+}
+
+func histogramLines(buckets []dtos.HistogramBucketDTO) []string {
+	width := 0
+	for _, b := range buckets {
+		width = max(width, len(b.Label))
+	}
+
+	lines := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		lines = append(lines, fmt.Sprintf(
+			"  %-*s %3d %s", width, b.Label, b.Count, strings.Repeat("█", b.Count),
+		))
 	}
 
 	return lines
 }
 
+func mappedSuffix(mapped string) string {
+	if mapped == "" {
+		return ""
+	}
+
+	return " (" + mapped + ")"
+}
+
 func renderStatus(msg string) string {
 	l := strings.ToLower(msg)
 	if strings.Contains(l, "error") || strings.Contains(l, "failed") ||
@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+)
+
+type historyModel struct {
+	table     table.Model
+	revisions []dtos.StudentRevisionDTO
+	title     string
+}
+
+func newHistoryModel(revisions []dtos.StudentRevisionDTO) historyModel {
+	cols := []table.Column{
+		{Title: "Version", Width: 7},
+		{Title: "At", Width: 20},
+		{Title: "State", Width: 8},
+		{Title: "Changes", Width: 60},
+	}
+
+	rows := make([]table.Row, 0, len(revisions))
+
+	for _, r := range revisions {
+		rows = append(rows, table.Row{
+			strconv.Itoa(r.Version),
+			r.At,
+			revisionState(r),
+			strings.Join(r.Changes, "; "),
+		})
+	}
+
+	t := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+
+	var title string
+	if n := len(revisions); n > 0 {
+		last := revisions[n-1].Student
+		title = "History of " + last.Name + " " + last.Surname + ", " + strconv.Itoa(n) + " revisions"
+	}
+
+	return historyModel{
+		table:     styleTable(t),
+		revisions: revisions,
+		title:     title,
+	}
+}
+
+func (m historyModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m historyModel) Update(msg tea.Msg) (historyModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			return m, func() tea.Msg {
+				return tableBackMsg{}
+			}
+		case "enter":
+			if i := m.table.Cursor(); i >= 0 && i < len(m.revisions) {
+				revision := m.revisions[i]
+
+				return m, func() tea.Msg { return historyShowMsg{Revision: revision} }
+			}
+
+			return m, nil
+		}
+	}
+
+	m.table, cmd = m.table.Update(msg)
+
+	return m, cmd
+}
+
+func (m historyModel) View() string {
+	return renderStatus(m.title) + "\n" + baseStyle.Render(m.table.View()) + "\n" +
+		helpStyle.Render("↑/↓ to move | esc/q to back | enter to show revision and diff")
+}
+
+func revisionState(r dtos.StudentRevisionDTO) string {
+	if r.Deleted {
+		return "trash"
+	}
+
+	return "active"
+}
@@ -0,0 +1,329 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+)
+
+const searchHeight = 10
+
+var (
+	matchStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true).Underline(true)
+	selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color(purple))
+)
+
+// searchModel filters the roster while the query is typed. The roster is
+// loaded once when the screen opens, the root model only refines results
+// with the exact full name match and the typo tolerant search.
+type searchModel struct {
+	input    textinput.Model
+	roster   []dtos.StudentListItemDTO
+	results  []dtos.StudentListItemDTO
+	query    string
+	exactID  string
+	similar  bool
+	refining bool
+	cursor   int
+	offset   int
+}
+
+func newSearchModel(roster []dtos.StudentListItemDTO) searchModel {
+	ti := textinput.New()
+
+	ti.Placeholder = "Name, surname or both"
+	ti.Cursor.Style = cursorStyle
+	ti.CharLimit = 128
+	ti.Width = 40
+
+	ti.Focus()
+
+	return searchModel{
+		input:   ti,
+		roster:  roster,
+		results: roster,
+	}
+}
+
+func (m searchModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m searchModel) Update(msg tea.Msg) (searchModel, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			return m, func() tea.Msg { return tableBackMsg{} }
+		case "up", "ctrl+p":
+			m.moveCursor(-1)
+
+			return m, nil
+		case "down", "ctrl+n":
+			m.moveCursor(1)
+
+			return m, nil
+		case "pgup":
+			m.moveCursor(-searchHeight)
+
+			return m, nil
+		case "pgdown":
+			m.moveCursor(searchHeight)
+
+			return m, nil
+		case "enter":
+			if m.cursor < len(m.results) {
+				id := m.results[m.cursor].ID
+
+				return m, func() tea.Msg { return tableShowMsg{ID: id} }
+			}
+
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+
+	m.input, cmd = m.input.Update(msg)
+
+	q := m.input.Value()
+	if q == m.query {
+		return m, cmd
+	}
+
+	m.query = q
+	m.results = filterStudents(m.roster, q)
+	m.exactID = ""
+	m.similar = false
+	m.refining = true
+	m.cursor = 0
+	m.offset = 0
+
+	return m, tea.Batch(cmd, func() tea.Msg { return searchQueryMsg{Query: q} })
+}
+
+func (m *searchModel) moveCursor(delta int) {
+	if len(m.results) == 0 {
+		return
+	}
+
+	m.cursor = min(max(m.cursor+delta, 0), len(m.results)-1)
+
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	} else if m.cursor >= m.offset+searchHeight {
+		m.offset = m.cursor - searchHeight + 1
+	}
+}
+
+// pin moves the student to the top of the results and marks it as the exact
+// full name match.
+func (m searchModel) pin(id string) searchModel {
+	for i, s := range m.results {
+		if s.ID == id {
+			pinned := append([]dtos.StudentListItemDTO{s}, m.results[:i]...)
+			m.results = append(pinned, m.results[i+1:]...)
+			m.exactID = id
+
+			return m
+		}
+	}
+
+	for _, s := range m.roster {
+		if s.ID == id {
+			m.results = append([]dtos.StudentListItemDTO{s}, m.results...)
+			m.exactID = id
+
+			return m
+		}
+	}
+
+	return m
+}
+
+func (m searchModel) withSimilar(list []dtos.StudentListItemDTO) searchModel {
+	m.results = list
+	m.similar = true
+	m.cursor = 0
+	m.offset = 0
+
+	return m
+}
+
+func (m searchModel) View() string {
+	var b strings.Builder
+
+	b.WriteString("Search students:\n\n")
+	b.WriteString(m.input.View() + "\n\n")
+
+	terms := searchTerms(m.query)
+
+	b.WriteString(blurredStyle.Render(fmt.Sprintf(
+		"  %-14s %-16s %-4s %s", "Name", "Surname", "Age", "ID",
+	)) + "\n")
+
+	end := min(m.offset+searchHeight, len(m.results))
+
+	for i := m.offset; i < end; i++ {
+		s := m.results[i]
+
+		base := noStyle
+		prefix := "  "
+
+		if i == m.cursor {
+			base = selectedStyle
+			prefix = "> "
+		}
+
+		marker := " "
+		if s.ID == m.exactID {
+			marker = "="
+		}
+
+		b.WriteString(base.Render(prefix) +
+			highlightCell(s.Name, terms, 14, base) + " " +
+			highlightCell(s.Surname, terms, 16, base) + " " +
+			base.Render(fmt.Sprintf("%-4s %s %s", strconv.Itoa(s.Age), s.ID, marker)) + "\n")
+	}
+
+	var summary string
+
+	switch {
+	case len(m.results) == 0:
+		summary = fmt.Sprintf("no students match %q", m.query)
+	case m.similar:
+		summary = fmt.Sprintf("no exact matches, %d similar names", len(m.results))
+	default:
+		summary = fmt.Sprintf("%d of %d students", len(m.results), len(m.roster))
+	}
+
+	if m.exactID != "" {
+		summary += ", = marks the exact full name"
+	}
+
+	if m.refining {
+		summary += ", looking up…"
+	}
+
+	b.WriteString(helpStyle.Render(summary) + "\n")
+	b.WriteString(helpStyle.Render("type to filter | ↑/↓ to move | enter to show | esc to back"))
+
+	return b.String()
+}
+
+// filterStudents keeps the students whose name or surname contains every
+// term of the query, ignoring letter case.
+func filterStudents(roster []dtos.StudentListItemDTO, query string) []dtos.StudentListItemDTO {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return roster
+	}
+
+	var out []dtos.StudentListItemDTO
+
+	for _, s := range roster {
+		name := strings.ToLower(s.Name)
+		surname := strings.ToLower(s.Surname)
+
+		matched := true
+
+		for _, t := range terms {
+			if !strings.Contains(name, t) && !strings.Contains(surname, t) {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			out = append(out, s)
+		}
+	}
+
+	return out
+}
+
+func searchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// splitFullName reads a two word query as a name and a surname, capitalized
+// the way they are stored.
+func splitFullName(query string) (string, string, bool) {
+	words := strings.Fields(query)
+	if len(words) != 2 {
+		return "", "", false
+	}
+
+	return capitalize(words[0]), capitalize(words[1]), true
+}
+
+func capitalize(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+// highlightCell cuts the value to the column width and renders the parts
+// matching any of the terms with matchStyle. The table bubble measures
+// cells without skipping styles, so the search rows are rendered by hand.
+func highlightCell(value string, terms []string, width int, base lipgloss.Style) string {
+	runes := []rune(value)
+	if len(runes) > width {
+		runes = append(runes[:width-1], '…')
+	}
+
+	lower := []rune(strings.ToLower(string(runes)))
+	if len(lower) != len(runes) {
+		lower = runes
+	}
+
+	marked := make([]bool, len(runes))
+
+	for _, t := range terms {
+		tr := []rune(t)
+
+		for i := 0; i+len(tr) <= len(lower); i++ {
+			if string(lower[i:i+len(tr)]) == t {
+				for j := i; j < i+len(tr); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+
+		style := base
+		if marked[i] {
+			style = matchStyle
+		}
+
+		b.WriteString(style.Render(string(runes[i:j])))
+
+		i = j
+	}
+
+	if pad := width - len(runes); pad > 0 {
+		b.WriteString(strings.Repeat(" ", pad))
+	}
+
+	return b.String()
+}
@@ -1,57 +1,480 @@
 package tui
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+)
+
+const (
+	defaultTableWidth  = 120
+	defaultTableHeight = 10
+	// tableChrome is the number of lines around the rows: filter bar, table
+	// borders and header, pager, status and help.
+	tableChrome     = 11
+	minTableHeight  = 3
+	tableIDMinWidth = 110
+	tableIDWidth    = 36
 )
 
 var baseStyle = lipgloss.NewStyle().
 	BorderStyle(lipgloss.NormalBorder()).
 	BorderForeground(lipgloss.Color("240"))
 
+// sortKeys maps the sort keypresses to the query sort keys.
+var sortKeys = map[string]string{
+	"n": "name",
+	"s": "surname",
+	"a": "age",
+	"v": "avg",
+}
+
+// tableModel pages through the roster with the student query, the root model
+// runs the query whenever the table asks for it with tableQueryMsg.
 type tableModel struct {
-	table table.Model
+	table     table.Model
+	items     []dtos.StudentListItemDTO
+	total     int
+	page      int
+	sort      string
+	desc      bool
+	filter    textinput.Model
+	applied   string
+	filtering bool
+	status    string
+	showID    bool
+	width     int
+	height    int
 }
 
-func newTableModel(t table.Model) tableModel {
-	return tableModel{
-		table: styleTable(t),
+func newTableModel(width, height int) tableModel {
+	fi := textinput.New()
+
+	fi.Prompt = "/ "
+	fi.Placeholder = "Gun, Mikhail Gun, age:18-25, avg:60-, grades:no"
+	fi.Cursor.Style = cursorStyle
+	fi.CharLimit = 128
+	fi.Width = 60
+
+	t := table.New(table.WithFocused(true))
+
+	m := tableModel{
+		table:  styleTable(t),
+		filter: fi,
 	}
+
+	return m.resize(width, height)
 }
 
 func (m tableModel) Init() tea.Cmd {
 	return nil
 }
 
+func (m tableModel) pageSize() int {
+	if m.height <= 0 {
+		return defaultTableHeight
+	}
+
+	return max(m.height-tableChrome, minTableHeight)
+}
+
+func (m tableModel) pages() int {
+	return max((m.total+m.pageSize()-1)/m.pageSize(), 1)
+}
+
+// query builds the student query of the current page, sort and filter.
+func (m tableModel) query() (dtos.StudentQueryDTO, error) {
+	q, err := parseTableFilter(m.applied)
+	if err != nil {
+		return dtos.StudentQueryDTO{}, err
+	}
+
+	q.Sort = m.sort
+	q.Desc = m.desc
+	q.Offset = m.page * m.pageSize()
+	q.Limit = m.pageSize()
+	q.IncludeGrades = true
+
+	return q, nil
+}
+
+func (m tableModel) withPage(page dtos.StudentPageDTO) tableModel {
+	m.items = page.Items
+	m.total = page.Total
+	m.table.SetRows(m.rows())
+	m.table.SetCursor(min(m.table.Cursor(), max(len(m.items)-1, 0)))
+
+	return m
+}
+
+// resize spreads the width over the name, surname and grades columns and
+// drops the ID column on narrow terminals.
+func (m tableModel) resize(width, height int) tableModel {
+	m.width, m.height = width, height
+
+	if width <= 0 {
+		width = defaultTableWidth
+	}
+
+	m.showID = width >= tableIDMinWidth
+
+	cols := []table.Column{
+		{Title: "#", Width: 5},
+		{Title: "Name"},
+		{Title: "Surname"},
+		{Title: "Age", Width: 4},
+		{Title: "AVG", Width: 6},
+		{Title: "Grades"},
+	}
+
+	if m.showID {
+		cols = append(cols, table.Column{Title: "ID", Width: tableIDWidth})
+	}
+
+	// every cell is padded by one space on both sides, the border takes two
+	free := width - 2 - 2*len(cols) - 5 - 4 - 6
+	if m.showID {
+		free -= tableIDWidth
+	}
+
+	free = max(free, 24)
+
+	cols[1].Width = free * 3 / 10
+	cols[2].Width = free * 3 / 10
+	cols[5].Width = free - cols[1].Width - cols[2].Width
+
+	m.table.SetRows(nil)
+	m.table.SetColumns(cols)
+	m.table.SetRows(m.rows())
+	m.table.SetHeight(m.pageSize())
+
+	return m
+}
+
+func (m tableModel) rows() []table.Row {
+	offset := m.page * m.pageSize()
+
+	rows := make([]table.Row, 0, len(m.items))
+
+	for i, s := range m.items {
+		values := make([]string, len(s.Grades))
+		for j, g := range s.Grades {
+			values[j] = strconv.Itoa(g.Value)
+		}
+
+		row := table.Row{
+			strconv.Itoa(offset + i + 1),
+			s.Name,
+			s.Surname,
+			strconv.Itoa(s.Age),
+			formatAVG(s.AvgGrade),
+			strings.Join(values, ","),
+		}
+
+		if m.showID {
+			row = append(row, s.ID)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+func (m tableModel) selected() (dtos.StudentListItemDTO, bool) {
+	i := m.table.Cursor()
+	if i < 0 || i >= len(m.items) {
+		return dtos.StudentListItemDTO{}, false
+	}
+
+	return m.items[i], true
+}
+
 func (m tableModel) Update(msg tea.Msg) (tableModel, tea.Cmd) {
-	var cmd tea.Cmd
+	reload := func() tea.Msg { return tableQueryMsg{} }
 
-	switch msg := msg.(type) {
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc", "q", "ctrl+c":
-			return m, func() tea.Msg {
-				return tableBackMsg{}
-			}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+
+		m.table, cmd = m.table.Update(msg)
+
+		return m, cmd
+	}
+
+	if m.filtering {
+		switch key.String() {
 		case "enter":
-			row := m.table.SelectedRow()
-			if len(row) > 1 {
-				id := row[1]
+			m.filtering = false
+			m.applied = strings.TrimSpace(m.filter.Value())
+			m.page = 0
+			m.filter.Blur()
+			m.table.Focus()
 
-				return m, func() tea.Msg { return tableShowMsg{ID: id} }
-			}
+			return m, reload
+		case "esc":
+			m.filtering = false
+			m.filter.SetValue(m.applied)
+			m.filter.Blur()
+			m.table.Focus()
 
 			return m, nil
 		}
+
+		var cmd tea.Cmd
+
+		m.filter, cmd = m.filter.Update(msg)
+
+		return m, cmd
+	}
+
+	s := key.String()
+
+	if sort, ok := sortKeys[s]; ok {
+		m.desc = m.sort == sort && !m.desc
+		m.sort = sort
+		m.page = 0
+
+		return m, reload
 	}
 
+	switch s {
+	case "esc", "q", "ctrl+c":
+		return m, func() tea.Msg {
+			return tableBackMsg{}
+		}
+	case "/":
+		m.filtering = true
+		m.table.Blur()
+
+		return m, m.filter.Focus()
+	case "0":
+		m.sort, m.desc, m.page = "", false, 0
+
+		return m, reload
+	case "right", "l", "pgdown":
+		if m.page+1 < m.pages() {
+			m.page++
+			m.table.SetCursor(0)
+
+			return m, reload
+		}
+
+		return m, nil
+	case "left", "pgup":
+		if m.page > 0 {
+			m.page--
+			m.table.SetCursor(0)
+
+			return m, reload
+		}
+
+		return m, nil
+	case "enter", "e", "d", "g":
+		st, ok := m.selected()
+		if !ok {
+			return m, nil
+		}
+
+		return m, func() tea.Msg {
+			switch s {
+			case "e":
+				return tableEditMsg{ID: st.ID}
+			case "d":
+				return tableDeleteMsg{ID: st.ID}
+			case "g":
+				return tableGradesMsg{ID: st.ID}
+			default:
+				return tableShowMsg{ID: st.ID}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+
 	m.table, cmd = m.table.Update(msg)
 
 	return m, cmd
 }
 
 func (m tableModel) View() string {
-	return baseStyle.Render(m.table.View()) + "\n" +
-		helpStyle.Render("↑/↓ to move | esc/q to back | enter to show")
+	var b strings.Builder
+
+	switch {
+	case m.filtering:
+		b.WriteString(m.filter.View() + "\n")
+	case m.applied != "":
+		b.WriteString(blurredStyle.Render("filter: "+m.applied) + "\n")
+	default:
+		b.WriteString(blurredStyle.Render("no filter, press / to add one") + "\n")
+	}
+
+	b.WriteString(baseStyle.Render(m.table.View()) + "\n")
+
+	pager := fmt.Sprintf("page %d/%d | %d students", m.page+1, m.pages(), m.total)
+	if m.sort != "" {
+		dir := "↑"
+		if m.desc {
+			dir = "↓"
+		}
+
+		pager += " | sorted by " + m.sort + " " + dir
+	}
+
+	b.WriteString(blurredStyle.Render(pager))
+
+	if m.status != "" {
+		b.WriteString("\n" + renderStatus(m.status))
+	}
+
+	help := "↑/↓ to move | ←/→ to page | n/s/a/v to sort, again to reverse, 0 to reset" +
+		" | / to filter\nenter to show | e to edit | d to delete | g to add grades | esc/q to back"
+	if m.filtering {
+		help = "enter to apply the filter | esc to cancel"
+	}
+
+	b.WriteString("\n" + helpStyle.Render(help))
+
+	return b.String()
+}
+
+// formatAVG shows the average the service took with the grading policy.
+func formatAVG(avg *float64) string {
+	if avg == nil {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f", *avg)
+}
+
+// parseTableFilter reads the filter bar: bare words are a surname prefix or
+// a name and a surname prefix, age:, avg: and grades: narrow the rest.
+func parseTableFilter(s string) (dtos.StudentQueryDTO, error) {
+	var (
+		q     dtos.StudentQueryDTO
+		words []string
+	)
+
+	for _, term := range strings.Fields(s) {
+		key, value, ok := strings.Cut(term, ":")
+		if !ok {
+			words = append(words, capitalize(term))
+
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "name":
+			q.Name = capitalize(value)
+		case "surname":
+			q.Surname = capitalize(value)
+		case "age":
+			lo, hi, err := parseRange(value)
+			if err != nil {
+				return q, fmt.Errorf("age: %w", err)
+			}
+
+			if q.MinAge, err = toAge(lo); err != nil {
+				return q, fmt.Errorf("age: %w", err)
+			}
+
+			if q.MaxAge, err = toAge(hi); err != nil {
+				return q, fmt.Errorf("age: %w", err)
+			}
+		case "avg":
+			lo, hi, err := parseRange(value)
+			if err != nil {
+				return q, fmt.Errorf("avg: %w", err)
+			}
+
+			q.MinAVG, q.MaxAVG = lo, hi
+		case "grades":
+			var has bool
+
+			switch strings.ToLower(value) {
+			case "yes":
+				has = true
+			case "no":
+			default:
+				return q, fmt.Errorf("grades: want yes or no, got %q", value)
+			}
+
+			q.HasGrades = &has
+		default:
+			return q, fmt.Errorf("unknown filter %q, use name, surname, age, avg or grades", key)
+		}
+	}
+
+	switch len(words) {
+	case 0:
+	case 1:
+		q.Surname = words[0]
+	case 2:
+		q.Name, q.Surname = words[0], words[1]
+	default:
+		return q, errors.New("type at most a name and a surname")
+	}
+
+	return q, nil
+}
+
+// parseRange reads "n", "lo-hi", "lo-" or "-hi".
+func parseRange(s string) (*float64, *float64, error) {
+	parse := func(v string) (*float64, error) {
+		if v == "" {
+			return nil, nil
+		}
+
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", v)
+		}
+
+		return &f, nil
+	}
+
+	loStr, hiStr, isRange := strings.Cut(s, "-")
+	if !isRange {
+		hiStr = loStr
+	}
+
+	lo, err := parse(loStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hi, err := parse(hiStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if lo == nil && hi == nil {
+		return nil, nil, errors.New("want a number or a lo-hi range")
+	}
+
+	return lo, hi, nil
+}
+
+// toAge rejects fractional ages instead of cutting 17.9 down to 17.
+func toAge(f *float64) (*int, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	if *f != math.Trunc(*f) {
+		return nil, fmt.Errorf("%v is not a whole number of years", *f)
+	}
+
+	v := int(*f)
+
+	return &v, nil
 }
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+)
+
+type trashModel struct {
+	table    table.Model
+	students []dtos.DeletedStudentDTO
+}
+
+func newTrashModel(list []dtos.DeletedStudentDTO) trashModel {
+	cols := []table.Column{
+		{Title: "ID", Width: 36},
+		{Title: "Name", Width: 14},
+		{Title: "Surname", Width: 16},
+		{Title: "Age", Width: 4},
+		{Title: "Deleted at", Width: 20},
+		{Title: "Grades", Width: 24},
+	}
+
+	rows := make([]table.Row, 0, len(list))
+
+	for _, s := range list {
+		rows = append(rows, table.Row{
+			s.ID,
+			s.Name,
+			s.Surname,
+			strconv.Itoa(s.Age),
+			s.DeletedAt,
+			joinGradeValues(s.Grades),
+		})
+	}
+
+	t := table.New(
+		table.WithColumns(cols),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+
+	return trashModel{
+		table:    styleTable(t),
+		students: list,
+	}
+}
+
+func (m trashModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m trashModel) Update(msg tea.Msg) (trashModel, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			return m, func() tea.Msg {
+				return tableBackMsg{}
+			}
+		case "enter", "r":
+			if i := m.table.Cursor(); i >= 0 && i < len(m.students) {
+				student := m.students[i]
+
+				return m, func() tea.Msg { return trashRestoreMsg{Student: student} }
+			}
+
+			return m, nil
+		}
+	}
+
+	m.table, cmd = m.table.Update(msg)
+
+	return m, cmd
+}
+
+func (m trashModel) View() string {
+	return baseStyle.Render(m.table.View()) + "\n" +
+		helpStyle.Render("↑/↓ to move | esc/q to back | enter/r to restore")
+}
@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/k6zma/avito-lab1/internal/application/dtos"
+)
+
+const (
+	tuiTestPrefix = "TUI"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func boolPtr(v bool) *bool {
+	return &v
+}
+
+func TestParseTableFilter(t *testing.T) {
+	tests := []struct {
+		filter  string
+		want    dtos.StudentQueryDTO
+		wantErr string
+	}{
+		{"", dtos.StudentQueryDTO{}, ""},
+		{"gun", dtos.StudentQueryDTO{Surname: "Gun"}, ""},
+		{"mikhail gun", dtos.StudentQueryDTO{Name: "Mikhail", Surname: "Gun"}, ""},
+		{"name:anna surname:pet", dtos.StudentQueryDTO{Name: "Anna", Surname: "Pet"}, ""},
+		{"age:18-25", dtos.StudentQueryDTO{MinAge: intPtr(18), MaxAge: intPtr(25)}, ""},
+		{"age:20", dtos.StudentQueryDTO{MinAge: intPtr(20), MaxAge: intPtr(20)}, ""},
+		{"avg:60.5-", dtos.StudentQueryDTO{MinAVG: floatPtr(60.5)}, ""},
+		{"AVG:-90", dtos.StudentQueryDTO{MaxAVG: floatPtr(90)}, ""},
+		{"grades:no", dtos.StudentQueryDTO{HasGrades: boolPtr(false)}, ""},
+		{"grades:YES", dtos.StudentQueryDTO{HasGrades: boolPtr(true)}, ""},
+		{"age:17.9", dtos.StudentQueryDTO{}, "not a whole number"},
+		{"age:abc", dtos.StudentQueryDTO{}, "is not a number"},
+		{"avg:-", dtos.StudentQueryDTO{}, "want a number"},
+		{"grades:maybe", dtos.StudentQueryDTO{}, "want yes or no"},
+		{"email:x", dtos.StudentQueryDTO{}, "unknown filter"},
+		{"a b c", dtos.StudentQueryDTO{}, "at most a name and a surname"},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-filter-%s-№%d", tuiTestPrefix, tc.filter, i+1), func(t *testing.T) {
+			got, err := parseTableFilter(tc.filter)
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("[%s][ParseTableFilter] filter %q: want error with %q, got=%v", tuiTestPrefix, tc.filter, tc.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil || !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("[%s][ParseTableFilter] filter %q: got=%+v want=%+v (err=%v)", tuiTestPrefix, tc.filter, got, tc.want, err)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		value   string
+		lo, hi  *float64
+		wantErr bool
+	}{
+		{"5", floatPtr(5), floatPtr(5), false},
+		{"1.5-2", floatPtr(1.5), floatPtr(2), false},
+		{"10-", floatPtr(10), nil, false},
+		{"-10", nil, floatPtr(10), false},
+		{"", nil, nil, true},
+		{"-", nil, nil, true},
+		{"x-2", nil, nil, true},
+		{"1-y", nil, nil, true},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-range-%s-№%d", tuiTestPrefix, tc.value, i+1), func(t *testing.T) {
+			lo, hi, err := parseRange(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("[%s][ParseRange] value %q: wantErr=%v got=%v", tuiTestPrefix, tc.value, tc.wantErr, err)
+			}
+
+			if !reflect.DeepEqual(lo, tc.lo) || !reflect.DeepEqual(hi, tc.hi) {
+				t.Fatalf("[%s][ParseRange] value %q: got=%v-%v want=%v-%v", tuiTestPrefix, tc.value, lo, hi, tc.lo, tc.hi)
+			}
+		})
+	}
+}
+
+func TestToAge(t *testing.T) {
+	tests := []struct {
+		value   *float64
+		want    *int
+		wantErr bool
+	}{
+		{nil, nil, false},
+		{floatPtr(18), intPtr(18), false},
+		{floatPtr(0), intPtr(0), false},
+		{floatPtr(17.9), nil, true},
+		{floatPtr(-0.5), nil, true},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-age-№%d", tuiTestPrefix, i+1), func(t *testing.T) {
+			got, err := toAge(tc.value)
+			if (err != nil) != tc.wantErr || !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("[%s][ToAge] got=%v (err=%v) want=%v (wantErr=%v)", tuiTestPrefix, got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilterStudents(t *testing.T) {
+	roster := []dtos.StudentListItemDTO{
+		{ID: "1", Name: "Mikhail", Surname: "Gunin"},
+		{ID: "2", Name: "Alexander", Surname: "Gunin"},
+		{ID: "3", Name: "Anna", Surname: "Petrova"},
+	}
+
+	tests := []struct {
+		query   string
+		wantIDs []string
+	}{
+		{"", []string{"1", "2", "3"}},
+		{"  ", []string{"1", "2", "3"}},
+		{"GUN", []string{"1", "2"}},
+		{"an", []string{"2", "3"}},
+		{"an gun", []string{"2"}},
+		{"zzz", nil},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-filter-students-%s-№%d", tuiTestPrefix, tc.query, i+1), func(t *testing.T) {
+			var ids []string
+			for _, st := range filterStudents(roster, tc.query) {
+				ids = append(ids, st.ID)
+			}
+
+			if !reflect.DeepEqual(ids, tc.wantIDs) {
+				t.Fatalf("[%s][FilterStudents] query %q: got=%v want=%v", tuiTestPrefix, tc.query, ids, tc.wantIDs)
+			}
+		})
+	}
+}
+
+func TestSplitFullName(t *testing.T) {
+	tests := []struct {
+		query         string
+		name, surname string
+		ok            bool
+	}{
+		{"mikhail gunin", "Mikhail", "Gunin", true},
+		{"  Anna   petrova ", "Anna", "Petrova", true},
+		{"gunin", "", "", false},
+		{"a b c", "", "", false},
+		{"", "", "", false},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-split-%s-№%d", tuiTestPrefix, tc.query, i+1), func(t *testing.T) {
+			name, surname, ok := splitFullName(tc.query)
+			if name != tc.name || surname != tc.surname || ok != tc.ok {
+				t.Fatalf("[%s][SplitFullName] query %q: got=%q %q %v want=%q %q %v",
+					tuiTestPrefix, tc.query, name, surname, ok, tc.name, tc.surname, tc.ok)
+			}
+		})
+	}
+}
+
+func TestHighlightCell(t *testing.T) {
+	// Case transforms stand for the styles, they render without a terminal.
+	prev := matchStyle
+	matchStyle = lipgloss.NewStyle().Transform(strings.ToUpper)
+
+	t.Cleanup(func() {
+		matchStyle = prev
+	})
+
+	base := lipgloss.NewStyle().Transform(strings.ToLower)
+
+	tests := []struct {
+		value string
+		terms []string
+		width int
+		want  string
+	}{
+		{"Gunin", nil, 8, "gunin   "},
+		{"Gunin", []string{"gun"}, 5, "GUNin"},
+		{"Alexander", []string{"x"}, 5, "aleX…"},
+		{"Alexander", []string{"der"}, 5, "alex…"},
+		{"Anna", []string{"n", "a"}, 4, "ANNA"},
+		{"Petrova", []string{"tr", "va"}, 7, "peTRoVA"},
+	}
+
+	for i, tc := range tests {
+		t.Run(fmt.Sprintf("[%s]-highlight-%s-№%d", tuiTestPrefix, tc.value, i+1), func(t *testing.T) {
+			got := highlightCell(tc.value, tc.terms, tc.width, base)
+			if got != tc.want {
+				t.Fatalf("[%s][HighlightCell] value %q terms %v: got=%q want=%q", tuiTestPrefix, tc.value, tc.terms, got, tc.want)
+			}
+		})
+	}
+}
+
+// setStatus is a finished read that leaves its label in the status line.
+func setStatus(label string) func() applyFunc {
+	return func() applyFunc {
+		return func(m rootModel) (rootModel, tea.Cmd) {
+			m.status = label
+
+			return m, nil
+		}
+	}
+}
+
+func TestAsync_FinishAppliesOnlyTheOperationInFlight(t *testing.T) {
+	m, _ := rootModel{}.read("first", setStatus("first"))
+	m, _ = m.write("second", setStatus("second"))
+
+	if m.busy == nil || m.busy.label != "first" || len(m.queue) != 1 {
+		t.Fatalf("[%s][Finish] want first in flight and second queued, got busy=%+v queue=%d", tuiTestPrefix, m.busy, len(m.queue))
+	}
+
+	first := m.busy.seq
+
+	m, _ = m.finish(opDoneMsg{seq: first + 10, apply: setStatus("stale")()})
+	if m.status != "" || m.busy == nil || m.busy.seq != first {
+		t.Fatalf("[%s][Finish] result of another operation must be dropped: status=%q busy=%+v", tuiTestPrefix, m.status, m.busy)
+	}
+
+	m, _ = m.finish(opDoneMsg{seq: first, apply: setStatus("first")()})
+	if m.status != "first" || m.busy == nil || m.busy.label != "second" || !m.busy.write || len(m.queue) != 0 {
+		t.Fatalf("[%s][Finish] want first applied and second started: status=%q busy=%+v queue=%d",
+			tuiTestPrefix, m.status, m.busy, len(m.queue))
+	}
+
+	m, _ = m.finish(opDoneMsg{seq: m.busy.seq, apply: setStatus("second")()})
+	if m.status != "second" || m.busy != nil {
+		t.Fatalf("[%s][Finish] want second applied and nothing in flight: status=%q busy=%+v", tuiTestPrefix, m.status, m.busy)
+	}
+}
+
+func TestAsync_UpdateBusy(t *testing.T) {
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+
+	m, _ := rootModel{}.write("saving", setStatus("saved"))
+	m, _ = m.read("loading", setStatus("loaded"))
+
+	saving := m.busy.seq
+
+	m, _ = m.updateBusy(esc)
+	if m.busy == nil || m.busy.seq != saving || m.busy.note == "" {
+		t.Fatalf("[%s][UpdateBusy] esc must not cancel a write: busy=%+v", tuiTestPrefix, m.busy)
+	}
+
+	m, _ = m.finish(opDoneMsg{seq: saving, apply: setStatus("saved")()})
+
+	loading := m.busy.seq
+
+	m, _ = m.updateBusy(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if m.busy == nil || m.busy.seq != loading {
+		t.Fatalf("[%s][UpdateBusy] other keys must be ignored: busy=%+v", tuiTestPrefix, m.busy)
+	}
+
+	m, _ = m.updateBusy(esc)
+	if m.busy != nil {
+		t.Fatalf("[%s][UpdateBusy] esc must cancel a read: busy=%+v", tuiTestPrefix, m.busy)
+	}
+
+	m, _ = m.finish(opDoneMsg{seq: loading, apply: setStatus("loaded")()})
+	if m.status != "saved" {
+		t.Fatalf("[%s][UpdateBusy] result of a cancelled read must be dropped: status=%q", tuiTestPrefix, m.status)
+	}
+
+	if _, cmd := m.updateBusy(tea.KeyMsg{Type: tea.KeyCtrlC}); cmd == nil {
+		t.Fatalf("[%s][UpdateBusy] ctrl+c must quit", tuiTestPrefix)
+	}
+}
+
+func TestAsync_ReloadsAreCoalesced(t *testing.T) {
+	m, _ := rootModel{}.reload(tableReloadKey, "loading", setStatus("loaded"))
+	m, _ = m.write("saving", setStatus("saved"))
+	m, _ = m.reload(tableReloadKey, "resize 1", setStatus("resize 1"))
+	m, _ = m.reload(tableReloadKey, "resize 2", setStatus("resize 2"))
+
+	if len(m.queue) != 2 || m.queue[0].label != "saving" || m.queue[1].label != "resize 2" {
+		t.Fatalf("[%s][Coalesce] want the write and the latest reload queued, got=%+v", tuiTestPrefix, m.queue)
+	}
+
+	m, _ = m.updateBusy(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.busy == nil || m.busy.label != "saving" || len(m.queue) != 0 {
+		t.Fatalf("[%s][Coalesce] esc must drop the queued reload with the read: busy=%+v queue=%+v",
+			tuiTestPrefix, m.busy, m.queue)
+	}
+}
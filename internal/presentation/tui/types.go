@@ -1,5 +1,7 @@
 package tui
 
+import "github.com/k6zma/avito-lab1/internal/application/dtos"
+
 type mode int
 
 const (
@@ -9,10 +11,21 @@ const (
 	modeAddGrades
 	modeIDInput
 	modeDetail
+	modeAudit
+	modeConfirm
+	modeTrash
+	modeHistory
+	modeEdit
+	modeSearch
+
+	actionAVG     = "avg"
+	actionDel     = "del"
+	actionShow    = "show"
+	actionRestore = "restore"
+	actionHistory = "history"
+	actionEdit    = "edit"
 
-	actionAVG  = "avg"
-	actionDel  = "del"
-	actionShow = "show"
+	tableReloadKey = "table"
 )
 
 type (
@@ -24,14 +37,44 @@ type (
 		ID string
 	}
 
+	tableEditMsg struct {
+		ID string
+	}
+
+	tableDeleteMsg struct {
+		ID string
+	}
+
+	tableGradesMsg struct {
+		ID string
+	}
+
+	tableQueryMsg struct{}
+
 	createSubmittedMsg struct {
-		Name, Surname, Age, GradesCSV string
+		Input studentInput
 	}
 
 	createCancelMsg struct{}
 
+	editSubmittedMsg struct {
+		Update dtos.StudentUpdateDTO
+	}
+
+	editCancelMsg struct{}
+
+	searchQueryMsg struct {
+		Query string
+	}
+
+	searchRefinedMsg struct {
+		Query   string
+		ExactID string
+		Similar []dtos.StudentListItemDTO
+	}
+
 	addGradesSubmittedMsg struct {
-		ID, Grades string
+		ID, Grades, CourseID string
 	}
 
 	addGradesCancelMsg struct{}
@@ -39,4 +82,24 @@ type (
 	idSubmittedMsg string
 
 	idCancelMsg struct{}
+
+	auditShowMsg struct {
+		Entry dtos.AuditEntryDTO
+	}
+
+	confirmMsg struct{}
+
+	confirmCancelMsg struct{}
+
+	trashRestoreMsg struct {
+		Student dtos.DeletedStudentDTO
+	}
+
+	historyOpenMsg struct {
+		ID string
+	}
+
+	historyShowMsg struct {
+		Revision dtos.StudentRevisionDTO
+	}
 )
@@ -0,0 +1,13 @@
+package validators
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func IsValidationError(err error) bool {
+	var validationErrs validator.ValidationErrors
+
+	return errors.As(err, &validationErrs)
+}
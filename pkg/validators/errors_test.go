@@ -0,0 +1,48 @@
+package validators_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/k6zma/avito-lab1/pkg/validators"
+)
+
+const (
+	errorsTestPrefix = "ValidationErrors"
+)
+
+type isValidationErrorCase struct {
+	testName string
+	err      error
+	want     bool
+}
+
+func TestIsValidationError(t *testing.T) {
+	if err := validators.InitValidators(); err != nil {
+		t.Fatalf("InitValidators() error = %v, want nil", err)
+	}
+
+	validationErr := validators.Validate.Struct(capitalizedFieldStruct{ValueCapitalized: "test"})
+	if validationErr == nil {
+		t.Fatalf("[%s] expected validation error for non-capitalized value", errorsTestPrefix)
+	}
+
+	tests := []isValidationErrorCase{
+		{"nil error", nil, false},
+		{"plain error", errors.New("plain"), false},
+		{"validation error", validationErr, true},
+		{"wrapped validation error", fmt.Errorf("wrapped: %w", validationErr), true},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("[%s]-%s-№%d", errorsTestPrefix, tt.testName, i+1), func(t *testing.T) {
+			if got := validators.IsValidationError(tt.err); got != tt.want {
+				t.Errorf(
+					"IsValidationError(%v) = %v, want %v",
+					tt.err, got, tt.want,
+				)
+			}
+		})
+	}
+}